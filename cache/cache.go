@@ -0,0 +1,235 @@
+// Package cache 为远程存储提供本地磁盘读缓存与元数据缓存
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/goairix/fs"
+)
+
+// DefaultMaxSize 默认的缓存空间上限
+const DefaultMaxSize int64 = 1 << 30
+
+// Options 缓存选项
+type Options struct {
+	MaxSize int64 // 缓存占用的最大磁盘空间，超出时淘汰最近最少使用的文件，为 0 时使用 DefaultMaxSize
+}
+
+// Stats 缓存统计
+type Stats struct {
+	Hits      int64 // 命中次数
+	Misses    int64 // 未命中次数，包括不可缓存的读取
+	Evictions int64 // 因空间不足淘汰的文件数
+	Entries   int   // 缓存的文件数
+	Size      int64 // 缓存占用的磁盘空间
+}
+
+// Cache 带本地磁盘读缓存的文件系统。
+// Open 与 OpenReader 先通过 Stat 获取远程文件的 ETag 或修改时间，版本一致时从缓存读取，
+// 未命中时下载到缓存目录，并发读取同一文件只下载一次。
+// 通过 Cache 进行的写入、删除、移动与元数据修改会使缓存失效，
+// 其他途径修改的文件在下次读取时因版本变化重新下载。
+// 超过缓存空间上限的文件以及无法获取版本的文件直接从远程读取
+type Cache struct {
+	fs.Wrapper
+	store *store
+}
+
+// New 创建缓存，cacheDir 为缓存目录，不存在时自动创建，目录中已有的缓存文件会被继续使用。
+// 缓存目录只能由一个 Cache 使用
+func New(remote fs.FileSystem, cacheDir string, opts Options) (*Cache, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	store, err := newStore(cacheDir, opts.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{Wrapper: fs.Wrapper{FileSystem: remote}, store: store}, nil
+}
+
+// Stats 返回缓存统计
+func (c *Cache) Stats() Stats {
+	return c.store.stats()
+}
+
+func (c *Cache) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	file, err := c.open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return c.FileSystem.Open(ctx, path, opts...)
+	}
+
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Range == nil {
+		return file, nil
+	}
+	start := min(max(o.Range.Offset, 0), file.size)
+	length := file.size - start
+	if o.Range.Length > 0 {
+		length = min(o.Range.Length, length)
+	}
+	return &sectionFile{SectionReader: io.NewSectionReader(file, start, length), file: file}, nil
+}
+
+func (c *Cache) OpenReader(ctx context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	file, err := c.open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return fs.OpenReader(ctx, c.FileSystem, path, opts...)
+	}
+	return file, nil
+}
+
+// open 从缓存打开文件，文件不可缓存时返回 nil
+func (c *Cache) open(ctx context.Context, path string) (*cachedFile, error) {
+	info, err := c.FileSystem.Stat(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := cacheKey(path, info)
+	if !ok || info.Size() > c.store.maxSize {
+		c.store.miss()
+		return nil, nil
+	}
+
+	file, err := c.store.open(ctx, key, fs.CleanPath(path), info.Size(), func(ctx context.Context) (io.ReadCloser, error) {
+		return c.FileSystem.Open(ctx, path)
+	})
+	if errors.Is(err, errChanged) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cachedFile{File: file, size: info.Size()}, nil
+}
+
+// cacheKey 根据路径与文件版本生成缓存的 key，目录或无法获取版本时返回 false
+func cacheKey(path string, info fs.FileInfo) (string, bool) {
+	if info.IsDir() {
+		return "", false
+	}
+	var version string
+	if e, ok := info.(fs.ETagger); ok {
+		version = e.ETag()
+	}
+	if version == "" {
+		if info.ModTime().IsZero() {
+			return "", false
+		}
+		version = strconv.FormatInt(info.ModTime().UnixNano(), 10)
+	}
+
+	h := sha256.New()
+	for _, s := range []string{fs.CleanPath(path), version, strconv.FormatInt(info.Size(), 10)} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+func (c *Cache) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	c.invalidate(path)
+	return c.FileSystem.Create(ctx, path, opts...)
+}
+
+func (c *Cache) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		c.invalidate(path)
+	}
+	return c.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+}
+
+func (c *Cache) Remove(ctx context.Context, path string, opts ...fs.Option) error {
+	c.invalidate(path)
+	return c.FileSystem.Remove(ctx, path, opts...)
+}
+
+func (c *Cache) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
+	c.invalidateDir(path)
+	return c.FileSystem.RemoveDir(ctx, path, opts...)
+}
+
+func (c *Cache) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	c.invalidate(dst)
+	return c.FileSystem.Copy(ctx, src, dst, opts...)
+}
+
+func (c *Cache) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	c.invalidate(src)
+	c.invalidate(dst)
+	return c.FileSystem.Move(ctx, src, dst, opts...)
+}
+
+func (c *Cache) Rename(ctx context.Context, oldPath, newPath string, opts ...fs.Option) error {
+	c.invalidateDir(oldPath)
+	c.invalidateDir(newPath)
+	return c.FileSystem.Rename(ctx, oldPath, newPath, opts...)
+}
+
+func (c *Cache) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	c.invalidate(path)
+	return c.FileSystem.SetMetadata(ctx, path, metadata, opts...)
+}
+
+func (c *Cache) Uploader() fs.Uploader {
+	return &uploader{Uploader: c.FileSystem.Uploader(), cache: c}
+}
+
+func (c *Cache) invalidate(path string) {
+	c.store.invalidate(fs.CleanPath(path), false)
+}
+
+func (c *Cache) invalidateDir(path string) {
+	c.store.invalidate(fs.CleanPath(path), true)
+}
+
+// uploader 上传完成前使缓存失效
+type uploader struct {
+	fs.Uploader
+	cache *Cache
+}
+
+func (u *uploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	u.cache.invalidate(path)
+	return u.Uploader.Upload(ctx, path, reader, opts...)
+}
+
+func (u *uploader) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	u.cache.invalidate(path)
+	return u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...)
+}
+
+// cachedFile 缓存文件，实现 fs.RangeReader 接口
+type cachedFile struct {
+	*os.File
+	size int64
+}
+
+func (f *cachedFile) Size() int64 {
+	return f.size
+}
+
+// sectionFile 范围读取时限制可读取的区间
+type sectionFile struct {
+	*io.SectionReader
+	file *cachedFile
+}
+
+func (f *sectionFile) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,211 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/cache"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/goairix/fs/fstest"
+)
+
+func newMemory(t *testing.T) fs.FileSystem {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// countingFS 记录 Open 的调用次数，delay 让并发读取重叠
+type countingFS struct {
+	fs.FileSystem
+	opens atomic.Int64
+	delay time.Duration
+}
+
+func (c *countingFS) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	c.opens.Add(1)
+	time.Sleep(c.delay)
+	return c.FileSystem.Open(ctx, path, opts...)
+}
+
+func read(t *testing.T, fsys fs.FileSystem, path string, opts ...fs.Option) string {
+	t.Helper()
+	r, err := fsys.Open(context.Background(), path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func write(t *testing.T, fsys fs.FileSystem, path, data string) {
+	t.Helper()
+	w, err := fsys.Create(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheFileSystem(t *testing.T) {
+	fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+		c, err := cache.New(newMemory(t), t.TempDir(), cache.Options{MaxSize: 1 << 20})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	})
+}
+
+func TestCacheHit(t *testing.T) {
+	ctx := context.Background()
+	remote := &countingFS{FileSystem: newMemory(t), delay: 20 * time.Millisecond}
+	c, err := cache.New(remote, t.TempDir(), cache.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, remote, "a.txt", "0123456789")
+
+	// 并发读取同一文件只下载一次
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := read(t, c, "a.txt"); got != "0123456789" {
+				t.Errorf("read %q", got)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := remote.opens.Load(); n != 1 {
+		t.Fatalf("downloaded %d times, want 1", n)
+	}
+
+	if got := read(t, c, "a.txt", fs.WithRange(2, 3)); got != "234" {
+		t.Fatalf("range read = %q, want %q", got, "234")
+	}
+	reader, err := fs.OpenReader(ctx, c, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 2)
+	if _, err := reader.ReadAt(buf, 8); err != nil {
+		t.Fatal(err)
+	}
+	_ = reader.Close()
+	if string(buf) != "89" || reader.Size() != 10 {
+		t.Fatalf("ReadAt = %q, Size() = %d", buf, reader.Size())
+	}
+	if n := remote.opens.Load(); n != 1 {
+		t.Fatalf("cached reads downloaded %d times, want 1", n)
+	}
+	if stats := c.Stats(); stats.Hits != 2 || stats.Entries != 1 || stats.Size != 10 {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+
+	if _, err := c.Open(ctx, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Open missing file: %v, want ErrNotExist", err)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	remote := newMemory(t)
+	c, err := cache.New(remote, t.TempDir(), cache.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, remote, "a.txt", "old")
+	read(t, c, "a.txt")
+
+	// 其他途径的修改改变了文件版本，重新下载
+	write(t, remote, "a.txt", "external")
+	if got := read(t, c, "a.txt"); got != "external" {
+		t.Fatalf("read after external write = %q", got)
+	}
+	if stats := c.Stats(); stats.Entries != 1 {
+		t.Fatalf("stale version was kept: %+v", stats)
+	}
+
+	// 通过缓存的写入与删除立即删除缓存文件
+	write(t, c, "a.txt", "new")
+	if stats := c.Stats(); stats.Entries != 0 {
+		t.Fatalf("write did not invalidate the cache: %+v", stats)
+	}
+	if got := read(t, c, "a.txt"); got != "new" {
+		t.Fatalf("read after write = %q", got)
+	}
+	if err := c.Remove(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if stats := c.Stats(); stats.Entries != 0 {
+		t.Fatalf("Remove did not invalidate the cache: %+v", stats)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	remote := &countingFS{FileSystem: newMemory(t)}
+	dir := t.TempDir()
+	c, err := cache.New(remote, dir, cache.Options{MaxSize: 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		write(t, remote, name+".txt", strings.Repeat(name, 10))
+	}
+	read(t, c, "a.txt")
+	read(t, c, "b.txt")
+	read(t, c, "a.txt")
+	read(t, c, "c.txt") // 淘汰最近最少使用的 b
+	if stats := c.Stats(); stats.Evictions != 1 || stats.Entries != 2 || stats.Size != 20 {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+	before := remote.opens.Load()
+	read(t, c, "a.txt")
+	if remote.opens.Load() != before {
+		t.Fatal("recently used file was evicted")
+	}
+
+	// 超过上限的文件直接从远程读取
+	write(t, remote, "big.txt", strings.Repeat("z", 100))
+	if got := read(t, c, "big.txt"); len(got) != 100 {
+		t.Fatalf("read %d bytes", len(got))
+	}
+	if stats := c.Stats(); stats.Size > 25 {
+		t.Fatalf("cache grew beyond MaxSize: %+v", stats)
+	}
+
+	// 重新创建时继续使用已有的缓存文件
+	c2, err := cache.New(remote, dir, cache.Options{MaxSize: 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := c2.Stats(); stats.Entries != 2 || stats.Size != 20 {
+		t.Fatalf("reopened Stats() = %+v", stats)
+	}
+	before = remote.opens.Load()
+	read(t, c2, "c.txt")
+	if remote.opens.Load() != before || c2.Stats().Hits != 1 {
+		t.Fatal("cached file was not reused after reopening")
+	}
+}
@@ -0,0 +1,401 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+const (
+	DefaultMetaTTL    = time.Minute // 默认的元数据缓存有效期
+	DefaultMaxEntries = 10000       // 默认最多缓存的元数据条目数
+)
+
+// MetaOptions 元数据缓存选项
+type MetaOptions struct {
+	TTL         time.Duration // 缓存有效期，为 0 时使用 DefaultMetaTTL
+	NegativeTTL time.Duration // 文件不存在时的缓存有效期，为 0 时与 TTL 相同，小于 0 时不缓存
+	MaxEntries  int           // 最多缓存的条目数，超出时淘汰最近最少使用的条目，为 0 时使用 DefaultMaxEntries
+}
+
+// MetaStats 元数据缓存统计
+type MetaStats struct {
+	Hits      int64 // 命中次数
+	Misses    int64 // 未命中次数
+	Evictions int64 // 因数量超出上限淘汰的条目数
+	Entries   int   // 缓存的条目数
+}
+
+// metaOp 缓存的操作
+type metaOp uint8
+
+const (
+	opStat metaOp = iota
+	opExists
+	opIsDir
+	opIsFile
+	opMimeType
+	opMetadata
+)
+
+type metaKey struct {
+	op   metaOp
+	path string
+}
+
+// metaEntry 元数据缓存条目
+type metaEntry struct {
+	key     metaKey
+	value   any
+	err     error
+	expires time.Time
+}
+
+// metaCall 正在进行的查询，相同条目的并发查询等待同一次请求
+type metaCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// MetaCache 带元数据缓存的文件系统，缓存 Stat、Exists、IsDir、IsFile、GetMimeType 与 GetMetadata 的结果，
+// 减少对象存储的 HEAD 与 List 请求。
+// 文件不存在的结果按 NegativeTTL 缓存，其他错误不缓存，并发查询同一条目只发起一次请求。
+// 通过 MetaCache 进行的修改会使文件及其上级目录的缓存失效，其他途径的修改在缓存过期后可见
+type MetaCache struct {
+	fs.Wrapper
+	ttl         time.Duration
+	negativeTTL time.Duration
+	maxEntries  int
+
+	mu         sync.Mutex
+	lru        *list.List // 最近使用的条目在前
+	entries    map[metaKey]*list.Element
+	calls      map[metaKey]*metaCall
+	generation uint64 // 每次失效时递增，失效前发起的查询结果不写入缓存
+	hits       int64
+	misses     int64
+	evictions  int64
+}
+
+// NewMeta 创建元数据缓存
+func NewMeta(fsys fs.FileSystem, opts MetaOptions) *MetaCache {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultMetaTTL
+	}
+	if opts.NegativeTTL == 0 {
+		opts.NegativeTTL = opts.TTL
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultMaxEntries
+	}
+	return &MetaCache{
+		Wrapper:     fs.Wrapper{FileSystem: fsys},
+		ttl:         opts.TTL,
+		negativeTTL: opts.NegativeTTL,
+		maxEntries:  opts.MaxEntries,
+		lru:         list.New(),
+		entries:     make(map[metaKey]*list.Element),
+		calls:       make(map[metaKey]*metaCall),
+	}
+}
+
+// Stats 返回缓存统计
+func (c *MetaCache) Stats() MetaStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return MetaStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   c.lru.Len(),
+	}
+}
+
+// Invalidate 删除路径为 path 或位于目录 path 下的缓存，用于其他途径修改文件后立即生效
+func (c *MetaCache) Invalidate(path string) {
+	c.invalidateDir(path)
+}
+
+func (c *MetaCache) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	value, err := c.get(ctx, opStat, path, func() (any, error) {
+		return c.FileSystem.Stat(ctx, path, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(fs.FileInfo), nil
+}
+
+func (c *MetaCache) Exists(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	value, err := c.get(ctx, opExists, path, func() (any, error) {
+		return c.FileSystem.Exists(ctx, path, opts...)
+	})
+	if err != nil {
+		return false, err
+	}
+	return value.(bool), nil
+}
+
+func (c *MetaCache) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	value, err := c.get(ctx, opIsDir, path, func() (any, error) {
+		return c.FileSystem.IsDir(ctx, path, opts...)
+	})
+	if err != nil {
+		return false, err
+	}
+	return value.(bool), nil
+}
+
+func (c *MetaCache) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	value, err := c.get(ctx, opIsFile, path, func() (any, error) {
+		return c.FileSystem.IsFile(ctx, path, opts...)
+	})
+	if err != nil {
+		return false, err
+	}
+	return value.(bool), nil
+}
+
+func (c *MetaCache) GetMimeType(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	value, err := c.get(ctx, opMimeType, path, func() (any, error) {
+		return c.FileSystem.GetMimeType(ctx, path, opts...)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+func (c *MetaCache) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]interface{}, error) {
+	value, err := c.get(ctx, opMetadata, path, func() (any, error) {
+		return c.FileSystem.GetMetadata(ctx, path, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 返回副本，避免调用方修改缓存的内容
+	return maps.Clone(value.(map[string]interface{})), nil
+}
+
+// get 返回缓存的结果，未命中或已过期时通过 load 查询，相同条目的并发查询只发起一次请求
+func (c *MetaCache) get(ctx context.Context, op metaOp, p string, load func() (any, error)) (any, error) {
+	key := metaKey{op: op, path: fs.CleanPath(p)}
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		e := elem.Value.(*metaEntry)
+		if time.Now().Before(e.expires) {
+			c.lru.MoveToFront(elem)
+			c.hits++
+			c.mu.Unlock()
+			return e.value, e.err
+		}
+		c.removeLocked(elem)
+	}
+	c.misses++
+
+	for {
+		call, waiting := c.calls[key]
+		if !waiting {
+			call = &metaCall{done: make(chan struct{})}
+			c.calls[key] = call
+			generation := c.generation
+			c.mu.Unlock()
+
+			call.value, call.err = load()
+			c.mu.Lock()
+			delete(c.calls, key)
+			if generation == c.generation {
+				c.storeLocked(key, call.value, call.err)
+			}
+			c.mu.Unlock()
+			close(call.done)
+			return call.value, call.err
+		}
+
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// 发起查询的请求被取消时，由仍在等待的请求重新查询
+		if ctx.Err() != nil || !errors.Is(call.err, context.Canceled) && !errors.Is(call.err, context.DeadlineExceeded) {
+			return call.value, call.err
+		}
+		c.mu.Lock()
+	}
+}
+
+// storeLocked 写入缓存，文件不存在的结果按 NegativeTTL 缓存，其他错误不缓存，调用时需持有锁
+func (c *MetaCache) storeLocked(key metaKey, value any, err error) {
+	notExist := errors.Is(err, fs.ErrNotExist) || key.op == opExists && value == false
+	if err != nil && !notExist {
+		return
+	}
+	ttl := c.ttl
+	if notExist {
+		ttl = c.negativeTTL
+	}
+	if ttl < 0 {
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&metaEntry{key: key, value: value, err: err, expires: time.Now().Add(ttl)})
+	for c.lru.Len() > c.maxEntries {
+		c.removeLocked(c.lru.Back())
+		c.evictions++
+	}
+}
+
+func (c *MetaCache) removeLocked(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*metaEntry).key)
+}
+
+// invalidate 删除文件及其上级目录的缓存，新建的文件会改变上级目录是否存在
+func (c *MetaCache) invalidate(p string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for p := fs.CleanPath(p); ; p = path.Dir(p) {
+		if p == "." {
+			p = ""
+		}
+		c.removePathLocked(p)
+		if p == "" {
+			return
+		}
+	}
+}
+
+// invalidateDir 删除目录及其中全部文件与上级目录的缓存
+func (c *MetaCache) invalidateDir(p string) {
+	c.invalidate(p)
+
+	prefix := fs.CleanPath(p) + "/"
+	if prefix == "/" {
+		prefix = ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key.path, prefix) {
+			c.removeLocked(elem)
+		}
+	}
+}
+
+func (c *MetaCache) removePathLocked(p string) {
+	for op := opStat; op <= opMetadata; op++ {
+		if elem, ok := c.entries[metaKey{op: op, path: p}]; ok {
+			c.removeLocked(elem)
+		}
+	}
+}
+
+func (c *MetaCache) MakeDir(ctx context.Context, path string, perm os.FileMode, opts ...fs.Option) error {
+	defer c.invalidate(path)
+	return c.FileSystem.MakeDir(ctx, path, perm, opts...)
+}
+
+func (c *MetaCache) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
+	defer c.invalidateDir(path)
+	return c.FileSystem.RemoveDir(ctx, path, opts...)
+}
+
+func (c *MetaCache) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	defer c.invalidate(path)
+	writer, err := c.FileSystem.Create(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &metaWriter{WriteCloser: writer, invalidate: func() { c.invalidate(path) }}, nil
+}
+
+func (c *MetaCache) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	file, err := c.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+	if err != nil || flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE) == 0 {
+		return file, err
+	}
+	c.invalidate(path)
+	return &metaFile{ReadWriteCloser: file, invalidate: func() { c.invalidate(path) }}, nil
+}
+
+func (c *MetaCache) Remove(ctx context.Context, path string, opts ...fs.Option) error {
+	defer c.invalidate(path)
+	return c.FileSystem.Remove(ctx, path, opts...)
+}
+
+func (c *MetaCache) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	defer c.invalidate(dst)
+	return c.FileSystem.Copy(ctx, src, dst, opts...)
+}
+
+func (c *MetaCache) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	defer c.invalidate(dst)
+	defer c.invalidate(src)
+	return c.FileSystem.Move(ctx, src, dst, opts...)
+}
+
+func (c *MetaCache) Rename(ctx context.Context, oldPath, newPath string, opts ...fs.Option) error {
+	defer c.invalidateDir(newPath)
+	defer c.invalidateDir(oldPath)
+	return c.FileSystem.Rename(ctx, oldPath, newPath, opts...)
+}
+
+func (c *MetaCache) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	defer c.invalidate(path)
+	return c.FileSystem.SetMetadata(ctx, path, metadata, opts...)
+}
+
+func (c *MetaCache) Uploader() fs.Uploader {
+	return &metaUploader{Uploader: c.FileSystem.Uploader(), cache: c}
+}
+
+// metaUploader 上传完成后使缓存失效
+type metaUploader struct {
+	fs.Uploader
+	cache *MetaCache
+}
+
+func (u *metaUploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	defer u.cache.invalidate(path)
+	return u.Uploader.Upload(ctx, path, reader, opts...)
+}
+
+func (u *metaUploader) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	defer u.cache.invalidate(path)
+	return u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...)
+}
+
+// metaWriter 写入完成后使缓存失效
+type metaWriter struct {
+	io.WriteCloser
+	invalidate func()
+}
+
+func (w *metaWriter) Close() error {
+	defer w.invalidate()
+	return w.WriteCloser.Close()
+}
+
+// metaFile 写入完成后使缓存失效
+type metaFile struct {
+	io.ReadWriteCloser
+	invalidate func()
+}
+
+func (f *metaFile) Close() error {
+	defer f.invalidate()
+	return f.ReadWriteCloser.Close()
+}
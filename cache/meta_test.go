@@ -0,0 +1,172 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/cache"
+	"github.com/goairix/fs/fstest"
+)
+
+// statCounter 记录 Stat 与 Exists 的调用次数，delay 让并发查询重叠
+type statCounter struct {
+	fs.FileSystem
+	stats  atomic.Int64
+	exists atomic.Int64
+	delay  time.Duration
+}
+
+func (s *statCounter) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	s.stats.Add(1)
+	time.Sleep(s.delay)
+	return s.FileSystem.Stat(ctx, path, opts...)
+}
+
+func (s *statCounter) Exists(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	s.exists.Add(1)
+	return s.FileSystem.Exists(ctx, path, opts...)
+}
+
+func exists(t *testing.T, fsys fs.FileSystem, path string) bool {
+	t.Helper()
+	ok, err := fsys.Exists(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ok
+}
+
+func TestMetaCacheFileSystem(t *testing.T) {
+	fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+		return cache.NewMeta(newMemory(t), cache.MetaOptions{TTL: time.Hour, MaxEntries: 50})
+	})
+}
+
+func TestMetaCacheHit(t *testing.T) {
+	ctx := context.Background()
+	remote := &statCounter{FileSystem: newMemory(t), delay: 20 * time.Millisecond}
+	c := cache.NewMeta(remote, cache.MetaOptions{TTL: 100 * time.Millisecond})
+	write(t, remote, "a/b.txt", "x")
+
+	// 并发查询同一条目只发起一次请求
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if info, err := c.Stat(ctx, "a/b.txt"); err != nil || info.Size() != 1 {
+				t.Errorf("Stat = %v, %v", info, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := c.Stat(ctx, "/a//b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if n := remote.stats.Load(); n != 1 {
+		t.Fatalf("Stat requested %d times, want 1", n)
+	}
+	if stats := c.Stats(); stats.Hits == 0 || stats.Hits+stats.Misses != 11 || stats.Entries != 1 {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if _, err := c.Stat(ctx, "a/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if n := remote.stats.Load(); n != 2 {
+		t.Fatalf("expired entry was not requested again, %d requests", n)
+	}
+}
+
+func TestMetaCacheNegative(t *testing.T) {
+	ctx := context.Background()
+	remote := &statCounter{FileSystem: newMemory(t)}
+	c := cache.NewMeta(remote, cache.MetaOptions{TTL: time.Hour})
+
+	if exists(t, c, "n/x.txt") {
+		t.Fatal("missing file exists")
+	}
+	if _, err := c.Stat(ctx, "n/x.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Stat missing file: %v, want ErrNotExist", err)
+	}
+	write(t, remote, "n/x.txt", "external")
+	if exists(t, c, "n/x.txt") || remote.exists.Load() != 1 {
+		t.Fatal("negative result was not cached")
+	}
+	c.Invalidate("n")
+	if !exists(t, c, "n/x.txt") {
+		t.Fatal("Invalidate did not drop the cached result")
+	}
+
+	// NegativeTTL 小于 0 时不缓存文件不存在的结果
+	c = cache.NewMeta(remote, cache.MetaOptions{TTL: time.Hour, NegativeTTL: -1})
+	for range 2 {
+		exists(t, c, "missing.txt")
+	}
+	if n := remote.exists.Load(); n != 4 {
+		t.Fatalf("Exists requested %d times, want 4", n)
+	}
+}
+
+func TestMetaCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMeta(newMemory(t), cache.MetaOptions{TTL: time.Hour})
+
+	// 写入使上级目录的缓存失效
+	if exists(t, c, "d") {
+		t.Fatal("missing directory exists")
+	}
+	write(t, c, "d/e/f.txt", "hi")
+	if !exists(t, c, "d") {
+		t.Fatal("parent directory was not invalidated by the write")
+	}
+
+	if _, err := c.GetMetadata(ctx, "d/e/f.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetMetadata(ctx, "d/e/f.txt", map[string]interface{}{"k": "v"}); err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := c.GetMetadata(ctx, "d/e/f.txt")
+	if err != nil || metadata["k"] != "v" {
+		t.Fatalf("GetMetadata after SetMetadata = %v, %v", metadata, err)
+	}
+	// 修改返回的元数据不影响缓存
+	metadata["k"] = "changed"
+	if metadata, _ := c.GetMetadata(ctx, "d/e/f.txt"); metadata["k"] != "v" {
+		t.Fatalf("cached metadata was modified: %v", metadata)
+	}
+
+	if err := c.RemoveDir(ctx, "d"); err != nil {
+		t.Fatal(err)
+	}
+	if exists(t, c, "d/e/f.txt") {
+		t.Fatal("RemoveDir did not invalidate the files in the directory")
+	}
+}
+
+func TestMetaCacheEviction(t *testing.T) {
+	remote := &statCounter{FileSystem: newMemory(t)}
+	c := cache.NewMeta(remote, cache.MetaOptions{TTL: time.Hour, MaxEntries: 2})
+	for _, name := range []string{"a", "b", "a", "c"} {
+		exists(t, c, name)
+	}
+	if stats := c.Stats(); stats.Entries != 2 || stats.Evictions != 1 {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+	before := remote.exists.Load()
+	exists(t, c, "a")
+	if remote.exists.Load() != before {
+		t.Fatal("recently used entry was evicted")
+	}
+	exists(t, c, "b")
+	if remote.exists.Load() != before+1 {
+		t.Fatal("least recently used entry was not evicted")
+	}
+}
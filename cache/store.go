@@ -0,0 +1,317 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// errChanged 下载的文件大小与 Stat 不一致，说明文件在下载过程中被修改
+var errChanged = errors.New("cache: file changed during download")
+
+// tempSuffix 下载中的临时文件后缀，启动时会清理残留的临时文件
+const tempSuffix = ".tmp"
+
+// entry 缓存条目，对应缓存目录中的一个文件
+type entry struct {
+	key  string
+	path string // 远程文件路径，启动时从磁盘加载的条目为空
+	size int64
+}
+
+// call 正在进行的下载，相同 key 的并发请求等待同一次下载
+type call struct {
+	done chan struct{}
+	err  error
+}
+
+// store 按最近最少使用淘汰的磁盘缓存
+type store struct {
+	dir     string
+	maxSize int64
+
+	mu        sync.Mutex
+	lru       *list.List               // 最近使用的条目在前
+	entries   map[string]*list.Element // key -> 条目
+	byPath    map[string]string        // 远程文件路径 -> 最新的 key
+	calls     map[string]*call
+	size      int64
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+func newStore(dir string, maxSize int64) (*store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &store{
+		dir:     dir,
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		byPath:  make(map[string]string),
+		calls:   make(map[string]*call),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load 加载缓存目录中已有的文件，按修改时间确定淘汰顺序
+func (s *store) load() error {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	var infos []os.FileInfo
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.Type().IsRegular() {
+			continue
+		}
+		// 只处理缓存创建的文件，缓存目录中的其他文件保持不变
+		if isTempName(dirEntry.Name()) {
+			_ = os.Remove(filepath.Join(s.dir, dirEntry.Name()))
+			continue
+		}
+		if !isKey(dirEntry.Name()) {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b os.FileInfo) int {
+		return b.ModTime().Compare(a.ModTime())
+	})
+
+	for _, info := range infos {
+		s.entries[info.Name()] = s.lru.PushBack(&entry{key: info.Name(), size: info.Size()})
+		s.size += info.Size()
+	}
+	s.evict()
+	return nil
+}
+
+// isKey 判断文件名是否为 cacheKey 生成的 key，即 64 位小写十六进制字符
+func isKey(name string) bool {
+	if len(name) != 64 {
+		return false
+	}
+	for _, c := range name {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// isTempName 判断文件名是否为 fill 创建的临时文件，即 <key>-<随机数字>.tmp
+func isTempName(name string) bool {
+	name, ok := strings.CutSuffix(name, tempSuffix)
+	if !ok {
+		return false
+	}
+	key, random, ok := strings.Cut(name, "-")
+	if !ok || !isKey(key) || random == "" {
+		return false
+	}
+	for _, c := range random {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// open 打开 key 对应的缓存文件，未命中时通过 fetch 下载，相同 key 的并发请求只下载一次
+func (s *store) open(ctx context.Context, key, path string, size int64, fetch func(ctx context.Context) (io.ReadCloser, error)) (*os.File, error) {
+	hit := true
+	for {
+		s.mu.Lock()
+		if elem, ok := s.entries[key]; ok {
+			s.lru.MoveToFront(elem)
+			s.bindLocked(key, path)
+			if hit {
+				s.hits++
+			} else {
+				s.misses++
+			}
+			s.mu.Unlock()
+
+			file, err := os.Open(s.filename(key))
+			if errors.Is(err, os.ErrNotExist) {
+				// 打开前被淘汰，重新下载
+				s.remove(key)
+				hit = false
+				continue
+			}
+			return file, err
+		}
+		hit = false
+
+		c, waiting := s.calls[key]
+		if !waiting {
+			c = &call{done: make(chan struct{})}
+			s.calls[key] = c
+			s.mu.Unlock()
+
+			c.err = s.fill(ctx, key, path, size, fetch)
+			s.mu.Lock()
+			delete(s.calls, key)
+			s.mu.Unlock()
+			close(c.done)
+		} else {
+			s.mu.Unlock()
+			select {
+			case <-c.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		if c.err != nil {
+			// 发起下载的请求被取消时，由仍在等待的请求重新下载
+			if ctx.Err() == nil && (errors.Is(c.err, context.Canceled) || errors.Is(c.err, context.DeadlineExceeded)) && waiting {
+				continue
+			}
+			s.mu.Lock()
+			s.misses++
+			s.mu.Unlock()
+			return nil, c.err
+		}
+	}
+}
+
+// fill 下载文件并加入缓存，size 为文件的预期大小
+func (s *store) fill(ctx context.Context, key, path string, size int64, fetch func(ctx context.Context) (io.ReadCloser, error)) error {
+	body, err := fetch(ctx)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(s.dir, key+"-*"+tempSuffix)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n != size {
+		err = errChanged
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.filename(key))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[key]; ok {
+		return nil
+	}
+	s.entries[key] = s.lru.PushFront(&entry{key: key, size: size})
+	s.bindLocked(key, path)
+	s.size += size
+	s.evict()
+	return nil
+}
+
+// bindLocked 记录 key 对应的远程文件路径，同一路径旧版本的缓存不会再被读取，直接删除
+func (s *store) bindLocked(key, path string) {
+	if oldKey, ok := s.byPath[path]; ok && oldKey != key {
+		s.removeLocked(oldKey)
+	}
+	s.entries[key].Value.(*entry).path = path
+	s.byPath[path] = key
+}
+
+// evict 淘汰最近最少使用的条目，直到占用空间不超过上限，调用时需持有锁
+func (s *store) evict() {
+	for s.size > s.maxSize && s.lru.Len() > 1 {
+		s.removeLocked(s.lru.Back().Value.(*entry).key)
+		s.evictions++
+	}
+}
+
+// remove 删除 key 对应的缓存
+func (s *store) remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(key)
+}
+
+func (s *store) removeLocked(key string) {
+	elem, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	e := elem.Value.(*entry)
+	s.lru.Remove(elem)
+	delete(s.entries, key)
+	if s.byPath[e.path] == key {
+		delete(s.byPath, e.path)
+	}
+	s.size -= e.size
+	// 已打开的缓存文件在关闭前仍可读取
+	_ = os.Remove(s.filename(key))
+}
+
+// invalidate 删除路径为 path 或位于目录 path 下的缓存
+func (s *store) invalidate(path string, dir bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.byPath[path]; ok {
+		s.removeLocked(key)
+	}
+	if !dir {
+		return
+	}
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	if prefix == "/" {
+		prefix = ""
+	}
+	for p, key := range s.byPath {
+		if strings.HasPrefix(p, prefix) {
+			s.removeLocked(key)
+		}
+	}
+}
+
+// miss 记录一次未使用缓存的读取
+func (s *store) miss() {
+	s.mu.Lock()
+	s.misses++
+	s.mu.Unlock()
+}
+
+func (s *store) stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Stats{
+		Hits:      s.hits,
+		Misses:    s.misses,
+		Evictions: s.evictions,
+		Entries:   s.lru.Len(),
+		Size:      s.size,
+	}
+}
+
+func (s *store) filename(key string) string {
+	return filepath.Join(s.dir, key)
+}
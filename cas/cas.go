@@ -0,0 +1,402 @@
+// Package cas 在任意文件系统之上提供内容寻址的去重存储，相同内容只保存一份
+package cas
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	pathpkg "path"
+	"sync"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+// 底层文件系统中的目录
+const (
+	refDir    = "refs"    // 引用记录，与逻辑路径一一对应
+	blobDir   = "blobs"   // 按 SHA-256 存储的内容
+	linkDir   = "links"   // 引用计数，每个引用在 links/<hash>/ 下有一个标记文件
+	uploadDir = "uploads" // 分片上传的临时文件
+)
+
+// DefaultGracePeriod 默认的回收宽限期
+const DefaultGracePeriod = time.Hour
+
+// maxRecordSize 引用记录的大小上限
+const maxRecordSize = 1 << 20
+
+var (
+	ErrInvalidHash   = errors.New("cas: invalid hash")             // 哈希不是小写十六进制的 SHA-256
+	ErrInvalidRecord = errors.New("cas: invalid reference record") // 引用记录无法解析
+)
+
+// Config 去重存储配置
+type Config struct {
+	// TempDir 写入与上传时暂存数据的本地目录，用于在上传前计算哈希，为空时使用 os.TempDir()
+	TempDir string
+	// GracePeriod 回收宽限期，GC 不回收在宽限期内创建的内容与引用，避免回收其他进程正在写入的内容，
+	// 为 0 时使用 DefaultGracePeriod，小于 0 时不设宽限期
+	GracePeriod time.Duration
+}
+
+// Store 内容寻址的去重存储，实现 fs.FileSystem。
+// 内容按 SHA-256 保存在底层文件系统的 blobs 目录，逻辑路径在 refs 目录中保存为记录哈希、大小、类型与元数据的引用，
+// 每个引用在 links 目录中有一个标记文件作为引用计数。Copy 只写入引用，不复制内容；
+// 不再被引用的内容由 GC 回收
+type Store struct {
+	fsys        fs.FileSystem
+	tempDir     string
+	gracePeriod time.Duration
+	paths       keyLocker // 逻辑路径的锁
+	hashes      keyLocker // 内容的锁，写入引用与回收内容互斥
+}
+
+// New 返回基于 fsys 的去重存储，fsys 应专用于该存储
+func New(fsys fs.FileSystem, conf Config) *Store {
+	s := &Store{
+		fsys:        fsys,
+		tempDir:     conf.TempDir,
+		gracePeriod: conf.GracePeriod,
+	}
+	if s.gracePeriod == 0 {
+		s.gracePeriod = DefaultGracePeriod
+	}
+	return s
+}
+
+// HasBlob 判断内容是否已经存在，hash 为小写十六进制的 SHA-256。
+// 客户端可以在上传前计算哈希，内容已存在时通过 Link 直接创建引用，不再上传（秒传）
+func (s *Store) HasBlob(ctx context.Context, hash string) (bool, error) {
+	if !validHash(hash) {
+		return false, ErrInvalidHash
+	}
+	return s.fsys.IsFile(ctx, blobPath(hash))
+}
+
+// Link 在 path 处创建引用已有内容的文件，内容不存在时返回 fs.ErrNotExist。
+// 支持 fs.WithContentType 与 fs.WithMetadata
+func (s *Store) Link(ctx context.Context, path, hash string, opts ...fs.Option) error {
+	if !validHash(hash) {
+		return ErrInvalidHash
+	}
+	info, err := s.fsys.Stat(ctx, blobPath(hash))
+	if err != nil {
+		return fs.NewPathError("cas", "Link", path, err, nil)
+	}
+	return s.commit(ctx, "Link", path, newRecord(hash, info.Size(), opts), nil)
+}
+
+// RefCount 返回内容的引用数，包括尚未被 GC 清理的失效引用
+func (s *Store) RefCount(ctx context.Context, hash string) (int, error) {
+	if !validHash(hash) {
+		return 0, ErrInvalidHash
+	}
+	links, err := s.links(ctx, hash)
+	return len(links), err
+}
+
+// record 引用记录
+type record struct {
+	Hash        string            `json:"hash"`
+	Size        int64             `json:"size"`
+	ContentType string            `json:"content_type,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+func newRecord(hash string, size int64, opts []fs.Option) *record {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &record{Hash: hash, Size: size, ContentType: o.ContentType, Metadata: toStringMetadata(o.Metadata)}
+}
+
+// readRef 读取 path 的引用记录
+func (s *Store) readRef(ctx context.Context, op, path string) (*record, error) {
+	reader, err := s.fsys.Open(ctx, refPath(path))
+	if err != nil {
+		return nil, fs.NewPathError("cas", op, path, err, nil)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(reader, maxRecordSize))
+	if err != nil {
+		return nil, fs.NewPathError("cas", op, path, err, nil)
+	}
+	rec := &record{}
+	if err = json.Unmarshal(data, rec); err != nil || !validHash(rec.Hash) {
+		return nil, fs.NewPathError("cas", op, path, ErrInvalidRecord, nil)
+	}
+	return rec, nil
+}
+
+// writeRef 写入 path 的引用记录
+func (s *Store) writeRef(ctx context.Context, path string, rec *record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.put(ctx, refPath(path), data)
+}
+
+// put 写入一个小文件
+func (s *Store) put(ctx context.Context, path string, data []byte) error {
+	writer, err := s.fsys.Create(ctx, path)
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(data); err != nil {
+		_ = writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// commit 在 path 处写入引用 rec。内容不存在时调用 upload 上传到 blob，upload 为 nil 时返回 fs.ErrNotExist。
+// 新引用写入后移除旧引用的计数
+func (s *Store) commit(ctx context.Context, op, path string, rec *record, upload func(blob string) error) error {
+	path = fs.CleanPath(path)
+	unlock := s.paths.lock(path)
+	defer unlock()
+
+	old, err := s.readRef(ctx, op, path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err = s.link(ctx, op, path, rec, upload); err != nil {
+		return err
+	}
+	if old != nil && old.Hash != rec.Hash {
+		return s.unlink(ctx, path, old.Hash)
+	}
+	return nil
+}
+
+// link 确保内容存在后增加引用计数并写入引用记录，期间持有内容的锁，GC 不会回收该内容
+func (s *Store) link(ctx context.Context, op, path string, rec *record, upload func(blob string) error) error {
+	unlock := s.hashes.lock(rec.Hash)
+	defer unlock()
+
+	blob := blobPath(rec.Hash)
+	exists, err := s.fsys.IsFile(ctx, blob)
+	if err != nil {
+		return fs.NewPathError("cas", op, path, err, nil)
+	}
+	if !exists {
+		if upload == nil {
+			return fs.NewPathError("cas", op, path, fmt.Errorf("blob %s: %w", rec.Hash, fs.ErrNotExist), fs.ErrNotExist)
+		}
+		if err = upload(blob); err != nil {
+			return fs.NewPathError("cas", op, path, err, nil)
+		}
+	}
+	if err = s.put(ctx, linkPath(rec.Hash, path), []byte(path)); err != nil {
+		return fs.NewPathError("cas", op, path, err, nil)
+	}
+	if err = s.writeRef(ctx, path, rec); err != nil {
+		return fs.NewPathError("cas", op, path, err, nil)
+	}
+	return nil
+}
+
+// unlink 移除 path 对内容的引用计数
+func (s *Store) unlink(ctx context.Context, path, hash string) error {
+	unlock := s.hashes.lock(hash)
+	defer unlock()
+	return ignoreNotExist(s.fsys.Remove(ctx, linkPath(hash, path)))
+}
+
+// links 返回内容的引用标记
+func (s *Store) links(ctx context.Context, hash string) ([]fs.FileInfo, error) {
+	infos, err := s.fsys.List(ctx, pathpkg.Join(linkDir, hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	links := infos[:0]
+	for _, info := range infos {
+		if !info.IsDir() {
+			links = append(links, info)
+		}
+	}
+	return links, nil
+}
+
+// refPath 返回逻辑路径的引用记录在底层文件系统中的路径
+func refPath(path string) string {
+	return pathpkg.Join(refDir, fs.CleanPath(path))
+}
+
+// blobPath 返回内容在底层文件系统中的路径，按哈希的前两级分目录
+func blobPath(hash string) string {
+	return pathpkg.Join(blobDir, hash[:2], hash[2:4], hash)
+}
+
+// linkPath 返回 path 对内容的引用标记的路径，标记文件以路径的哈希命名，内容为路径
+func linkPath(hash, path string) string {
+	sum := sha256.Sum256([]byte(fs.CleanPath(path)))
+	return pathpkg.Join(linkDir, hash, hex.EncodeToString(sum[:]))
+}
+
+// validHash 判断是否为小写十六进制的 SHA-256
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func ignoreNotExist(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func toStringMetadata(metadata map[string]any) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		result[k] = fmt.Sprintf("%v", v)
+	}
+	return result
+}
+
+// spool 将数据暂存到本地临时文件并计算哈希
+type spool struct {
+	file *os.File
+	hash []byte
+	size int64
+}
+
+// newSpool 暂存 reader 的全部数据
+func newSpool(dir string, reader io.Reader) (*spool, error) {
+	w, err := newSpoolWriter(dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(w, reader); err != nil {
+		w.remove()
+		return nil, err
+	}
+	return w.finish()
+}
+
+// spoolWriter 写入暂存文件
+type spoolWriter struct {
+	file *os.File
+	sum  hashWriter
+}
+
+func newSpoolWriter(dir string) (*spoolWriter, error) {
+	file, err := os.CreateTemp(dir, "cas-*")
+	if err != nil {
+		return nil, err
+	}
+	return &spoolWriter{file: file, sum: hashWriter{h: sha256.New()}}, nil
+}
+
+func (w *spoolWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	_, _ = w.sum.Write(p[:n])
+	return n, err
+}
+
+// finish 结束写入，返回可以从头读取的暂存数据
+func (w *spoolWriter) finish() (*spool, error) {
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		w.remove()
+		return nil, err
+	}
+	return &spool{file: w.file, hash: w.sum.h.Sum(nil), size: w.sum.n}, nil
+}
+
+func (w *spoolWriter) remove() {
+	_ = w.file.Close()
+	_ = os.Remove(w.file.Name())
+}
+
+func (s *spool) sum() string {
+	return hex.EncodeToString(s.hash)
+}
+
+func (s *spool) remove() {
+	_ = s.file.Close()
+	_ = os.Remove(s.file.Name())
+}
+
+// hashWriter 计算写入数据的哈希与大小
+type hashWriter struct {
+	h interface {
+		io.Writer
+		Sum([]byte) []byte
+	}
+	n int64
+}
+
+func (w *hashWriter) Write(p []byte) (int, error) {
+	n, err := w.h.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// hashReader 读取 reader 的全部数据，返回哈希与大小
+func hashReader(reader io.Reader) (string, int64, error) {
+	w := &hashWriter{h: sha256.New()}
+	if _, err := io.Copy(w, reader); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(w.h.Sum(nil)), w.n, nil
+}
+
+// keyLocker 按 key 加锁，不再使用的锁会被释放
+type keyLocker struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock 锁定 key，返回解锁函数
+func (l *keyLocker) lock(key string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLock)
+	}
+	kl, ok := l.locks[key]
+	if !ok {
+		kl = &keyLock{}
+		l.locks[key] = kl
+	}
+	kl.refs++
+	l.mu.Unlock()
+
+	kl.Lock()
+	return func() {
+		kl.Unlock()
+		l.mu.Lock()
+		if kl.refs--; kl.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
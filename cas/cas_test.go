@@ -0,0 +1,285 @@
+package cas_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/cas"
+	"github.com/goairix/fs/driver/memory"
+)
+
+func newStore(t *testing.T, conf cas.Config) (*cas.Store, fs.FileSystem) {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.TempDir = t.TempDir()
+	return cas.New(m, conf), m
+}
+
+func sum(data string) string {
+	h := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(h[:])
+}
+
+func write(t *testing.T, fsys fs.FileSystem, path, data string) {
+	t.Helper()
+	w, err := fsys.Create(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func read(t *testing.T, fsys fs.FileSystem, path string) string {
+	t.Helper()
+	r, err := fsys.Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// blobs 返回底层文件系统中保存的内容数
+func blobs(t *testing.T, m fs.FileSystem) int {
+	t.Helper()
+	n := 0
+	err := fs.Walk(context.Background(), m, "blobs", func(_ string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			n++
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func refCount(t *testing.T, s *cas.Store, hash string) int {
+	t.Helper()
+	n, err := s.RefCount(context.Background(), hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestDedup(t *testing.T) {
+	ctx := context.Background()
+	s, m := newStore(t, cas.Config{})
+	data := strings.Repeat("pdf content ", 1000)
+
+	write(t, s, "a/x.pdf", data)
+	if err := s.Uploader().Upload(ctx, "b/y.pdf", iotest.OneByteReader(strings.NewReader(data))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Uploader().Upload(ctx, "b/z.pdf", strings.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if n := blobs(t, m); n != 1 {
+		t.Fatalf("stored %d blobs for identical content, want 1", n)
+	}
+	for _, path := range []string{"a/x.pdf", "b/y.pdf", "b/z.pdf"} {
+		if got := read(t, s, path); got != data {
+			t.Fatalf("content of %s differs, got %d bytes", path, len(got))
+		}
+	}
+
+	info, err := s.Stat(ctx, "a/x.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etagger, ok := info.(fs.ETagger); !ok || etagger.ETag() != sum(data) || info.Size() != int64(len(data)) {
+		t.Fatalf("Stat = %v, want the content hash as ETag", info)
+	}
+	if mimeType, err := s.GetMimeType(ctx, "a/x.pdf"); err != nil || mimeType != "application/pdf" {
+		t.Fatalf("GetMimeType = %q, %v", mimeType, err)
+	}
+
+	// 内容已存在时直接创建引用
+	if ok, err := s.HasBlob(ctx, sum(data)); !ok || err != nil {
+		t.Fatalf("HasBlob = %v, %v", ok, err)
+	}
+	if err := s.Link(ctx, "c/linked.pdf", sum(data), fs.WithMetadata(fs.Metadata{"k": "v"})); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, s, "c/linked.pdf"); got != data {
+		t.Fatalf("linked content differs, got %d bytes", len(got))
+	}
+	if metadata, err := s.GetMetadata(ctx, "c/linked.pdf"); err != nil || metadata["k"] != "v" {
+		t.Fatalf("GetMetadata of the link = %v, %v", metadata, err)
+	}
+	if err := s.Link(ctx, "c/missing.pdf", sum("missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Link missing content: %v, want ErrNotExist", err)
+	}
+	if _, err := s.HasBlob(ctx, "zz"); !errors.Is(err, cas.ErrInvalidHash) {
+		t.Fatalf("HasBlob invalid hash: %v, want ErrInvalidHash", err)
+	}
+
+	// 复制只写入引用
+	if err := s.Copy(ctx, "a/x.pdf", "a/copy.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if n := blobs(t, m); n != 1 || refCount(t, s, sum(data)) != 5 {
+		t.Fatalf("after Copy: %d blobs, %d references", n, refCount(t, s, sum(data)))
+	}
+}
+
+func TestRefCount(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newStore(t, cas.Config{})
+	write(t, s, "a.txt", "same")
+	write(t, s, "d/b.txt", "same")
+	write(t, s, "d/c.txt", "same")
+	if n := refCount(t, s, sum("same")); n != 3 {
+		t.Fatalf("RefCount = %d, want 3", n)
+	}
+
+	// 覆盖释放原内容的引用
+	write(t, s, "a.txt", "other")
+	if n := refCount(t, s, sum("same")); n != 2 {
+		t.Fatalf("RefCount after overwrite = %d, want 2", n)
+	}
+	if err := s.Move(ctx, "d/b.txt", "e.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if n := refCount(t, s, sum("same")); n != 2 {
+		t.Fatalf("RefCount after Move = %d, want 2", n)
+	}
+	if err := s.Remove(ctx, "e.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveDir(ctx, "d"); err != nil {
+		t.Fatal(err)
+	}
+	if n := refCount(t, s, sum("same")); n != 0 {
+		t.Fatalf("RefCount after removing all references = %d, want 0", n)
+	}
+	if n := refCount(t, s, sum("other")); n != 1 {
+		t.Fatalf("RefCount of the new content = %d, want 1", n)
+	}
+}
+
+func TestGC(t *testing.T) {
+	ctx := context.Background()
+	s, m := newStore(t, cas.Config{GracePeriod: -1})
+	garbage := strings.Repeat("garbage", 100)
+	write(t, s, "keep.txt", "keep")
+	write(t, s, "a.txt", garbage)
+	write(t, s, "d/b.txt", garbage)
+
+	if err := s.Remove(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := s.GC(ctx)
+	if err != nil || stats.Removed != 0 || stats.Blobs != 2 {
+		t.Fatalf("GC with a remaining reference = %+v, %v", stats, err)
+	}
+	if err := s.RemoveDir(ctx, "d"); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = s.GC(ctx)
+	if err != nil || stats.Removed != 1 || stats.RemovedBytes != int64(len(garbage)) {
+		t.Fatalf("GC = %+v, %v", stats, err)
+	}
+	if ok, _ := s.HasBlob(ctx, sum(garbage)); ok {
+		t.Fatal("unreferenced content was not removed")
+	}
+	if got := read(t, s, "keep.txt"); got != "keep" || blobs(t, m) != 1 {
+		t.Fatalf("referenced content was removed, read %q", got)
+	}
+
+	// 引用记录被其他进程改写后清理失效的引用标记
+	write(t, s, "stale.txt", "stale")
+	raw, err := m.Create(ctx, "refs/stale.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fmt.Fprintf(raw, `{"hash":%q,"size":4}`, sum("keep")); err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = s.GC(ctx)
+	if err != nil || stats.StaleLinks != 1 || stats.Removed != 1 {
+		t.Fatalf("GC with a stale link = %+v, %v", stats, err)
+	}
+
+	// 宽限期内的内容不回收
+	g := cas.New(m, cas.Config{TempDir: t.TempDir()})
+	write(t, g, "fresh.txt", "fresh")
+	if err := g.Remove(ctx, "fresh.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err := g.GC(ctx); err != nil || stats.Removed != 0 {
+		t.Fatalf("GC within the grace period = %+v, %v", stats, err)
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newStore(t, cas.Config{})
+	u := s.Uploader()
+	id1, err := u.InitMultipartUpload(ctx, "a.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, err := u.InitMultipartUpload(ctx, "a.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id1 == id2 {
+		t.Fatal("concurrent uploads to the same path share an upload ID")
+	}
+	etag1, err := u.UploadPart(ctx, "a.bin", id1, 1, strings.NewReader("one"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag2, err := u.UploadPart(ctx, "a.bin", id2, 1, strings.NewReader("two"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploads, err := u.ListMultipartUploads(ctx); err != nil || len(uploads) != 2 {
+		t.Fatalf("ListMultipartUploads = %v, %v", uploads, err)
+	}
+
+	if err := u.CompleteMultipartUpload(ctx, "a.bin", id1, []fs.MultipartPart{{PartNumber: 1, ETag: etag1}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, s, "a.bin"); got != "one" {
+		t.Fatalf("read %q after the first upload", got)
+	}
+	if err := u.CompleteMultipartUpload(ctx, "a.bin", id2, []fs.MultipartPart{{PartNumber: 1, ETag: etag2}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, s, "a.bin"); got != "two" || refCount(t, s, sum("one")) != 0 {
+		t.Fatalf("read %q after the second upload", got)
+	}
+	if _, err := u.UploadPart(ctx, "a.bin", "../x", 1, strings.NewReader("x")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("UploadPart with an invalid upload ID: %v, want ErrNotExist", err)
+	}
+}
@@ -0,0 +1,460 @@
+package cas
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+// listConcurrency List 时同时读取引用记录的文件数
+const listConcurrency = 16
+
+func (s *Store) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
+	infos, err := s.fsys.List(ctx, refPath(path), opts...)
+	if err != nil {
+		if fs.CleanPath(path) == "" && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return infos, s.fileInfos(ctx, path, infos)
+}
+
+func (s *Store) MakeDir(ctx context.Context, path string, perm os.FileMode, opts ...fs.Option) error {
+	return s.fsys.MakeDir(ctx, refPath(path), perm, opts...)
+}
+
+// RemoveDir 删除目录下的全部引用后删除目录，内容由 GC 回收
+func (s *Store) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
+	var files []string
+	err := fs.Walk(ctx, s.fsys, refPath(path), func(name string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files = append(files, logicalPath(name))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for _, file := range files {
+		if err = s.Remove(ctx, file); err != nil {
+			return err
+		}
+	}
+	return s.fsys.RemoveDir(ctx, refPath(path), opts...)
+}
+
+// Create 将写入的数据暂存到本地，关闭时计算哈希，内容不存在时才上传
+func (s *Store) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	w, err := newSpoolWriter(s.tempDir)
+	if err != nil {
+		return nil, fs.NewPathError("cas", "Create", path, err, nil)
+	}
+	return &writer{spoolWriter: w, done: func(sp *spool) error {
+		return s.store(ctx, "Create", path, sp, opts)
+	}}, nil
+}
+
+// store 将暂存的数据保存到 path
+func (s *Store) store(ctx context.Context, op, path string, sp *spool, opts []fs.Option) error {
+	defer sp.remove()
+	rec := newRecord(sp.sum(), sp.size, opts)
+	return s.commit(ctx, op, path, rec, func(blob string) error {
+		return s.fsys.Uploader().Upload(ctx, blob, sp.file, blobOptions(path, rec, opts)...)
+	})
+}
+
+// blobOptions 返回上传内容的选项，内容不带逻辑路径的元数据，类型取自引用或逻辑路径的扩展名
+func blobOptions(path string, rec *record, opts []fs.Option) []fs.Option {
+	opts = append(opts[:len(opts):len(opts)], func(o *fs.Options) {
+		o.Metadata = nil
+	})
+	if rec.ContentType == "" {
+		if contentType := fs.TypeByExtension(path); contentType != "" {
+			opts = append(opts, fs.WithContentType(contentType))
+		}
+	}
+	return opts
+}
+
+func (s *Store) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	rec, err := s.readRef(ctx, "Open", path)
+	if err != nil {
+		return nil, err
+	}
+	return s.fsys.Open(ctx, blobPath(rec.Hash), opts...)
+}
+
+// OpenFile 只读打开时读取内容，写入时与 Create 相同，不支持 os.O_RDWR 与 os.O_APPEND
+func (s *Store) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		reader, err := s.Open(ctx, path, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &readOnlyFile{ReadCloser: reader}, nil
+	}
+	if flag&(os.O_RDWR|os.O_APPEND) != 0 {
+		return nil, fs.NewPathError("cas", "OpenFile", path, fs.ErrUnsupported, nil)
+	}
+	if flag&os.O_EXCL != 0 {
+		exists, err := s.Exists(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fs.NewPathError("cas", "OpenFile", path, fs.ErrExist, nil)
+		}
+	}
+	w, err := s.Create(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &writeOnlyFile{writer: w.(*writer)}, nil
+}
+
+// Remove 删除引用，内容由 GC 回收
+func (s *Store) Remove(ctx context.Context, path string, opts ...fs.Option) error {
+	path = fs.CleanPath(path)
+	unlock := s.paths.lock(path)
+	defer unlock()
+
+	rec, err := s.readRef(ctx, "Remove", path)
+	if err != nil {
+		return err
+	}
+	if err = s.fsys.Remove(ctx, refPath(path), opts...); err != nil {
+		return err
+	}
+	return s.unlink(ctx, path, rec.Hash)
+}
+
+// Copy 只写入引用，不复制内容
+func (s *Store) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	rec, err := s.readRef(ctx, "Copy", src)
+	if err != nil {
+		return err
+	}
+	return s.commit(ctx, "Copy", dst, rec, nil)
+}
+
+func (s *Store) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	return s.move(ctx, "Move", src, dst)
+}
+
+func (s *Store) Rename(ctx context.Context, oldPath, newPath string, opts ...fs.Option) error {
+	return s.move(ctx, "Rename", oldPath, newPath)
+}
+
+// move 写入新引用后删除旧引用
+func (s *Store) move(ctx context.Context, op, src, dst string) error {
+	if fs.CleanPath(src) == fs.CleanPath(dst) {
+		_, err := s.readRef(ctx, op, src)
+		return err
+	}
+	rec, err := s.readRef(ctx, op, src)
+	if err != nil {
+		return err
+	}
+	if err = s.commit(ctx, op, dst, rec, nil); err != nil {
+		return err
+	}
+	return ignoreNotExist(s.Remove(ctx, src))
+}
+
+// Stat 返回引用记录中的大小，ETag 为内容的 SHA-256
+func (s *Store) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	info, err := s.fsys.Stat(ctx, refPath(path), opts...)
+	if err != nil {
+		if fs.CleanPath(path) == "" && errors.Is(err, fs.ErrNotExist) {
+			return &fileInfo{name: ".", isDir: true}, nil
+		}
+		return nil, err
+	}
+	return s.fileInfo(ctx, path, info)
+}
+
+// fileInfo 将引用记录的文件信息转换为逻辑路径的文件信息
+func (s *Store) fileInfo(ctx context.Context, path string, info fs.FileInfo) (fs.FileInfo, error) {
+	name := pathpkg.Base(fs.CleanPath(path))
+	if fs.CleanPath(path) == "" {
+		name = "."
+	}
+	if info.IsDir() {
+		return &fileInfo{name: name, modTime: info.ModTime(), isDir: true}, nil
+	}
+	rec, err := s.readRef(ctx, "Stat", path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{name: name, size: rec.Size, modTime: info.ModTime(), hash: rec.Hash}, nil
+}
+
+// fileInfos 并发读取 dir 下各文件的引用记录，将 infos 替换为逻辑路径的文件信息
+func (s *Store) fileInfos(ctx context.Context, dir string, infos []fs.FileInfo) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, listConcurrency)
+	)
+	for i, info := range infos {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			converted, err := s.fileInfo(ctx, pathpkg.Join(fs.CleanPath(dir), info.Name()), info)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			infos[i] = converted
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func (s *Store) GetMimeType(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	rec, err := s.readRef(ctx, "GetMimeType", path)
+	if err != nil {
+		return "", err
+	}
+	if rec.ContentType != "" {
+		return rec.ContentType, nil
+	}
+	if contentType := fs.TypeByExtension(path); contentType != "" {
+		return contentType, nil
+	}
+
+	// 读取文件前512字节用于检测文件类型
+	reader, err := s.fsys.Open(ctx, blobPath(rec.Hash), fs.WithRange(0, 512))
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	head, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head), nil
+}
+
+// SetMetadata 替换引用记录中的元数据，不修改内容
+func (s *Store) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	path = fs.CleanPath(path)
+	unlock := s.paths.lock(path)
+	defer unlock()
+
+	rec, err := s.readRef(ctx, "SetMetadata", path)
+	if err != nil {
+		return err
+	}
+	rec.Metadata = toStringMetadata(metadata)
+	if err = s.writeRef(ctx, path, rec); err != nil {
+		return fs.NewPathError("cas", "SetMetadata", path, err, nil)
+	}
+	return nil
+}
+
+func (s *Store) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]interface{}, error) {
+	rec, err := s.readRef(ctx, "GetMetadata", path)
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]interface{}, len(rec.Metadata))
+	for k, v := range rec.Metadata {
+		metadata[k] = v
+	}
+	return metadata, nil
+}
+
+func (s *Store) Exists(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	if fs.CleanPath(path) == "" {
+		return true, nil
+	}
+	return s.fsys.Exists(ctx, refPath(path), opts...)
+}
+
+func (s *Store) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	if fs.CleanPath(path) == "" {
+		return true, nil
+	}
+	return s.fsys.IsDir(ctx, refPath(path), opts...)
+}
+
+func (s *Store) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	return s.fsys.IsFile(ctx, refPath(path), opts...)
+}
+
+// SignFullUrl 返回内容的签名访问地址，地址中的文件名为内容的哈希
+func (s *Store) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	rec, err := s.readRef(ctx, "SignFullUrl", path)
+	if err != nil {
+		return "", err
+	}
+	return s.fsys.SignFullUrl(ctx, blobPath(rec.Hash), opts...)
+}
+
+// FullUrl 返回内容的访问地址，地址中的文件名为内容的哈希
+func (s *Store) FullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	rec, err := s.readRef(ctx, "FullUrl", path)
+	if err != nil {
+		return "", err
+	}
+	return s.fsys.FullUrl(ctx, blobPath(rec.Hash), opts...)
+}
+
+// RelativePath 不支持，同一内容可能被多个路径引用，无法从访问地址得到路径
+func (s *Store) RelativePath(ctx context.Context, fullUrl string, opts ...fs.Option) (string, error) {
+	return "", fs.NewPathError("cas", "RelativePath", fullUrl, fs.ErrUnsupported, nil)
+}
+
+func (s *Store) Uploader() fs.Uploader {
+	return &uploader{s: s}
+}
+
+func (s *Store) OpenReader(ctx context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	rec, err := s.readRef(ctx, "OpenReader", path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.OpenReader(ctx, s.fsys, blobPath(rec.Hash), opts...)
+}
+
+func (s *Store) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	return fs.Walk(ctx, s.fsys, refPath(root), func(name string, info fs.FileInfo, err error) error {
+		name = logicalPath(name)
+		if err == nil && info != nil {
+			if info, err = s.fileInfo(ctx, name, info); err != nil {
+				return err
+			}
+		}
+		return fn(name, info, err)
+	}, opts...)
+}
+
+func (s *Store) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	result, err := fs.ListPage(ctx, s.fsys, refPath(path), opts...)
+	if err != nil {
+		if fs.CleanPath(path) == "" && errors.Is(err, fs.ErrNotExist) {
+			return &fs.ListResult{}, nil
+		}
+		return nil, err
+	}
+	return result, s.fileInfos(ctx, path, result.Files)
+}
+
+// logicalPath 将引用记录在底层文件系统中的路径转换为逻辑路径
+func logicalPath(name string) string {
+	name = fs.CleanPath(name)
+	if name == refDir {
+		return ""
+	}
+	return strings.TrimPrefix(name, refDir+"/")
+}
+
+// fileInfo 逻辑路径的文件信息
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+	hash    string
+}
+
+func (i *fileInfo) Name() string {
+	return i.name
+}
+
+func (i *fileInfo) Size() int64 {
+	return i.size
+}
+
+func (i *fileInfo) Mode() os.FileMode {
+	if i.isDir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (i *fileInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+func (i *fileInfo) IsDir() bool {
+	return i.isDir
+}
+
+func (i *fileInfo) Sys() interface{} {
+	return nil
+}
+
+// ETag 返回内容的 SHA-256，目录返回空字符串
+func (i *fileInfo) ETag() string {
+	return i.hash
+}
+
+// writer 暂存写入的数据，关闭时保存
+type writer struct {
+	*spoolWriter
+	done   func(sp *spool) error
+	closed bool
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	return w.spoolWriter.Write(p)
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return os.ErrClosed
+	}
+	w.closed = true
+	sp, err := w.finish()
+	if err != nil {
+		return err
+	}
+	return w.done(sp)
+}
+
+// readOnlyFile 只读打开的文件
+type readOnlyFile struct {
+	io.ReadCloser
+}
+
+func (f *readOnlyFile) Write([]byte) (int, error) {
+	return 0, os.ErrPermission
+}
+
+// writeOnlyFile 只写打开的文件
+type writeOnlyFile struct {
+	*writer
+}
+
+func (f *writeOnlyFile) Read([]byte) (int, error) {
+	return 0, os.ErrPermission
+}
@@ -0,0 +1,130 @@
+package cas
+
+import (
+	"context"
+	"errors"
+	"io"
+	pathpkg "path"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+// GCStats 垃圾回收的统计
+type GCStats struct {
+	Blobs        int   // 检查的内容数
+	Removed      int   // 回收的内容数
+	RemovedBytes int64 // 回收的内容大小
+	StaleLinks   int   // 清理的失效引用数
+}
+
+// GC 回收不再被引用的内容。检查每个内容的引用标记，标记对应的路径已删除或引用了其他内容时清理该标记，
+// 没有有效引用的内容被删除。检查与删除一个内容时持有该内容的锁，与同一个 Store 并发的写入互斥；
+// 宽限期内创建的内容与标记不会被回收或清理，避免影响其他进程正在进行的写入
+func (s *Store) GC(ctx context.Context) (GCStats, error) {
+	var (
+		stats GCStats
+		blobs []fs.FileInfo
+	)
+	err := fs.Walk(ctx, s.fsys, blobDir, func(name string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && validHash(info.Name()) {
+			blobs = append(blobs, info)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return stats, err
+	}
+
+	now := time.Now()
+	for _, blob := range blobs {
+		if err = ctx.Err(); err != nil {
+			return stats, err
+		}
+		stats.Blobs++
+		if s.recent(now, blob.ModTime()) {
+			continue
+		}
+		if err = s.collect(ctx, now, blob, &stats); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// collect 清理内容的失效引用，没有有效引用时删除内容
+func (s *Store) collect(ctx context.Context, now time.Time, blob fs.FileInfo, stats *GCStats) error {
+	hash := blob.Name()
+	unlock := s.hashes.lock(hash)
+	defer unlock()
+
+	links, err := s.links(ctx, hash)
+	if err != nil {
+		return err
+	}
+	live := 0
+	for _, link := range links {
+		if s.recent(now, link.ModTime()) {
+			live++
+			continue
+		}
+		name := pathpkg.Join(linkDir, hash, link.Name())
+		ok, err := s.validLink(ctx, name, hash)
+		if err != nil {
+			return err
+		}
+		if ok {
+			live++
+			continue
+		}
+		if err = ignoreNotExist(s.fsys.Remove(ctx, name)); err != nil {
+			return err
+		}
+		stats.StaleLinks++
+	}
+	if live > 0 {
+		return nil
+	}
+
+	if err = ignoreNotExist(s.fsys.Remove(ctx, blobPath(hash))); err != nil {
+		return err
+	}
+	// 本地文件系统需要删除空的标记目录
+	_ = s.fsys.RemoveDir(ctx, pathpkg.Join(linkDir, hash))
+	stats.Removed++
+	stats.RemovedBytes += blob.Size()
+	return nil
+}
+
+// validLink 判断引用标记对应的路径是否仍然引用该内容
+func (s *Store) validLink(ctx context.Context, name, hash string) (bool, error) {
+	reader, err := s.fsys.Open(ctx, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	path, err := io.ReadAll(io.LimitReader(reader, maxRecordSize))
+	_ = reader.Close()
+	if err != nil {
+		return false, err
+	}
+
+	rec, err := s.readRef(ctx, "GC", string(path))
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrInvalidRecord) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return rec.Hash == hash, nil
+}
+
+// recent 判断是否在宽限期内
+func (s *Store) recent(now, modTime time.Time) bool {
+	return s.gracePeriod > 0 && now.Sub(modTime) < s.gracePeriod
+}
@@ -0,0 +1,186 @@
+package cas
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	pathpkg "path"
+	"strings"
+
+	"github.com/goairix/fs"
+)
+
+// uploader 去重存储的上传器
+type uploader struct {
+	s *Store
+}
+
+// Upload 计算哈希后上传，内容已存在时不上传。reader 实现 io.Seeker 时先读取一遍计算哈希，否则暂存到本地
+func (u *uploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	seeker, ok := reader.(io.ReadSeeker)
+	if !ok {
+		sp, err := newSpool(u.s.tempDir, reader)
+		if err != nil {
+			return fs.NewPathError("cas", "Upload", path, err, nil)
+		}
+		return u.s.store(ctx, "Upload", path, sp, opts)
+	}
+
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fs.NewPathError("cas", "Upload", path, err, nil)
+	}
+	hash, size, err := hashReader(seeker)
+	if err != nil {
+		return fs.NewPathError("cas", "Upload", path, err, nil)
+	}
+	rec := newRecord(hash, size, opts)
+	return u.s.commit(ctx, "Upload", path, rec, func(blob string) error {
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		return u.s.fsys.Uploader().Upload(ctx, blob, seeker, blobOptions(path, rec, opts)...)
+	})
+}
+
+// InitMultipartUpload 分片上传到底层文件系统的 uploads/<随机标识>/<path>，完成时计算哈希后转为内容。
+// 返回的 UploadID 包含随机标识，同一路径的并发上传互不影响
+func (u *uploader) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	token, err := newUploadToken()
+	if err != nil {
+		return "", fs.NewPathError("cas", "InitMultipartUpload", path, err, nil)
+	}
+	uploadID, err := u.s.fsys.Uploader().InitMultipartUpload(ctx, uploadPath(token, path), opts...)
+	if err != nil {
+		return "", err
+	}
+	return token + "." + uploadID, nil
+}
+
+func (u *uploader) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
+	upload, id, err := parseUploadID(path, uploadID)
+	if err != nil {
+		return "", fs.NewPathError("cas", "UploadPart", path, err, fs.ErrNotExist)
+	}
+	return u.s.fsys.Uploader().UploadPart(ctx, upload, id, partNumber, data, opts...)
+}
+
+// CompleteMultipartUpload 完成分片上传后读取一遍文件计算哈希，内容已存在时删除上传的文件，否则将其移动为内容。
+// 类型与元数据取自初始化分片上传时的选项
+func (u *uploader) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	upload, id, err := parseUploadID(path, uploadID)
+	if err != nil {
+		return fs.NewPathError("cas", "CompleteMultipartUpload", path, err, fs.ErrNotExist)
+	}
+	if err = u.s.fsys.Uploader().CompleteMultipartUpload(ctx, upload, id, parts, opts...); err != nil {
+		return err
+	}
+	defer func() {
+		_ = u.s.fsys.Remove(context.WithoutCancel(ctx), upload)
+	}()
+
+	rec, err := u.uploadRecord(ctx, upload)
+	if err != nil {
+		return fs.NewPathError("cas", "CompleteMultipartUpload", path, err, nil)
+	}
+	return u.s.commit(ctx, "CompleteMultipartUpload", path, rec, func(blob string) error {
+		return u.s.fsys.Move(ctx, upload, blob)
+	})
+}
+
+// uploadRecord 读取上传完成的文件，返回其引用记录
+func (u *uploader) uploadRecord(ctx context.Context, upload string) (*record, error) {
+	reader, err := u.s.fsys.Open(ctx, upload)
+	if err != nil {
+		return nil, err
+	}
+	hash, size, err := hashReader(reader)
+	_ = reader.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &record{Hash: hash, Size: size}
+	if rec.ContentType, err = u.s.fsys.GetMimeType(ctx, upload); err != nil {
+		return nil, err
+	}
+	// 不支持自定义元数据的驱动返回错误，此时不记录元数据
+	if metadata, err := u.s.fsys.GetMetadata(ctx, upload); err == nil {
+		rec.Metadata = toStringMetadata(metadata)
+	}
+	return rec, nil
+}
+
+func (u *uploader) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
+	upload, id, err := parseUploadID(path, uploadID)
+	if err != nil {
+		return fs.NewPathError("cas", "AbortMultipartUpload", path, err, fs.ErrNotExist)
+	}
+	return u.s.fsys.Uploader().AbortMultipartUpload(ctx, upload, id, opts...)
+}
+
+func (u *uploader) ListMultipartUploads(ctx context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
+	uploads, err := u.s.fsys.Uploader().ListMultipartUploads(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	result := uploads[:0]
+	for _, upload := range uploads {
+		rest, ok := strings.CutPrefix(fs.CleanPath(upload.Path), uploadDir+"/")
+		if !ok {
+			continue
+		}
+		token, name, ok := strings.Cut(rest, "/")
+		if !ok || !validUploadToken(token) {
+			continue
+		}
+		upload.Path = name
+		upload.UploadID = token + "." + upload.UploadID
+		result = append(result, upload)
+	}
+	return result, nil
+}
+
+func (u *uploader) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
+	upload, id, err := parseUploadID(path, uploadID)
+	if err != nil {
+		return nil, fs.NewPathError("cas", "ListUploadedParts", path, err, fs.ErrNotExist)
+	}
+	return u.s.fsys.Uploader().ListUploadedParts(ctx, upload, id, opts...)
+}
+
+// errInvalidUploadID UploadID 不是 InitMultipartUpload 返回的格式
+var errInvalidUploadID = errors.New("cas: invalid upload ID")
+
+// newUploadToken 返回分片上传的随机标识，32 位小写十六进制字符
+func newUploadToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func validUploadToken(token string) bool {
+	if len(token) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(token)
+	return err == nil && strings.ToLower(token) == token
+}
+
+// parseUploadID 将 <随机标识>.<驱动的 UploadID> 拆分为上传文件在底层文件系统中的路径与驱动的 UploadID
+func parseUploadID(path, uploadID string) (string, string, error) {
+	token, id, ok := strings.Cut(uploadID, ".")
+	if !ok || id == "" || !validUploadToken(token) {
+		return "", "", errInvalidUploadID
+	}
+	return uploadPath(token, path), id, nil
+}
+
+// uploadPath 返回分片上传的文件在底层文件系统中的路径
+func uploadPath(token, path string) string {
+	return pathpkg.Join(uploadDir, token, fs.CleanPath(path))
+}
@@ -0,0 +1,241 @@
+// Package compress 为文件系统提供透明压缩，写入时压缩，读取时解压
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/goairix/fs"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec 压缩算法
+type Codec string
+
+const (
+	Gzip Codec = "gzip" // gzip，兼容性最好
+	Zstd Codec = "zstd" // zstd，压缩率与速度更好
+)
+
+const (
+	MetaCodec = "fs-compression"   // 记录压缩算法的元数据键
+	MetaSize  = "fs-original-size" // 记录原始大小的元数据键
+	sniffSize = 4                  // 识别压缩格式需要的字节数
+)
+
+// Config 压缩配置
+type Config struct {
+	Codec Codec // 压缩算法，为空时使用 Gzip
+	// Level 压缩级别，为 0 时使用算法的默认级别。gzip 为 1 到 9，zstd 为 1 到 22，按 zstd 的级别映射到编码器支持的级别
+	Level int
+	// ContentEncoding 为 true 时将文件的 Content-Encoding 设置为压缩算法，浏览器通过 FullUrl 下载时自动解压，
+	// 需要驱动支持 fs.WithContentEncoding
+	ContentEncoding bool
+}
+
+// codec 压缩算法的实现
+type codec struct {
+	name      Codec
+	magic     []byte
+	newWriter func(w io.Writer, level int) (io.WriteCloser, error)
+	newReader func(r io.Reader) (io.ReadCloser, error)
+	// trailer 追加在压缩数据末尾、记录原始大小的结尾，解压时不产生数据；原始大小以小端序写在 sizeAt 开始的 8 字节
+	trailer []byte
+	sizeAt  int
+}
+
+// newTrailer 返回记录原始大小的结尾
+func (c *codec) newTrailer(size int64) []byte {
+	trailer := bytes.Clone(c.trailer)
+	binary.LittleEndian.PutUint64(trailer[c.sizeAt:], uint64(size))
+	return trailer
+}
+
+// parseTrailer 解析 newTrailer 返回的结尾，b 不是结尾时返回 false
+func (c *codec) parseTrailer(b []byte) (int64, bool) {
+	if len(b) != len(c.trailer) ||
+		!bytes.Equal(b[:c.sizeAt], c.trailer[:c.sizeAt]) ||
+		!bytes.Equal(b[c.sizeAt+8:], c.trailer[c.sizeAt+8:]) {
+		return 0, false
+	}
+	size := int64(binary.LittleEndian.Uint64(b[c.sizeAt:]))
+	return size, size >= 0
+}
+
+var codecs = map[Codec]*codec{
+	Gzip: {
+		name:  Gzip,
+		magic: []byte{0x1f, 0x8b},
+		newWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			if level == 0 {
+				level = gzip.DefaultCompression
+			}
+			return gzip.NewWriterLevel(w, level)
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		// 空的 gzip 成员，原始大小记录在头部的扩展字段（子字段 ID 为 FS）
+		trailer: []byte{
+			0x1f, 0x8b, 0x08, 0x04, 0, 0, 0, 0, 0, 0xff, // 头部，FLG 为 FEXTRA
+			12, 0, 'F', 'S', 8, 0, 0, 0, 0, 0, 0, 0, 0, 0, // 扩展字段
+			0x03, 0x00, // 空的 deflate 块
+			0, 0, 0, 0, 0, 0, 0, 0, // CRC32 与 ISIZE
+		},
+		sizeAt: 16,
+	},
+	Zstd: {
+		name:  Zstd,
+		magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		newWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			encLevel := zstd.SpeedDefault
+			if level != 0 {
+				encLevel = zstd.EncoderLevelFromZstd(level)
+			}
+			return zstd.NewWriter(w, zstd.WithEncoderLevel(encLevel), zstd.WithEncoderConcurrency(1), zstd.WithZeroFrames(true))
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			return dec.IOReadCloser(), nil
+		},
+		// zstd 可跳过帧，内容为 FSOS 与原始大小
+		trailer: []byte{
+			0x5e, 0x2a, 0x4d, 0x18, 12, 0, 0, 0, // 可跳过帧的魔数与内容长度
+			'F', 'S', 'O', 'S', 0, 0, 0, 0, 0, 0, 0, 0,
+		},
+		sizeAt: 12,
+	},
+}
+
+// compressor 按配置压缩写入的数据
+type compressor struct {
+	codec           *codec
+	level           int
+	contentEncoding bool
+}
+
+func newCompressor(conf Config) (*compressor, error) {
+	name := conf.Codec
+	if name == "" {
+		name = Gzip
+	}
+	c, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("compress: unsupported codec %q", name)
+	}
+	if _, err := c.newWriter(io.Discard, conf.Level); err != nil {
+		return nil, fmt.Errorf("compress: invalid level %d for %s: %w", conf.Level, name, err)
+	}
+	return &compressor{codec: c, level: conf.Level, contentEncoding: conf.ContentEncoding}, nil
+}
+
+// writeOptions 返回写入压缩文件的选项，size 小于 0 时元数据中不记录原始大小，由压缩数据末尾的结尾记录。
+// 未指定文件类型时按扩展名设置，避免驱动根据压缩后的内容检测类型
+func (c *compressor) writeOptions(path string, opts []fs.Option, size int64) []fs.Option {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	metadata := fs.Metadata{}
+	for k, v := range o.Metadata {
+		if !isCompressMeta(k) {
+			metadata[k] = v
+		}
+	}
+	metadata[MetaCodec] = string(c.codec.name)
+	if size >= 0 {
+		metadata[MetaSize] = strconv.FormatInt(size, 10)
+	}
+
+	opts = append(opts[:len(opts):len(opts)], fs.WithMetadata(metadata))
+	if o.ContentType == "" {
+		if contentType := fs.TypeByExtension(path); contentType != "" {
+			opts = append(opts, fs.WithContentType(contentType))
+		}
+	}
+	if c.contentEncoding {
+		opts = append(opts, fs.WithContentEncoding(string(c.codec.name)))
+	}
+	return opts
+}
+
+// isCompressMeta 判断是否为压缩元数据，驱动返回的元数据键的大小写可能与写入时不同
+func isCompressMeta(key string) bool {
+	return strings.EqualFold(key, MetaCodec) || strings.EqualFold(key, MetaSize)
+}
+
+// lookup 不区分大小写查找元数据
+func lookup(metadata map[string]any, key string) (string, bool) {
+	for k, v := range metadata {
+		if strings.EqualFold(k, key) {
+			return fmt.Sprint(v), true
+		}
+	}
+	return "", false
+}
+
+// originalSize 返回元数据中记录的原始大小
+func originalSize(metadata map[string]any) (int64, bool) {
+	value, ok := lookup(metadata, MetaSize)
+	if !ok {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	return size, err == nil
+}
+
+// codecOf 返回元数据中记录的压缩算法，未压缩时返回 nil
+func codecOf(metadata map[string]any) (*codec, error) {
+	name, ok := lookup(metadata, MetaCodec)
+	if !ok {
+		return nil, nil
+	}
+	c, ok := codecs[Codec(name)]
+	if !ok {
+		return nil, fmt.Errorf("compress: unsupported codec %q: %w", name, fs.ErrUnsupported)
+	}
+	return c, nil
+}
+
+// decompress 返回解压 body 的读取流。设置了 Content-Encoding 时 HTTP 客户端可能已经自动解压，
+// 内容不是压缩格式时原样返回
+func decompress(c *codec, body io.ReadCloser) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(body)
+	head, err := buffered.Peek(sniffSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.HasPrefix(head, c.magic) {
+		return &readCloser{Reader: buffered, closers: []io.Closer{body}}, nil
+	}
+	reader, err := c.newReader(buffered)
+	if err != nil {
+		return nil, err
+	}
+	return &readCloser{Reader: reader, closers: []io.Closer{reader, body}}, nil
+}
+
+// readCloser 关闭时依次关闭解压器与驱动的读取流
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *readCloser) Close() error {
+	var err error
+	for _, c := range r.closers {
+		if closeErr := c.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,238 @@
+package compress_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/compress"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/klauspost/compress/zstd"
+)
+
+var codecs = []compress.Codec{compress.Gzip, compress.Zstd}
+
+// countingFS 记录 SetMetadata 的调用次数
+type countingFS struct {
+	fs.Wrapper
+	setMetadata int
+}
+
+func (c *countingFS) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	c.setMetadata++
+	return c.FileSystem.SetMetadata(ctx, path, metadata, opts...)
+}
+
+func newFS(t *testing.T, codec compress.Codec) (fs.FileSystem, *countingFS) {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counting := &countingFS{Wrapper: fs.Wrapper{FileSystem: m}}
+	f, err := compress.New(counting, compress.Config{Codec: codec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, counting
+}
+
+func readAll(t *testing.T, f fs.FileSystem, path string, opts ...fs.Option) string {
+	t.Helper()
+	r, err := f.Open(context.Background(), path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestOriginalSizeWithoutSetMetadata(t *testing.T) {
+	ctx := context.Background()
+	data := strings.Repeat("hello compress ", 1000)
+	for _, codec := range codecs {
+		t.Run(string(codec), func(t *testing.T) {
+			f, counting := newFS(t, codec)
+
+			w, err := f.Create(ctx, "create.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.WriteString(w, data); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			uploader := f.Uploader()
+			// 不支持 Seek 的 reader 上传前无法确定原始大小
+			if err := uploader.Upload(ctx, "stream.txt", iotest.OneByteReader(strings.NewReader(data))); err != nil {
+				t.Fatal(err)
+			}
+			if err := uploader.Upload(ctx, "seek.txt", strings.NewReader(data)); err != nil {
+				t.Fatal(err)
+			}
+			if err := uploader.Upload(ctx, "empty.txt", iotest.OneByteReader(strings.NewReader(""))); err != nil {
+				t.Fatal(err)
+			}
+
+			if counting.setMetadata != 0 {
+				t.Fatalf("SetMetadata called %d times while writing", counting.setMetadata)
+			}
+			for path, want := range map[string]string{"create.txt": data, "stream.txt": data, "seek.txt": data, "empty.txt": ""} {
+				info, err := f.Stat(ctx, path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Size() != int64(len(want)) {
+					t.Errorf("Stat(%q).Size() = %d, want %d", path, info.Size(), len(want))
+				}
+				if got := readAll(t, f, path); got != want {
+					t.Errorf("content of %q differs, got %d bytes", path, len(got))
+				}
+				if got := readAll(t, f, path, fs.WithRange(int64(len(want))/2, 0)); got != want[len(want)/2:] {
+					t.Errorf("range read of %q differs, got %d bytes", path, len(got))
+				}
+			}
+		})
+	}
+}
+
+func TestStoredDataIsCompressed(t *testing.T) {
+	ctx := context.Background()
+	data := strings.Repeat("a", 100000)
+	for _, codec := range codecs {
+		t.Run(string(codec), func(t *testing.T) {
+			f, counting := newFS(t, codec)
+			if err := f.Uploader().Upload(ctx, "a.txt", bytes.NewReader([]byte(data))); err != nil {
+				t.Fatal(err)
+			}
+			info, err := counting.Stat(ctx, "a.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size() >= int64(len(data))/10 {
+				t.Fatalf("stored %d bytes for %d bytes of data", info.Size(), len(data))
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, conf := range []compress.Config{{Codec: "lz4"}, {Codec: compress.Gzip, Level: 42}} {
+		if _, err := compress.New(nil, conf); err == nil {
+			t.Errorf("New(%+v) succeeded", conf)
+		}
+	}
+}
+
+func TestStandardFormat(t *testing.T) {
+	ctx := context.Background()
+	data := strings.Repeat("standard ", 1000)
+	for _, codec := range codecs {
+		t.Run(string(codec), func(t *testing.T) {
+			f, counting := newFS(t, codec)
+			// 结尾记录原始大小，标准的解压工具可以直接解压
+			w, err := f.Create(ctx, "a.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.WriteString(w, data); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			raw := readAll(t, counting, "a.txt")
+
+			var r io.Reader
+			switch codec {
+			case compress.Gzip:
+				gz, err := gzip.NewReader(strings.NewReader(raw))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer gz.Close()
+				r = gz
+			case compress.Zstd:
+				zr, err := zstd.NewReader(strings.NewReader(raw))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer zr.Close()
+				r = zr
+			}
+			got, err := io.ReadAll(r)
+			if err != nil || string(got) != data {
+				t.Fatalf("standard decoder read %d bytes, %v", len(got), err)
+			}
+		})
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	ctx := context.Background()
+	f, counting := newFS(t, compress.Zstd)
+	data := strings.Repeat(`{"id":1},`, 1000)
+	if err := f.Uploader().Upload(ctx, "a.json", strings.NewReader(data), fs.WithMetadata(fs.Metadata{"k": "v"})); err != nil {
+		t.Fatal(err)
+	}
+	if mimeType, err := f.GetMimeType(ctx, "a.json"); err != nil || mimeType != "application/json" {
+		t.Fatalf("GetMimeType = %q, %v", mimeType, err)
+	}
+	metadata, err := f.GetMetadata(ctx, "a.json")
+	if err != nil || len(metadata) != 1 || metadata["k"] != "v" {
+		t.Fatalf("GetMetadata = %v, %v, want only the user metadata", metadata, err)
+	}
+
+	// 设置元数据保留压缩元数据
+	if err := f.SetMetadata(ctx, "a.json", map[string]interface{}{"k": "w", compress.MetaCodec: "gzip"}); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := counting.GetMetadata(ctx, "a.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codec, _ := raw[compress.MetaCodec].(string); codec != string(compress.Zstd) || raw["k"] != "w" {
+		t.Fatalf("stored metadata after SetMetadata = %v", raw)
+	}
+	if got := readAll(t, f, "a.json"); got != data {
+		t.Fatalf("read after SetMetadata differs, got %d bytes", len(got))
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	ctx := context.Background()
+	f, counting := newFS(t, compress.Gzip)
+
+	// 未压缩的文件原样读取
+	if err := counting.Uploader().Upload(ctx, "plain.txt", strings.NewReader("plain")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, f, "plain.txt"); got != "plain" {
+		t.Fatalf("read uncompressed file = %q", got)
+	}
+	if info, err := f.Stat(ctx, "plain.txt"); err != nil || info.Size() != 5 {
+		t.Fatalf("Stat uncompressed file = %v, %v", info, err)
+	}
+
+	// 传输层已经解压的数据不再解压
+	err := counting.Uploader().Upload(ctx, "decoded.txt", strings.NewReader("decoded"),
+		fs.WithMetadata(fs.Metadata{compress.MetaCodec: string(compress.Gzip)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, f, "decoded.txt"); got != "decoded" {
+		t.Fatalf("read decoded file = %q", got)
+	}
+}
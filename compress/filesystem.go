@@ -0,0 +1,356 @@
+package compress
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	pathpkg "path"
+	"sync"
+
+	"github.com/goairix/fs"
+)
+
+// listConcurrency List 时同时读取元数据的文件数
+const listConcurrency = 16
+
+// fileSystem 透明压缩的文件系统
+type fileSystem struct {
+	fs.Wrapper
+	c *compressor
+}
+
+// New 返回透明压缩的文件系统。Create 与 Upload 写入的文件按配置的算法压缩，
+// 算法记录在文件的元数据中，因此驱动需要支持自定义元数据；原始大小记录在元数据或压缩数据末尾的结尾中。
+// Open 按元数据中的算法解压，没有压缩元数据的文件原样读取。
+// Stat、List 与 Walk 返回原始大小，需要为每个文件读取一次元数据
+func New(fsys fs.FileSystem, conf Config) (fs.FileSystem, error) {
+	c, err := newCompressor(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSystem{Wrapper: fs.Wrapper{FileSystem: fsys}, c: c}, nil
+}
+
+func (f *fileSystem) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	writer, err := f.FileSystem.Create(ctx, path, f.c.writeOptions(path, opts, -1)...)
+	if err != nil {
+		return nil, err
+	}
+	return f.newWriter(writer)
+}
+
+// newWriter 返回压缩写入 w 的 io.WriteCloser，关闭时在压缩数据末尾追加记录原始大小的结尾
+func (f *fileSystem) newWriter(w io.WriteCloser) (*writer, error) {
+	enc, err := f.c.codec.newWriter(w, f.c.level)
+	if err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	return &writer{enc: enc, w: w, c: f.c.codec}, nil
+}
+
+func (f *fileSystem) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	metadata, err := f.FileSystem.GetMetadata(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := codecOf(metadata)
+	if err != nil {
+		return nil, fs.NewPathError("compress", "Open", path, err, nil)
+	}
+	if c == nil {
+		return f.FileSystem.Open(ctx, path, opts...)
+	}
+
+	// 压缩后的内容不支持范围读取，从头解压后跳过 offset 之前的内容
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	body, err := f.FileSystem.Open(ctx, path, append(opts[:len(opts):len(opts)], withoutRange)...)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := decompress(c, body)
+	if err != nil {
+		_ = body.Close()
+		return nil, fs.NewPathError("compress", "Open", path, err, nil)
+	}
+	if o.Range == nil {
+		return reader, nil
+	}
+	if _, err = io.CopyN(io.Discard, reader, o.Range.Offset); err != nil && err != io.EOF {
+		_ = reader.Close()
+		return nil, fs.NewPathError("compress", "Open", path, err, nil)
+	}
+	if o.Range.Length > 0 {
+		return &readCloser{Reader: io.LimitReader(reader, o.Range.Length), closers: []io.Closer{reader}}, nil
+	}
+	return reader, nil
+}
+
+// withoutRange 清除范围读取选项
+func withoutRange(o *fs.Options) {
+	o.Range = nil
+}
+
+// OpenFile 只读打开时透明解压，写入时重新压缩整个文件，不支持 os.O_RDWR 与 os.O_APPEND
+func (f *fileSystem) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		reader, err := f.Open(ctx, path, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &readOnlyFile{ReadCloser: reader}, nil
+	}
+	if flag&(os.O_RDWR|os.O_APPEND) != 0 {
+		return nil, fs.NewPathError("compress", "OpenFile", path, fs.ErrUnsupported, nil)
+	}
+
+	file, err := f.FileSystem.OpenFile(ctx, path, flag|os.O_TRUNC, perm, f.c.writeOptions(path, opts, -1)...)
+	if err != nil {
+		return nil, err
+	}
+	w, err := f.newWriter(file)
+	if err != nil {
+		return nil, err
+	}
+	return &writeOnlyFile{writer: w}, nil
+}
+
+func (f *fileSystem) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
+	infos, err := f.FileSystem.List(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return f.originalInfos(ctx, path, infos), nil
+}
+
+// Stat 返回压缩文件的原始大小
+func (f *fileSystem) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	info, err := f.FileSystem.Stat(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return f.originalInfo(ctx, path, info)
+}
+
+// originalInfo 读取文件的元数据，返回原始大小的文件信息。
+// 元数据中没有原始大小时读取压缩数据末尾的结尾，没有结尾时保留驱动返回的大小
+func (f *fileSystem) originalInfo(ctx context.Context, path string, info fs.FileInfo) (fs.FileInfo, error) {
+	if info.IsDir() {
+		return info, nil
+	}
+	metadata, err := f.FileSystem.GetMetadata(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if size, ok := originalSize(metadata); ok {
+		return &fileInfo{FileInfo: info, size: size}, nil
+	}
+	c, err := codecOf(metadata)
+	if err != nil || c == nil {
+		return info, nil
+	}
+	size, ok, err := f.trailerSize(ctx, path, c, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return &fileInfo{FileInfo: info, size: size}, nil
+	}
+	return info, nil
+}
+
+// trailerSize 通过范围读取压缩数据末尾的结尾获得原始大小
+func (f *fileSystem) trailerSize(ctx context.Context, path string, c *codec, size int64) (int64, bool, error) {
+	n := int64(len(c.trailer))
+	if size < n {
+		return 0, false, nil
+	}
+	body, err := f.FileSystem.Open(ctx, path, fs.WithRange(size-n, n))
+	if err != nil {
+		return 0, false, err
+	}
+	defer body.Close()
+	trailer, err := io.ReadAll(io.LimitReader(body, n+1))
+	if err != nil {
+		return 0, false, err
+	}
+	original, ok := c.parseTrailer(trailer)
+	return original, ok, nil
+}
+
+// originalInfos 并发读取 dir 下各文件的元数据，返回原始大小的文件信息。
+// 列出后被删除的文件不返回，读取元数据的其他错误保留驱动返回的大小，不影响整个列表
+func (f *fileSystem) originalInfos(ctx context.Context, dir string, infos []fs.FileInfo) []fs.FileInfo {
+	var (
+		wg      sync.WaitGroup
+		removed = make([]bool, len(infos))
+		sem     = make(chan struct{}, listConcurrency)
+	)
+	for i, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			original, err := f.originalInfo(ctx, pathpkg.Join(dir, info.Name()), info)
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				removed[i] = true
+			case err == nil:
+				infos[i] = original
+			}
+		}()
+	}
+	wg.Wait()
+
+	result := infos[:0]
+	for i, info := range infos {
+		if !removed[i] {
+			result = append(result, info)
+		}
+	}
+	return result
+}
+
+// SetMetadata 保留文件的压缩元数据，驱动设置元数据时会替换全部自定义元数据
+func (f *fileSystem) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	current, err := f.FileSystem.GetMetadata(ctx, path, opts...)
+	if err != nil {
+		return err
+	}
+	merged := make(map[string]interface{}, len(metadata)+2)
+	for k, v := range metadata {
+		if !isCompressMeta(k) {
+			merged[k] = v
+		}
+	}
+	for _, key := range []string{MetaCodec, MetaSize} {
+		if v, ok := lookup(current, key); ok {
+			merged[key] = v
+		}
+	}
+	return f.FileSystem.SetMetadata(ctx, path, merged, opts...)
+}
+
+// GetMetadata 不返回压缩元数据
+func (f *fileSystem) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]interface{}, error) {
+	metadata, err := f.FileSystem.GetMetadata(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	maps.DeleteFunc(metadata, func(k string, _ interface{}) bool {
+		return isCompressMeta(k)
+	})
+	return metadata, nil
+}
+
+func (f *fileSystem) Uploader() fs.Uploader {
+	return &uploader{Uploader: f.FileSystem.Uploader(), fsys: f}
+}
+
+// OpenReader 压缩文件的随机读取每次从头解压，适合顺序读取；未压缩的文件使用驱动的实现
+func (f *fileSystem) OpenReader(ctx context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	metadata, err := f.FileSystem.GetMetadata(ctx, path)
+	if err == nil {
+		if c, _ := codecOf(metadata); c != nil {
+			return fs.OpenReader(ctx, struct{ fs.FileSystem }{f}, path, opts...)
+		}
+	}
+	return fs.OpenReader(ctx, f.FileSystem, path, opts...)
+}
+
+func (f *fileSystem) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	return fs.Walk(ctx, f.FileSystem, root, func(path string, info fs.FileInfo, err error) error {
+		if err == nil && info != nil {
+			// 与 List 一致，遍历后被删除的文件跳过，读取元数据的其他错误保留驱动返回的大小
+			original, metaErr := f.originalInfo(ctx, path, info)
+			if errors.Is(metaErr, fs.ErrNotExist) {
+				return nil
+			}
+			if metaErr == nil {
+				info = original
+			}
+		}
+		return fn(path, info, err)
+	}, opts...)
+}
+
+func (f *fileSystem) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	result, err := fs.ListPage(ctx, f.FileSystem, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	result.Files = f.originalInfos(ctx, path, result.Files)
+	return result, nil
+}
+
+// fileInfo 返回原始大小的文件信息
+type fileInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (i *fileInfo) Size() int64 {
+	return i.size
+}
+
+// ETag 返回压缩后内容的 ETag，可以用于判断文件是否变化
+func (i *fileInfo) ETag() string {
+	if etagger, ok := i.FileInfo.(fs.ETagger); ok {
+		return etagger.ETag()
+	}
+	return ""
+}
+
+// writer 压缩写入的数据，关闭时追加记录原始大小的结尾
+type writer struct {
+	enc io.WriteCloser
+	w   io.WriteCloser
+	c   *codec
+	n   int64
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	n, err := w.enc.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *writer) Close() error {
+	err := w.enc.Close()
+	if err == nil {
+		_, err = w.w.Write(w.c.newTrailer(w.n))
+	}
+	if closeErr := w.w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// readOnlyFile 只读打开的文件
+type readOnlyFile struct {
+	io.ReadCloser
+}
+
+func (f *readOnlyFile) Write([]byte) (int, error) {
+	return 0, os.ErrPermission
+}
+
+// writeOnlyFile 只写打开的文件
+type writeOnlyFile struct {
+	*writer
+}
+
+func (f *writeOnlyFile) Read([]byte) (int, error) {
+	return 0, os.ErrPermission
+}
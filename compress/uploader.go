@@ -0,0 +1,51 @@
+package compress
+
+import (
+	"context"
+	"io"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/internal/ioutil"
+)
+
+// uploader 压缩上传数据的上传器，分片上传的数据不压缩
+type uploader struct {
+	fs.Uploader
+	fsys *fileSystem
+}
+
+// Upload 压缩上传数据，分片阈值与分片大小按压缩后的数据计算。
+// reader 实现 io.Seeker 时原始大小随上传写入元数据，否则在压缩数据末尾追加记录原始大小的结尾
+func (u *uploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	size := int64(-1)
+	if seeker, ok := reader.(io.Seeker); ok {
+		var err error
+		if size, err = ioutil.Remaining(seeker); err != nil {
+			return fs.NewPathError("compress", "Upload", path, err, nil)
+		}
+	}
+
+	opts = u.fsys.c.writeOptions(path, opts, size)
+	pr, pw := io.Pipe()
+	counter := &ioutil.CountingReader{Reader: reader}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		enc, err := u.fsys.c.codec.newWriter(pw, u.fsys.c.level)
+		if err == nil {
+			_, err = io.Copy(enc, counter)
+			if closeErr := enc.Close(); err == nil {
+				err = closeErr
+			}
+			if err == nil && size < 0 {
+				_, err = pw.Write(u.fsys.c.codec.newTrailer(counter.N))
+			}
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	err := u.Uploader.Upload(ctx, path, pr, opts...)
+	// 等待压缩结束，返回后不再读取 reader
+	_ = pr.CloseWithError(io.ErrClosedPipe)
+	<-done
+	return err
+}
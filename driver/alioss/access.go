@@ -12,7 +12,7 @@ import (
 )
 
 func (driver *ossFs) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
@@ -31,9 +31,9 @@ func (driver *ossFs) SignFullUrl(ctx context.Context, path string, opts ...fs.Op
 		expires = o.SignUrlExpires
 	}
 
-	signUrl, err := driver.bucket.SignURL(path, oss.HTTPGet, int64(expires.Seconds()), oss.WithContext(ctx))
+	signUrl, err := driver.bucket.SignURL(key, oss.HTTPGet, int64(expires.Seconds()), oss.WithContext(ctx))
 	if err != nil {
-		return "", err
+		return "", wrapError("SignFullUrl", path, err)
 	}
 
 	signUrl = strings.ReplaceAll(signUrl, "http://", "https://")
@@ -42,11 +42,11 @@ func (driver *ossFs) SignFullUrl(ctx context.Context, path string, opts ...fs.Op
 		signUrl = strings.ReplaceAll(signUrl, endpoint, cdnDomain)
 	}
 
-	return signUrl, err
+	return signUrl, wrapError("SignFullUrl", path, err)
 }
 
 func (driver *ossFs) FullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
@@ -68,13 +68,13 @@ func (driver *ossFs) FullUrl(ctx context.Context, path string, opts ...fs.Option
 		}
 
 		var err error
-		fullUrl, err = driver.bucket.SignURL(path, oss.HTTPGet, int64(expires.Seconds()), oss.WithContext(ctx))
+		fullUrl, err = driver.bucket.SignURL(key, oss.HTTPGet, int64(expires.Seconds()), oss.WithContext(ctx))
 		if err != nil {
-			return "", err
+			return "", wrapError("FullUrl", path, err)
 		}
 		fullUrl = strings.ReplaceAll(fullUrl, "http://", "https://")
 	} else {
-		fullUrl = fmt.Sprintf("%s/%s", cdnDomain, path)
+		fullUrl = fmt.Sprintf("%s/%s", cdnDomain, key)
 	}
 
 	if useCdnDomain {
@@ -87,7 +87,12 @@ func (driver *ossFs) FullUrl(ctx context.Context, path string, opts ...fs.Option
 func (driver *ossFs) RelativePath(ctx context.Context, fullUrl string, opts ...fs.Option) (string, error) {
 	u, err := url.Parse(fullUrl)
 	if err != nil {
-		return "", nil
+		return "", wrapError("RelativePath", fullUrl, err)
 	}
-	return strings.TrimPrefix(u.Path, "/"), nil
+
+	relativePath := strings.TrimPrefix(u.Path, "/")
+	if driver.config.SubPath != "" {
+		relativePath = strings.TrimPrefix(relativePath, strings.Trim(driver.config.SubPath, "/")+"/")
+	}
+	return relativePath, nil
 }
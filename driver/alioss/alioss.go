@@ -2,6 +2,7 @@ package alioss
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -51,9 +52,9 @@ func New(config Config) (fs.FileSystem, error) {
 }
 
 func (driver *ossFs) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	var fileInfos []fs.FileInfo
-	prefix := strings.TrimRight(path, "/")
+	prefix := strings.TrimRight(key, "/")
 	if prefix != "" {
 		prefix += "/"
 	}
@@ -67,11 +68,15 @@ func (driver *ossFs) List(ctx context.Context, path string, opts ...fs.Option) (
 			oss.WithContext(ctx),
 		)
 		if err != nil {
-			return nil, err
+			return nil, wrapError("List", path, err)
 		}
 
 		// 添加文件
 		for _, object := range lsRes.Objects {
+			if object.Key == prefix {
+				// 跳过目录占位对象
+				continue
+			}
 			fileInfos = append(fileInfos, newOssFileInfo(object))
 		}
 
@@ -91,25 +96,99 @@ func (driver *ossFs) List(ctx context.Context, path string, opts ...fs.Option) (
 	return fileInfos, nil
 }
 
+func (driver *ossFs) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	prefix := strings.TrimRight(driver.path(path), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	lsRes, err := driver.bucket.ListObjects(
+		oss.Marker(o.Marker),
+		oss.Prefix(prefix),
+		oss.Delimiter("/"),
+		oss.MaxKeys(o.ListLimit()),
+		oss.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, wrapError("ListPage", path, err)
+	}
+
+	result := &fs.ListResult{}
+	for _, object := range lsRes.Objects {
+		if object.Key == prefix {
+			// 跳过目录占位对象
+			continue
+		}
+		result.Files = append(result.Files, newOssFileInfo(object))
+	}
+	for _, prefix := range lsRes.CommonPrefixes {
+		result.Files = append(result.Files, newOssFileInfo(oss.ObjectProperties{
+			Key: prefix,
+		}))
+	}
+	if lsRes.IsTruncated {
+		result.NextMarker = lsRes.NextMarker
+	}
+	return result, nil
+}
+
+func (driver *ossFs) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	prefix := strings.TrimRight(driver.path(root), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	// 不设置分隔符，按页列出前缀下的全部对象
+	return fs.WalkObjects(ctx, driver, root, fn, func(yield func(string, fs.FileInfo) bool) error {
+		marker := ""
+		for {
+			lsRes, err := driver.bucket.ListObjects(
+				oss.Marker(marker),
+				oss.Prefix(prefix),
+				oss.WithContext(ctx),
+			)
+			if err != nil {
+				return wrapError("Walk", root, err)
+			}
+			for _, object := range lsRes.Objects {
+				if !yield(strings.TrimPrefix(object.Key, prefix), newOssFileInfo(object)) {
+					return nil
+				}
+			}
+			if !lsRes.IsTruncated || len(lsRes.Objects) == 0 {
+				return nil
+			}
+			marker = lsRes.NextMarker
+			if marker == "" {
+				marker = lsRes.Objects[len(lsRes.Objects)-1].Key
+			}
+		}
+	}, opts...)
+}
+
 func (driver *ossFs) MakeDir(_ context.Context, _ string, _ os.FileMode, opts ...fs.Option) error {
 	// OSS目录在写入文件时自动创建
 	return nil
 }
 
 func (driver *ossFs) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
-	path = driver.path(path)
-	prefix := strings.TrimRight(path, "/") + "/"
+	key := driver.path(path)
+	prefix := strings.TrimRight(key, "/") + "/"
 	marker := ""
 	for {
 		lsRes, err := driver.bucket.ListObjects(oss.Marker(marker), oss.Prefix(prefix), oss.WithContext(ctx))
 		if err != nil {
-			return err
+			return wrapError("RemoveDir", path, err)
 		}
 
 		for _, object := range lsRes.Objects {
 			err = driver.bucket.DeleteObject(object.Key, oss.WithContext(ctx))
 			if err != nil {
-				return err
+				return wrapError("RemoveDir", path, err)
 			}
 		}
 
@@ -122,43 +201,59 @@ func (driver *ossFs) RemoveDir(ctx context.Context, path string, opts ...fs.Opti
 }
 
 func (driver *ossFs) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
-	path = driver.path(path)
-	return newOssWriter(ctx, driver.bucket, path, opts...), nil
+	return newOssWriter(ctx, driver, path, opts...), nil
 }
 
 func (driver *ossFs) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
-	path = driver.path(path)
-	return driver.bucket.GetObject(path, oss.WithContext(ctx))
+	key := driver.path(path)
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	options := []oss.Option{oss.WithContext(ctx)}
+	if o.Range != nil {
+		// 使用标准的范围读取行为，范围无效时返回错误而不是整个文件
+		options = append(options,
+			oss.NormalizedRange(strings.TrimPrefix(o.Range.HeaderValue(), "bytes=")),
+			oss.RangeBehavior("standard"),
+		)
+	}
+	reader, err := driver.bucket.GetObject(key, options...)
+	if err != nil {
+		return nil, wrapError("Open", path, err)
+	}
+	return reader, nil
 }
 
 func (driver *ossFs) OpenFile(ctx context.Context, path string, flag int, _ os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
 	if flag&os.O_RDWR != 0 {
-		return newOssReadWriter(ctx, driver.bucket, driver.path(path), opts...), nil
+		return newOssReadWriter(ctx, driver, path, opts...), nil
 	}
 	if flag&os.O_WRONLY != 0 {
-		return newOssReadWriter(ctx, driver.bucket, driver.path(path), opts...), nil
+		return newOssReadWriter(ctx, driver, path, opts...), nil
 	}
 	reader, err := driver.Open(ctx, path, opts...)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("OpenFile", path, err)
 	}
 	return newOssReadOnlyWrapper(reader), nil
 }
 
 func (driver *ossFs) Remove(ctx context.Context, path string, opts ...fs.Option) error {
-	return driver.bucket.DeleteObject(driver.path(path), oss.WithContext(ctx))
+	return wrapError("Remove", path, driver.bucket.DeleteObject(driver.path(path), oss.WithContext(ctx)))
 }
 
 func (driver *ossFs) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
-	src = driver.path(src)
-	dst = driver.path(dst)
-	_, err := driver.bucket.CopyObject(src, dst, oss.WithContext(ctx))
-	return err
+	srcKey := driver.path(src)
+	dstKey := driver.path(dst)
+	_, err := driver.bucket.CopyObject(srcKey, dstKey, oss.WithContext(ctx))
+	return wrapError("Copy", src, err)
 }
 
 func (driver *ossFs) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
 	if err := driver.Copy(ctx, src, dst); err != nil {
-		return err
+		return wrapError("Move", src, err)
 	}
 	return driver.Remove(ctx, src)
 }
@@ -168,26 +263,33 @@ func (driver *ossFs) Rename(ctx context.Context, oldPath, newPath string, opts .
 }
 
 func (driver *ossFs) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
-	path = driver.path(path)
-	header, err := driver.bucket.GetObjectMeta(path, oss.WithContext(ctx))
+	key := driver.path(path)
+	header, err := driver.bucket.GetObjectMeta(key, oss.WithContext(ctx))
 	if err != nil {
-		return nil, err
+		// 目录在对象存储中为公共前缀，没有实体对象
+		if errors.Is(errorKind(err), fs.ErrNotExist) {
+			if isDir, dirErr := driver.IsDir(ctx, path); dirErr == nil && isDir {
+				return newOssFileInfo(oss.ObjectProperties{Key: strings.TrimRight(key, "/") + "/"}), nil
+			}
+		}
+		return nil, wrapError("Stat", path, err)
 	}
 
 	lastModified, _ := time.ParseInLocation(time.RFC1123, header.Get("Last-Modified"), time.Local)
 	fileSize, _ := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
 
 	return newOssFileInfo(oss.ObjectProperties{
-		Key:          path,
+		Key:          key,
 		Size:         fileSize,
 		LastModified: lastModified,
+		ETag:         header.Get("ETag"),
 	}), nil
 }
 
 func (driver *ossFs) GetMimeType(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	header, err := driver.bucket.GetObjectDetailedMeta(path, oss.WithContext(ctx))
+	header, err := driver.bucket.GetObjectDetailedMeta(driver.path(path), oss.WithContext(ctx))
 	if err != nil {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 
 	contentType := header.Get("Content-Type")
@@ -198,43 +300,53 @@ func (driver *ossFs) GetMimeType(ctx context.Context, path string, opts ...fs.Op
 	// 如果对象没有 Content-Type，则读取文件内容进行检测
 	obj, err := driver.Open(ctx, path)
 	if err != nil {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 	defer func() {
 		_ = obj.Close()
 	}()
 
 	buffer := make([]byte, 512)
-	_, err = obj.Read(buffer)
+	n, err := obj.Read(buffer)
 	if err != nil && err != io.EOF {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 
-	return http.DetectContentType(buffer), nil
+	return http.DetectContentType(buffer[:n]), nil
 }
 
 func (driver *ossFs) SetMetadata(ctx context.Context, path string, metadata map[string]any, opts ...fs.Option) error {
+	key := driver.path(path)
+	header, err := driver.bucket.GetObjectDetailedMeta(key, oss.WithContext(ctx))
+	if err != nil {
+		return wrapError("SetMetadata", path, err)
+	}
+
+	// 替换元数据时保留原有的 Content-Type 与 Content-Encoding
 	options := []oss.Option{
 		oss.WithContext(ctx),
+		oss.MetadataDirective(oss.MetaReplace),
+	}
+	if contentType := header.Get("Content-Type"); contentType != "" {
+		options = append(options, oss.ContentType(contentType))
+	}
+	if contentEncoding := header.Get("Content-Encoding"); contentEncoding != "" {
+		options = append(options, oss.ContentEncoding(contentEncoding))
 	}
 	for k, v := range metadata {
 		options = append(options, oss.Meta(k, fmt.Sprintf("%v", v)))
 	}
 
-	// OSS中需要通过复制对象来更新元数据
-	_, err := driver.bucket.CopyObject(driver.path(path), driver.path(path+"_tmp"), options...)
-	if err != nil {
-		return err
-	}
-
-	return driver.Move(ctx, path+"_tmp", path)
+	// OSS中需要通过复制对象到自身来更新元数据
+	_, err = driver.bucket.CopyObject(key, key, options...)
+	return wrapError("SetMetadata", path, err)
 }
 
 func (driver *ossFs) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]any, error) {
-	path = driver.path(path)
-	header, err := driver.bucket.GetObjectMeta(path, oss.WithContext(ctx))
+	key := driver.path(path)
+	header, err := driver.bucket.GetObjectDetailedMeta(key, oss.WithContext(ctx))
 	if err != nil {
-		return nil, err
+		return nil, wrapError("GetMetadata", path, err)
 	}
 
 	metadata := make(map[string]interface{})
@@ -258,27 +370,25 @@ func (driver *ossFs) Exists(ctx context.Context, path string, opts ...fs.Option)
 }
 
 func (driver *ossFs) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
-	path = driver.path(path)
-	prefix := strings.TrimRight(path, "/") + "/"
+	key := driver.path(path)
+	prefix := strings.TrimRight(key, "/") + "/"
 	lsRes, err := driver.bucket.ListObjects(oss.Prefix(prefix), oss.MaxKeys(1), oss.WithContext(ctx))
 	if err != nil {
-		return false, err
+		return false, wrapError("IsDir", path, err)
 	}
 	return len(lsRes.Objects) > 0 || len(lsRes.CommonPrefixes) > 0, nil
 }
 
 func (driver *ossFs) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
-	path = driver.path(path)
-	exist, err := driver.bucket.IsObjectExist(path, oss.WithContext(ctx))
+	key := driver.path(path)
+	exist, err := driver.bucket.IsObjectExist(key, oss.WithContext(ctx))
 	if err != nil {
-		return false, err
+		return false, wrapError("IsFile", path, err)
 	}
 	return exist, nil
 }
 
+// path 获取规范化的对象键，路径中的 ".." 不会超出子目录
 func (driver *ossFs) path(path string) string {
-	if driver.config.SubPath != "" {
-		return strings.Trim(driver.config.SubPath, "/") + "/" + path
-	}
-	return path
+	return fs.JoinPath(driver.config.SubPath, path)
 }
@@ -0,0 +1,48 @@
+package alioss
+
+import (
+	"errors"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/goairix/fs"
+)
+
+// wrapError 将 OSS 错误转换为统一的文件系统错误
+func wrapError(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return err
+	}
+	return fs.NewPathError("alioss", op, path, err, errorKind(err))
+}
+
+// errorKind 返回 OSS 错误对应的通用错误
+func errorKind(err error) error {
+	var serviceErr oss.ServiceError
+	if errors.As(err, &serviceErr) {
+		switch serviceErr.Code {
+		case "NoSuchKey", "NoSuchBucket", "NoSuchUpload":
+			return fs.ErrNotExist
+		case "AccessDenied":
+			return fs.ErrPermission
+		case "FileAlreadyExists":
+			return fs.ErrExist
+		case "PreconditionFailed":
+			return fs.ErrPreconditionFailed
+		case "NotImplemented":
+			return fs.ErrUnsupported
+		case "InternalError", "ServiceUnavailable", "SlowDown", "RequestTimeout":
+			return fs.ErrTemporary
+		}
+		return fs.ErrorKindFromStatus(serviceErr.StatusCode)
+	}
+
+	var responseErr oss.UnexpectedStatusCodeError
+	if errors.As(err, &responseErr) {
+		return fs.ErrorKindFromStatus(responseErr.Got())
+	}
+	return nil
+}
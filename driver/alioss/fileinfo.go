@@ -2,6 +2,7 @@ package alioss
 
 import (
 	"os"
+	"path"
 	"strings"
 	"time"
 
@@ -18,7 +19,7 @@ func newOssFileInfo(info oss.ObjectProperties) *ossFileInfo {
 }
 
 func (f *ossFileInfo) Name() string {
-	return f.info.Key
+	return path.Base(strings.TrimSuffix(f.info.Key, "/"))
 }
 
 func (f *ossFileInfo) Size() int64 {
@@ -26,6 +27,9 @@ func (f *ossFileInfo) Size() int64 {
 }
 
 func (f *ossFileInfo) Mode() os.FileMode {
+	if f.IsDir() {
+		return os.ModeDir | 0755
+	}
 	return 0644 // OSS不支持文件权限，返回默认值
 }
 
@@ -40,3 +44,8 @@ func (f *ossFileInfo) IsDir() bool {
 func (f *ossFileInfo) Sys() interface{} {
 	return f.info
 }
+
+// ETag 实现 fs.ETagger 接口
+func (f *ossFileInfo) ETag() string {
+	return strings.Trim(f.info.ETag, `"`)
+}
@@ -3,6 +3,7 @@ package alioss
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -14,22 +15,12 @@ func (driver *ossFs) Uploader() fs.Uploader {
 }
 
 func (driver *ossFs) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
-	file, err := driver.Create(ctx, path, opts...)
-	if err != nil {
-		return err
-	}
-
-	_, err = io.Copy(file, reader)
-	if err != nil {
-		_ = file.Close()
-		return err
-	}
-
-	return file.Close()
+	err := fs.ManagedUpload(ctx, driver, path, reader, driver.putObject("Upload", path, opts...), opts...)
+	return wrapError("Upload", path, err)
 }
 
 func (driver *ossFs) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
@@ -40,18 +31,24 @@ func (driver *ossFs) InitMultipartUpload(ctx context.Context, path string, opts
 	if o.ContentType != "" {
 		options = append(options, oss.ContentType(o.ContentType))
 	}
+	if o.ContentEncoding != "" {
+		options = append(options, oss.ContentEncoding(o.ContentEncoding))
+	}
+	for k, v := range o.Metadata {
+		options = append(options, oss.Meta(k, fmt.Sprintf("%v", v)))
+	}
 
-	initMultipartUploadResult, err := driver.bucket.InitiateMultipartUpload(path, options...)
+	initMultipartUploadResult, err := driver.bucket.InitiateMultipartUpload(key, options...)
 	if err != nil {
-		return "", err
+		return "", wrapError("InitMultipartUpload", path, err)
 	}
 	return initMultipartUploadResult.UploadID, nil
 }
 
 func (driver *ossFs) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	initMultipartUploadResult := oss.InitiateMultipartUploadResult{
-		Key:      path,
+		Key:      key,
 		UploadID: uploadID,
 		Bucket:   driver.bucket.BucketName,
 	}
@@ -61,11 +58,11 @@ func (driver *ossFs) UploadPart(ctx context.Context, path string, uploadID strin
 	if seeker, ok := data.(io.Seeker); ok {
 		size, err := seeker.Seek(0, io.SeekEnd)
 		if err != nil {
-			return "", err
+			return "", wrapError("UploadPart", path, err)
 		}
 		_, err = seeker.Seek(0, io.SeekStart)
 		if err != nil {
-			return "", err
+			return "", wrapError("UploadPart", path, err)
 		}
 		partSize = size
 	} else {
@@ -73,7 +70,7 @@ func (driver *ossFs) UploadPart(ctx context.Context, path string, uploadID strin
 		buf := new(bytes.Buffer)
 		size, err := io.Copy(buf, data)
 		if err != nil {
-			return "", err
+			return "", wrapError("UploadPart", path, err)
 		}
 		data = buf
 		partSize = size
@@ -81,16 +78,16 @@ func (driver *ossFs) UploadPart(ctx context.Context, path string, uploadID strin
 
 	part, err := driver.bucket.UploadPart(initMultipartUploadResult, data, partSize, partNumber, oss.WithContext(ctx))
 	if err != nil {
-		return "", err
+		return "", wrapError("UploadPart", path, err)
 	}
 
 	return part.ETag, nil
 }
 
 func (driver *ossFs) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
 	initMultipartUploadResult := oss.InitiateMultipartUploadResult{
-		Key:      path,
+		Key:      key,
 		UploadID: uploadID,
 		Bucket:   driver.bucket.BucketName,
 	}
@@ -102,23 +99,23 @@ func (driver *ossFs) CompleteMultipartUpload(ctx context.Context, path string, u
 		}
 	}
 	_, err := driver.bucket.CompleteMultipartUpload(initMultipartUploadResult, ossParts, oss.WithContext(ctx))
-	return err
+	return wrapError("CompleteMultipartUpload", path, err)
 }
 
 func (driver *ossFs) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
 	initMultipartUploadResult := oss.InitiateMultipartUploadResult{
-		Key:      path,
+		Key:      key,
 		UploadID: uploadID,
 		Bucket:   driver.bucket.BucketName,
 	}
-	return driver.bucket.AbortMultipartUpload(initMultipartUploadResult, oss.WithContext(ctx))
+	return wrapError("AbortMultipartUpload", path, driver.bucket.AbortMultipartUpload(initMultipartUploadResult, oss.WithContext(ctx)))
 }
 
 func (driver *ossFs) ListMultipartUploads(ctx context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
 	initMultipartUploadResult, err := driver.bucket.ListMultipartUploads(oss.WithContext(ctx))
 	if err != nil {
-		return nil, err
+		return nil, wrapError("ListMultipartUploads", "", err)
 	}
 
 	uploads := make([]fs.MultipartUploadInfo, 0, len(initMultipartUploadResult.Uploads))
@@ -133,9 +130,9 @@ func (driver *ossFs) ListMultipartUploads(ctx context.Context, opts ...fs.Option
 }
 
 func (driver *ossFs) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	initMultipartUploadResult := oss.InitiateMultipartUploadResult{
-		Key:      path,
+		Key:      key,
 		UploadID: uploadID,
 		Bucket:   driver.bucket.BucketName,
 	}
@@ -143,7 +140,7 @@ func (driver *ossFs) ListUploadedParts(ctx context.Context, path string, uploadI
 	// 列出已上传的分片
 	lpr, err := driver.bucket.ListUploadedParts(initMultipartUploadResult, oss.WithContext(ctx))
 	if err != nil {
-		return nil, err
+		return nil, wrapError("ListUploadedParts", path, err)
 	}
 
 	parts := make([]fs.MultipartPart, 0, len(lpr.UploadedParts))
@@ -1,7 +1,6 @@
 package alioss
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -10,69 +9,51 @@ import (
 	"github.com/goairix/fs"
 )
 
-// ossWriter 实现 io.WriteCloser 接口
+// ossWriter 实现 io.WriteCloser 接口，数据超过阈值后自动切换为分片上传
 type ossWriter struct {
-	ctx         context.Context
-	bucket      *oss.Bucket
-	path        string
-	buffer      *bytes.Buffer
-	metadata    fs.Metadata
-	contentType string
+	io.WriteCloser
+	ctx    context.Context
+	bucket *oss.Bucket
+	path   string
 }
 
-func newOssWriter(ctx context.Context, bucket *oss.Bucket, path string, opts ...fs.Option) *ossWriter {
-	o := &fs.Options{}
-	for _, opt := range opts {
-		opt(o)
-	}
-
-	writer := &ossWriter{
-		ctx:    ctx,
-		bucket: bucket,
-		path:   path,
-		buffer: bytes.NewBuffer(nil),
-	}
-	if o.ContentType != "" {
-		writer.contentType = o.ContentType
-	}
-	if o.Metadata != nil {
-		writer.metadata = o.Metadata
+func newOssWriter(ctx context.Context, driver *ossFs, path string, opts ...fs.Option) *ossWriter {
+	return &ossWriter{
+		WriteCloser: fs.NewStreamWriter(ctx, driver, path, driver.putObject("Create", path, opts...), opts...),
+		ctx:         ctx,
+		bucket:      driver.bucket,
+		path:        driver.path(path),
 	}
-
-	return writer
 }
 
-func (w *ossWriter) Write(p []byte) (n int, err error) {
-	select {
-	case <-w.ctx.Done():
-		return 0, w.ctx.Err()
-	default:
-		return w.buffer.Write(p)
+// putObject 返回单次上传完整文件的函数
+func (driver *ossFs) putObject(op, path string, opts ...fs.Option) fs.PutFunc {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
 	}
-}
 
-func (w *ossWriter) Close() error {
-	select {
-	case <-w.ctx.Done():
-		return w.ctx.Err()
-	default:
+	return func(ctx context.Context, body io.Reader, _ int64) error {
 		options := []oss.Option{
-			oss.WithContext(w.ctx),
+			oss.WithContext(ctx),
 		}
 
 		// 设置 ContentType
-		if w.contentType != "" {
-			options = append(options, oss.ContentType(w.contentType))
+		if o.ContentType != "" {
+			options = append(options, oss.ContentType(o.ContentType))
+		}
+		if o.ContentEncoding != "" {
+			options = append(options, oss.ContentEncoding(o.ContentEncoding))
 		}
 
 		// 处理metadata
-		if w.metadata != nil {
-			for k, v := range w.metadata {
+		if o.Metadata != nil {
+			for k, v := range o.Metadata {
 				options = append(options, oss.Meta(k, fmt.Sprintf("%v", v)))
 			}
 		}
 
-		return w.bucket.PutObject(w.path, bytes.NewReader(w.buffer.Bytes()), options...)
+		return wrapError(op, path, driver.bucket.PutObject(driver.path(path), body, options...))
 	}
 }
 
@@ -82,9 +63,9 @@ type ossReadWriter struct {
 	reader io.ReadCloser
 }
 
-func newOssReadWriter(ctx context.Context, bucket *oss.Bucket, path string, opts ...fs.Option) *ossReadWriter {
+func newOssReadWriter(ctx context.Context, driver *ossFs, path string, opts ...fs.Option) *ossReadWriter {
 	return &ossReadWriter{
-		ossWriter: newOssWriter(ctx, bucket, path, opts...),
+		ossWriter: newOssWriter(ctx, driver, path, opts...),
 	}
 }
 
@@ -93,7 +74,7 @@ func (rw *ossReadWriter) Read(p []byte) (n int, err error) {
 		var err error
 		rw.reader, err = rw.bucket.GetObject(rw.path, oss.WithContext(rw.ctx))
 		if err != nil {
-			return 0, err
+			return 0, wrapError("OpenFile", rw.path, err)
 		}
 	}
 	return rw.reader.Read(p)
@@ -12,7 +12,7 @@ import (
 )
 
 func (driver *obsFs) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
@@ -34,12 +34,12 @@ func (driver *obsFs) SignFullUrl(ctx context.Context, path string, opts ...fs.Op
 	input := &obs.CreateSignedUrlInput{
 		Method:  obs.HttpMethodGet,
 		Bucket:  driver.config.BucketName,
-		Key:     path,
+		Key:     key,
 		Expires: int(expires.Seconds()),
 	}
 	output, err := driver.client.CreateSignedUrl(input)
 	if err != nil {
-		return "", err
+		return "", wrapError("SignFullUrl", path, err)
 	}
 
 	signUrl := strings.ReplaceAll(strings.ReplaceAll(output.SignedUrl, "http://", "https://"), ":443", "")
@@ -47,11 +47,11 @@ func (driver *obsFs) SignFullUrl(ctx context.Context, path string, opts ...fs.Op
 		signUrl = strings.ReplaceAll(signUrl, endpoint, cdnDomain)
 	}
 
-	return signUrl, err
+	return signUrl, wrapError("SignFullUrl", path, err)
 }
 
 func (driver *obsFs) FullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
@@ -75,16 +75,16 @@ func (driver *obsFs) FullUrl(ctx context.Context, path string, opts ...fs.Option
 		input := &obs.CreateSignedUrlInput{
 			Method:  obs.HttpMethodGet,
 			Bucket:  driver.config.BucketName,
-			Key:     path,
+			Key:     key,
 			Expires: int(expires.Seconds()),
 		}
 		output, err := driver.client.CreateSignedUrl(input)
 		if err != nil {
-			return "", err
+			return "", wrapError("FullUrl", path, err)
 		}
 		fullUrl = strings.ReplaceAll(strings.ReplaceAll(output.SignedUrl, "http://", "https://"), ":443", "")
 	} else {
-		fullUrl = fmt.Sprintf("%s/%s", cdnDomain, path)
+		fullUrl = fmt.Sprintf("%s/%s", cdnDomain, key)
 	}
 
 	if useCdnDomain {
@@ -97,7 +97,12 @@ func (driver *obsFs) FullUrl(ctx context.Context, path string, opts ...fs.Option
 func (driver *obsFs) RelativePath(ctx context.Context, fullUrl string, opts ...fs.Option) (string, error) {
 	u, err := url.Parse(fullUrl)
 	if err != nil {
-		return "", nil
+		return "", wrapError("RelativePath", fullUrl, err)
 	}
-	return strings.TrimPrefix(u.Path, "/"), nil
+
+	relativePath := strings.TrimPrefix(u.Path, "/")
+	if driver.config.SubPath != "" {
+		relativePath = strings.TrimPrefix(relativePath, strings.Trim(driver.config.SubPath, "/")+"/")
+	}
+	return relativePath, nil
 }
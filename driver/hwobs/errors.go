@@ -0,0 +1,41 @@
+package hwobs
+
+import (
+	"errors"
+
+	"github.com/goairix/fs"
+	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+)
+
+// wrapError 将 OBS 错误转换为统一的文件系统错误
+func wrapError(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return err
+	}
+	return fs.NewPathError("hwobs", op, path, err, errorKind(err))
+}
+
+// errorKind 返回 OBS 错误对应的通用错误
+func errorKind(err error) error {
+	var obsErr obs.ObsError
+	if !errors.As(err, &obsErr) {
+		return nil
+	}
+	switch obsErr.Code {
+	case "NoSuchKey", "NoSuchBucket", "NoSuchUpload":
+		return fs.ErrNotExist
+	case "AccessDenied":
+		return fs.ErrPermission
+	case "PreconditionFailed":
+		return fs.ErrPreconditionFailed
+	case "NotImplemented":
+		return fs.ErrUnsupported
+	case "InternalError", "ServiceUnavailable", "SlowDown", "RequestTimeout":
+		return fs.ErrTemporary
+	}
+	return fs.ErrorKindFromStatus(obsErr.StatusCode)
+}
@@ -2,6 +2,7 @@ package hwobs
 
 import (
 	"os"
+	"path"
 	"strings"
 	"time"
 
@@ -18,7 +19,7 @@ func newObsFileInfo(info obs.Content) *obsFileInfo {
 }
 
 func (f *obsFileInfo) Name() string {
-	return f.info.Key
+	return path.Base(strings.TrimSuffix(f.info.Key, "/"))
 }
 
 func (f *obsFileInfo) Size() int64 {
@@ -26,6 +27,9 @@ func (f *obsFileInfo) Size() int64 {
 }
 
 func (f *obsFileInfo) Mode() os.FileMode {
+	if f.IsDir() {
+		return os.ModeDir | 0755
+	}
 	return 0644 // OBS不支持文件权限，返回默认值
 }
 
@@ -40,3 +44,8 @@ func (f *obsFileInfo) IsDir() bool {
 func (f *obsFileInfo) Sys() interface{} {
 	return f.info
 }
+
+// ETag 实现 fs.ETagger 接口
+func (f *obsFileInfo) ETag() string {
+	return strings.Trim(f.info.ETag, `"`)
+}
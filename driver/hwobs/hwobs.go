@@ -42,9 +42,9 @@ func New(config Config) (fs.FileSystem, error) {
 }
 
 func (driver *obsFs) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	var fileInfos []fs.FileInfo
-	prefix := strings.TrimRight(path, "/")
+	prefix := strings.TrimRight(key, "/")
 	if prefix != "" {
 		prefix += "/"
 	}
@@ -56,14 +56,19 @@ func (driver *obsFs) List(ctx context.Context, path string, opts ...fs.Option) (
 			Marker: marker,
 		}
 		input.Prefix = prefix
+		input.Delimiter = "/"
 
 		output, err := driver.client.ListObjects(input)
 		if err != nil {
-			return nil, err
+			return nil, wrapError("List", path, err)
 		}
 
 		// 添加文件
 		for _, object := range output.Contents {
+			if object.Key == prefix {
+				// 跳过目录占位对象
+				continue
+			}
 			fileInfos = append(fileInfos, newObsFileInfo(object))
 		}
 
@@ -83,14 +88,92 @@ func (driver *obsFs) List(ctx context.Context, path string, opts ...fs.Option) (
 	return fileInfos, nil
 }
 
+func (driver *obsFs) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	prefix := strings.TrimRight(driver.path(path), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	input := &obs.ListObjectsInput{
+		Bucket: driver.config.BucketName,
+		Marker: o.Marker,
+	}
+	input.Prefix = prefix
+	input.Delimiter = "/"
+	input.MaxKeys = o.ListLimit()
+
+	output, err := driver.client.ListObjects(input)
+	if err != nil {
+		return nil, wrapError("ListPage", path, err)
+	}
+
+	result := &fs.ListResult{}
+	for _, object := range output.Contents {
+		if object.Key == prefix {
+			// 跳过目录占位对象
+			continue
+		}
+		result.Files = append(result.Files, newObsFileInfo(object))
+	}
+	for _, prefix := range output.CommonPrefixes {
+		result.Files = append(result.Files, newObsFileInfo(obs.Content{
+			Key: prefix,
+		}))
+	}
+	if output.IsTruncated {
+		result.NextMarker = output.NextMarker
+	}
+	return result, nil
+}
+
+func (driver *obsFs) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	prefix := strings.TrimRight(driver.path(root), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	// 不设置分隔符，按页列出前缀下的全部对象
+	return fs.WalkObjects(ctx, driver, root, fn, func(yield func(string, fs.FileInfo) bool) error {
+		marker := ""
+		for {
+			input := &obs.ListObjectsInput{
+				Bucket: driver.config.BucketName,
+				Marker: marker,
+			}
+			input.Prefix = prefix
+
+			output, err := driver.client.ListObjects(input)
+			if err != nil {
+				return wrapError("Walk", root, err)
+			}
+			for _, object := range output.Contents {
+				if !yield(strings.TrimPrefix(object.Key, prefix), newObsFileInfo(object)) {
+					return nil
+				}
+			}
+			if !output.IsTruncated || len(output.Contents) == 0 {
+				return nil
+			}
+			marker = output.NextMarker
+			if marker == "" {
+				marker = output.Contents[len(output.Contents)-1].Key
+			}
+		}
+	}, opts...)
+}
+
 func (driver *obsFs) MakeDir(ctx context.Context, path string, perm os.FileMode, opts ...fs.Option) error {
 	// OBS目录在写入文件时自动创建
 	return nil
 }
 
 func (driver *obsFs) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
-	path = driver.path(path)
-	prefix := strings.TrimRight(path, "/") + "/"
+	key := driver.path(path)
+	prefix := strings.TrimRight(key, "/") + "/"
 	marker := ""
 	for {
 		input := &obs.ListObjectsInput{
@@ -101,7 +184,7 @@ func (driver *obsFs) RemoveDir(ctx context.Context, path string, opts ...fs.Opti
 
 		output, err := driver.client.ListObjects(input)
 		if err != nil {
-			return err
+			return wrapError("RemoveDir", path, err)
 		}
 
 		for _, object := range output.Contents {
@@ -110,7 +193,7 @@ func (driver *obsFs) RemoveDir(ctx context.Context, path string, opts ...fs.Opti
 				Key:    object.Key,
 			})
 			if err != nil {
-				return err
+				return wrapError("RemoveDir", path, err)
 			}
 		}
 
@@ -123,60 +206,72 @@ func (driver *obsFs) RemoveDir(ctx context.Context, path string, opts ...fs.Opti
 }
 
 func (driver *obsFs) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
-	path = driver.path(path)
-	return newObsWriter(ctx, driver.client, driver.config.BucketName, path, opts...), nil
+	return newObsWriter(ctx, driver, path, opts...), nil
 }
 
 func (driver *obsFs) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	input := &obs.GetObjectInput{}
 	input.Bucket = driver.config.BucketName
-	input.Key = path
-	output, err := driver.client.GetObject(input)
+	input.Key = key
+
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var output *obs.GetObjectOutput
+	var err error
+	if o.Range != nil {
+		// SDK 的 RangeStart/RangeEnd 不支持单字节与读取到末尾的范围，这里直接设置请求头
+		output, err = driver.client.GetObject(input, obs.WithCustomHeader("Range", o.Range.HeaderValue()))
+	} else {
+		output, err = driver.client.GetObject(input)
+	}
 	if err != nil {
-		return nil, err
+		return nil, wrapError("Open", path, err)
 	}
 	return output.Body, nil
 }
 
 func (driver *obsFs) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
 	if flag&os.O_RDWR != 0 {
-		return newObsReadWriter(ctx, driver.client, driver.config.BucketName, driver.path(path), opts...), nil
+		return newObsReadWriter(ctx, driver, path, opts...), nil
 	}
 	if flag&os.O_WRONLY != 0 {
-		return newObsReadWriter(ctx, driver.client, driver.config.BucketName, path, opts...), nil
+		return newObsReadWriter(ctx, driver, path, opts...), nil
 	}
 	reader, err := driver.Open(ctx, path, opts...)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("OpenFile", path, err)
 	}
 	return newObsReadOnlyWrapper(reader), nil
 }
 
 func (driver *obsFs) Remove(ctx context.Context, path string, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
 	_, err := driver.client.DeleteObject(&obs.DeleteObjectInput{
 		Bucket: driver.config.BucketName,
-		Key:    path,
+		Key:    key,
 	})
-	return err
+	return wrapError("Remove", path, err)
 }
 
 func (driver *obsFs) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
-	src = driver.path(src)
-	dst = driver.path(dst)
+	srcKey := driver.path(src)
+	dstKey := driver.path(dst)
 	input := &obs.CopyObjectInput{}
 	input.Bucket = driver.config.BucketName
-	input.Key = dst
+	input.Key = dstKey
 	input.CopySourceBucket = driver.config.BucketName
-	input.CopySourceKey = src
+	input.CopySourceKey = srcKey
 	_, err := driver.client.CopyObject(input)
-	return err
+	return wrapError("Copy", src, err)
 }
 
 func (driver *obsFs) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
 	if err := driver.Copy(ctx, src, dst); err != nil {
-		return err
+		return wrapError("Move", src, err)
 	}
 	return driver.Remove(ctx, src)
 }
@@ -186,20 +281,27 @@ func (driver *obsFs) Rename(ctx context.Context, oldPath, newPath string, opts .
 }
 
 func (driver *obsFs) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	input := &obs.GetObjectMetadataInput{
 		Bucket: driver.config.BucketName,
-		Key:    path,
+		Key:    key,
 	}
 	output, err := driver.client.GetObjectMetadata(input)
 	if err != nil {
-		return nil, err
+		// 目录在对象存储中为公共前缀，没有实体对象
+		if errors.Is(errorKind(err), fs.ErrNotExist) {
+			if isDir, dirErr := driver.IsDir(ctx, path); dirErr == nil && isDir {
+				return newObsFileInfo(obs.Content{Key: strings.TrimRight(key, "/") + "/"}), nil
+			}
+		}
+		return nil, wrapError("Stat", path, err)
 	}
 
 	return newObsFileInfo(obs.Content{
-		Key:          path,
+		Key:          key,
 		Size:         output.ContentLength,
 		LastModified: output.LastModified,
+		ETag:         output.ETag,
 	}), nil
 }
 
@@ -210,7 +312,7 @@ func (driver *obsFs) GetMimeType(ctx context.Context, path string, opts ...fs.Op
 	}
 	output, err := driver.client.GetObjectMetadata(input)
 	if err != nil {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 
 	if output.ContentType != "" {
@@ -220,7 +322,7 @@ func (driver *obsFs) GetMimeType(ctx context.Context, path string, opts ...fs.Op
 	// 如果对象没有 Content-Type，则读取文件内容进行检测
 	obj, err := driver.Open(ctx, path)
 	if err != nil {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 	defer func() {
 		_ = obj.Close()
@@ -229,41 +331,50 @@ func (driver *obsFs) GetMimeType(ctx context.Context, path string, opts ...fs.Op
 	buffer := make([]byte, 512)
 	n, err := obj.Read(buffer)
 	if err != nil && err != io.EOF {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 
 	return http.DetectContentType(buffer[:n]), nil
 }
 
 func (driver *obsFs) SetMetadata(ctx context.Context, path string, metadata map[string]any, opts ...fs.Option) error {
+	key := driver.path(path)
+	head, err := driver.client.GetObjectMetadata(&obs.GetObjectMetadataInput{
+		Bucket: driver.config.BucketName,
+		Key:    key,
+	})
+	if err != nil {
+		return wrapError("SetMetadata", path, err)
+	}
+
+	// 复制到自身并替换元数据，保留原有的 ContentType 与 ContentEncoding
 	input := &obs.CopyObjectInput{}
 	input.Bucket = driver.config.BucketName
-	input.Key = driver.path(path)
+	input.Key = key
 	input.CopySourceBucket = driver.config.BucketName
-	input.CopySourceKey = path + "_tmp"
+	input.CopySourceKey = key
+	input.MetadataDirective = obs.ReplaceMetadata
+	input.ContentType = head.ContentType
+	input.ContentEncoding = head.ContentEncoding
 
 	input.Metadata = make(map[string]string)
 	for k, v := range metadata {
 		input.Metadata[k] = fmt.Sprintf("%v", v)
 	}
 
-	_, err := driver.client.CopyObject(input)
-	if err != nil {
-		return err
-	}
-
-	return driver.Move(ctx, path+"_tmp", path)
+	_, err = driver.client.CopyObject(input)
+	return wrapError("SetMetadata", path, err)
 }
 
 func (driver *obsFs) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]any, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	input := &obs.GetObjectMetadataInput{
 		Bucket: driver.config.BucketName,
-		Key:    path,
+		Key:    key,
 	}
 	output, err := driver.client.GetObjectMetadata(input)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("GetMetadata", path, err)
 	}
 
 	metadata := make(map[string]interface{})
@@ -281,39 +392,36 @@ func (driver *obsFs) Exists(ctx context.Context, path string, opts ...fs.Option)
 }
 
 func (driver *obsFs) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
-	path = strings.TrimRight(driver.path(path), "/") + "/"
+	key := strings.TrimRight(driver.path(path), "/") + "/"
 	input := &obs.ListObjectsInput{
 		Bucket: driver.config.BucketName,
 	}
-	input.Prefix = path
+	input.Prefix = key
 	input.Delimiter = "/"
 	input.MaxKeys = 1
 	output, err := driver.client.ListObjects(input)
 	if err != nil {
-		return false, err
+		return false, wrapError("IsDir", path, err)
 	}
 	return len(output.Contents) > 0 || len(output.CommonPrefixes) > 0, nil
 }
 
 func (driver *obsFs) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	_, err := driver.client.GetObjectMetadata(&obs.GetObjectMetadataInput{
 		Bucket: driver.config.BucketName,
-		Key:    path,
+		Key:    key,
 	})
 	if err != nil {
-		var obsErr obs.ObsError
-		if errors.As(err, &obsErr) && obsErr.StatusCode == 404 {
+		if errors.Is(errorKind(err), fs.ErrNotExist) {
 			return false, nil
 		}
-		return false, err
+		return false, wrapError("IsFile", path, err)
 	}
 	return true, nil
 }
 
+// path 获取规范化的对象键，路径中的 ".." 不会超出子目录
 func (driver *obsFs) path(path string) string {
-	if driver.config.SubPath != "" {
-		return strings.Trim(driver.config.SubPath, "/") + "/" + path
-	}
-	return path
+	return fs.JoinPath(driver.config.SubPath, path)
 }
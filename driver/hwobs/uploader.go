@@ -2,6 +2,7 @@ package hwobs
 
 import (
 	"context"
+	"fmt"
 	"io"
 
 	"github.com/goairix/fs"
@@ -13,59 +14,58 @@ func (driver *obsFs) Uploader() fs.Uploader {
 }
 
 func (driver *obsFs) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
-	file, err := driver.Create(ctx, path, opts...)
-	if err != nil {
-		return err
-	}
-
-	_, err = io.Copy(file, reader)
-	if err != nil {
-		_ = file.Close()
-		return err
-	}
-
-	return file.Close()
+	err := fs.ManagedUpload(ctx, driver, path, reader, driver.putObject("Upload", path, opts...), opts...)
+	return wrapError("Upload", path, err)
 }
 
 func (driver *obsFs) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
 	}
 	input := &obs.InitiateMultipartUploadInput{}
 	input.Bucket = driver.config.BucketName
-	input.Key = path
+	input.Key = key
 	if o.ContentType != "" {
 		input.ContentType = o.ContentType
 	}
+	if o.ContentEncoding != "" {
+		input.ContentEncoding = o.ContentEncoding
+	}
+	if o.Metadata != nil {
+		input.Metadata = make(map[string]string)
+		for k, v := range o.Metadata {
+			input.Metadata[k] = fmt.Sprintf("%v", v)
+		}
+	}
 
 	output, err := driver.client.InitiateMultipartUpload(input)
 	if err != nil {
-		return "", err
+		return "", wrapError("InitMultipartUpload", path, err)
 	}
 
 	return output.UploadId, nil
 }
 
 func (driver *obsFs) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	input := &obs.UploadPartInput{
 		Bucket:     driver.config.BucketName,
-		Key:        path,
+		Key:        key,
 		PartNumber: partNumber,
 		UploadId:   uploadID,
 		Body:       data,
 	}
 	output, err := driver.client.UploadPart(input)
 	if err != nil {
-		return "", err
+		return "", wrapError("UploadPart", path, err)
 	}
 	return output.ETag, nil
 }
 
 func (driver *obsFs) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
 	obsParts := make([]obs.Part, len(parts))
 	for i, part := range parts {
 		obsParts[i] = obs.Part{
@@ -75,24 +75,24 @@ func (driver *obsFs) CompleteMultipartUpload(ctx context.Context, path string, u
 	}
 	input := &obs.CompleteMultipartUploadInput{
 		Bucket:   driver.config.BucketName,
-		Key:      path,
+		Key:      key,
 		UploadId: uploadID,
 		Parts:    obsParts,
 	}
 	_, err := driver.client.CompleteMultipartUpload(input)
-	return err
+	return wrapError("CompleteMultipartUpload", path, err)
 }
 
 func (driver *obsFs) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
 	input := &obs.AbortMultipartUploadInput{
 		Bucket:   driver.config.BucketName,
-		Key:      path,
+		Key:      key,
 		UploadId: uploadID,
 	}
 
 	_, err := driver.client.AbortMultipartUpload(input)
-	return err
+	return wrapError("AbortMultipartUpload", path, err)
 }
 
 func (driver *obsFs) ListMultipartUploads(ctx context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
@@ -101,7 +101,7 @@ func (driver *obsFs) ListMultipartUploads(ctx context.Context, opts ...fs.Option
 	}
 	output, err := driver.client.ListMultipartUploads(input)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("ListMultipartUploads", "", err)
 	}
 
 	uploads := make([]fs.MultipartUploadInfo, 0, len(output.Uploads))
@@ -116,15 +116,15 @@ func (driver *obsFs) ListMultipartUploads(ctx context.Context, opts ...fs.Option
 }
 
 func (driver *obsFs) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	input := &obs.ListPartsInput{
 		Bucket:   driver.config.BucketName,
-		Key:      path,
+		Key:      key,
 		UploadId: uploadID,
 	}
 	output, err := driver.client.ListParts(input)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("ListUploadedParts", path, err)
 	}
 
 	parts := make([]fs.MultipartPart, 0, len(output.Parts))
@@ -1,7 +1,6 @@
 package hwobs
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -10,76 +9,58 @@ import (
 	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
 )
 
-// obsWriter 实现 io.WriteCloser 接口
+// obsWriter 实现 io.WriteCloser 接口，数据超过阈值后自动切换为分片上传
 type obsWriter struct {
-	ctx         context.Context
-	client      *obs.ObsClient
-	bucket      string
-	path        string
-	buffer      *bytes.Buffer
-	metadata    fs.Metadata
-	contentType string
+	io.WriteCloser
+	ctx    context.Context
+	client *obs.ObsClient
+	bucket string
+	path   string
 }
 
-func newObsWriter(ctx context.Context, client *obs.ObsClient, bucket, path string, opts ...fs.Option) *obsWriter {
-	o := &fs.Options{}
-	for _, opt := range opts {
-		opt(o)
-	}
-
-	writer := &obsWriter{
-		ctx:    ctx,
-		client: client,
-		bucket: bucket,
-		path:   path,
-		buffer: bytes.NewBuffer(nil),
-	}
-
-	if o.ContentType != "" {
-		writer.contentType = o.ContentType
+func newObsWriter(ctx context.Context, driver *obsFs, path string, opts ...fs.Option) *obsWriter {
+	return &obsWriter{
+		WriteCloser: fs.NewStreamWriter(ctx, driver, path, driver.putObject("Create", path, opts...), opts...),
+		ctx:         ctx,
+		client:      driver.client,
+		bucket:      driver.config.BucketName,
+		path:        driver.path(path),
 	}
-	if o.Metadata != nil {
-		writer.metadata = o.Metadata
-	}
-
-	return writer
 }
 
-func (w *obsWriter) Write(p []byte) (n int, err error) {
-	select {
-	case <-w.ctx.Done():
-		return 0, w.ctx.Err()
-	default:
-		return w.buffer.Write(p)
+// putObject 返回单次上传完整文件的函数
+func (driver *obsFs) putObject(op, path string, opts ...fs.Option) fs.PutFunc {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
 	}
-}
 
-func (w *obsWriter) Close() error {
-	select {
-	case <-w.ctx.Done():
-		return w.ctx.Err()
-	default:
+	return func(_ context.Context, body io.Reader, size int64) error {
 		input := &obs.PutObjectInput{
-			Body: bytes.NewReader(w.buffer.Bytes()),
+			Body: body,
 		}
-		input.Bucket = w.bucket
-		input.Key = w.path
+		input.Bucket = driver.config.BucketName
+		input.Key = driver.path(path)
+		input.ContentLength = size
 
 		// 设置 ContentType
-		if w.contentType != "" {
-			input.ContentType = w.contentType
+		if o.ContentType != "" {
+			input.ContentType = o.ContentType
+		}
+		if o.ContentEncoding != "" {
+			input.ContentEncoding = o.ContentEncoding
 		}
 
 		// 处理metadata
-		if w.metadata != nil {
+		if o.Metadata != nil {
 			input.Metadata = make(map[string]string)
-			for k, v := range w.metadata {
+			for k, v := range o.Metadata {
 				input.Metadata[k] = fmt.Sprintf("%v", v)
 			}
 		}
 
-		_, err := w.client.PutObject(input)
-		return err
+		_, err := driver.client.PutObject(input)
+		return wrapError(op, path, err)
 	}
 }
 
@@ -89,9 +70,9 @@ type obsReadWriter struct {
 	reader io.ReadCloser
 }
 
-func newObsReadWriter(ctx context.Context, client *obs.ObsClient, bucket, path string, opts ...fs.Option) *obsReadWriter {
+func newObsReadWriter(ctx context.Context, driver *obsFs, path string, opts ...fs.Option) *obsReadWriter {
 	return &obsReadWriter{
-		obsWriter: newObsWriter(ctx, client, bucket, path, opts...),
+		obsWriter: newObsWriter(ctx, driver, path, opts...),
 	}
 }
 
@@ -102,7 +83,7 @@ func (rw *obsReadWriter) Read(p []byte) (n int, err error) {
 		input.Key = rw.path
 		output, err := rw.client.GetObject(input)
 		if err != nil {
-			return 0, err
+			return 0, wrapError("OpenFile", rw.path, err)
 		}
 		rw.reader = output.Body
 	}
@@ -15,8 +15,12 @@ func (driver *localFs) SignFullUrl(ctx context.Context, path string, opts ...fs.
 		opt(o)
 	}
 
+	fullPath, err := driver.path(path)
+	if err != nil {
+		return "", wrapError("SignFullUrl", path, err)
+	}
 	if o.CdnDomain != "" {
-		return fmt.Sprintf("%s/%s", o.CdnDomain, driver.path(path)), nil
+		return fmt.Sprintf("%s/%s", o.CdnDomain, fullPath), nil
 	}
 
 	return path, nil
@@ -28,8 +32,12 @@ func (driver *localFs) FullUrl(ctx context.Context, path string, opts ...fs.Opti
 		opt(o)
 	}
 
+	fullPath, err := driver.path(path)
+	if err != nil {
+		return "", wrapError("FullUrl", path, err)
+	}
 	if o.CdnDomain != "" {
-		return fmt.Sprintf("%s/%s", o.CdnDomain, driver.path(path)), nil
+		return fmt.Sprintf("%s/%s", o.CdnDomain, fullPath), nil
 	}
 
 	return path, nil
@@ -38,7 +46,15 @@ func (driver *localFs) FullUrl(ctx context.Context, path string, opts ...fs.Opti
 func (driver *localFs) RelativePath(ctx context.Context, fullUrl string, opts ...fs.Option) (string, error) {
 	u, err := url.Parse(fullUrl)
 	if err != nil {
-		return "", err
+		return "", wrapError("RelativePath", fullUrl, err)
+	}
+	relativePath := fs.CleanPath(u.Path)
+	if subPath := fs.CleanPath(driver.subPath); subPath != "" {
+		relativePath = strings.TrimPrefix(relativePath, subPath+"/")
+	}
+	// 还原的路径同样不能超出根目录
+	if _, err = driver.name(relativePath); err != nil {
+		return "", wrapError("RelativePath", fullUrl, err)
 	}
-	return strings.TrimLeft(u.Path, "/"), nil
+	return relativePath, nil
 }
@@ -0,0 +1,41 @@
+package local
+
+import (
+	"errors"
+	"syscall"
+
+	"github.com/goairix/fs"
+)
+
+// wrapError 将本地文件系统错误转换为统一的文件系统错误
+func wrapError(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return err
+	}
+
+	var kind error
+	switch {
+	case errors.Is(err, syscall.EISDIR):
+		kind = fs.ErrIsDir
+	case errors.Is(err, syscall.ENOTDIR):
+		kind = fs.ErrNotDir
+	case isPathEscape(err):
+		kind = fs.ErrPathEscapes
+	}
+	return fs.NewPathError("local", op, path, err, kind)
+}
+
+// isPathEscape 判断是否为 os.Root 拒绝超出根目录的路径或符号链接时返回的错误，
+// 该错误未导出，只能通过错误信息判断
+func isPathEscape(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if err.Error() == "path escapes from parent" {
+			return true
+		}
+	}
+	return false
+}
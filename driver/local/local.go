@@ -2,20 +2,34 @@ package local
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/goairix/fs"
 )
 
-// localFs 本地文件系统
+// multipartDir 分片上传的状态与分片文件所在的目录，位于 RootPath 下
+const multipartDir = ".multipart"
+
+// errReserved 访问分片上传目录时返回的错误
+var errReserved = fmt.Errorf("%s is reserved for multipart uploads: %w", multipartDir, fs.ErrPermission)
+
+// localFs 本地文件系统，全部操作通过 os.Root 进行，不会访问根目录之外的文件
 type localFs struct {
 	rootPath         string
 	subPath          string
+	root             *os.Root // RootPath 与 SubPath 对应的目录
+	parts            *os.Root // 分片文件所在的目录，分片只能通过文件名访问
+	reserved         bool     // SubPath 为空时分片上传目录位于根目录中，不允许访问
 	multipartStorage MultipartStorage
+	multipartMu      sync.Mutex // 保护分片上传状态的读取与更新
 }
 
 type Config struct {
@@ -26,24 +40,46 @@ type Config struct {
 
 func New(conf Config) (fs.FileSystem, error) {
 	if conf.MultipartStorage == nil {
-		conf.MultipartStorage, _ = NewFileMultipartStorage(filepath.Join(conf.RootPath, ".multipart"))
+		conf.MultipartStorage, _ = NewFileMultipartStorage(filepath.Join(conf.RootPath, multipartDir))
+	}
+	partDir := filepath.Join(conf.RootPath, multipartDir, "parts")
+	if err := os.MkdirAll(partDir, 0755); err != nil {
+		return nil, err
+	}
+	parts, err := os.OpenRoot(partDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// 根目录不存在时自动创建
+	dir := filepath.Join(conf.RootPath, fs.CleanPath(conf.SubPath))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		_ = parts.Close()
+		return nil, err
 	}
+
 	return &localFs{
 		rootPath:         conf.RootPath,
 		subPath:          conf.SubPath,
+		root:             root,
+		parts:            parts,
+		reserved:         fs.CleanPath(conf.SubPath) == "",
 		multipartStorage: conf.MultipartStorage,
 	}, nil
 }
 
 func (driver *localFs) List(_ context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
-	fullPath := driver.fullPath(path)
-	entries, err := os.ReadDir(fullPath)
+	entries, err := driver.readDir(path)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("List", path, err)
 	}
 
 	var files []fs.FileInfo
-	for _, entry := range entries {
+	for _, entry := range driver.visible(path, entries) {
 		info, err := entry.Info()
 		if err != nil {
 			continue
@@ -53,12 +89,83 @@ func (driver *localFs) List(_ context.Context, path string, opts ...fs.Option) (
 	return files, nil
 }
 
+func (driver *localFs) ListPage(_ context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// 标记为已读取的目录项数量
+	var offset int
+	if o.Marker != "" {
+		var err error
+		if offset, err = strconv.Atoi(o.Marker); err != nil || offset < 0 {
+			return nil, wrapError("ListPage", path, fmt.Errorf("invalid marker %q", o.Marker))
+		}
+	}
+
+	dir, err := driver.open(path)
+	if err != nil {
+		return nil, wrapError("ListPage", path, err)
+	}
+	defer func() {
+		_ = dir.Close()
+	}()
+
+	if offset > 0 {
+		if _, err = dir.ReadDir(offset); err != nil && err != io.EOF {
+			return nil, wrapError("ListPage", path, err)
+		}
+	}
+	// 多读取一项用于判断是否还有下一页
+	limit := o.ListLimit()
+	entries, err := dir.ReadDir(limit + 1)
+	if err != nil && err != io.EOF {
+		return nil, wrapError("ListPage", path, err)
+	}
+
+	result := &fs.ListResult{}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		result.NextMarker = strconv.Itoa(offset + limit)
+	}
+	for _, entry := range driver.visible(path, entries) {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		result.Files = append(result.Files, info)
+	}
+	return result, nil
+}
+
 func (driver *localFs) MakeDir(_ context.Context, path string, perm os.FileMode, opts ...fs.Option) error {
-	return os.MkdirAll(driver.fullPath(path), perm)
+	name, err := driver.name(path)
+	if err != nil {
+		return wrapError("MakeDir", path, err)
+	}
+	return wrapError("MakeDir", path, driver.root.MkdirAll(name, perm))
 }
 
 func (driver *localFs) RemoveDir(_ context.Context, path string, opts ...fs.Option) error {
-	return os.RemoveAll(driver.fullPath(path))
+	name, err := driver.name(path)
+	if err != nil {
+		return wrapError("RemoveDir", path, err)
+	}
+	if name == "." && driver.reserved {
+		// 删除根目录时保留分片上传目录
+		entries, err := driver.readDir(path)
+		if err != nil {
+			return wrapError("RemoveDir", path, err)
+		}
+		for _, entry := range driver.visible(path, entries) {
+			if err = driver.root.RemoveAll(entry.Name()); err != nil {
+				return wrapError("RemoveDir", path, err)
+			}
+		}
+		return nil
+	}
+	return wrapError("RemoveDir", path, driver.root.RemoveAll(name))
 }
 
 func (driver *localFs) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
@@ -67,9 +174,9 @@ func (driver *localFs) Create(ctx context.Context, path string, opts ...fs.Optio
 		opt(options)
 	}
 
-	file, err := os.Create(driver.fullPath(path))
+	file, err := driver.openFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("Create", path, err)
 	}
 
 	// 本地文件系统不处理 ContentType，只处理 Metadata
@@ -84,15 +191,61 @@ func (driver *localFs) Create(ctx context.Context, path string, opts ...fs.Optio
 }
 
 func (driver *localFs) Open(_ context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
-	return os.Open(driver.fullPath(path))
+	options := &fs.Options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	file, err := driver.open(path)
+	if err != nil {
+		return nil, wrapError("Open", path, err)
+	}
+	if options.Range == nil {
+		return file, nil
+	}
+
+	if _, err = file.Seek(options.Range.Offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, wrapError("Open", path, err)
+	}
+	if options.Range.Length <= 0 {
+		return file, nil
+	}
+	return &limitedFile{Reader: io.LimitReader(file, options.Range.Length), file: file}, nil
+}
+
+func (driver *localFs) OpenReader(_ context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	file, err := driver.open(path)
+	if err != nil {
+		return nil, wrapError("OpenReader", path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, wrapError("OpenReader", path, err)
+	}
+	if info.IsDir() {
+		_ = file.Close()
+		return nil, wrapError("OpenReader", path, fs.ErrIsDir)
+	}
+	return &localFile{File: file, size: info.Size()}, nil
 }
 
 func (driver *localFs) OpenFile(_ context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
-	return os.OpenFile(driver.fullPath(path), flag, perm)
+	file, err := driver.openFile(path, flag, perm)
+	if err != nil {
+		return nil, wrapError("OpenFile", path, err)
+	}
+	return file, nil
 }
 
 func (driver *localFs) Remove(_ context.Context, path string, opts ...fs.Option) error {
-	return os.Remove(driver.fullPath(path))
+	name, err := driver.name(path)
+	if err != nil {
+		return wrapError("Remove", path, err)
+	}
+	return wrapError("Remove", path, driver.root.Remove(name))
 }
 
 func (driver *localFs) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
@@ -113,25 +266,29 @@ func (driver *localFs) Copy(ctx context.Context, src, dst string, opts ...fs.Opt
 	}()
 
 	_, err = io.Copy(destFile, sourceFile)
-	return err
+	return wrapError("Copy", src, err)
 }
 
 func (driver *localFs) Move(_ context.Context, src, dst string, opts ...fs.Option) error {
-	return os.Rename(driver.fullPath(src), driver.fullPath(dst))
+	return wrapError("Move", src, driver.rename(src, dst))
 }
 
 func (driver *localFs) Rename(_ context.Context, oldPath, newPath string, opts ...fs.Option) error {
-	return os.Rename(driver.fullPath(oldPath), driver.fullPath(newPath))
+	return wrapError("Rename", oldPath, driver.rename(oldPath, newPath))
 }
 
 func (driver *localFs) Stat(_ context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
-	return os.Stat(driver.fullPath(path))
+	info, err := driver.stat(path)
+	if err != nil {
+		return nil, wrapError("Stat", path, err)
+	}
+	return info, nil
 }
 
 func (driver *localFs) GetMimeType(_ context.Context, path string, opts ...fs.Option) (string, error) {
-	file, err := os.Open(driver.fullPath(path))
+	file, err := driver.open(path)
 	if err != nil {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 	defer func() {
 		_ = file.Close()
@@ -139,21 +296,25 @@ func (driver *localFs) GetMimeType(_ context.Context, path string, opts ...fs.Op
 
 	// 读取文件前512字节用于检测文件类型
 	buffer := make([]byte, 512)
-	_, err = file.Read(buffer)
+	n, err := file.Read(buffer)
 	if err != nil && err != io.EOF {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 
 	// 使用 http.DetectContentType 检测 MIME 类型
-	return http.DetectContentType(buffer), nil
+	return http.DetectContentType(buffer[:n]), nil
 }
 
 func (driver *localFs) SetMetadata(_ context.Context, path string, metadata map[string]any, opts ...fs.Option) error {
 	// 本地文件系统只支持修改文件权限和时间戳
 	if mode, ok := metadata["mode"]; ok {
 		if m, ok := mode.(os.FileMode); ok {
-			if err := os.Chmod(driver.fullPath(path), m); err != nil {
-				return err
+			name, err := driver.name(path)
+			if err != nil {
+				return wrapError("SetMetadata", path, err)
+			}
+			if err = driver.root.Chmod(name, m); err != nil {
+				return wrapError("SetMetadata", path, err)
 			}
 		}
 	}
@@ -161,9 +322,9 @@ func (driver *localFs) SetMetadata(_ context.Context, path string, metadata map[
 }
 
 func (driver *localFs) GetMetadata(_ context.Context, path string, opts ...fs.Option) (map[string]any, error) {
-	info, err := os.Stat(driver.fullPath(path))
+	info, err := driver.stat(path)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("GetMetadata", path, err)
 	}
 
 	return map[string]interface{}{
@@ -176,46 +337,136 @@ func (driver *localFs) GetMetadata(_ context.Context, path string, opts ...fs.Op
 }
 
 func (driver *localFs) Exists(_ context.Context, path string, opts ...fs.Option) (bool, error) {
-	_, err := os.Stat(driver.fullPath(path))
+	_, err := driver.stat(path)
 	if err == nil {
 		return true, nil
 	}
 	if os.IsNotExist(err) {
 		return false, nil
 	}
-	return false, err
+	return false, wrapError("Exists", path, err)
 }
 
 func (driver *localFs) IsDir(_ context.Context, path string, opts ...fs.Option) (bool, error) {
-	info, err := os.Stat(driver.fullPath(path))
+	info, err := driver.stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
 		}
-		return false, err
+		return false, wrapError("IsDir", path, err)
 	}
 	return info.IsDir(), nil
 }
 
 func (driver *localFs) IsFile(_ context.Context, path string, opts ...fs.Option) (bool, error) {
-	info, err := os.Stat(driver.fullPath(path))
+	info, err := driver.stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
 		}
-		return false, err
+		return false, wrapError("IsFile", path, err)
 	}
 	return !info.IsDir(), nil
 }
 
-// fullPath 获取完整路径
-func (driver *localFs) fullPath(path string, opts ...fs.Option) string {
-	return filepath.Join(driver.rootPath, driver.path(path))
+// rename 移动文件或目录，自动创建目标的上级目录
+func (driver *localFs) rename(src, dst string) error {
+	srcName, err := driver.name(src)
+	if err != nil {
+		return err
+	}
+	dstName, err := driver.name(dst)
+	if err != nil {
+		return err
+	}
+	if err = driver.root.MkdirAll(filepath.Dir(dstName), 0755); err != nil {
+		return err
+	}
+	return driver.root.Rename(srcName, dstName)
+}
+
+// open 以只读方式打开文件或目录
+func (driver *localFs) open(path string) (*os.File, error) {
+	name, err := driver.name(path)
+	if err != nil {
+		return nil, err
+	}
+	return driver.root.Open(name)
 }
 
-func (driver *localFs) path(path string) string {
-	if driver.subPath != "" {
-		return strings.Trim(driver.subPath, "/") + "/" + path
+// openFile 以指定模式打开文件，创建文件时与对象存储一致，自动创建上级目录
+func (driver *localFs) openFile(path string, flag int, perm os.FileMode) (*os.File, error) {
+	name, err := driver.name(path)
+	if err != nil {
+		return nil, err
+	}
+	if flag&os.O_CREATE != 0 {
+		if err = driver.root.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return driver.root.OpenFile(name, flag, perm)
+}
+
+func (driver *localFs) stat(path string) (os.FileInfo, error) {
+	name, err := driver.name(path)
+	if err != nil {
+		return nil, err
+	}
+	return driver.root.Stat(name)
+}
+
+// readDir 读取目录内容，按名称排序
+func (driver *localFs) readDir(path string) ([]os.DirEntry, error) {
+	dir, err := driver.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dir.Close()
+	}()
+	entries, err := dir.ReadDir(-1)
+	slices.SortFunc(entries, func(a, b os.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, err
+}
+
+// name 返回路径相对于根目录的规范化名称，开头的 "/" 会被忽略；
+// 路径中的 ".." 超出根目录时返回 fs.ErrPathEscapes，指向根目录之外的符号链接由 os.Root 拒绝
+func (driver *localFs) name(path string) (string, error) {
+	name := strings.TrimLeft(filepath.ToSlash(path), "/")
+	if name == "" {
+		return ".", nil
+	}
+	if !filepath.IsLocal(name) {
+		return "", fs.ErrPathEscapes
+	}
+	name = filepath.Clean(name)
+	if driver.reserved && (name == multipartDir || strings.HasPrefix(name, multipartDir+string(filepath.Separator))) {
+		return "", errReserved
+	}
+	return name, nil
+}
+
+// visible 去掉根目录列出结果中的分片上传目录
+func (driver *localFs) visible(path string, entries []os.DirEntry) []os.DirEntry {
+	if !driver.reserved {
+		return entries
+	}
+	if name, _ := driver.name(path); name != "." {
+		return entries
+	}
+	return slices.DeleteFunc(entries, func(entry os.DirEntry) bool {
+		return entry.Name() == multipartDir
+	})
+}
+
+// path 返回包含子目录的访问路径，用于生成 URL
+func (driver *localFs) path(path string) (string, error) {
+	name, err := driver.name(path)
+	if err != nil {
+		return "", err
 	}
-	return path
+	return fs.JoinPath(driver.subPath, filepath.ToSlash(name)), nil
 }
@@ -0,0 +1,23 @@
+package local_test
+
+import (
+	"testing"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/local"
+	"github.com/goairix/fs/fstest"
+)
+
+func TestFileSystem(t *testing.T) {
+	for _, sub := range []string{"", "sub/dir"} {
+		t.Run("sub="+sub, func(t *testing.T) {
+			fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+				fsys, err := local.New(local.Config{RootPath: t.TempDir(), SubPath: sub})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return fsys
+			}, fstest.WithoutMetadata())
+		})
+	}
+}
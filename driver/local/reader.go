@@ -0,0 +1,26 @@
+package local
+
+import (
+	"io"
+	"os"
+)
+
+// localFile 实现 fs.RangeReader 接口
+type localFile struct {
+	*os.File
+	size int64
+}
+
+func (f *localFile) Size() int64 {
+	return f.size
+}
+
+// limitedFile 范围读取时限制可读取的长度
+type limitedFile struct {
+	io.Reader
+	file *os.File
+}
+
+func (f *limitedFile) Close() error {
+	return f.file.Close()
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/goairix/fs"
 )
 
 // MultipartStorage 本地文件驱动分片上传状态存储器
@@ -32,8 +34,12 @@ func NewFileMultipartStorage(storageDir string) (*FileMultipartStorage, error) {
 	return &FileMultipartStorage{storageDir: storageDir}, nil
 }
 
-func (s *FileMultipartStorage) getFilePath(uploadID string) string {
-	return filepath.Join(s.storageDir, uploadID+".json")
+func (s *FileMultipartStorage) getFilePath(uploadID string) (string, error) {
+	// UploadID 由调用方传入，不能包含路径分隔符，避免读取或删除状态目录之外的文件
+	if uploadID == "" || !filepath.IsLocal(uploadID) || filepath.Base(uploadID) != uploadID {
+		return "", fmt.Errorf("upload ID not found: %w", fs.ErrNotExist)
+	}
+	return filepath.Join(s.storageDir, uploadID+".json"), nil
 }
 
 func (s *FileMultipartStorage) Save(upload *MultipartUpload) error {
@@ -41,14 +47,22 @@ func (s *FileMultipartStorage) Save(upload *MultipartUpload) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(s.getFilePath(upload.UploadID), data, 0644)
+	filePath, err := s.getFilePath(upload.UploadID)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0644)
 }
 
 func (s *FileMultipartStorage) Get(uploadID string) (*MultipartUpload, error) {
-	data, err := os.ReadFile(s.getFilePath(uploadID))
+	filePath, err := s.getFilePath(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("upload ID not found")
+			return nil, fmt.Errorf("upload ID not found: %w", fs.ErrNotExist)
 		}
 		return nil, err
 	}
@@ -61,7 +75,11 @@ func (s *FileMultipartStorage) Get(uploadID string) (*MultipartUpload, error) {
 }
 
 func (s *FileMultipartStorage) Delete(uploadID string) error {
-	return os.Remove(s.getFilePath(uploadID))
+	filePath, err := s.getFilePath(uploadID)
+	if err != nil {
+		return err
+	}
+	return os.Remove(filePath)
 }
 
 func (s *FileMultipartStorage) List() ([]*MultipartUpload, error) {
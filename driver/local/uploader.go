@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/goairix/fs"
@@ -15,7 +16,7 @@ import (
 type MultipartUpload struct {
 	Path       string         `json:"path"`
 	UploadID   string         `json:"upload_id"`
-	Parts      map[int]string `json:"parts"` // partNumber -> tempFilePath
+	Parts      map[int]string `json:"parts"` // partNumber -> 分片目录中的文件名
 	CreateTime string         `json:"create_time"`
 }
 
@@ -24,31 +25,19 @@ func (driver *localFs) Uploader() fs.Uploader {
 }
 
 func (driver *localFs) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
-	options := &fs.Options{}
-	for _, opt := range opts {
-		opt(options)
-	}
-
-	// 创建目标目录
-	basePath := filepath.Dir(path)
-	if ok, _ := driver.Exists(ctx, basePath); !ok {
-		if err := os.MkdirAll(driver.fullPath(basePath), 0755); err != nil {
-			return err
+	// 与对象存储驱动一致，大文件通过分片上传接口并发上传
+	put := func(ctx context.Context, body io.Reader, _ int64) error {
+		file, err := driver.Create(ctx, path, opts...)
+		if err != nil {
+			return wrapError("Upload", path, err)
 		}
+		if _, err = io.Copy(file, body); err != nil {
+			_ = file.Close()
+			return wrapError("Upload", path, err)
+		}
+		return wrapError("Upload", path, file.Close())
 	}
-
-	file, err := driver.Create(ctx, path, opts...)
-	if err != nil {
-		return err
-	}
-
-	_, err = io.Copy(file, reader)
-	if err != nil {
-		_ = file.Close()
-		return err
-	}
-
-	return file.Close()
+	return wrapError("Upload", path, fs.ManagedUpload(ctx, driver, path, reader, put, opts...))
 }
 
 func (driver *localFs) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
@@ -56,68 +45,80 @@ func (driver *localFs) InitMultipartUpload(ctx context.Context, path string, opt
 	for _, opt := range opts {
 		opt(o)
 	}
+	if _, err := driver.name(path); err != nil {
+		return "", wrapError("InitMultipartUpload", path, err)
+	}
 	uploadID := uuid.New().String()
 	upload := &MultipartUpload{
-		Path:     path,
-		UploadID: uploadID,
-		Parts:    make(map[int]string),
+		Path:       path,
+		UploadID:   uploadID,
+		Parts:      make(map[int]string),
+		CreateTime: time.Now().Format(time.RFC3339),
 	}
 	if err := driver.multipartStorage.Save(upload); err != nil {
-		return "", err
+		return "", wrapError("InitMultipartUpload", path, err)
 	}
 	return uploadID, nil
 }
 
 func (driver *localFs) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
-	upload, err := driver.multipartStorage.Get(uploadID)
-	if err != nil {
-		return "", err
+	if _, err := driver.multipartStorage.Get(uploadID); err != nil {
+		return "", wrapError("UploadPart", path, err)
 	}
 
-	// 创建临时文件存储分片
-	tempFile, err := os.CreateTemp("", fmt.Sprintf("part-%d-*", partNumber))
+	// 分片文件保存在分片目录中，状态中只记录文件名
+	partName := fmt.Sprintf("%s-%d-%s", uploadID, partNumber, uuid.New().String())
+	partFile, err := driver.parts.OpenFile(partName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
 	if err != nil {
-		return "", err
+		return "", wrapError("UploadPart", path, err)
 	}
 	defer func() {
-		_ = tempFile.Close()
+		_ = partFile.Close()
 	}()
 
 	// 写入分片数据
-	if _, err := io.Copy(tempFile, data); err != nil {
-		_ = os.Remove(tempFile.Name())
-		return "", err
+	if _, err := io.Copy(partFile, data); err != nil {
+		_ = driver.parts.Remove(partName)
+		return "", wrapError("UploadPart", path, err)
+	}
+
+	// 分片可能并发上传，读取并更新上传状态时需要加锁
+	driver.multipartMu.Lock()
+	defer driver.multipartMu.Unlock()
+	upload, err := driver.multipartStorage.Get(uploadID)
+	if err != nil {
+		_ = driver.parts.Remove(partName)
+		return "", wrapError("UploadPart", path, err)
 	}
 
-	upload.Parts[partNumber] = tempFile.Name()
-	upload.CreateTime = time.Now().Format(time.RFC3339)
+	// 重复上传同一分片时替换原有分片
+	if oldName, ok := upload.Parts[partNumber]; ok {
+		driver.removePart(oldName)
+	}
+	upload.Parts[partNumber] = partName
 	if err := driver.multipartStorage.Save(upload); err != nil {
-		_ = os.Remove(tempFile.Name())
-		return "", err
+		_ = driver.parts.Remove(partName)
+		return "", wrapError("UploadPart", path, err)
 	}
-	return tempFile.Name(), nil
+	return partName, nil
 }
 
 func (driver *localFs) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	driver.multipartMu.Lock()
+	defer driver.multipartMu.Unlock()
+
 	upload, err := driver.multipartStorage.Get(uploadID)
 	if err != nil {
-		return err
+		return wrapError("CompleteMultipartUpload", path, err)
 	}
 	defer func() {
 		_ = driver.multipartStorage.Delete(uploadID)
 	}()
 
-	// 创建目标目录
-	basePath := filepath.Dir(path)
-	if ok, _ := driver.Exists(ctx, basePath); !ok {
-		if err := os.MkdirAll(driver.fullPath(basePath), 0755); err != nil {
-			return err
-		}
-	}
-
-	destFile, err := os.Create(driver.fullPath(path))
+	// 目标文件同样不能超出根目录，自动创建目标目录
+	destFile, err := driver.openFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
-		return err
+		return wrapError("CompleteMultipartUpload", path, err)
 	}
 	defer func() {
 		_ = destFile.Close()
@@ -125,40 +126,43 @@ func (driver *localFs) CompleteMultipartUpload(ctx context.Context, path string,
 
 	// 按顺序合并分片
 	for _, part := range parts {
-		tempPath, ok := upload.Parts[part.PartNumber]
+		partName, ok := upload.Parts[part.PartNumber]
 		if !ok {
-			return fmt.Errorf("part %d not found", part.PartNumber)
+			return wrapError("CompleteMultipartUpload", path, fmt.Errorf("part %d not found: %w", part.PartNumber, fs.ErrNotExist))
 		}
 
 		// 读取分片数据
-		tempFile, err := os.Open(tempPath)
+		partFile, err := driver.openPart(partName)
 		if err != nil {
-			return err
+			return wrapError("CompleteMultipartUpload", path, err)
 		}
 
 		// 写入目标文件
-		if _, err := io.Copy(destFile, tempFile); err != nil {
-			_ = tempFile.Close()
-			return err
+		if _, err := io.Copy(destFile, partFile); err != nil {
+			_ = partFile.Close()
+			return wrapError("CompleteMultipartUpload", path, err)
 		}
-		_ = tempFile.Close()
+		_ = partFile.Close()
 
-		// 删除临时文件
-		_ = os.Remove(tempPath)
+		// 删除分片文件
+		driver.removePart(partName)
 	}
 
 	return nil
 }
 
 func (driver *localFs) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
+	driver.multipartMu.Lock()
+	defer driver.multipartMu.Unlock()
+
 	upload, err := driver.multipartStorage.Get(uploadID)
 	if err != nil {
 		return nil
 	}
 
-	// 删除所有临时文件
-	for _, tempPath := range upload.Parts {
-		_ = os.Remove(tempPath)
+	// 删除所有分片文件
+	for _, partName := range upload.Parts {
+		driver.removePart(partName)
 	}
 
 	return driver.multipartStorage.Delete(uploadID)
@@ -167,7 +171,7 @@ func (driver *localFs) AbortMultipartUpload(ctx context.Context, path string, up
 func (driver *localFs) ListMultipartUploads(ctx context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
 	uploads, err := driver.multipartStorage.List()
 	if err != nil {
-		return nil, err
+		return nil, wrapError("ListMultipartUploads", "", err)
 	}
 
 	result := make([]fs.MultipartUploadInfo, len(uploads))
@@ -185,19 +189,50 @@ func (driver *localFs) ListMultipartUploads(ctx context.Context, opts ...fs.Opti
 func (driver *localFs) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
 	upload, err := driver.multipartStorage.Get(uploadID)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("ListUploadedParts", path, err)
+	}
+
+	partNumbers := make([]int, 0, len(upload.Parts))
+	for partNumber := range upload.Parts {
+		partNumbers = append(partNumbers, partNumber)
 	}
+	sort.Ints(partNumbers)
 
-	parts := make([]fs.MultipartPart, len(upload.Parts))
-	for i, partPath := range upload.Parts {
-		info, err := os.Stat(partPath)
+	parts := make([]fs.MultipartPart, 0, len(partNumbers))
+	for _, partNumber := range partNumbers {
+		partName := upload.Parts[partNumber]
+		if !validPartName(partName) {
+			continue
+		}
+		info, err := driver.parts.Stat(partName)
 		if err != nil {
 			continue
 		}
-		parts[i] = fs.MultipartPart{
-			PartNumber: i + 1,
+		parts = append(parts, fs.MultipartPart{
+			PartNumber: partNumber,
+			ETag:       partName,
 			Size:       info.Size(),
-		}
+		})
 	}
 	return parts, nil
 }
+
+// validPartName 判断状态中记录的分片文件名是否有效，状态可能来自外部存储，只接受分片目录中的文件名
+func validPartName(name string) bool {
+	return name != "" && filepath.IsLocal(name) && filepath.Base(name) == name
+}
+
+// openPart 通过分片目录打开分片文件
+func (driver *localFs) openPart(name string) (*os.File, error) {
+	if !validPartName(name) {
+		return nil, fmt.Errorf("invalid part %q: %w", name, fs.ErrNotExist)
+	}
+	return driver.parts.Open(name)
+}
+
+// removePart 通过分片目录删除分片文件
+func (driver *localFs) removePart(name string) {
+	if validPartName(name) {
+		_ = driver.parts.Remove(name)
+	}
+}
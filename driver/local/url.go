@@ -0,0 +1,32 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/goairix/fs"
+)
+
+func init() {
+	fs.Register("file", openURL)
+}
+
+// openURL 根据 URL 创建本地文件系统，格式为
+//
+//	file:///var/data?sub_path=uploads
+//
+// URL 的路径为根目录，sub_path 为可选参数
+func openURL(_ context.Context, u *url.URL) (fs.FileSystem, error) {
+	if u.Host != "" && u.Host != "localhost" {
+		return nil, errors.New("file URL must not have a host other than localhost")
+	}
+	if u.Path == "" {
+		return nil, errors.New("missing root path")
+	}
+	return New(Config{
+		RootPath: u.Path,
+		SubPath:  strings.Trim(u.Query().Get("sub_path"), "/"),
+	})
+}
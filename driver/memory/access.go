@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+func (driver *memoryFs) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	key := driver.key(path)
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	expires := 2 * time.Hour
+	if o.SignUrlExpires > 0 {
+		expires = o.SignUrlExpires
+	}
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("Expires", expiresAt)
+	query.Set("Signature", driver.sign(key, expiresAt))
+
+	return fmt.Sprintf("%s/%s?%s", driver.baseURL(o), key, query.Encode()), nil
+}
+
+func (driver *memoryFs) FullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	if driver.config.AccessMode == fs.Private {
+		return driver.SignFullUrl(ctx, path, opts...)
+	}
+
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return fmt.Sprintf("%s/%s", driver.baseURL(o), driver.key(path)), nil
+}
+
+func (driver *memoryFs) RelativePath(ctx context.Context, fullUrl string, opts ...fs.Option) (string, error) {
+	u, err := url.Parse(fullUrl)
+	if err != nil {
+		return "", newError("RelativePath", fullUrl, err)
+	}
+
+	originalPath := u.Path
+	if base, err := url.Parse(driver.config.BaseURL); err == nil {
+		originalPath = strings.TrimPrefix(originalPath, strings.TrimRight(base.Path, "/"))
+	}
+	return strings.TrimPrefix(originalPath, "/"), nil
+}
+
+// sign 使用 HMAC-SHA256 计算签名
+func (driver *memoryFs) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, driver.signKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// baseURL 获取文件访问基础地址，优先使用 cdn 域名
+func (driver *memoryFs) baseURL(o *fs.Options) string {
+	if o.CdnDomain != "" {
+		return strings.TrimRight(o.CdnDomain, "/")
+	}
+	return strings.TrimRight(driver.config.BaseURL, "/")
+}
@@ -0,0 +1,8 @@
+package memory
+
+import "github.com/goairix/fs"
+
+// newError 创建统一的文件系统错误
+func newError(op, path string, err error) error {
+	return fs.NewPathError("memory", op, path, err, nil)
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"os"
+	"time"
+)
+
+// memoryFileInfo 实现 fs.FileInfo 接口
+type memoryFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+	etag    string
+}
+
+func newFileInfo(name string, obj *object) *memoryFileInfo {
+	return &memoryFileInfo{
+		name:    name,
+		size:    int64(len(obj.data)),
+		modTime: obj.modTime,
+		etag:    obj.etag,
+	}
+}
+
+func newDirInfo(name string) *memoryFileInfo {
+	return &memoryFileInfo{name: name, isDir: true}
+}
+
+func (f *memoryFileInfo) Name() string {
+	return f.name
+}
+
+func (f *memoryFileInfo) Size() int64 {
+	return f.size
+}
+
+func (f *memoryFileInfo) Mode() os.FileMode {
+	if f.isDir {
+		return os.ModeDir | 0755
+	}
+	return 0644 // 内存文件系统不支持文件权限，返回默认值
+}
+
+func (f *memoryFileInfo) ModTime() time.Time {
+	return f.modTime
+}
+
+func (f *memoryFileInfo) IsDir() bool {
+	return f.isDir
+}
+
+func (f *memoryFileInfo) Sys() interface{} {
+	return nil
+}
+
+// ETag 实现 fs.ETagger 接口
+func (f *memoryFileInfo) ETag() string {
+	return f.etag
+}
@@ -0,0 +1,445 @@
+package memory
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+type Config struct {
+	BaseURL    string        // 文件访问基础地址，如 https://static.example.com
+	SignKey    string        // URL 签名密钥，为空时随机生成
+	AccessMode fs.AccessMode // 访问模式
+}
+
+// object 内存中的文件对象，data 写入后不再修改，读取时可直接共享
+type object struct {
+	data        []byte
+	contentType string
+	metadata    map[string]string
+	modTime     time.Time
+	etag        string
+}
+
+// memoryFs 内存文件系统，目录语义与对象存储驱动保持一致
+type memoryFs struct {
+	mu      sync.RWMutex
+	objects map[string]*object
+	uploads map[string]*multipartUpload
+	config  Config
+	signKey []byte
+}
+
+func New(config Config) (fs.FileSystem, error) {
+	signKey := []byte(config.SignKey)
+	if len(signKey) == 0 {
+		signKey = make([]byte, 32)
+		if _, err := rand.Read(signKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return &memoryFs{
+		objects: make(map[string]*object),
+		uploads: make(map[string]*multipartUpload),
+		config:  config,
+		signKey: signKey,
+	}, nil
+}
+
+func (driver *memoryFs) List(_ context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
+	prefix := driver.prefix(path)
+
+	driver.mu.RLock()
+	defer driver.mu.RUnlock()
+
+	var fileInfos []fs.FileInfo
+	dirs := make(map[string]struct{})
+	for _, key := range driver.sortedKeys(prefix) {
+		name := strings.TrimPrefix(key, prefix)
+		if i := strings.Index(name, "/"); i >= 0 {
+			// 与对象存储的公共前缀一致，子目录只返回一次
+			name = name[:i]
+			if _, ok := dirs[name]; !ok {
+				dirs[name] = struct{}{}
+				fileInfos = append(fileInfos, newDirInfo(name))
+			}
+			continue
+		}
+		fileInfos = append(fileInfos, newFileInfo(name, driver.objects[key]))
+	}
+	return fileInfos, nil
+}
+
+func (driver *memoryFs) ListPage(_ context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	prefix := driver.prefix(path)
+	limit := o.ListLimit()
+
+	driver.mu.RLock()
+	defer driver.mu.RUnlock()
+
+	// 与对象存储一致，标记为上一页最后一项相对目录的键，子目录以 "/" 结尾
+	result := &fs.ListResult{}
+	var last string
+	for _, key := range driver.sortedKeys(prefix) {
+		name := strings.TrimPrefix(key, prefix)
+		marker := name
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[:i]
+			marker = name + "/"
+		}
+		if marker <= o.Marker || marker == last {
+			continue
+		}
+		if len(result.Files) == limit {
+			result.NextMarker = last
+			break
+		}
+		last = marker
+		if strings.HasSuffix(marker, "/") {
+			result.Files = append(result.Files, newDirInfo(name))
+		} else {
+			result.Files = append(result.Files, newFileInfo(name, driver.objects[key]))
+		}
+	}
+	return result, nil
+}
+
+func (driver *memoryFs) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	prefix := driver.prefix(root)
+
+	// 遍历前复制对象列表，fn 中可以继续操作文件系统
+	driver.mu.RLock()
+	keys := driver.sortedKeys(prefix)
+	infos := make([]fs.FileInfo, len(keys))
+	for i, key := range keys {
+		infos[i] = newFileInfo(baseName(key), driver.objects[key])
+	}
+	driver.mu.RUnlock()
+
+	return fs.WalkObjects(ctx, driver, root, fn, func(yield func(string, fs.FileInfo) bool) error {
+		for i, key := range keys {
+			if !yield(strings.TrimPrefix(key, prefix), infos[i]) {
+				break
+			}
+		}
+		return nil
+	}, opts...)
+}
+
+func (driver *memoryFs) MakeDir(_ context.Context, _ string, _ os.FileMode, opts ...fs.Option) error {
+	// 与对象存储一致，目录在写入文件时自动创建
+	return nil
+}
+
+func (driver *memoryFs) RemoveDir(_ context.Context, path string, opts ...fs.Option) error {
+	prefix := driver.prefix(path)
+
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+
+	for key := range driver.objects {
+		if strings.HasPrefix(key, prefix) {
+			delete(driver.objects, key)
+		}
+	}
+	return nil
+}
+
+func (driver *memoryFs) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	return newMemoryWriter(ctx, driver, driver.key(path), nil, opts...), nil
+}
+
+func (driver *memoryFs) Open(_ context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	obj, err := driver.object("Open", path)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	data := obj.data
+	if o.Range != nil {
+		start := min(max(o.Range.Offset, 0), int64(len(data)))
+		end := int64(len(data))
+		if o.Range.Length > 0 {
+			end = min(start+o.Range.Length, end)
+		}
+		data = data[start:end]
+	}
+	return newMemoryReader(data), nil
+}
+
+func (driver *memoryFs) OpenReader(_ context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	obj, err := driver.object("OpenReader", path)
+	if err != nil {
+		return nil, err
+	}
+	return newMemoryReader(obj.data), nil
+}
+
+func (driver *memoryFs) OpenFile(ctx context.Context, path string, flag int, _ os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		reader, err := driver.Open(ctx, path, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return newMemoryReadOnlyWrapper(reader), nil
+	}
+
+	driver.mu.RLock()
+	obj, exists := driver.objects[driver.key(path)]
+	driver.mu.RUnlock()
+
+	if exists && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0 {
+		return nil, newError("OpenFile", path, fs.ErrExist)
+	}
+	if !exists && flag&os.O_CREATE == 0 {
+		return nil, newError("OpenFile", path, fs.ErrNotExist)
+	}
+
+	// 对象存储不支持随机写，追加模式下以原有内容为基础，其余模式覆盖写入
+	var initial []byte
+	if exists && flag&os.O_APPEND != 0 {
+		initial = obj.data
+	}
+	writer := newMemoryWriter(ctx, driver, driver.key(path), initial, opts...)
+	if exists && flag&os.O_RDWR != 0 {
+		return newMemoryReadWriter(writer, obj.data), nil
+	}
+	return newMemoryReadWriter(writer, nil), nil
+}
+
+func (driver *memoryFs) Remove(_ context.Context, path string, opts ...fs.Option) error {
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+
+	// 与对象存储一致，删除不存在的文件不返回错误
+	delete(driver.objects, driver.key(path))
+	return nil
+}
+
+func (driver *memoryFs) Copy(_ context.Context, src, dst string, opts ...fs.Option) error {
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+
+	obj, ok := driver.objects[driver.key(src)]
+	if !ok {
+		return newError("Copy", src, fs.ErrNotExist)
+	}
+	copied := *obj
+	copied.metadata = copyMetadata(obj.metadata)
+	copied.modTime = time.Now()
+	driver.objects[driver.key(dst)] = &copied
+	return nil
+}
+
+func (driver *memoryFs) Move(_ context.Context, src, dst string, opts ...fs.Option) error {
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+
+	srcKey, dstKey := driver.key(src), driver.key(dst)
+	obj, ok := driver.objects[srcKey]
+	if !ok {
+		return newError("Move", src, fs.ErrNotExist)
+	}
+	delete(driver.objects, srcKey)
+	driver.objects[dstKey] = obj
+	return nil
+}
+
+func (driver *memoryFs) Rename(ctx context.Context, oldPath, newPath string, opts ...fs.Option) error {
+	return driver.Move(ctx, oldPath, newPath)
+}
+
+func (driver *memoryFs) Stat(_ context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	key := driver.key(path)
+
+	driver.mu.RLock()
+	defer driver.mu.RUnlock()
+
+	if obj, ok := driver.objects[key]; ok {
+		return newFileInfo(baseName(key), obj), nil
+	}
+	if driver.hasPrefix(driver.prefix(path)) {
+		return newDirInfo(baseName(key)), nil
+	}
+	return nil, newError("Stat", path, fs.ErrNotExist)
+}
+
+func (driver *memoryFs) GetMimeType(_ context.Context, path string, opts ...fs.Option) (string, error) {
+	obj, err := driver.object("GetMimeType", path)
+	if err != nil {
+		return "", err
+	}
+	if obj.contentType != "" {
+		return obj.contentType, nil
+	}
+	if contentType := fs.TypeByExtension(path); contentType != "" {
+		return contentType, nil
+	}
+
+	// 读取文件前512字节用于检测文件类型
+	return http.DetectContentType(obj.data[:min(len(obj.data), 512)]), nil
+}
+
+func (driver *memoryFs) SetMetadata(_ context.Context, path string, metadata map[string]any, opts ...fs.Option) error {
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+
+	key := driver.key(path)
+	obj, ok := driver.objects[key]
+	if !ok {
+		return newError("SetMetadata", path, fs.ErrNotExist)
+	}
+
+	// 与对象存储一致，设置元数据会替换原有的全部元数据
+	updated := *obj
+	updated.metadata = toStringMetadata(metadata)
+	driver.objects[key] = &updated
+	return nil
+}
+
+func (driver *memoryFs) GetMetadata(_ context.Context, path string, opts ...fs.Option) (map[string]any, error) {
+	obj, err := driver.object("GetMetadata", path)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]interface{})
+	for k, v := range obj.metadata {
+		metadata[k] = v
+	}
+	return metadata, nil
+}
+
+func (driver *memoryFs) Exists(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	if ok, err := driver.IsFile(ctx, path); err == nil && ok {
+		return true, nil
+	}
+	return driver.IsDir(ctx, path)
+}
+
+func (driver *memoryFs) IsDir(_ context.Context, path string, opts ...fs.Option) (bool, error) {
+	driver.mu.RLock()
+	defer driver.mu.RUnlock()
+	return driver.hasPrefix(driver.prefix(path)), nil
+}
+
+func (driver *memoryFs) IsFile(_ context.Context, path string, opts ...fs.Option) (bool, error) {
+	driver.mu.RLock()
+	defer driver.mu.RUnlock()
+	_, ok := driver.objects[driver.key(path)]
+	return ok, nil
+}
+
+// put 写入文件对象
+func (driver *memoryFs) put(key string, data []byte, contentType string, metadata fs.Metadata) {
+	sum := md5.Sum(data)
+	obj := &object{
+		data:        data,
+		contentType: contentType,
+		metadata:    toStringMetadata(metadata),
+		modTime:     time.Now(),
+		etag:        hex.EncodeToString(sum[:]),
+	}
+
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+	driver.objects[key] = obj
+}
+
+// object 获取文件对象
+func (driver *memoryFs) object(op, path string) (*object, error) {
+	driver.mu.RLock()
+	defer driver.mu.RUnlock()
+
+	obj, ok := driver.objects[driver.key(path)]
+	if !ok {
+		return nil, newError(op, path, fs.ErrNotExist)
+	}
+	return obj, nil
+}
+
+// hasPrefix 判断是否存在指定前缀的对象，调用方需持有读锁
+func (driver *memoryFs) hasPrefix(prefix string) bool {
+	for key := range driver.objects {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys 返回指定前缀下排序后的对象键，调用方需持有读锁
+func (driver *memoryFs) sortedKeys(prefix string) []string {
+	keys := make([]string, 0)
+	for key := range driver.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// key 获取规范化的对象键
+func (driver *memoryFs) key(p string) string {
+	return fs.CleanPath(p)
+}
+
+// prefix 获取目录前缀，根目录为空字符串
+func (driver *memoryFs) prefix(p string) string {
+	key := driver.key(p)
+	if key == "" {
+		return ""
+	}
+	return key + "/"
+}
+
+func baseName(key string) string {
+	if key == "" {
+		return "."
+	}
+	return path.Base(key)
+}
+
+func toStringMetadata(metadata map[string]any) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	result := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		result[k] = fmt.Sprintf("%v", v)
+	}
+	return result
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	result := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		result[k] = v
+	}
+	return result
+}
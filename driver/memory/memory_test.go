@@ -0,0 +1,72 @@
+package memory_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/goairix/fs/fstest"
+)
+
+func TestFileSystem(t *testing.T) {
+	for _, baseURL := range []string{"https://static.example.com", "https://example.com/static/"} {
+		t.Run(baseURL, func(t *testing.T) {
+			fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+				fsys, err := memory.New(memory.Config{BaseURL: baseURL})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return fsys
+			})
+		})
+	}
+}
+
+func TestPrivateFullUrl(t *testing.T) {
+	ctx := context.Background()
+	fsys, err := memory.New(memory.Config{BaseURL: "https://static.example.com", AccessMode: fs.Private})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullUrl, err := fsys.FullUrl(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(fullUrl, "https://static.example.com/a.txt?") || !strings.Contains(fullUrl, "Signature=") {
+		t.Fatalf("FullUrl = %q, want a signed URL", fullUrl)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	fsys, err := memory.New(memory.Config{BaseURL: "https://static.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fsys.Uploader().Upload(ctx, fmt.Sprintf("d/%d.txt", i), strings.NewReader("x")); err != nil {
+				t.Error(err)
+			}
+			if _, err := fsys.List(ctx, "d"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	infos, err := fsys.List(ctx, "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 20 {
+		t.Fatalf("List returned %d files, want 20", len(infos))
+	}
+}
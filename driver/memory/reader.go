@@ -0,0 +1,16 @@
+package memory
+
+import "bytes"
+
+// memoryReader 实现 fs.RangeReader 接口，文件数据不可变，可以直接共享
+type memoryReader struct {
+	*bytes.Reader
+}
+
+func newMemoryReader(data []byte) *memoryReader {
+	return &memoryReader{Reader: bytes.NewReader(data)}
+}
+
+func (r *memoryReader) Close() error {
+	return nil
+}
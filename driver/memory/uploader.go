@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/goairix/fs"
+	"github.com/google/uuid"
+)
+
+// multipartUpload 分片上传状态
+type multipartUpload struct {
+	key         string
+	path        string
+	parts       map[int]*uploadedPart
+	contentType string
+	metadata    fs.Metadata
+	createTime  time.Time
+}
+
+// uploadedPart 已上传的分片
+type uploadedPart struct {
+	data []byte
+	etag string
+}
+
+func (driver *memoryFs) Uploader() fs.Uploader {
+	return driver
+}
+
+func (driver *memoryFs) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// 与对象存储驱动一致，大文件通过分片上传接口并发上传
+	put := func(_ context.Context, body io.Reader, _ int64) error {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return newError("Upload", path, err)
+		}
+		driver.put(driver.key(path), data, o.ContentType, o.Metadata)
+		return nil
+	}
+	return fs.ManagedUpload(ctx, driver, path, reader, put, opts...)
+}
+
+func (driver *memoryFs) InitMultipartUpload(_ context.Context, path string, opts ...fs.Option) (string, error) {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	uploadID := uuid.New().String()
+
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+	driver.uploads[uploadID] = &multipartUpload{
+		key:         driver.key(path),
+		path:        path,
+		parts:       make(map[int]*uploadedPart),
+		contentType: o.ContentType,
+		metadata:    o.Metadata,
+		createTime:  time.Now(),
+	}
+	return uploadID, nil
+}
+
+func (driver *memoryFs) UploadPart(_ context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return "", newError("UploadPart", path, err)
+	}
+	sum := md5.Sum(content)
+	etag := hex.EncodeToString(sum[:])
+
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+
+	upload, ok := driver.uploads[uploadID]
+	if !ok || upload.key != driver.key(path) {
+		return "", newError("UploadPart", path, fs.ErrNotExist)
+	}
+	upload.parts[partNumber] = &uploadedPart{data: content, etag: etag}
+	return etag, nil
+}
+
+func (driver *memoryFs) CompleteMultipartUpload(_ context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	driver.mu.Lock()
+	upload, ok := driver.uploads[uploadID]
+	if !ok || upload.key != driver.key(path) {
+		driver.mu.Unlock()
+		return newError("CompleteMultipartUpload", path, fs.ErrNotExist)
+	}
+
+	// 按顺序合并分片
+	var buffer bytes.Buffer
+	for _, part := range parts {
+		uploaded, ok := upload.parts[part.PartNumber]
+		if !ok {
+			driver.mu.Unlock()
+			return newError("CompleteMultipartUpload", path, fmt.Errorf("part %d not found: %w", part.PartNumber, fs.ErrNotExist))
+		}
+		if part.ETag != "" && part.ETag != uploaded.etag {
+			driver.mu.Unlock()
+			return newError("CompleteMultipartUpload", path, fmt.Errorf("part %d etag mismatch: %w", part.PartNumber, fs.ErrPreconditionFailed))
+		}
+		buffer.Write(uploaded.data)
+	}
+	delete(driver.uploads, uploadID)
+	driver.mu.Unlock()
+
+	driver.put(upload.key, buffer.Bytes(), upload.contentType, upload.metadata)
+	return nil
+}
+
+func (driver *memoryFs) AbortMultipartUpload(_ context.Context, path string, uploadID string, opts ...fs.Option) error {
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+
+	if _, ok := driver.uploads[uploadID]; !ok {
+		return newError("AbortMultipartUpload", path, fs.ErrNotExist)
+	}
+	delete(driver.uploads, uploadID)
+	return nil
+}
+
+func (driver *memoryFs) ListMultipartUploads(_ context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
+	driver.mu.RLock()
+	defer driver.mu.RUnlock()
+
+	uploads := make([]fs.MultipartUploadInfo, 0, len(driver.uploads))
+	for uploadID, upload := range driver.uploads {
+		uploads = append(uploads, fs.MultipartUploadInfo{
+			UploadID:   uploadID,
+			Path:       upload.path,
+			CreateTime: upload.createTime,
+		})
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		return uploads[i].CreateTime.Before(uploads[j].CreateTime)
+	})
+	return uploads, nil
+}
+
+func (driver *memoryFs) ListUploadedParts(_ context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
+	driver.mu.RLock()
+	defer driver.mu.RUnlock()
+
+	upload, ok := driver.uploads[uploadID]
+	if !ok || upload.key != driver.key(path) {
+		return nil, newError("ListUploadedParts", path, fs.ErrNotExist)
+	}
+
+	parts := make([]fs.MultipartPart, 0, len(upload.parts))
+	for partNumber, part := range upload.parts {
+		parts = append(parts, fs.MultipartPart{
+			PartNumber: partNumber,
+			ETag:       part.etag,
+			Size:       int64(len(part.data)),
+		})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+	return parts, nil
+}
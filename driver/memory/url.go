@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/goairix/fs"
+)
+
+func init() {
+	fs.Register("mem", openURL)
+}
+
+// openURL 根据 URL 创建内存文件系统，每次调用都会创建新的实例，格式为
+//
+//	mem://?base_url=https://static.example.com&sign_key=secret&access_mode=private
+//
+// 全部参数均为可选参数
+func openURL(_ context.Context, u *url.URL) (fs.FileSystem, error) {
+	q := u.Query()
+	accessMode, err := fs.ParseAccessMode(q.Get("access_mode"))
+	if err != nil {
+		return nil, err
+	}
+	return New(Config{
+		BaseURL:    q.Get("base_url"),
+		SignKey:    q.Get("sign_key"),
+		AccessMode: accessMode,
+	})
+}
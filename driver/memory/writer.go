@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/goairix/fs"
+)
+
+// memoryWriter 实现 io.WriteCloser 接口，关闭时写入文件对象
+type memoryWriter struct {
+	ctx         context.Context
+	driver      *memoryFs
+	key         string
+	buffer      *bytes.Buffer
+	metadata    fs.Metadata
+	contentType string
+	closed      bool
+}
+
+func newMemoryWriter(ctx context.Context, driver *memoryFs, key string, initial []byte, opts ...fs.Option) *memoryWriter {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	buffer.Write(initial)
+
+	return &memoryWriter{
+		ctx:         ctx,
+		driver:      driver,
+		key:         key,
+		buffer:      buffer,
+		metadata:    o.Metadata,
+		contentType: o.ContentType,
+	}
+}
+
+func (w *memoryWriter) Write(p []byte) (n int, err error) {
+	if w.closed {
+		return 0, newError("Write", w.key, os.ErrClosed)
+	}
+	select {
+	case <-w.ctx.Done():
+		return 0, w.ctx.Err()
+	default:
+		return w.buffer.Write(p)
+	}
+}
+
+func (w *memoryWriter) Close() error {
+	if w.closed {
+		return newError("Close", w.key, os.ErrClosed)
+	}
+	w.closed = true
+
+	select {
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	default:
+		w.driver.put(w.key, bytes.Clone(w.buffer.Bytes()), w.contentType, w.metadata)
+		return nil
+	}
+}
+
+// memoryReadWriter 实现 io.ReadWriteCloser 接口
+type memoryReadWriter struct {
+	*memoryWriter
+	reader io.Reader
+}
+
+func newMemoryReadWriter(writer *memoryWriter, data []byte) *memoryReadWriter {
+	return &memoryReadWriter{
+		memoryWriter: writer,
+		reader:       bytes.NewReader(data),
+	}
+}
+
+func (rw *memoryReadWriter) Read(p []byte) (n int, err error) {
+	return rw.reader.Read(p)
+}
+
+// memoryReadOnlyWrapper 包装只读流为 ReadWriteCloser
+type memoryReadOnlyWrapper struct {
+	reader io.ReadCloser
+}
+
+func newMemoryReadOnlyWrapper(reader io.ReadCloser) *memoryReadOnlyWrapper {
+	return &memoryReadOnlyWrapper{reader: reader}
+}
+
+func (w *memoryReadOnlyWrapper) Read(p []byte) (n int, err error) {
+	return w.reader.Read(p)
+}
+
+func (w *memoryReadOnlyWrapper) Write(_ []byte) (n int, err error) {
+	return 0, fmt.Errorf("cannot write to read-only file")
+}
+
+func (w *memoryReadOnlyWrapper) Close() error {
+	return w.reader.Close()
+}
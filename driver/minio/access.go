@@ -11,13 +11,13 @@ import (
 )
 
 func (driver *minioFs) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
 	}
 
-	endpoint := fmt.Sprintf("https://%s/%s", driver.config.Endpoint, driver.config.BucketName)
+	endpoint := driver.endpoint()
 	cdnDomain := endpoint
 	var useCdnDomain bool
 	if o.CdnDomain != "" {
@@ -30,12 +30,12 @@ func (driver *minioFs) SignFullUrl(ctx context.Context, path string, opts ...fs.
 		expires = o.SignUrlExpires
 	}
 
-	signUrl, err := driver.client.PresignedGetObject(ctx, driver.config.BucketName, path, expires, nil)
+	signUrl, err := driver.client.PresignedGetObject(ctx, driver.config.BucketName, key, expires, nil)
 	if err != nil {
-		return "", err
+		return "", wrapError("SignFullUrl", path, err)
 	}
 
-	fullUrl := strings.ReplaceAll(signUrl.String(), "http://", "https://")
+	fullUrl := signUrl.String()
 	if useCdnDomain {
 		fullUrl = strings.ReplaceAll(fullUrl, endpoint, cdnDomain)
 	}
@@ -44,13 +44,13 @@ func (driver *minioFs) SignFullUrl(ctx context.Context, path string, opts ...fs.
 }
 
 func (driver *minioFs) FullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
 	}
 
-	endpoint := fmt.Sprintf("https://%s/%s", driver.config.Endpoint, driver.config.BucketName)
+	endpoint := driver.endpoint()
 	cdnDomain := endpoint
 	var useCdnDomain bool
 	if o.CdnDomain != "" {
@@ -64,15 +64,13 @@ func (driver *minioFs) FullUrl(ctx context.Context, path string, opts ...fs.Opti
 		if o.SignUrlExpires > 0 {
 			expires = o.SignUrlExpires
 		}
-		signUrl, err := driver.client.PresignedGetObject(ctx, driver.config.BucketName, path, expires, nil)
+		signUrl, err := driver.client.PresignedGetObject(ctx, driver.config.BucketName, key, expires, nil)
 		if err != nil {
-			return "", err
-		}
-		if driver.config.UseSSL {
-			fullUrl = strings.ReplaceAll(signUrl.String(), "http://", "https://")
+			return "", wrapError("FullUrl", path, err)
 		}
+		fullUrl = signUrl.String()
 	} else {
-		fullUrl = fmt.Sprintf("%s/%s", cdnDomain, path)
+		fullUrl = fmt.Sprintf("%s/%s", cdnDomain, key)
 	}
 
 	if useCdnDomain {
@@ -85,10 +83,22 @@ func (driver *minioFs) FullUrl(ctx context.Context, path string, opts ...fs.Opti
 func (driver *minioFs) RelativePath(ctx context.Context, fullUrl string, opts ...fs.Option) (string, error) {
 	u, err := url.Parse(fullUrl)
 	if err != nil {
-		return "", err
+		return "", wrapError("RelativePath", fullUrl, err)
 	}
 
-	var originalPath = strings.TrimPrefix(u.Path, "/")
-	originalPath = strings.Replace(originalPath, driver.config.BucketName, "", 1)
-	return strings.TrimPrefix(originalPath, "/"), nil
+	relativePath := strings.TrimPrefix(u.Path, "/")
+	relativePath = strings.TrimPrefix(relativePath, driver.config.BucketName+"/")
+	if driver.config.SubPath != "" {
+		relativePath = strings.TrimPrefix(relativePath, strings.Trim(driver.config.SubPath, "/")+"/")
+	}
+	return relativePath, nil
+}
+
+// endpoint 获取存储桶访问地址，协议与 UseSSL 配置保持一致
+func (driver *minioFs) endpoint() string {
+	scheme := "http"
+	if driver.config.UseSSL {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/%s", scheme, driver.config.Endpoint, driver.config.BucketName)
 }
@@ -0,0 +1,38 @@
+package minio
+
+import (
+	"errors"
+
+	"github.com/goairix/fs"
+	"github.com/minio/minio-go/v7"
+)
+
+// wrapError 将 MinIO 错误转换为统一的文件系统错误
+func wrapError(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return err
+	}
+	return fs.NewPathError("minio", op, path, err, errorKind(err))
+}
+
+// errorKind 返回 MinIO 错误对应的通用错误
+func errorKind(err error) error {
+	resp := minio.ToErrorResponse(err)
+	switch resp.Code {
+	case "NoSuchKey", "NoSuchBucket", "NoSuchUpload", "NotFound":
+		return fs.ErrNotExist
+	case "AccessDenied":
+		return fs.ErrPermission
+	case "PreconditionFailed":
+		return fs.ErrPreconditionFailed
+	case "NotImplemented":
+		return fs.ErrUnsupported
+	case "InternalError", "ServiceUnavailable", "SlowDown", "RequestTimeout":
+		return fs.ErrTemporary
+	}
+	return fs.ErrorKindFromStatus(resp.StatusCode)
+}
@@ -2,6 +2,8 @@ package minio
 
 import (
 	"os"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -17,7 +19,7 @@ func newMinioFileInfo(info minio.ObjectInfo) *minioFileInfo {
 }
 
 func (f *minioFileInfo) Name() string {
-	return f.info.Key
+	return path.Base(strings.TrimSuffix(f.info.Key, "/"))
 }
 
 func (f *minioFileInfo) Size() int64 {
@@ -25,6 +27,9 @@ func (f *minioFileInfo) Size() int64 {
 }
 
 func (f *minioFileInfo) Mode() os.FileMode {
+	if f.IsDir() {
+		return os.ModeDir | 0755
+	}
 	return 0644 // MinIO不支持文件权限，返回默认值
 }
 
@@ -33,9 +38,14 @@ func (f *minioFileInfo) ModTime() time.Time {
 }
 
 func (f *minioFileInfo) IsDir() bool {
-	return f.info.Key[len(f.info.Key)-1] == '/'
+	return strings.HasSuffix(f.info.Key, "/")
 }
 
 func (f *minioFileInfo) Sys() interface{} {
 	return f.info
 }
+
+// ETag 实现 fs.ETagger 接口
+func (f *minioFileInfo) ETag() string {
+	return strings.Trim(f.info.ETag, `"`)
+}
@@ -2,6 +2,7 @@ package minio
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -65,11 +66,11 @@ func New(config Config) (fs.FileSystem, error) {
 }
 
 func (driver *minioFs) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	var fileInfos []fs.FileInfo
 
 	// 使用ListObjects来获取指定前缀的对象
-	prefix := strings.TrimRight(path, "/")
+	prefix := strings.TrimRight(key, "/")
 	if prefix != "" {
 		prefix += "/"
 	}
@@ -80,7 +81,11 @@ func (driver *minioFs) List(ctx context.Context, path string, opts ...fs.Option)
 
 	for object := range driver.client.ListObjects(ctx, driver.config.BucketName, options) {
 		if object.Err != nil {
-			return nil, object.Err
+			return nil, wrapError("List", path, object.Err)
+		}
+		if object.Key == prefix {
+			// 跳过目录占位对象
+			continue
 		}
 		fileInfos = append(fileInfos, newMinioFileInfo(object))
 	}
@@ -88,79 +93,187 @@ func (driver *minioFs) List(ctx context.Context, path string, opts ...fs.Option)
 	return fileInfos, nil
 }
 
+func (driver *minioFs) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	prefix := strings.TrimRight(driver.path(path), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	// minio.Client 的 ListObjects 不返回续传标记，这里使用 Core 接口
+	output, err := driver.core.ListObjectsV2(driver.config.BucketName, prefix, "", o.Marker, "/", o.ListLimit())
+	if err != nil {
+		return nil, wrapError("ListPage", path, err)
+	}
+
+	result := &fs.ListResult{}
+	for _, object := range output.Contents {
+		if object.Key == prefix {
+			// 跳过目录占位对象
+			continue
+		}
+		result.Files = append(result.Files, newMinioFileInfo(object))
+	}
+	for _, prefix := range output.CommonPrefixes {
+		result.Files = append(result.Files, newMinioFileInfo(minio.ObjectInfo{
+			Key: prefix.Prefix,
+		}))
+	}
+	if output.IsTruncated {
+		result.NextMarker = output.NextContinuationToken
+	}
+	return result, nil
+}
+
+func (driver *minioFs) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	prefix := strings.TrimRight(driver.path(root), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return fs.WalkObjects(ctx, driver, root, fn, func(yield func(string, fs.FileInfo) bool) error {
+		// 提前结束遍历时取消列举
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		options := minio.ListObjectsOptions{
+			Prefix:    prefix,
+			Recursive: true,
+		}
+		for object := range driver.client.ListObjects(ctx, driver.config.BucketName, options) {
+			if object.Err != nil {
+				return wrapError("Walk", root, object.Err)
+			}
+			if !yield(strings.TrimPrefix(object.Key, prefix), newMinioFileInfo(object)) {
+				return nil
+			}
+		}
+		return nil
+	}, opts...)
+}
+
 func (driver *minioFs) MakeDir(_ context.Context, _ string, _ os.FileMode, opts ...fs.Option) error {
 	// MinIO目录在写入文件时自动创建
 	return nil
 }
 
 func (driver *minioFs) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
 	options := minio.ListObjectsOptions{
-		Prefix:    filepath.Clean(path) + "/",
+		Prefix:    filepath.Clean(key) + "/",
 		Recursive: true,
 	}
 
 	// 删除目录下的所有对象
 	for object := range driver.client.ListObjects(ctx, driver.config.BucketName, options) {
+		if object.Err != nil {
+			return wrapError("RemoveDir", path, object.Err)
+		}
 		err := driver.client.RemoveObject(ctx, driver.config.BucketName, object.Key, minio.RemoveObjectOptions{})
 		if err != nil {
-			return err
+			return wrapError("RemoveDir", path, err)
 		}
 	}
 	return nil
 }
 
 func (driver *minioFs) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
-	path = driver.path(path)
-	return newMinioWriter(ctx, driver.client, driver.config.BucketName, path, opts...), nil
+	return newMinioWriter(ctx, driver, path, opts...), nil
 }
 
 func (driver *minioFs) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
-	path = driver.path(path)
-	return driver.client.GetObject(ctx, driver.config.BucketName, path, minio.GetObjectOptions{})
+	key := driver.path(path)
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	object, err := driver.client.GetObject(ctx, driver.config.BucketName, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, wrapError("Open", path, err)
+	}
+	// GetObject 延迟到首次读取时才发起请求，这里提前获取对象信息以便及时返回错误
+	info, err := object.Stat()
+	if err != nil {
+		_ = object.Close()
+		return nil, wrapError("Open", path, err)
+	}
+	if o.Range == nil {
+		return object, nil
+	}
+
+	// minio.Object 自行维护读取位置并据此设置 Range 请求头，范围读取需通过 Seek 实现
+	// 同时按文件大小限制读取长度，避免读取位置位于文件末尾时请求越界
+	offset := min(max(o.Range.Offset, 0), info.Size)
+	length := info.Size - offset
+	if o.Range.Length > 0 {
+		length = min(o.Range.Length, length)
+	}
+	if _, err = object.Seek(offset, io.SeekStart); err != nil {
+		_ = object.Close()
+		return nil, wrapError("Open", path, err)
+	}
+	return &limitedObject{Reader: io.LimitReader(object, length), object: object}, nil
+}
+
+func (driver *minioFs) OpenReader(ctx context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	key := driver.path(path)
+	object, err := driver.client.GetObject(ctx, driver.config.BucketName, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, wrapError("OpenReader", path, err)
+	}
+	info, err := object.Stat()
+	if err != nil {
+		_ = object.Close()
+		return nil, wrapError("OpenReader", path, err)
+	}
+	return &minioReader{Object: object, size: info.Size}, nil
 }
 
 func (driver *minioFs) OpenFile(ctx context.Context, path string, flag int, _ os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
 	// MinIO不支持追加模式，这里实现读写功能
 	if flag&os.O_RDWR != 0 {
-		return newMinioReadWriter(ctx, driver.client, driver.config.BucketName, driver.path(path), opts...), nil
+		return newMinioReadWriter(ctx, driver, path, opts...), nil
 	}
 	if flag&os.O_WRONLY != 0 {
 		// 对于只写模式，也返回 ReadWriter，但读取时会返回错误
-		return newMinioReadWriter(ctx, driver.client, driver.config.BucketName, driver.path(path), opts...), nil
+		return newMinioReadWriter(ctx, driver, path, opts...), nil
 	}
 	// 对于只读模式，包装成 ReadWriteCloser
 	reader, err := driver.Open(ctx, path, opts...)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("OpenFile", path, err)
 	}
 	return newMinioReadOnlyWrapper(reader), nil
 }
 
 func (driver *minioFs) Remove(ctx context.Context, path string, opts ...fs.Option) error {
-	path = driver.path(path)
-	return driver.client.RemoveObject(ctx, driver.config.BucketName, path, minio.RemoveObjectOptions{})
+	key := driver.path(path)
+	return wrapError("Remove", path, driver.client.RemoveObject(ctx, driver.config.BucketName, key, minio.RemoveObjectOptions{}))
 }
 
 func (driver *minioFs) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
-	src = driver.path(src)
-	dst = driver.path(dst)
+	srcKey := driver.path(src)
+	dstKey := driver.path(dst)
 	_, err := driver.client.CopyObject(ctx,
 		minio.CopyDestOptions{
 			Bucket: driver.config.BucketName,
-			Object: dst,
+			Object: dstKey,
 		},
 		minio.CopySrcOptions{
 			Bucket: driver.config.BucketName,
-			Object: src,
+			Object: srcKey,
 		})
-	return err
+	return wrapError("Copy", src, err)
 }
 
 func (driver *minioFs) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
 	// 先复制后删除来实现移动
 	if err := driver.Copy(ctx, src, dst); err != nil {
-		return err
+		return wrapError("Move", src, err)
 	}
 	return driver.Remove(ctx, src)
 }
@@ -170,10 +283,16 @@ func (driver *minioFs) Rename(ctx context.Context, oldPath, newPath string, opts
 }
 
 func (driver *minioFs) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
-	path = driver.path(path)
-	info, err := driver.client.StatObject(ctx, driver.config.BucketName, path, minio.StatObjectOptions{})
+	key := driver.path(path)
+	info, err := driver.client.StatObject(ctx, driver.config.BucketName, key, minio.StatObjectOptions{})
 	if err != nil {
-		return nil, err
+		// 目录在对象存储中为公共前缀，没有实体对象
+		if errors.Is(errorKind(err), fs.ErrNotExist) {
+			if isDir, dirErr := driver.IsDir(ctx, path); dirErr == nil && isDir {
+				return newMinioFileInfo(minio.ObjectInfo{Key: strings.TrimRight(key, "/") + "/"}), nil
+			}
+		}
+		return nil, wrapError("Stat", path, err)
 	}
 	return newMinioFileInfo(info), nil
 }
@@ -181,7 +300,7 @@ func (driver *minioFs) Stat(ctx context.Context, path string, opts ...fs.Option)
 func (driver *minioFs) GetMimeType(ctx context.Context, path string, opts ...fs.Option) (string, error) {
 	stat, err := driver.client.StatObject(ctx, driver.config.BucketName, driver.path(path), minio.StatObjectOptions{})
 	if err != nil {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 
 	if stat.ContentType != "" {
@@ -191,51 +310,61 @@ func (driver *minioFs) GetMimeType(ctx context.Context, path string, opts ...fs.
 	// 如果对象没有 ContentType，则读取文件内容进行检测
 	obj, err := driver.Open(ctx, path)
 	if err != nil {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 	defer func() {
 		_ = obj.Close()
 	}()
 
 	buffer := make([]byte, 512)
-	_, err = obj.Read(buffer)
+	n, err := obj.Read(buffer)
 	if err != nil && err != io.EOF {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 
-	return http.DetectContentType(buffer), nil
+	return http.DetectContentType(buffer[:n]), nil
 }
 
 func (driver *minioFs) SetMetadata(ctx context.Context, path string, metadata map[string]any, opts ...fs.Option) error {
+	key := driver.path(path)
+	stat, err := driver.client.StatObject(ctx, driver.config.BucketName, key, minio.StatObjectOptions{})
+	if err != nil {
+		return wrapError("SetMetadata", path, err)
+	}
+
 	// 将metadata转换为字符串map
 	strMetadata := make(map[string]string)
 	for k, v := range metadata {
 		strMetadata[k] = fmt.Sprintf("%v", v)
 	}
+	// 替换元数据时保留原有的 ContentType 与 ContentEncoding
+	if stat.ContentType != "" {
+		strMetadata["Content-Type"] = stat.ContentType
+	}
+	if contentEncoding := stat.Metadata.Get("Content-Encoding"); contentEncoding != "" {
+		strMetadata["Content-Encoding"] = contentEncoding
+	}
 
-	// MinIO中需要通过复制对象来更新元数据
-	_, err := driver.client.CopyObject(ctx,
+	// MinIO中需要通过复制对象到自身来更新元数据
+	_, err = driver.client.CopyObject(ctx,
 		minio.CopyDestOptions{
 			Bucket:          driver.config.BucketName,
-			Object:          driver.path(path + "_tmp"),
+			Object:          key,
 			ReplaceMetadata: true,
 			UserMetadata:    strMetadata,
 		},
 		minio.CopySrcOptions{
 			Bucket: driver.config.BucketName,
-			Object: driver.path(path),
+			Object: key,
 		})
-	if err != nil {
-		return err
-	}
-	return driver.Move(ctx, path+"_tmp", path)
+	return wrapError("SetMetadata", path, err)
 }
 
 func (driver *minioFs) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]any, error) {
-	path = driver.path(path)
-	info, err := driver.client.StatObject(ctx, driver.config.BucketName, path, minio.StatObjectOptions{})
+	key := driver.path(path)
+	info, err := driver.client.StatObject(ctx, driver.config.BucketName, key, minio.StatObjectOptions{})
 	if err != nil {
-		return nil, err
+		return nil, wrapError("GetMetadata", path, err)
 	}
 
 	metadata := make(map[string]interface{})
@@ -256,9 +385,9 @@ func (driver *minioFs) Exists(ctx context.Context, path string, opts ...fs.Optio
 }
 
 func (driver *minioFs) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	options := minio.ListObjectsOptions{
-		Prefix:    strings.TrimRight(path, "/") + "/",
+		Prefix:    strings.TrimRight(key, "/") + "/",
 		Recursive: false,
 		MaxKeys:   1,
 	}
@@ -269,26 +398,24 @@ func (driver *minioFs) IsDir(ctx context.Context, path string, opts ...fs.Option
 		return false, nil
 	}
 	if object.Err != nil {
-		return false, object.Err
+		return false, wrapError("IsDir", path, object.Err)
 	}
 	return true, nil
 }
 
 func (driver *minioFs) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
-	path = driver.path(path)
-	_, err := driver.client.StatObject(ctx, driver.config.BucketName, path, minio.StatObjectOptions{})
+	key := driver.path(path)
+	_, err := driver.client.StatObject(ctx, driver.config.BucketName, key, minio.StatObjectOptions{})
 	if err == nil {
 		return true, nil
 	}
-	if strings.Contains(err.Error(), "The specified key does not exist.") {
+	if errors.Is(errorKind(err), fs.ErrNotExist) {
 		return false, nil
 	}
-	return false, err
+	return false, wrapError("IsFile", path, err)
 }
 
+// path 获取规范化的对象键，路径中的 ".." 不会超出子目录
 func (driver *minioFs) path(path string) string {
-	if driver.config.SubPath != "" {
-		return strings.Trim(driver.config.SubPath, "/") + "/" + path
-	}
-	return path
+	return fs.JoinPath(driver.config.SubPath, path)
 }
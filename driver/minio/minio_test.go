@@ -0,0 +1,36 @@
+package minio_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/minio"
+	"github.com/goairix/fs/fstest"
+	"github.com/goairix/fs/fstest/s3fake"
+)
+
+func TestFileSystem(t *testing.T) {
+	srv := s3fake.New()
+	defer srv.Close()
+
+	var n int
+	for _, sub := range []string{"", "sub/dir"} {
+		t.Run("sub="+sub, func(t *testing.T) {
+			fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+				n++
+				fsys, err := minio.New(minio.Config{
+					Endpoint:        srv.Endpoint,
+					AccessKeyID:     "test",
+					SecretAccessKey: "test",
+					BucketName:      fmt.Sprintf("bucket-%d", n),
+					SubPath:         sub,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return fsys
+			})
+		})
+	}
+}
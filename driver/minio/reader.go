@@ -0,0 +1,51 @@
+package minio
+
+import (
+	"io"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// minioReader 实现 fs.RangeReader 接口
+type minioReader struct {
+	*minio.Object
+	size int64
+}
+
+// Read 读取位置位于文件末尾时直接返回 io.EOF，避免发起越界的范围请求
+func (r *minioReader) Read(p []byte) (int, error) {
+	current, err := r.Object.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if current >= r.size {
+		return 0, io.EOF
+	}
+	return r.Object.Read(p)
+}
+
+// Seek minio.Object 不支持以当前位置为基准向前移动，这里换算为绝对位置
+func (r *minioReader) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekCurrent {
+		return r.Object.Seek(offset, whence)
+	}
+	current, err := r.Object.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	return r.Object.Seek(current+offset, io.SeekStart)
+}
+
+func (r *minioReader) Size() int64 {
+	return r.size
+}
+
+// limitedObject 范围读取时限制可读取的长度
+type limitedObject struct {
+	io.Reader
+	object *minio.Object
+}
+
+func (r *limitedObject) Close() error {
+	return r.object.Close()
+}
@@ -3,6 +3,7 @@ package minio
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 
 	"github.com/goairix/fs"
@@ -14,22 +15,12 @@ func (driver *minioFs) Uploader() fs.Uploader {
 }
 
 func (driver *minioFs) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
-	file, err := driver.Create(ctx, path, opts...)
-	if err != nil {
-		return err
-	}
-
-	_, err = io.Copy(file, reader)
-	if err != nil {
-		_ = file.Close()
-		return err
-	}
-
-	return file.Close()
+	err := fs.ManagedUpload(ctx, driver, path, reader, driver.putObject("Upload", path, opts...), opts...)
+	return wrapError("Upload", path, err)
 }
 
 func (driver *minioFs) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
@@ -38,26 +29,35 @@ func (driver *minioFs) InitMultipartUpload(ctx context.Context, path string, opt
 	if o.ContentType != "" {
 		options.ContentType = o.ContentType
 	}
-	uploadID, err := driver.core.NewMultipartUpload(ctx, driver.config.BucketName, path, options)
+	if o.ContentEncoding != "" {
+		options.ContentEncoding = o.ContentEncoding
+	}
+	if o.Metadata != nil {
+		options.UserMetadata = make(map[string]string)
+		for k, v := range o.Metadata {
+			options.UserMetadata[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	uploadID, err := driver.core.NewMultipartUpload(ctx, driver.config.BucketName, key, options)
 	if err != nil {
-		return "", err
+		return "", wrapError("InitMultipartUpload", path, err)
 	}
 	return uploadID, nil
 }
 
 func (driver *minioFs) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	// 计算数据大小
 	var size int64
 	if seeker, ok := data.(io.Seeker); ok {
 		var err error
 		size, err = seeker.Seek(0, io.SeekEnd)
 		if err != nil {
-			return "", err
+			return "", wrapError("UploadPart", path, err)
 		}
 		_, err = seeker.Seek(0, io.SeekStart)
 		if err != nil {
-			return "", err
+			return "", wrapError("UploadPart", path, err)
 		}
 	} else {
 		// 如果无法获取大小，则先将数据读入内存
@@ -65,21 +65,21 @@ func (driver *minioFs) UploadPart(ctx context.Context, path string, uploadID str
 		var err error
 		size, err = io.Copy(buf, data)
 		if err != nil {
-			return "", err
+			return "", wrapError("UploadPart", path, err)
 		}
 		data = buf
 	}
 
-	part, err := driver.core.PutObjectPart(ctx, driver.config.BucketName, path, uploadID, partNumber, data, size, minio.PutObjectPartOptions{})
+	part, err := driver.core.PutObjectPart(ctx, driver.config.BucketName, key, uploadID, partNumber, data, size, minio.PutObjectPartOptions{})
 	if err != nil {
-		return "", err
+		return "", wrapError("UploadPart", path, err)
 	}
 
 	return part.ETag, nil
 }
 
 func (driver *minioFs) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
 	// 转换分片信息格式
 	completeParts := make([]minio.CompletePart, len(parts))
 	for i, part := range parts {
@@ -89,20 +89,20 @@ func (driver *minioFs) CompleteMultipartUpload(ctx context.Context, path string,
 		}
 	}
 
-	_, err := driver.core.CompleteMultipartUpload(ctx, driver.config.BucketName, path, uploadID, completeParts, minio.PutObjectOptions{})
-	return err
+	_, err := driver.core.CompleteMultipartUpload(ctx, driver.config.BucketName, key, uploadID, completeParts, minio.PutObjectOptions{})
+	return wrapError("CompleteMultipartUpload", path, err)
 }
 
 func (driver *minioFs) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
-	path = driver.path(path)
-	return driver.core.AbortMultipartUpload(ctx, driver.config.BucketName, path, uploadID)
+	key := driver.path(path)
+	return wrapError("AbortMultipartUpload", path, driver.core.AbortMultipartUpload(ctx, driver.config.BucketName, key, uploadID))
 }
 
 func (driver *minioFs) ListMultipartUploads(ctx context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
 	var uploads []fs.MultipartUploadInfo
 	for multipart := range driver.client.ListIncompleteUploads(ctx, driver.config.BucketName, "", true) {
 		if multipart.Err != nil {
-			return nil, multipart.Err
+			return nil, wrapError("ListMultipartUploads", "", multipart.Err)
 		}
 		uploads = append(uploads, fs.MultipartUploadInfo{
 			UploadID:   multipart.UploadID,
@@ -114,7 +114,7 @@ func (driver *minioFs) ListMultipartUploads(ctx context.Context, opts ...fs.Opti
 }
 
 func (driver *minioFs) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 
 	var parts []fs.MultipartPart
 
@@ -124,9 +124,9 @@ func (driver *minioFs) ListUploadedParts(ctx context.Context, path string, uploa
 
 	for {
 		// 获取分片列表
-		result, err := driver.core.ListObjectParts(ctx, driver.config.BucketName, path, uploadID, partNumberMarker, maxParts)
+		result, err := driver.core.ListObjectParts(ctx, driver.config.BucketName, key, uploadID, partNumberMarker, maxParts)
 		if err != nil {
-			return nil, err
+			return nil, wrapError("ListUploadedParts", path, err)
 		}
 
 		// 添加分片信息
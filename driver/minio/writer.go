@@ -1,7 +1,6 @@
 package minio
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -10,80 +9,54 @@ import (
 	"github.com/minio/minio-go/v7"
 )
 
-// minioWriter 实现 io.WriteCloser 接口
+// minioWriter 实现 io.WriteCloser 接口，数据超过阈值后自动切换为分片上传
 type minioWriter struct {
-	ctx         context.Context
-	client      *minio.Client
-	bucket      string
-	path        string
-	buffer      *bytes.Buffer
-	metadata    fs.Metadata
-	contentType string
+	io.WriteCloser
+	ctx    context.Context
+	client *minio.Client
+	bucket string
+	path   string
 }
 
-func newMinioWriter(ctx context.Context, client *minio.Client, bucket, path string, opts ...fs.Option) *minioWriter {
-	o := &fs.Options{}
-	for _, opt := range opts {
-		opt(o)
-	}
-
-	writer := &minioWriter{
-		ctx:    ctx,
-		client: client,
-		bucket: bucket,
-		path:   path,
-		buffer: bytes.NewBuffer(nil),
-	}
-
-	if o.ContentType != "" {
-		writer.contentType = o.ContentType
-	}
-	if o.Metadata != nil {
-		writer.metadata = o.Metadata
+func newMinioWriter(ctx context.Context, driver *minioFs, path string, opts ...fs.Option) *minioWriter {
+	return &minioWriter{
+		WriteCloser: fs.NewStreamWriter(ctx, driver, path, driver.putObject("Create", path, opts...), opts...),
+		ctx:         ctx,
+		client:      driver.client,
+		bucket:      driver.config.BucketName,
+		path:        driver.path(path),
 	}
-
-	return writer
 }
 
-func (w *minioWriter) Write(p []byte) (n int, err error) {
-	select {
-	case <-w.ctx.Done():
-		return 0, w.ctx.Err()
-	default:
-		return w.buffer.Write(p)
+// putObject 返回单次上传完整文件的函数
+func (driver *minioFs) putObject(op, path string, opts ...fs.Option) fs.PutFunc {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
 	}
-}
 
-func (w *minioWriter) Close() error {
-	select {
-	case <-w.ctx.Done():
-		return w.ctx.Err()
-	default:
-		opts := minio.PutObjectOptions{}
+	return func(ctx context.Context, body io.Reader, size int64) error {
+		options := minio.PutObjectOptions{}
 
 		// 设置 ContentType
-		if w.contentType != "" {
-			opts.ContentType = w.contentType
+		if o.ContentType != "" {
+			options.ContentType = o.ContentType
+		}
+		if o.ContentEncoding != "" {
+			options.ContentEncoding = o.ContentEncoding
 		}
 
 		// 处理metadata
-		if w.metadata != nil {
+		if o.Metadata != nil {
 			userMetadata := make(map[string]string)
-			for k, v := range w.metadata {
+			for k, v := range o.Metadata {
 				userMetadata[k] = fmt.Sprintf("%v", v)
 			}
-			opts.UserMetadata = userMetadata
+			options.UserMetadata = userMetadata
 		}
 
-		_, err := w.client.PutObject(
-			w.ctx,
-			w.bucket,
-			w.path,
-			bytes.NewReader(w.buffer.Bytes()),
-			int64(w.buffer.Len()),
-			opts,
-		)
-		return err
+		_, err := driver.client.PutObject(ctx, driver.config.BucketName, driver.path(path), body, size, options)
+		return wrapError(op, path, err)
 	}
 }
 
@@ -93,9 +66,9 @@ type minioReadWriter struct {
 	reader io.ReadCloser
 }
 
-func newMinioReadWriter(ctx context.Context, client *minio.Client, bucket, path string, opts ...fs.Option) *minioReadWriter {
+func newMinioReadWriter(ctx context.Context, driver *minioFs, path string, opts ...fs.Option) *minioReadWriter {
 	return &minioReadWriter{
-		minioWriter: newMinioWriter(ctx, client, bucket, path, opts...),
+		minioWriter: newMinioWriter(ctx, driver, path, opts...),
 	}
 }
 
@@ -109,7 +82,7 @@ func (rw *minioReadWriter) Read(p []byte) (n int, err error) {
 			minio.GetObjectOptions{},
 		)
 		if err != nil {
-			return 0, err
+			return 0, wrapError("OpenFile", rw.path, err)
 		}
 	}
 	return rw.reader.Read(p)
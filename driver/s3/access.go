@@ -13,18 +13,13 @@ import (
 )
 
 func (driver *s3Fs) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
 	}
 
-	var endpoint string
-	if driver.config.UsePathStyle {
-		endpoint = fmt.Sprintf("https://%s/%s", driver.config.Endpoint, driver.config.BucketName)
-	} else {
-		endpoint = fmt.Sprintf("https://%s.%s", driver.config.BucketName, driver.config.Endpoint)
-	}
+	endpoint := driver.endpoint()
 	cdnDomain := endpoint
 	var useCdnDomain bool
 	if o.CdnDomain != "" {
@@ -40,12 +35,12 @@ func (driver *s3Fs) SignFullUrl(ctx context.Context, path string, opts ...fs.Opt
 	presignClient := s3.NewPresignClient(driver.client)
 	signResult, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(driver.config.BucketName),
-		Key:    aws.String(path),
+		Key:    aws.String(key),
 	}, func(opts *s3.PresignOptions) {
 		opts.Expires = expires
 	})
 	if err != nil {
-		return "", err
+		return "", wrapError("SignFullUrl", path, err)
 	}
 
 	signUrl := strings.ReplaceAll(signResult.URL, "http://", "https://")
@@ -53,22 +48,17 @@ func (driver *s3Fs) SignFullUrl(ctx context.Context, path string, opts ...fs.Opt
 		signUrl = strings.ReplaceAll(signUrl, endpoint, cdnDomain)
 	}
 
-	return signUrl, err
+	return signUrl, nil
 }
 
 func (driver *s3Fs) FullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
 	}
 
-	var endpoint string
-	if driver.config.UsePathStyle {
-		endpoint = fmt.Sprintf("https://%s/%s", driver.config.Endpoint, driver.config.BucketName)
-	} else {
-		endpoint = fmt.Sprintf("https://%s.%s", driver.config.BucketName, driver.config.Endpoint)
-	}
+	endpoint := driver.endpoint()
 	cdnDomain := endpoint
 	var useCdnDomain bool
 	if o.CdnDomain != "" {
@@ -86,17 +76,17 @@ func (driver *s3Fs) FullUrl(ctx context.Context, path string, opts ...fs.Option)
 		presignClient := s3.NewPresignClient(driver.client)
 		signResult, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
 			Bucket: aws.String(driver.config.BucketName),
-			Key:    aws.String(path),
+			Key:    aws.String(key),
 		}, func(opts *s3.PresignOptions) {
 			opts.Expires = expires
 		})
 		if err != nil {
-			return "", err
+			return "", wrapError("FullUrl", path, err)
 		}
 
 		fullUrl = strings.ReplaceAll(signResult.URL, "http://", "https://")
 	} else {
-		fullUrl = fmt.Sprintf("%s/%s", cdnDomain, path)
+		fullUrl = fmt.Sprintf("%s/%s", cdnDomain, key)
 	}
 
 	if useCdnDomain {
@@ -109,14 +99,25 @@ func (driver *s3Fs) FullUrl(ctx context.Context, path string, opts ...fs.Option)
 func (driver *s3Fs) RelativePath(ctx context.Context, fullUrl string, opts ...fs.Option) (string, error) {
 	u, err := url.Parse(fullUrl)
 	if err != nil {
-		return "", err
+		return "", wrapError("RelativePath", fullUrl, err)
 	}
 
+	relativePath := strings.TrimPrefix(u.Path, "/")
 	if driver.config.UsePathStyle {
-		var originalPath = strings.TrimPrefix(u.Path, "/")
-		originalPath = strings.ReplaceAll(originalPath, driver.config.BucketName, "")
-		return strings.TrimPrefix(originalPath, "/"), nil
-	} else {
-		return strings.TrimPrefix(u.Path, "/"), nil
+		relativePath = strings.TrimPrefix(relativePath, driver.config.BucketName+"/")
+	}
+	if driver.config.SubPath != "" {
+		relativePath = strings.TrimPrefix(relativePath, strings.Trim(driver.config.SubPath, "/")+"/")
+	}
+	return relativePath, nil
+}
+
+// endpoint 获取文件访问地址，Endpoint 中的协议头会被忽略
+func (driver *s3Fs) endpoint() string {
+	host := strings.TrimPrefix(driver.config.Endpoint, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	if driver.config.UsePathStyle {
+		return fmt.Sprintf("https://%s/%s", host, driver.config.BucketName)
 	}
+	return fmt.Sprintf("https://%s.%s", driver.config.BucketName, host)
 }
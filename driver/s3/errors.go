@@ -0,0 +1,54 @@
+package s3
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"github.com/goairix/fs"
+)
+
+// wrapError 将 S3 错误转换为统一的文件系统错误
+func wrapError(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return err
+	}
+	return fs.NewPathError("s3", op, path, err, errorKind(err))
+}
+
+// errorKind 返回 S3 错误对应的通用错误
+func errorKind(err error) error {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return fs.ErrNotExist
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchKey", "NoSuchBucket", "NoSuchUpload", "NotFound":
+			return fs.ErrNotExist
+		case "AccessDenied", "Forbidden":
+			return fs.ErrPermission
+		case "PreconditionFailed":
+			return fs.ErrPreconditionFailed
+		case "NotImplemented":
+			return fs.ErrUnsupported
+		case "InternalError", "ServiceUnavailable", "SlowDown", "RequestTimeout":
+			return fs.ErrTemporary
+		}
+	}
+
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		return fs.ErrorKindFromStatus(respErr.HTTPStatusCode())
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package s3
 
 import (
 	"os"
+	"path"
 	"strings"
 	"time"
 
@@ -21,14 +22,20 @@ func (f *s3FileInfo) Name() string {
 	if f.info.Key == nil {
 		return ""
 	}
-	return *f.info.Key
+	return path.Base(strings.TrimSuffix(*f.info.Key, "/"))
 }
 
 func (f *s3FileInfo) Size() int64 {
+	if f.info.Size == nil {
+		return 0
+	}
 	return *f.info.Size
 }
 
 func (f *s3FileInfo) Mode() os.FileMode {
+	if f.IsDir() {
+		return os.ModeDir | 0755
+	}
 	return 0644 // S3不支持文件权限，返回默认值
 }
 
@@ -40,9 +47,17 @@ func (f *s3FileInfo) ModTime() time.Time {
 }
 
 func (f *s3FileInfo) IsDir() bool {
-	return strings.HasSuffix(f.Name(), "/")
+	return f.info.Key != nil && strings.HasSuffix(*f.info.Key, "/")
 }
 
 func (f *s3FileInfo) Sys() interface{} {
 	return f.info
 }
+
+// ETag 实现 fs.ETagger 接口
+func (f *s3FileInfo) ETag() string {
+	if f.info.ETag == nil {
+		return ""
+	}
+	return strings.Trim(*f.info.ETag, `"`)
+}
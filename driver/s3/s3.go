@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 
@@ -21,7 +22,7 @@ import (
 type Config struct {
 	Region          string        // AWS 区域
 	Endpoint        string        // S3 服务地址（可选，用于兼容其他 S3 协议的存储服务）
-	AccessKeyID     string        // AccessKey
+	AccessKeyID     string        // AccessKey，为空时使用 AWS 默认凭证链（环境变量、共享配置文件、实例角色等）
 	SecretAccessKey string        // SecretKey
 	BucketName      string        // 存储桶名称
 	SubPath         string        // 子目录路径
@@ -36,14 +37,15 @@ type s3Fs struct {
 }
 
 func New(conf Config) (fs.FileSystem, error) {
-	cfg, err := config.LoadDefaultConfig(context.Background(),
-		config.WithRegion(conf.Region),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+	loadOpts := []func(*config.LoadOptions) error{config.WithRegion(conf.Region)}
+	if conf.AccessKeyID != "" {
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
 			conf.AccessKeyID,
 			conf.SecretAccessKey,
 			"",
-		)),
-	)
+		)))
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background(), loadOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -67,9 +69,9 @@ func New(conf Config) (fs.FileSystem, error) {
 }
 
 func (driver *s3Fs) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	var fileInfos []fs.FileInfo
-	prefix := strings.TrimRight(path, "/")
+	prefix := strings.TrimRight(key, "/")
 	if prefix != "" {
 		prefix += "/"
 	}
@@ -84,11 +86,15 @@ func (driver *s3Fs) List(ctx context.Context, path string, opts ...fs.Option) ([
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			return nil, err
+			return nil, wrapError("List", path, err)
 		}
 
 		// 添加文件
 		for _, object := range output.Contents {
+			if aws.ToString(object.Key) == prefix {
+				// 跳过目录占位对象
+				continue
+			}
 			fileInfos = append(fileInfos, newS3FileInfo(object))
 		}
 
@@ -103,14 +109,84 @@ func (driver *s3Fs) List(ctx context.Context, path string, opts ...fs.Option) ([
 	return fileInfos, nil
 }
 
+func (driver *s3Fs) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	prefix := strings.TrimRight(driver.path(path), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(driver.config.BucketName),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+		MaxKeys:   aws.Int32(int32(o.ListLimit())),
+	}
+	if o.Marker != "" {
+		input.ContinuationToken = aws.String(o.Marker)
+	}
+	output, err := driver.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, wrapError("ListPage", path, err)
+	}
+
+	result := &fs.ListResult{}
+	for _, object := range output.Contents {
+		if aws.ToString(object.Key) == prefix {
+			// 跳过目录占位对象
+			continue
+		}
+		result.Files = append(result.Files, newS3FileInfo(object))
+	}
+	for _, prefix := range output.CommonPrefixes {
+		result.Files = append(result.Files, newS3FileInfo(types.Object{
+			Key: prefix.Prefix,
+		}))
+	}
+	if aws.ToBool(output.IsTruncated) {
+		result.NextMarker = aws.ToString(output.NextContinuationToken)
+	}
+	return result, nil
+}
+
+func (driver *s3Fs) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	prefix := strings.TrimRight(driver.path(root), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	// 不设置分隔符，按页列出前缀下的全部对象
+	return fs.WalkObjects(ctx, driver, root, fn, func(yield func(string, fs.FileInfo) bool) error {
+		paginator := s3.NewListObjectsV2Paginator(driver.client, &s3.ListObjectsV2Input{
+			Bucket: aws.String(driver.config.BucketName),
+			Prefix: aws.String(prefix),
+		})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return wrapError("Walk", root, err)
+			}
+			for _, object := range output.Contents {
+				if !yield(strings.TrimPrefix(aws.ToString(object.Key), prefix), newS3FileInfo(object)) {
+					return nil
+				}
+			}
+		}
+		return nil
+	}, opts...)
+}
+
 func (driver *s3Fs) MakeDir(_ context.Context, _ string, _ os.FileMode, opts ...fs.Option) error {
 	// S3目录在写入文件时自动创建
 	return nil
 }
 
 func (driver *s3Fs) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
-	path = driver.path(path)
-	prefix := strings.TrimRight(path, "/") + "/"
+	key := driver.path(path)
+	prefix := strings.TrimRight(key, "/") + "/"
 
 	input := &s3.ListObjectsV2Input{
 		Bucket: aws.String(driver.config.BucketName),
@@ -121,7 +197,7 @@ func (driver *s3Fs) RemoveDir(ctx context.Context, path string, opts ...fs.Optio
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			return err
+			return wrapError("RemoveDir", path, err)
 		}
 
 		for _, object := range output.Contents {
@@ -130,7 +206,7 @@ func (driver *s3Fs) RemoveDir(ctx context.Context, path string, opts ...fs.Optio
 				Key:    object.Key,
 			})
 			if err != nil {
-				return err
+				return wrapError("RemoveDir", path, err)
 			}
 		}
 	}
@@ -138,59 +214,67 @@ func (driver *s3Fs) RemoveDir(ctx context.Context, path string, opts ...fs.Optio
 }
 
 func (driver *s3Fs) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
-	path = driver.path(path)
-	return newS3Writer(ctx, driver.client, driver.config.BucketName, path, opts...), nil
+	return newS3Writer(ctx, driver, path, opts...), nil
 }
 
 func (driver *s3Fs) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
-	path = driver.path(path)
-	output, err := driver.client.GetObject(ctx, &s3.GetObjectInput{
+	key := driver.path(path)
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	input := &s3.GetObjectInput{
 		Bucket: aws.String(driver.config.BucketName),
-		Key:    aws.String(path),
-	})
+		Key:    aws.String(key),
+	}
+	if o.Range != nil {
+		input.Range = aws.String(o.Range.HeaderValue())
+	}
+	output, err := driver.client.GetObject(ctx, input)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("Open", path, err)
 	}
 	return output.Body, nil
 }
 
 func (driver *s3Fs) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
 	if flag&os.O_RDWR != 0 {
-		return newS3ReadWriter(ctx, driver.client, driver.config.BucketName, driver.path(path), opts...), nil
+		return newS3ReadWriter(ctx, driver, path, opts...), nil
 	}
 	if flag&os.O_WRONLY != 0 {
-		return newS3ReadWriter(ctx, driver.client, driver.config.BucketName, driver.path(path), opts...), nil
+		return newS3ReadWriter(ctx, driver, path, opts...), nil
 	}
 	reader, err := driver.Open(ctx, path, opts...)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("OpenFile", path, err)
 	}
 	return newS3ReadOnlyWrapper(reader), nil
 }
 
 func (driver *s3Fs) Remove(ctx context.Context, path string, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
 	_, err := driver.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(driver.config.BucketName),
-		Key:    aws.String(path),
+		Key:    aws.String(key),
 	})
-	return err
+	return wrapError("Remove", path, err)
 }
 
 func (driver *s3Fs) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
-	src = driver.path(src)
-	dst = driver.path(dst)
+	srcKey := driver.path(src)
+	dstKey := driver.path(dst)
 	_, err := driver.client.CopyObject(ctx, &s3.CopyObjectInput{
 		Bucket:     aws.String(driver.config.BucketName),
-		Key:        aws.String(dst),
-		CopySource: aws.String(fmt.Sprintf("%s/%s", driver.config.BucketName, src)),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(driver.copySource(srcKey)),
 	})
-	return err
+	return wrapError("Copy", src, err)
 }
 
 func (driver *s3Fs) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
 	if err := driver.Copy(ctx, src, dst); err != nil {
-		return err
+		return wrapError("Move", src, err)
 	}
 	return driver.Remove(ctx, src)
 }
@@ -200,19 +284,28 @@ func (driver *s3Fs) Rename(ctx context.Context, oldPath, newPath string, opts ..
 }
 
 func (driver *s3Fs) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	output, err := driver.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(driver.config.BucketName),
-		Key:    aws.String(path),
+		Key:    aws.String(key),
 	})
 	if err != nil {
-		return nil, err
+		// 目录在对象存储中为公共前缀，没有实体对象
+		if errors.Is(errorKind(err), fs.ErrNotExist) {
+			if isDir, dirErr := driver.IsDir(ctx, path); dirErr == nil && isDir {
+				return newS3FileInfo(types.Object{
+					Key: aws.String(strings.TrimRight(key, "/") + "/"),
+				}), nil
+			}
+		}
+		return nil, wrapError("Stat", path, err)
 	}
 
 	return newS3FileInfo(types.Object{
-		Key:          aws.String(path),
+		Key:          aws.String(key),
 		Size:         output.ContentLength,
 		LastModified: output.LastModified,
+		ETag:         output.ETag,
 	}), nil
 }
 
@@ -222,7 +315,7 @@ func (driver *s3Fs) GetMimeType(ctx context.Context, path string, opts ...fs.Opt
 		Key:    aws.String(driver.path(path)),
 	})
 	if err != nil {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 
 	if output.ContentType != nil {
@@ -232,7 +325,7 @@ func (driver *s3Fs) GetMimeType(ctx context.Context, path string, opts ...fs.Opt
 	// 如果对象没有ContentType，则读取文件内容进行检测
 	obj, err := driver.Open(ctx, path)
 	if err != nil {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 	defer func() {
 		_ = obj.Close()
@@ -241,40 +334,49 @@ func (driver *s3Fs) GetMimeType(ctx context.Context, path string, opts ...fs.Opt
 	buffer := make([]byte, 512)
 	n, err := obj.Read(buffer)
 	if err != nil && err != io.EOF {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 
 	return http.DetectContentType(buffer[:n]), nil
 }
 
 func (driver *s3Fs) SetMetadata(ctx context.Context, path string, metadata map[string]any, opts ...fs.Option) error {
+	key := driver.path(path)
+	head, err := driver.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(driver.config.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return wrapError("SetMetadata", path, err)
+	}
+
+	// 复制到自身并替换元数据，保留原有的 ContentType 与 ContentEncoding
 	input := &s3.CopyObjectInput{
-		Bucket:     aws.String(driver.config.BucketName),
-		Key:        aws.String(driver.path(path + "_tmp")),
-		CopySource: aws.String(fmt.Sprintf("%s/%s", driver.config.BucketName, path)),
-		Metadata:   make(map[string]string),
+		Bucket:            aws.String(driver.config.BucketName),
+		Key:               aws.String(key),
+		CopySource:        aws.String(driver.copySource(key)),
+		ContentType:       head.ContentType,
+		ContentEncoding:   head.ContentEncoding,
+		Metadata:          make(map[string]string),
+		MetadataDirective: types.MetadataDirectiveReplace,
 	}
 
 	for k, v := range metadata {
 		input.Metadata[k] = fmt.Sprintf("%v", v)
 	}
 
-	_, err := driver.client.CopyObject(ctx, input)
-	if err != nil {
-		return err
-	}
-
-	return driver.Move(ctx, path+"_tmp", path)
+	_, err = driver.client.CopyObject(ctx, input)
+	return wrapError("SetMetadata", path, err)
 }
 
 func (driver *s3Fs) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]any, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	output, err := driver.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(driver.config.BucketName),
-		Key:    aws.String(path),
+		Key:    aws.String(key),
 	})
 	if err != nil {
-		return nil, err
+		return nil, wrapError("GetMetadata", path, err)
 	}
 
 	metadata := make(map[string]interface{})
@@ -292,41 +394,47 @@ func (driver *s3Fs) Exists(ctx context.Context, path string, opts ...fs.Option)
 }
 
 func (driver *s3Fs) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
-	path = driver.path(path)
-	path = strings.TrimRight(path, "/") + "/"
+	key := driver.path(path)
+	key = strings.TrimRight(key, "/") + "/"
 	input := &s3.ListObjectsV2Input{
 		Bucket:    aws.String(driver.config.BucketName),
-		Prefix:    aws.String(path),
+		Prefix:    aws.String(key),
 		Delimiter: aws.String("/"),
 		MaxKeys:   aws.Int32(1),
 	}
 
 	output, err := driver.client.ListObjectsV2(ctx, input)
 	if err != nil {
-		return false, err
+		return false, wrapError("IsDir", path, err)
 	}
 	return len(output.Contents) > 0 || len(output.CommonPrefixes) > 0, nil
 }
 
 func (driver *s3Fs) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	_, err := driver.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(driver.config.BucketName),
-		Key:    aws.String(path),
+		Key:    aws.String(key),
 	})
 	if err != nil {
-		var notFound *types.NotFound
-		if errors.As(err, &notFound) {
+		if errors.Is(errorKind(err), fs.ErrNotExist) {
 			return false, nil
 		}
-		return false, err
+		return false, wrapError("IsFile", path, err)
 	}
 	return true, nil
 }
 
+// path 获取规范化的对象键，路径中的 ".." 不会超出子目录
 func (driver *s3Fs) path(path string) string {
-	if driver.config.SubPath != "" {
-		return strings.Trim(driver.config.SubPath, "/") + "/" + path
+	return fs.JoinPath(driver.config.SubPath, path)
+}
+
+// copySource 获取复制源，对象键需要进行 URL 编码
+func (driver *s3Fs) copySource(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
 	}
-	return path
+	return driver.config.BucketName + "/" + strings.Join(segments, "/")
 }
@@ -0,0 +1,102 @@
+package s3_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/s3"
+	"github.com/goairix/fs/fstest"
+	"github.com/goairix/fs/fstest/s3fake"
+)
+
+func TestFileSystem(t *testing.T) {
+	srv := s3fake.New()
+	defer srv.Close()
+
+	var n int
+	for _, sub := range []string{"", "sub/dir"} {
+		t.Run("sub="+sub, func(t *testing.T) {
+			fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+				n++
+				bucket := fmt.Sprintf("bucket-%d", n)
+				srv.CreateBucket(bucket)
+				fsys, err := s3.New(s3.Config{
+					Region:          "us-east-1",
+					Endpoint:        srv.URL,
+					AccessKeyID:     "test",
+					SecretAccessKey: "test",
+					BucketName:      bucket,
+					UsePathStyle:    true,
+					SubPath:         sub,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return fsys
+			})
+		})
+	}
+}
+
+func TestCopySpecialKeys(t *testing.T) {
+	srv := s3fake.New()
+	defer srv.Close()
+	srv.CreateBucket("test")
+	fsys, err := s3.New(s3.Config{
+		Region:          "us-east-1",
+		Endpoint:        srv.URL,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+		BucketName:      "test",
+		UsePathStyle:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, name := range []string{"a b.txt", "a+b.txt", "100%.txt", "文件.txt"} {
+		if err = fsys.Uploader().Upload(ctx, "src/"+name, strings.NewReader(name)); err != nil {
+			t.Fatal(err)
+		}
+		if err = fsys.Copy(ctx, "src/"+name, "dst/"+name); err != nil {
+			t.Fatalf("Copy %q: %v", name, err)
+		}
+		r, err := fsys.Open(ctx, "dst/"+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil || string(data) != name {
+			t.Fatalf("Copy %q: got %q, %v", name, data, err)
+		}
+	}
+}
+
+func TestOpenURLDefaultCredentials(t *testing.T) {
+	srv := s3fake.New()
+	defer srv.Close()
+	srv.CreateBucket("test")
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+
+	ctx := context.Background()
+	fsys, err := fs.OpenURL(ctx, "s3://test/prefix?region=us-east-1&path_style=true&endpoint="+url.QueryEscape(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fsys.Uploader().Upload(ctx, "a.txt", strings.NewReader("a")); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := fsys.Exists(ctx, "a.txt"); err != nil || !ok {
+		t.Fatalf("Exists = %v, %v", ok, err)
+	}
+}
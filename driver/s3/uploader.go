@@ -2,6 +2,7 @@ package s3
 
 import (
 	"context"
+	"fmt"
 	"io"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -15,58 +16,57 @@ func (driver *s3Fs) Uploader() fs.Uploader {
 }
 
 func (driver *s3Fs) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
-	file, err := driver.Create(ctx, path, opts...)
-	if err != nil {
-		return err
-	}
-
-	_, err = io.Copy(file, reader)
-	if err != nil {
-		_ = file.Close()
-		return err
-	}
-
-	return file.Close()
+	err := fs.ManagedUpload(ctx, driver, path, reader, driver.putObject("Upload", path, opts...), opts...)
+	return wrapError("Upload", path, err)
 }
 
 func (driver *s3Fs) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
 	}
 	input := &s3.CreateMultipartUploadInput{
 		Bucket: aws.String(driver.config.BucketName),
-		Key:    aws.String(path),
+		Key:    aws.String(key),
 	}
 	if o.ContentType != "" {
 		input.ContentType = aws.String(o.ContentType)
 	}
+	if o.ContentEncoding != "" {
+		input.ContentEncoding = aws.String(o.ContentEncoding)
+	}
+	if o.Metadata != nil {
+		input.Metadata = make(map[string]string)
+		for k, v := range o.Metadata {
+			input.Metadata[k] = fmt.Sprintf("%v", v)
+		}
+	}
 	output, err := driver.client.CreateMultipartUpload(ctx, input)
 	if err != nil {
-		return "", err
+		return "", wrapError("InitMultipartUpload", path, err)
 	}
 	return *output.UploadId, nil
 }
 
 func (driver *s3Fs) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	input := &s3.UploadPartInput{
 		Bucket:     aws.String(driver.config.BucketName),
-		Key:        aws.String(path),
+		Key:        aws.String(key),
 		PartNumber: aws.Int32(int32(partNumber)),
 		UploadId:   aws.String(uploadID),
 		Body:       data,
 	}
 	output, err := driver.client.UploadPart(ctx, input)
 	if err != nil {
-		return "", err
+		return "", wrapError("UploadPart", path, err)
 	}
 	return *output.ETag, nil
 }
 
 func (driver *s3Fs) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
 	completedParts := make([]types.CompletedPart, len(parts))
 	for i, part := range parts {
 		completedParts[i] = types.CompletedPart{
@@ -76,23 +76,23 @@ func (driver *s3Fs) CompleteMultipartUpload(ctx context.Context, path string, up
 	}
 	input := &s3.CompleteMultipartUploadInput{
 		Bucket:          aws.String(driver.config.BucketName),
-		Key:             aws.String(path),
+		Key:             aws.String(key),
 		UploadId:        aws.String(uploadID),
 		MultipartUpload: &types.CompletedMultipartUpload{Parts: completedParts},
 	}
 	_, err := driver.client.CompleteMultipartUpload(ctx, input)
-	return err
+	return wrapError("CompleteMultipartUpload", path, err)
 }
 
 func (driver *s3Fs) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
 	input := &s3.AbortMultipartUploadInput{
 		Bucket:   aws.String(driver.config.BucketName),
-		Key:      aws.String(path),
+		Key:      aws.String(key),
 		UploadId: aws.String(uploadID),
 	}
 	_, err := driver.client.AbortMultipartUpload(ctx, input)
-	return err
+	return wrapError("AbortMultipartUpload", path, err)
 }
 
 func (driver *s3Fs) ListMultipartUploads(ctx context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
@@ -102,7 +102,7 @@ func (driver *s3Fs) ListMultipartUploads(ctx context.Context, opts ...fs.Option)
 
 	result, err := driver.client.ListMultipartUploads(ctx, input)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("ListMultipartUploads", "", err)
 	}
 
 	uploads := make([]fs.MultipartUploadInfo, len(result.Uploads))
@@ -117,16 +117,16 @@ func (driver *s3Fs) ListMultipartUploads(ctx context.Context, opts ...fs.Option)
 }
 
 func (driver *s3Fs) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	input := &s3.ListPartsInput{
 		Bucket:   aws.String(driver.config.BucketName),
-		Key:      aws.String(path),
+		Key:      aws.String(key),
 		UploadId: aws.String(uploadID),
 	}
 
 	result, err := driver.client.ListParts(ctx, input)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("ListUploadedParts", path, err)
 	}
 
 	parts := make([]fs.MultipartPart, len(result.Parts))
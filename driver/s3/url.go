@@ -0,0 +1,49 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/goairix/fs"
+)
+
+func init() {
+	fs.Register("s3", openURL)
+}
+
+// openURL 根据 URL 创建 S3 文件系统，格式为
+//
+//	s3://AccessKeyID:SecretAccessKey@bucket/subpath?region=us-east-1&endpoint=https://s3.example.com&path_style=true&access_mode=private
+//
+// endpoint、path_style 与 access_mode 为可选参数。用户信息中的密钥包含特殊字符（如 /、+、@）时需要进行百分号编码；
+// 省略用户信息时使用 AWS 默认凭证链，如 s3://bucket/subpath?region=us-east-1
+func openURL(_ context.Context, u *url.URL) (fs.FileSystem, error) {
+	if u.Host == "" {
+		return nil, errors.New("missing bucket name")
+	}
+	q := u.Query()
+	conf := Config{
+		Region:     q.Get("region"),
+		Endpoint:   q.Get("endpoint"),
+		BucketName: u.Host,
+		SubPath:    strings.Trim(u.Path, "/"),
+	}
+	if u.User != nil {
+		conf.AccessKeyID = u.User.Username()
+		conf.SecretAccessKey, _ = u.User.Password()
+	}
+
+	var err error
+	if v := q.Get("path_style"); v != "" {
+		if conf.UsePathStyle, err = strconv.ParseBool(v); err != nil {
+			return nil, errors.New("invalid path_style " + strconv.Quote(v))
+		}
+	}
+	if conf.AccessMode, err = fs.ParseAccessMode(q.Get("access_mode")); err != nil {
+		return nil, err
+	}
+	return New(conf)
+}
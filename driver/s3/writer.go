@@ -1,7 +1,6 @@
 package s3
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -11,64 +10,57 @@ import (
 	"github.com/goairix/fs"
 )
 
+// s3Writer 实现 io.WriteCloser 接口，数据超过阈值后自动切换为分片上传
 type s3Writer struct {
-	ctx         context.Context
-	client      *s3.Client
-	bucket      string
-	path        string
-	buffer      *bytes.Buffer
-	metadata    fs.Metadata
-	contentType string
+	io.WriteCloser
+	ctx    context.Context
+	client *s3.Client
+	bucket string
+	path   string
 }
 
-func newS3Writer(ctx context.Context, client *s3.Client, bucket, path string, opts ...fs.Option) *s3Writer {
+func newS3Writer(ctx context.Context, driver *s3Fs, path string, opts ...fs.Option) *s3Writer {
+	return &s3Writer{
+		WriteCloser: fs.NewStreamWriter(ctx, driver, path, driver.putObject("Create", path, opts...), opts...),
+		ctx:         ctx,
+		client:      driver.client,
+		bucket:      driver.config.BucketName,
+		path:        driver.path(path),
+	}
+}
+
+// putObject 返回单次上传完整文件的函数
+func (driver *s3Fs) putObject(op, path string, opts ...fs.Option) fs.PutFunc {
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
 	}
 
-	writer := &s3Writer{
-		ctx:    ctx,
-		client: client,
-		bucket: bucket,
-		path:   path,
-		buffer: bytes.NewBuffer(nil),
-	}
-
-	if o.ContentType != "" {
-		writer.contentType = o.ContentType
-	}
-	if o.Metadata != nil {
-		writer.metadata = o.Metadata
-	}
-
-	return writer
-}
-
-func (w *s3Writer) Write(p []byte) (n int, err error) {
-	return w.buffer.Write(p)
-}
-
-func (w *s3Writer) Close() error {
-	input := &s3.PutObjectInput{
-		Bucket: aws.String(w.bucket),
-		Key:    aws.String(w.path),
-		Body:   bytes.NewReader(w.buffer.Bytes()),
-	}
+	return func(ctx context.Context, body io.Reader, size int64) error {
+		input := &s3.PutObjectInput{
+			Bucket:        aws.String(driver.config.BucketName),
+			Key:           aws.String(driver.path(path)),
+			Body:          body,
+			ContentLength: aws.Int64(size),
+		}
 
-	if w.contentType != "" {
-		input.ContentType = aws.String(w.contentType)
-	}
+		if o.ContentType != "" {
+			input.ContentType = aws.String(o.ContentType)
+		}
+		if o.ContentEncoding != "" {
+			input.ContentEncoding = aws.String(o.ContentEncoding)
+		}
 
-	if w.metadata != nil {
-		input.Metadata = make(map[string]string)
-		for k, v := range w.metadata {
-			input.Metadata[k] = fmt.Sprintf("%v", v)
+		if o.Metadata != nil {
+			input.Metadata = make(map[string]string)
+			for k, v := range o.Metadata {
+				input.Metadata[k] = fmt.Sprintf("%v", v)
+			}
 		}
-	}
 
-	_, err := w.client.PutObject(w.ctx, input)
-	return err
+		_, err := driver.client.PutObject(ctx, input)
+		return wrapError(op, path, err)
+	}
 }
 
 type s3ReadWriter struct {
@@ -76,9 +68,9 @@ type s3ReadWriter struct {
 	reader io.ReadCloser
 }
 
-func newS3ReadWriter(ctx context.Context, client *s3.Client, bucket, path string, opts ...fs.Option) *s3ReadWriter {
+func newS3ReadWriter(ctx context.Context, driver *s3Fs, path string, opts ...fs.Option) *s3ReadWriter {
 	return &s3ReadWriter{
-		s3Writer: newS3Writer(ctx, client, bucket, path, opts...),
+		s3Writer: newS3Writer(ctx, driver, path, opts...),
 	}
 }
 
@@ -89,7 +81,7 @@ func (rw *s3ReadWriter) Read(p []byte) (n int, err error) {
 			Key:    aws.String(rw.path),
 		})
 		if err != nil {
-			return 0, err
+			return 0, wrapError("OpenFile", rw.path, err)
 		}
 		rw.reader = output.Body
 	}
@@ -11,7 +11,7 @@ import (
 )
 
 func (driver *cosFs) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
@@ -29,9 +29,9 @@ func (driver *cosFs) SignFullUrl(ctx context.Context, path string, opts ...fs.Op
 		expires = o.SignUrlExpires
 	}
 
-	signUrlResult, err := driver.client.Object.GetPresignedURL2(ctx, "GET", path, expires, nil)
+	signUrlResult, err := driver.client.Object.GetPresignedURL2(ctx, "GET", key, expires, nil)
 	if err != nil {
-		return "", err
+		return "", wrapError("SignFullUrl", path, err)
 	}
 
 	signUrl := strings.ReplaceAll(signUrlResult.String(), "http://", "https://")
@@ -39,11 +39,11 @@ func (driver *cosFs) SignFullUrl(ctx context.Context, path string, opts ...fs.Op
 		signUrl = strings.ReplaceAll(signUrl, driver.config.BucketURL, cdnDomain)
 	}
 
-	return signUrl, err
+	return signUrl, wrapError("SignFullUrl", path, err)
 }
 
 func (driver *cosFs) FullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
@@ -63,13 +63,13 @@ func (driver *cosFs) FullUrl(ctx context.Context, path string, opts ...fs.Option
 			expires = o.SignUrlExpires
 		}
 
-		signUrlResult, err := driver.client.Object.GetPresignedURL2(ctx, "GET", path, expires, nil)
+		signUrlResult, err := driver.client.Object.GetPresignedURL2(ctx, "GET", key, expires, nil)
 		if err != nil {
-			return "", err
+			return "", wrapError("FullUrl", path, err)
 		}
 		fullUrl = strings.ReplaceAll(signUrlResult.String(), "http://", "https://")
 	} else {
-		fullUrl = fmt.Sprintf("%s/%s", cdnDomain, path)
+		fullUrl = fmt.Sprintf("%s/%s", cdnDomain, key)
 	}
 
 	if useCdnDomain {
@@ -82,7 +82,12 @@ func (driver *cosFs) FullUrl(ctx context.Context, path string, opts ...fs.Option
 func (driver *cosFs) RelativePath(ctx context.Context, fullUrl string, opts ...fs.Option) (string, error) {
 	u, err := url.Parse(fullUrl)
 	if err != nil {
-		return "", nil
+		return "", wrapError("RelativePath", fullUrl, err)
 	}
-	return strings.TrimPrefix(u.Path, "/"), nil
+
+	relativePath := strings.TrimPrefix(u.Path, "/")
+	if driver.config.SubPath != "" {
+		relativePath = strings.TrimPrefix(relativePath, strings.Trim(driver.config.SubPath, "/")+"/")
+	}
+	return relativePath, nil
 }
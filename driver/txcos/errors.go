@@ -0,0 +1,44 @@
+package txcos
+
+import (
+	"errors"
+
+	"github.com/goairix/fs"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// wrapError 将 COS 错误转换为统一的文件系统错误
+func wrapError(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return err
+	}
+	return fs.NewPathError("txcos", op, path, err, errorKind(err))
+}
+
+// errorKind 返回 COS 错误对应的通用错误
+func errorKind(err error) error {
+	var cosErr *cos.ErrorResponse
+	if !errors.As(err, &cosErr) {
+		return nil
+	}
+	switch cosErr.Code {
+	case "NoSuchKey", "NoSuchBucket", "NoSuchUpload":
+		return fs.ErrNotExist
+	case "AccessDenied":
+		return fs.ErrPermission
+	case "PreconditionFailed":
+		return fs.ErrPreconditionFailed
+	case "NotImplemented":
+		return fs.ErrUnsupported
+	case "InternalError", "ServiceUnavailable", "SlowDown", "RequestTimeout":
+		return fs.ErrTemporary
+	}
+	if cosErr.Response != nil {
+		return fs.ErrorKindFromStatus(cosErr.Response.StatusCode)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package txcos
 
 import (
 	"os"
+	"path"
 	"strings"
 	"time"
 
@@ -18,7 +19,7 @@ func newCosFileInfo(info cos.Object) *cosFileInfo {
 }
 
 func (f *cosFileInfo) Name() string {
-	return f.info.Key
+	return path.Base(strings.TrimSuffix(f.info.Key, "/"))
 }
 
 func (f *cosFileInfo) Size() int64 {
@@ -26,11 +27,18 @@ func (f *cosFileInfo) Size() int64 {
 }
 
 func (f *cosFileInfo) Mode() os.FileMode {
+	if f.IsDir() {
+		return os.ModeDir | 0755
+	}
 	return 0644 // COS不支持文件权限，返回默认值
 }
 
 func (f *cosFileInfo) ModTime() time.Time {
-	t, _ := time.Parse(time.RFC1123, f.info.LastModified)
+	// 列举结果为 ISO8601 格式，HEAD 请求的响应头为 RFC1123 格式
+	t, err := time.Parse(time.RFC3339, f.info.LastModified)
+	if err != nil {
+		t, _ = time.Parse(time.RFC1123, f.info.LastModified)
+	}
 	return t.Local()
 }
 
@@ -41,3 +49,8 @@ func (f *cosFileInfo) IsDir() bool {
 func (f *cosFileInfo) Sys() interface{} {
 	return f.info
 }
+
+// ETag 实现 fs.ETagger 接口
+func (f *cosFileInfo) ETag() string {
+	return strings.Trim(f.info.ETag, `"`)
+}
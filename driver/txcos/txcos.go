@@ -2,6 +2,7 @@ package txcos
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -48,9 +49,9 @@ func New(config Config) (fs.FileSystem, error) {
 }
 
 func (driver *cosFs) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	var fileInfos []fs.FileInfo
-	prefix := strings.TrimRight(path, "/")
+	prefix := strings.TrimRight(key, "/")
 	if prefix != "" {
 		prefix += "/"
 	}
@@ -66,11 +67,15 @@ func (driver *cosFs) List(ctx context.Context, path string, opts ...fs.Option) (
 	for isTruncated {
 		res, _, err := driver.client.Bucket.Get(ctx, opt)
 		if err != nil {
-			return nil, err
+			return nil, wrapError("List", path, err)
 		}
 
 		// 添加文件
 		for _, object := range res.Contents {
+			if object.Key == prefix {
+				// 跳过目录占位对象
+				continue
+			}
 			fileInfos = append(fileInfos, newCosFileInfo(object))
 		}
 
@@ -89,14 +94,85 @@ func (driver *cosFs) List(ctx context.Context, path string, opts ...fs.Option) (
 	return fileInfos, nil
 }
 
+func (driver *cosFs) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	prefix := strings.TrimRight(driver.path(path), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	res, _, err := driver.client.Bucket.Get(ctx, &cos.BucketGetOptions{
+		Prefix:    prefix,
+		Delimiter: "/",
+		Marker:    o.Marker,
+		MaxKeys:   o.ListLimit(),
+	})
+	if err != nil {
+		return nil, wrapError("ListPage", path, err)
+	}
+
+	result := &fs.ListResult{}
+	for _, object := range res.Contents {
+		if object.Key == prefix {
+			// 跳过目录占位对象
+			continue
+		}
+		result.Files = append(result.Files, newCosFileInfo(object))
+	}
+	for _, prefix := range res.CommonPrefixes {
+		result.Files = append(result.Files, newCosFileInfo(cos.Object{
+			Key: prefix,
+		}))
+	}
+	if res.IsTruncated {
+		result.NextMarker = res.NextMarker
+	}
+	return result, nil
+}
+
+func (driver *cosFs) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	prefix := strings.TrimRight(driver.path(root), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	// 不设置分隔符，按页列出前缀下的全部对象
+	return fs.WalkObjects(ctx, driver, root, fn, func(yield func(string, fs.FileInfo) bool) error {
+		opt := &cos.BucketGetOptions{
+			Prefix: prefix,
+		}
+		for {
+			res, _, err := driver.client.Bucket.Get(ctx, opt)
+			if err != nil {
+				return wrapError("Walk", root, err)
+			}
+			for _, object := range res.Contents {
+				if !yield(strings.TrimPrefix(object.Key, prefix), newCosFileInfo(object)) {
+					return nil
+				}
+			}
+			if !res.IsTruncated || len(res.Contents) == 0 {
+				return nil
+			}
+			opt.Marker = res.NextMarker
+			if opt.Marker == "" {
+				opt.Marker = res.Contents[len(res.Contents)-1].Key
+			}
+		}
+	}, opts...)
+}
+
 func (driver *cosFs) MakeDir(_ context.Context, _ string, _ os.FileMode, opts ...fs.Option) error {
 	// COS目录在写入文件时自动创建
 	return nil
 }
 
 func (driver *cosFs) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
-	path = driver.path(path)
-	prefix := strings.TrimRight(path, "/") + "/"
+	key := driver.path(path)
+	prefix := strings.TrimRight(key, "/") + "/"
 	var marker string
 	opt := &cos.BucketGetOptions{
 		Prefix: prefix,
@@ -107,13 +183,13 @@ func (driver *cosFs) RemoveDir(ctx context.Context, path string, opts ...fs.Opti
 	for isTruncated {
 		res, _, err := driver.client.Bucket.Get(ctx, opt)
 		if err != nil {
-			return err
+			return wrapError("RemoveDir", path, err)
 		}
 
 		for _, object := range res.Contents {
 			_, err = driver.client.Object.Delete(ctx, object.Key)
 			if err != nil {
-				return err
+				return wrapError("RemoveDir", path, err)
 			}
 		}
 
@@ -125,50 +201,58 @@ func (driver *cosFs) RemoveDir(ctx context.Context, path string, opts ...fs.Opti
 }
 
 func (driver *cosFs) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
-	path = driver.path(path)
-	return newCosWriter(ctx, driver.client, path, opts...), nil
+	return newCosWriter(ctx, driver, path, opts...), nil
 }
 
 func (driver *cosFs) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
-	path = driver.path(path)
-	resp, err := driver.client.Object.Get(ctx, path, nil)
+	key := driver.path(path)
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var options *cos.ObjectGetOptions
+	if o.Range != nil {
+		options = &cos.ObjectGetOptions{Range: o.Range.HeaderValue()}
+	}
+	resp, err := driver.client.Object.Get(ctx, key, options)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("Open", path, err)
 	}
 	return resp.Body, nil
 }
 
 func (driver *cosFs) OpenFile(ctx context.Context, path string, flag int, _ os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
 	if flag&os.O_RDWR != 0 {
-		return newCosReadWriter(ctx, driver.client, driver.path(path), opts...), nil
+		return newCosReadWriter(ctx, driver, path, opts...), nil
 	}
 	if flag&os.O_WRONLY != 0 {
-		return newCosReadWriter(ctx, driver.client, driver.path(path), opts...), nil
+		return newCosReadWriter(ctx, driver, path, opts...), nil
 	}
 	reader, err := driver.Open(ctx, path, opts...)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("OpenFile", path, err)
 	}
 	return newCosReadOnlyWrapper(reader), nil
 }
 
 func (driver *cosFs) Remove(ctx context.Context, path string, opts ...fs.Option) error {
-	path = driver.path(path)
-	_, err := driver.client.Object.Delete(ctx, path)
-	return err
+	key := driver.path(path)
+	_, err := driver.client.Object.Delete(ctx, key)
+	return wrapError("Remove", path, err)
 }
 
 func (driver *cosFs) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
-	src = driver.path(src)
-	dst = driver.path(dst)
-	sourceURL := strings.ReplaceAll(driver.config.BucketURL, "https://", "") + "/" + src
-	_, _, err := driver.client.Object.Copy(ctx, dst, sourceURL, nil)
-	return err
+	srcKey := driver.path(src)
+	dstKey := driver.path(dst)
+	sourceURL := strings.ReplaceAll(driver.config.BucketURL, "https://", "") + "/" + srcKey
+	_, _, err := driver.client.Object.Copy(ctx, dstKey, sourceURL, nil)
+	return wrapError("Copy", src, err)
 }
 
 func (driver *cosFs) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
 	if err := driver.Copy(ctx, src, dst); err != nil {
-		return err
+		return wrapError("Move", src, err)
 	}
 	return driver.Remove(ctx, src)
 }
@@ -178,23 +262,30 @@ func (driver *cosFs) Rename(ctx context.Context, oldPath, newPath string, opts .
 }
 
 func (driver *cosFs) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
-	path = driver.path(path)
-	resp, err := driver.client.Object.Head(ctx, path, nil)
+	key := driver.path(path)
+	resp, err := driver.client.Object.Head(ctx, key, nil)
 	if err != nil {
-		return nil, err
+		// 目录在对象存储中为公共前缀，没有实体对象
+		if errors.Is(errorKind(err), fs.ErrNotExist) {
+			if isDir, dirErr := driver.IsDir(ctx, path); dirErr == nil && isDir {
+				return newCosFileInfo(cos.Object{Key: strings.TrimRight(key, "/") + "/"}), nil
+			}
+		}
+		return nil, wrapError("Stat", path, err)
 	}
 
 	return newCosFileInfo(cos.Object{
-		Key:          path,
+		Key:          key,
 		Size:         resp.ContentLength,
 		LastModified: resp.Header.Get("Last-Modified"),
+		ETag:         resp.Header.Get("ETag"),
 	}), nil
 }
 
 func (driver *cosFs) GetMimeType(ctx context.Context, path string, opts ...fs.Option) (string, error) {
 	resp, err := driver.client.Object.Head(ctx, driver.path(path), nil)
 	if err != nil {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 
 	contentType := resp.Header.Get("Content-Type")
@@ -205,7 +296,7 @@ func (driver *cosFs) GetMimeType(ctx context.Context, path string, opts ...fs.Op
 	// 如果对象没有Content-Type，则读取文件内容进行检测
 	obj, err := driver.Open(ctx, path)
 	if err != nil {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 	defer func() {
 		_ = obj.Close()
@@ -214,16 +305,24 @@ func (driver *cosFs) GetMimeType(ctx context.Context, path string, opts ...fs.Op
 	buffer := make([]byte, 512)
 	n, err := obj.Read(buffer)
 	if err != nil && err != io.EOF {
-		return "", err
+		return "", wrapError("GetMimeType", path, err)
 	}
 
 	return http.DetectContentType(buffer[:n]), nil
 }
 
 func (driver *cosFs) SetMetadata(ctx context.Context, path string, metadata map[string]any, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
+	resp, err := driver.client.Object.Head(ctx, key, nil)
+	if err != nil {
+		return wrapError("SetMetadata", path, err)
+	}
+
+	// 复制到自身并替换元数据，保留原有的 Content-Type 与 Content-Encoding
 	opt := &cos.ObjectCopyOptions{
 		ObjectCopyHeaderOptions: &cos.ObjectCopyHeaderOptions{
+			ContentType:           resp.Header.Get("Content-Type"),
+			ContentEncoding:       resp.Header.Get("Content-Encoding"),
 			XCosMetadataDirective: "Replaced",
 		},
 	}
@@ -234,20 +333,16 @@ func (driver *cosFs) SetMetadata(ctx context.Context, path string, metadata map[
 		}
 	}
 
-	sourceURL := driver.config.BucketURL + "/" + path
-	_, _, err := driver.client.Object.Copy(ctx, path+"_tmp", sourceURL, opt)
-	if err != nil {
-		return err
-	}
-
-	return driver.Move(ctx, path+"_tmp", path)
+	sourceURL := strings.ReplaceAll(driver.config.BucketURL, "https://", "") + "/" + key
+	_, _, err = driver.client.Object.Copy(ctx, key, sourceURL, opt)
+	return wrapError("SetMetadata", path, err)
 }
 
 func (driver *cosFs) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]any, error) {
-	path = driver.path(path)
-	resp, err := driver.client.Object.Head(ctx, path, nil)
+	key := driver.path(path)
+	resp, err := driver.client.Object.Head(ctx, key, nil)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("GetMetadata", path, err)
 	}
 
 	metadata := make(map[string]interface{})
@@ -277,28 +372,30 @@ func (driver *cosFs) Exists(ctx context.Context, path string, opts ...fs.Option)
 }
 
 func (driver *cosFs) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
-	path = driver.path(path)
-	path = strings.TrimRight(path, "/") + "/"
+	key := driver.path(path)
+	key = strings.TrimRight(key, "/") + "/"
 	opt := &cos.BucketGetOptions{
-		Prefix:    path,
+		Prefix:    key,
 		Delimiter: "/",
 		MaxKeys:   1,
 	}
 	res, _, err := driver.client.Bucket.Get(ctx, opt)
 	if err != nil {
-		return false, err
+		return false, wrapError("IsDir", path, err)
 	}
 	return len(res.Contents) > 0 || len(res.CommonPrefixes) > 0, nil
 }
 
 func (driver *cosFs) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
-	path = driver.path(path)
-	return driver.client.Object.IsExist(ctx, path)
+	key := driver.path(path)
+	exist, err := driver.client.Object.IsExist(ctx, key)
+	if err != nil {
+		return false, wrapError("IsFile", path, err)
+	}
+	return exist, nil
 }
 
+// path 获取规范化的对象键，路径中的 ".." 不会超出子目录
 func (driver *cosFs) path(path string) string {
-	if driver.config.SubPath != "" {
-		return strings.Trim(driver.config.SubPath, "/") + "/" + path
-	}
-	return path
+	return fs.JoinPath(driver.config.SubPath, path)
 }
@@ -2,7 +2,9 @@ package txcos
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"net/http"
 	"time"
 
 	"github.com/goairix/fs"
@@ -14,48 +16,46 @@ func (driver *cosFs) Uploader() fs.Uploader {
 }
 
 func (driver *cosFs) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
-	file, err := driver.Create(ctx, path, opts...)
-	if err != nil {
-		return err
-	}
-
-	_, err = io.Copy(file, reader)
-	if err != nil {
-		_ = file.Close()
-		return err
-	}
-
-	return file.Close()
+	err := fs.ManagedUpload(ctx, driver, path, reader, driver.putObject("Upload", path, opts...), opts...)
+	return wrapError("Upload", path, err)
 }
 
 func (driver *cosFs) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
 	}
-	options := &cos.InitiateMultipartUploadOptions{}
-	if o.ContentType != "" {
-		options.ContentType = o.ContentType
+	options := &cos.InitiateMultipartUploadOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
+			ContentType:     o.ContentType,
+			ContentEncoding: o.ContentEncoding,
+		},
+	}
+	if o.Metadata != nil {
+		options.XCosMetaXXX = &http.Header{}
+		for k, v := range o.Metadata {
+			options.XCosMetaXXX.Set(fmt.Sprintf("x-cos-meta-%s", k), fmt.Sprintf("%v", v))
+		}
 	}
-	res, _, err := driver.client.Object.InitiateMultipartUpload(ctx, path, options)
+	res, _, err := driver.client.Object.InitiateMultipartUpload(ctx, key, options)
 	if err != nil {
-		return "", err
+		return "", wrapError("InitMultipartUpload", path, err)
 	}
 	return res.UploadID, nil
 }
 
 func (driver *cosFs) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
-	path = driver.path(path)
-	res, err := driver.client.Object.UploadPart(ctx, path, uploadID, partNumber, data, nil)
+	key := driver.path(path)
+	res, err := driver.client.Object.UploadPart(ctx, key, uploadID, partNumber, data, nil)
 	if err != nil {
-		return "", err
+		return "", wrapError("UploadPart", path, err)
 	}
 	return res.Header.Get("ETag"), nil
 }
 
 func (driver *cosFs) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
-	path = driver.path(path)
+	key := driver.path(path)
 	opt := &cos.CompleteMultipartUploadOptions{
 		Parts: make([]cos.Object, len(parts)),
 	}
@@ -65,21 +65,21 @@ func (driver *cosFs) CompleteMultipartUpload(ctx context.Context, path string, u
 			ETag:       part.ETag,
 		}
 	}
-	_, _, err := driver.client.Object.CompleteMultipartUpload(ctx, path, uploadID, opt)
-	return err
+	_, _, err := driver.client.Object.CompleteMultipartUpload(ctx, key, uploadID, opt)
+	return wrapError("CompleteMultipartUpload", path, err)
 }
 
 func (driver *cosFs) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
-	path = driver.path(path)
-	_, err := driver.client.Object.AbortMultipartUpload(ctx, path, uploadID)
-	return err
+	key := driver.path(path)
+	_, err := driver.client.Object.AbortMultipartUpload(ctx, key, uploadID)
+	return wrapError("AbortMultipartUpload", path, err)
 }
 
 func (driver *cosFs) ListMultipartUploads(ctx context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
 	opt := &cos.ListMultipartUploadsOptions{}
 	v, _, err := driver.client.Bucket.ListMultipartUploads(ctx, opt)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("ListMultipartUploads", "", err)
 	}
 
 	result := make([]fs.MultipartUploadInfo, len(v.Uploads))
@@ -95,11 +95,11 @@ func (driver *cosFs) ListMultipartUploads(ctx context.Context, opts ...fs.Option
 }
 
 func (driver *cosFs) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
-	path = driver.path(path)
+	key := driver.path(path)
 	opt := &cos.ObjectListPartsOptions{}
-	v, _, err := driver.client.Object.ListParts(ctx, path, uploadID, opt)
+	v, _, err := driver.client.Object.ListParts(ctx, key, uploadID, opt)
 	if err != nil {
-		return nil, err
+		return nil, wrapError("ListUploadedParts", path, err)
 	}
 
 	parts := make([]fs.MultipartPart, len(v.Parts))
@@ -1,7 +1,6 @@
 package txcos
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -11,58 +10,48 @@ import (
 	"github.com/tencentyun/cos-go-sdk-v5"
 )
 
+// cosWriter 实现 io.WriteCloser 接口，数据超过阈值后自动切换为分片上传
 type cosWriter struct {
-	ctx         context.Context
-	client      *cos.Client
-	path        string
-	buffer      *bytes.Buffer
-	metadata    fs.Metadata
-	contentType string
+	io.WriteCloser
+	ctx    context.Context
+	client *cos.Client
+	path   string
 }
 
-func newCosWriter(ctx context.Context, client *cos.Client, path string, opts ...fs.Option) *cosWriter {
+func newCosWriter(ctx context.Context, driver *cosFs, path string, opts ...fs.Option) *cosWriter {
+	return &cosWriter{
+		WriteCloser: fs.NewStreamWriter(ctx, driver, path, driver.putObject("Create", path, opts...), opts...),
+		ctx:         ctx,
+		client:      driver.client,
+		path:        driver.path(path),
+	}
+}
+
+// putObject 返回单次上传完整文件的函数
+func (driver *cosFs) putObject(op, path string, opts ...fs.Option) fs.PutFunc {
 	o := &fs.Options{}
 	for _, opt := range opts {
 		opt(o)
 	}
 
-	writer := &cosWriter{
-		ctx:    ctx,
-		client: client,
-		path:   path,
-		buffer: bytes.NewBuffer(nil),
-	}
-
-	if o.ContentType != "" {
-		writer.contentType = o.ContentType
-	}
-	if o.Metadata != nil {
-		writer.metadata = o.Metadata
-	}
-
-	return writer
-}
-
-func (w *cosWriter) Write(p []byte) (n int, err error) {
-	return w.buffer.Write(p)
-}
-
-func (w *cosWriter) Close() error {
-	opt := &cos.ObjectPutOptions{}
-	if w.contentType != "" {
-		opt.ObjectPutHeaderOptions = &cos.ObjectPutHeaderOptions{
-			ContentType: w.contentType,
+	return func(ctx context.Context, body io.Reader, size int64) error {
+		opt := &cos.ObjectPutOptions{
+			ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
+				ContentType:     o.ContentType,
+				ContentEncoding: o.ContentEncoding,
+				ContentLength:   size,
+			},
 		}
-	}
-	if w.metadata != nil {
-		opt.XCosMetaXXX = &http.Header{}
-		for k, v := range w.metadata {
-			opt.XCosMetaXXX.Set(fmt.Sprintf("x-cos-meta-%s", k), fmt.Sprintf("%v", v))
+		if o.Metadata != nil {
+			opt.XCosMetaXXX = &http.Header{}
+			for k, v := range o.Metadata {
+				opt.XCosMetaXXX.Set(fmt.Sprintf("x-cos-meta-%s", k), fmt.Sprintf("%v", v))
+			}
 		}
-	}
 
-	_, err := w.client.Object.Put(w.ctx, w.path, bytes.NewReader(w.buffer.Bytes()), opt)
-	return err
+		_, err := driver.client.Object.Put(ctx, driver.path(path), body, opt)
+		return wrapError(op, path, err)
+	}
 }
 
 type cosReadWriter struct {
@@ -70,9 +59,9 @@ type cosReadWriter struct {
 	reader io.ReadCloser
 }
 
-func newCosReadWriter(ctx context.Context, client *cos.Client, path string, opts ...fs.Option) *cosReadWriter {
+func newCosReadWriter(ctx context.Context, driver *cosFs, path string, opts ...fs.Option) *cosReadWriter {
 	return &cosReadWriter{
-		cosWriter: newCosWriter(ctx, client, path, opts...),
+		cosWriter: newCosWriter(ctx, driver, path, opts...),
 	}
 }
 
@@ -80,11 +69,8 @@ func (rw *cosReadWriter) Read(p []byte) (n int, err error) {
 	if rw.reader == nil {
 		output, err := rw.client.Object.Get(rw.ctx, rw.path, nil)
 		if err != nil {
-			return 0, err
+			return 0, wrapError("OpenFile", rw.path, err)
 		}
-		defer func() {
-			_ = output.Body.Close()
-		}()
 		rw.reader = output.Body
 	}
 	return rw.reader.Read(p)
@@ -0,0 +1,190 @@
+// Package encrypt 为文件系统提供客户端信封加密，数据在离开进程前使用 AES-256-GCM 分段加密
+package encrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/goairix/fs"
+)
+
+const (
+	Algorithm   = "AES-256-GCM-64K"      // 加密算法：AES-256-GCM，明文按 SegmentSize 分段加密
+	SegmentSize = 64 << 10               // 每段明文的大小，最后一段可以更短
+	MetaAlg     = "fs-encryption"        // 记录加密算法的元数据键
+	MetaKey     = "fs-encryption-key"    // 记录加密后的数据密钥的元数据键，值为 base64 编码
+	keySize     = 32                     // 数据密钥的长度
+	nonceSize   = 12                     // 每段密文前附加的 nonce 长度
+	tagSize     = 16                     // 每段密文附加的认证标签长度
+	overhead    = nonceSize + tagSize    // 每段密文比明文多出的长度
+	segmentCost = SegmentSize + overhead // 每段完整密文的大小
+)
+
+var (
+	ErrNotEncrypted = errors.New("encrypt: object is not encrypted")       // 文件缺少加密元数据
+	ErrDecrypt      = errors.New("encrypt: message authentication failed") // 密文被篡改、截断或密钥不匹配
+)
+
+// KeyProvider 加密与解密数据密钥，每个文件使用随机生成的数据密钥加密，
+// 加密后的数据密钥保存在文件的元数据中，可以基于 KMS 等密钥管理服务实现
+type KeyProvider interface {
+	// WrapKey 加密数据密钥
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	// UnwrapKey 解密 WrapKey 返回的数据
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// aesKeyProvider 使用本地主密钥加密数据密钥
+type aesKeyProvider struct {
+	aead cipher.AEAD
+}
+
+// NewAESKeyProvider 返回使用 32 字节的主密钥通过 AES-256-GCM 加密数据密钥的 KeyProvider
+func NewAESKeyProvider(masterKey []byte) (KeyProvider, error) {
+	if len(masterKey) != keySize {
+		return nil, fmt.Errorf("encrypt: master key must be %d bytes, got %d", keySize, len(masterKey))
+	}
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &aesKeyProvider{aead: aead}, nil
+}
+
+func (p *aesKeyProvider) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize(), p.aead.NonceSize()+len(key)+p.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return p.aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (p *aesKeyProvider) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < p.aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, sealed := wrapped[:p.aead.NonceSize()], wrapped[p.aead.NonceSize():]
+	key, err := p.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypter 生成与解密数据密钥，并保存进行中的分片上传的状态
+type encrypter struct {
+	keys    KeyProvider
+	uploads *uploads
+}
+
+func newEncrypter(keys KeyProvider) *encrypter {
+	return &encrypter{keys: keys, uploads: newUploads()}
+}
+
+// newKey 生成数据密钥，返回加密器与写入文件的元数据
+func (e *encrypter) newKey(ctx context.Context) (cipher.AEAD, fs.Metadata, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	wrapped, err := e.keys.WrapKey(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, fs.Metadata{
+		MetaAlg: Algorithm,
+		MetaKey: base64.StdEncoding.EncodeToString(wrapped),
+	}, nil
+}
+
+// openKey 从文件的元数据中解密数据密钥
+func (e *encrypter) openKey(ctx context.Context, metadata map[string]any) (cipher.AEAD, error) {
+	alg, ok := lookup(metadata, MetaAlg)
+	if !ok {
+		return nil, ErrNotEncrypted
+	}
+	if alg != Algorithm {
+		return nil, fmt.Errorf("encrypt: unsupported algorithm %q: %w", alg, fs.ErrUnsupported)
+	}
+	encoded, _ := lookup(metadata, MetaKey)
+	wrapped, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(wrapped) == 0 {
+		return nil, fmt.Errorf("%w: invalid data key", ErrNotEncrypted)
+	}
+	key, err := e.keys.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return newAEAD(key)
+}
+
+// writeOptions 在写入选项中加入加密元数据，未指定文件类型时按扩展名设置，避免驱动根据密文检测类型
+func writeOptions(path string, opts []fs.Option, encMeta fs.Metadata) []fs.Option {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	metadata := maps.Clone(o.Metadata)
+	if metadata == nil {
+		metadata = fs.Metadata{}
+	}
+	maps.Copy(metadata, encMeta)
+	opts = append(opts[:len(opts):len(opts)], fs.WithMetadata(metadata))
+	if o.ContentType == "" {
+		if contentType := fs.TypeByExtension(path); contentType != "" {
+			opts = append(opts, fs.WithContentType(contentType))
+		}
+	}
+	return opts
+}
+
+// isEncMeta 判断是否为加密元数据，驱动返回的元数据键的大小写可能与写入时不同
+func isEncMeta(key string) bool {
+	return strings.EqualFold(key, MetaAlg) || strings.EqualFold(key, MetaKey)
+}
+
+// lookup 不区分大小写查找元数据
+func lookup(metadata map[string]any, key string) (string, bool) {
+	for k, v := range metadata {
+		if strings.EqualFold(k, key) {
+			return fmt.Sprint(v), true
+		}
+	}
+	return "", false
+}
+
+// plainSize 根据密文大小计算明文大小
+func plainSize(size int64) int64 {
+	segments := (size + segmentCost - 1) / segmentCost
+	return max(size-segments*overhead, 0)
+}
+
+// cipherSize 根据明文大小计算密文大小，空文件也有一段只包含 nonce 与认证标签的密文
+func cipherSize(size int64) int64 {
+	segments := max((size+SegmentSize-1)/SegmentSize, 1)
+	return size + segments*overhead
+}
+
+// newError 返回加密装饰器自身产生的错误
+func newError(op, path string, err error) error {
+	return fs.NewPathError("encrypt", op, path, err, nil)
+}
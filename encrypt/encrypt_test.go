@@ -0,0 +1,291 @@
+package encrypt_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/goairix/fs/encrypt"
+)
+
+func newKeys(t *testing.T) encrypt.KeyProvider {
+	t.Helper()
+	keys, err := encrypt.NewAESKeyProvider(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func newMemory(t *testing.T) fs.FileSystem {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// randomData 返回 n 个随机字节
+func randomData(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(rand.IntN(256))
+	}
+	return b
+}
+
+func readAll(t *testing.T, fsys fs.FileSystem, path string, opts ...fs.Option) ([]byte, error) {
+	t.Helper()
+	r, err := fsys.Open(context.Background(), path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// replace 保留加密元数据，用 data 替换底层文件的密文
+func replace(t *testing.T, m fs.FileSystem, path string, data []byte) {
+	t.Helper()
+	ctx := context.Background()
+	metadata, err := m.GetMetadata(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Uploader().Upload(ctx, path, bytes.NewReader(data), fs.WithMetadata(metadata)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	m := newMemory(t)
+	fsys := encrypt.New(m, newKeys(t))
+	for _, size := range []int{0, 1, encrypt.SegmentSize - 1, encrypt.SegmentSize, encrypt.SegmentSize + 1, 200000} {
+		data := randomData(size)
+		w, err := fsys.Create(ctx, "a.bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := fsys.Uploader().Upload(ctx, "b.bin", iotest.HalfReader(bytes.NewReader(data))); err != nil {
+			t.Fatal(err)
+		}
+
+		raw, err := readAll(t, m, "a.bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size >= 16 && bytes.Contains(raw, data[:16]) {
+			t.Fatalf("size %d: stored data contains the plaintext", size)
+		}
+		for _, path := range []string{"a.bin", "b.bin"} {
+			if got, err := readAll(t, fsys, path); err != nil || !bytes.Equal(got, data) {
+				t.Fatalf("size %d: read %s: %d bytes, %v", size, path, len(got), err)
+			}
+			if info, err := fsys.Stat(ctx, path); err != nil || info.Size() != int64(size) {
+				t.Fatalf("size %d: Stat(%s) = %v, %v", size, path, info, err)
+			}
+		}
+
+		// 跨段的范围读取
+		for _, rng := range [][2]int64{{0, 10}, {encrypt.SegmentSize - 5, 10}, {int64(size) / 2, 0}} {
+			off, length := min(rng[0], int64(size)), rng[1]
+			end := int64(size)
+			if length > 0 {
+				end = min(end, off+length)
+			}
+			got, err := readAll(t, fsys, "a.bin", fs.WithRange(off, length))
+			if err != nil || !bytes.Equal(got, data[off:end]) {
+				t.Fatalf("size %d: range %d+%d read %d bytes, %v", size, off, length, len(got), err)
+			}
+		}
+		r, err := fs.OpenReader(ctx, fsys, "a.bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size >= encrypt.SegmentSize+10 {
+			buf := make([]byte, 20)
+			off := int64(encrypt.SegmentSize - 10)
+			if n, err := r.ReadAt(buf, off); n != len(buf) || err != nil || !bytes.Equal(buf, data[off:off+20]) {
+				t.Fatalf("size %d: ReadAt across segments: %d, %v", size, n, err)
+			}
+		}
+		if _, err := r.Seek(int64(size)/3, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := io.ReadAll(r); err != nil || !bytes.Equal(got, data[size/3:]) || r.Size() != int64(size) {
+			t.Fatalf("size %d: read after Seek: %d bytes, %v", size, len(got), err)
+		}
+		_ = r.Close()
+	}
+}
+
+func TestTamper(t *testing.T) {
+	m := newMemory(t)
+	fsys := encrypt.New(m, newKeys(t))
+	data := randomData(100000)
+	if err := fsys.Uploader().Upload(context.Background(), "a.bin", bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := readAll(t, m, "a.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, off := range []int{0, 100, encrypt.SegmentSize + 40, len(raw) - 1} {
+		bad := bytes.Clone(raw)
+		bad[off] ^= 1
+		replace(t, m, "a.bin", bad)
+		if _, err := readAll(t, fsys, "a.bin"); !errors.Is(err, encrypt.ErrDecrypt) {
+			t.Errorf("flipped byte %d: %v, want ErrDecrypt", off, err)
+		}
+	}
+
+	// 使用其他主密钥无法解密
+	replace(t, m, "a.bin", raw)
+	other, err := encrypt.NewAESKeyProvider(bytes.Repeat([]byte{8}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := encrypt.New(m, other).Open(context.Background(), "a.bin"); !errors.Is(err, encrypt.ErrDecrypt) {
+		t.Errorf("open with another key: %v, want ErrDecrypt", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	m := newMemory(t)
+	fsys := encrypt.New(m, newKeys(t))
+	data := randomData(2*encrypt.SegmentSize + 100)
+	if err := fsys.Uploader().Upload(context.Background(), "a.bin", bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := readAll(t, m, "a.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := 100 + 12 + 16 // 最后一段：100 字节明文加 nonce 与认证标签
+	for name, truncated := range map[string][]byte{
+		"last segment":    raw[:len(raw)-last],
+		"last bytes":      raw[:len(raw)-10],
+		"partial segment": raw[:encrypt.SegmentSize],
+		"empty":           nil,
+	} {
+		replace(t, m, "a.bin", truncated)
+		if _, err := readAll(t, fsys, "a.bin"); !errors.Is(err, encrypt.ErrDecrypt) {
+			t.Errorf("dropped %s: %v, want ErrDecrypt", name, err)
+		}
+	}
+}
+
+func TestNotEncrypted(t *testing.T) {
+	ctx := context.Background()
+	m := newMemory(t)
+	fsys := encrypt.New(m, newKeys(t))
+	if err := m.Uploader().Upload(ctx, "plain.txt", bytes.NewReader([]byte("hello"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Open(ctx, "plain.txt"); !errors.Is(err, encrypt.ErrNotEncrypted) {
+		t.Fatalf("Open unencrypted file: %v, want ErrNotEncrypted", err)
+	}
+	if _, err := fs.OpenReader(ctx, fsys, "plain.txt"); !errors.Is(err, encrypt.ErrNotEncrypted) {
+		t.Fatalf("OpenReader unencrypted file: %v, want ErrNotEncrypted", err)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	ctx := context.Background()
+	m := newMemory(t)
+	fsys := encrypt.New(m, newKeys(t))
+	err := fsys.Uploader().Upload(ctx, "a.txt", bytes.NewReader([]byte("hello")), fs.WithMetadata(fs.Metadata{"owner": "alice"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := fsys.GetMetadata(ctx, "a.txt")
+	if err != nil || len(metadata) != 1 || metadata["owner"] != "alice" {
+		t.Fatalf("GetMetadata = %v, %v, want only the user metadata", metadata, err)
+	}
+
+	// 设置元数据保留加密元数据，不能覆盖
+	err = fsys.SetMetadata(ctx, "a.txt", map[string]interface{}{"owner": "bob", encrypt.MetaKey: "forged"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readAll(t, fsys, "a.txt"); err != nil || string(got) != "hello" {
+		t.Fatalf("read after SetMetadata = %q, %v", got, err)
+	}
+	if metadata, _ := fsys.GetMetadata(ctx, "a.txt"); metadata["owner"] != "bob" {
+		t.Fatalf("GetMetadata after SetMetadata = %v", metadata)
+	}
+}
+
+// recordingUploader 记录每次上传的分片密文
+type recordingUploader struct {
+	fs.Uploader
+	parts [][]byte
+}
+
+func (u *recordingUploader) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return "", err
+	}
+	u.parts = append(u.parts, b)
+	return u.Uploader.UploadPart(ctx, path, uploadID, partNumber, bytes.NewReader(b), opts...)
+}
+
+func TestUploadPartRetryUsesFreshNonce(t *testing.T) {
+	ctx := context.Background()
+	m := newMemory(t)
+	rec := &recordingUploader{Uploader: m.Uploader()}
+	u := encrypt.NewUploader(rec, newKeys(t))
+
+	id, err := u.InitMultipartUpload(ctx, "a.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte{'a'}, 100)
+	if _, err := u.UploadPart(ctx, "a.bin", id, 1, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	etag, err := u.UploadPart(ctx, "a.bin", id, 1, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.parts) != 2 {
+		t.Fatalf("recorded %d parts, want 2", len(rec.parts))
+	}
+	// 相同的明文得到相同的密文说明重新上传复用了 nonce
+	if bytes.Equal(rec.parts[0], rec.parts[1]) {
+		t.Fatal("re-uploaded part reused the keystream of the previous attempt")
+	}
+
+	err = u.CompleteMultipartUpload(ctx, "a.bin", id, []fs.MultipartPart{{PartNumber: 1, ETag: etag}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := encrypt.New(m, newKeys(t)).Open(ctx, "a.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("read %q, want %q", got, data)
+	}
+}
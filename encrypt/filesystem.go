@@ -0,0 +1,250 @@
+package encrypt
+
+import (
+	"context"
+	"crypto/cipher"
+	"io"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/goairix/fs"
+)
+
+// fileSystem 客户端加密的文件系统
+type fileSystem struct {
+	fs.Wrapper
+	e *encrypter
+}
+
+// New 返回客户端加密的文件系统。每个文件使用随机生成的数据密钥分段加密，
+// 数据密钥经 keys 加密后与算法一起保存在文件的元数据中，因此驱动需要支持自定义元数据。
+// Open 与 OpenReader 透明解密并支持范围读取，Stat、List 与 Walk 返回明文大小
+func New(fsys fs.FileSystem, keys KeyProvider) fs.FileSystem {
+	return &fileSystem{Wrapper: fs.Wrapper{FileSystem: fsys}, e: newEncrypter(keys)}
+}
+
+// openKey 读取文件的元数据并解密数据密钥
+func (f *fileSystem) openKey(ctx context.Context, op, path string) (cipher.AEAD, error) {
+	metadata, err := f.FileSystem.GetMetadata(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := f.e.openKey(ctx, metadata)
+	if err != nil {
+		return nil, newError(op, path, err)
+	}
+	return aead, nil
+}
+
+func (f *fileSystem) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
+	infos, err := f.FileSystem.List(ctx, path, opts...)
+	for i, info := range infos {
+		infos[i] = plainInfo(info)
+	}
+	return infos, err
+}
+
+func (f *fileSystem) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	aead, encMeta, err := f.e.newKey(ctx)
+	if err != nil {
+		return nil, newError("Create", path, err)
+	}
+	writer, err := f.FileSystem.Create(ctx, path, writeOptions(path, opts, encMeta)...)
+	if err != nil {
+		return nil, err
+	}
+	return newEncryptWriter(writer, aead), nil
+}
+
+func (f *fileSystem) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	aead, err := f.openKey(ctx, "Open", path)
+	if err != nil {
+		return nil, err
+	}
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Range == nil {
+		body, err := f.FileSystem.Open(ctx, path, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &readCloser{Reader: newDecryptReader(body, aead, 0, -1), Closer: body}, nil
+	}
+
+	// 范围读取时读取覆盖该范围的完整的段，需要密文大小确定最后一段
+	info, err := f.FileSystem.Stat(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	size := plainSize(info.Size())
+	offset, end := o.Range.Offset, size
+	if o.Range.Length > 0 {
+		end = min(offset+o.Range.Length, size)
+	}
+	if offset >= end {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	first, last := offset/SegmentSize, (end-1)/SegmentSize
+	ctOff := first * segmentCost
+	ctEnd := min((last+1)*segmentCost, info.Size())
+	body, err := f.FileSystem.Open(ctx, path, append(opts[:len(opts):len(opts)], fs.WithRange(ctOff, ctEnd-ctOff))...)
+	if err != nil {
+		return nil, err
+	}
+	reader := newDecryptReader(body, aead, uint64(first), (info.Size()+segmentCost-1)/segmentCost-1)
+	reader.skip = int(offset - first*SegmentSize)
+	reader.remaining = end - offset
+	return &readCloser{Reader: reader, Closer: body}, nil
+}
+
+// OpenFile 只读打开时透明解密，写入时重新加密整个文件，不支持 os.O_RDWR 与 os.O_APPEND
+func (f *fileSystem) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		reader, err := f.Open(ctx, path, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &readOnlyFile{ReadCloser: reader}, nil
+	}
+	if flag&(os.O_RDWR|os.O_APPEND) != 0 {
+		return nil, newError("OpenFile", path, fs.ErrUnsupported)
+	}
+
+	aead, encMeta, err := f.e.newKey(ctx)
+	if err != nil {
+		return nil, newError("OpenFile", path, err)
+	}
+	file, err := f.FileSystem.OpenFile(ctx, path, flag|os.O_TRUNC, perm, writeOptions(path, opts, encMeta)...)
+	if err != nil {
+		return nil, err
+	}
+	return &writeOnlyFile{encryptWriter: newEncryptWriter(file, aead)}, nil
+}
+
+func (f *fileSystem) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	info, err := f.FileSystem.Stat(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return plainInfo(info), nil
+}
+
+// SetMetadata 保留文件的加密元数据，驱动设置元数据时会替换全部自定义元数据
+func (f *fileSystem) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	current, err := f.FileSystem.GetMetadata(ctx, path, opts...)
+	if err != nil {
+		return err
+	}
+	merged := make(map[string]interface{}, len(metadata)+2)
+	for k, v := range metadata {
+		if !isEncMeta(k) {
+			merged[k] = v
+		}
+	}
+	for _, key := range []string{MetaAlg, MetaKey} {
+		if v, ok := lookup(current, key); ok {
+			merged[key] = v
+		}
+	}
+	return f.FileSystem.SetMetadata(ctx, path, merged, opts...)
+}
+
+// GetMetadata 不返回加密元数据
+func (f *fileSystem) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]interface{}, error) {
+	metadata, err := f.FileSystem.GetMetadata(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	maps.DeleteFunc(metadata, func(k string, _ interface{}) bool {
+		return isEncMeta(k)
+	})
+	return metadata, nil
+}
+
+func (f *fileSystem) Uploader() fs.Uploader {
+	return &uploader{Uploader: f.FileSystem.Uploader(), e: f.e}
+}
+
+func (f *fileSystem) OpenReader(ctx context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	aead, err := f.openKey(ctx, "OpenReader", path)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := fs.OpenReader(ctx, f.FileSystem, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newRangeReader(reader, aead), nil
+}
+
+func (f *fileSystem) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	return fs.Walk(ctx, f.FileSystem, root, func(path string, info fs.FileInfo, err error) error {
+		if info != nil {
+			info = plainInfo(info)
+		}
+		return fn(path, info, err)
+	}, opts...)
+}
+
+func (f *fileSystem) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	result, err := fs.ListPage(ctx, f.FileSystem, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for i, info := range result.Files {
+		result.Files[i] = plainInfo(info)
+	}
+	return result, nil
+}
+
+// fileInfo 返回明文大小的文件信息
+type fileInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func plainInfo(info fs.FileInfo) fs.FileInfo {
+	if info.IsDir() {
+		return info
+	}
+	return &fileInfo{FileInfo: info, size: plainSize(info.Size())}
+}
+
+func (i *fileInfo) Size() int64 {
+	return i.size
+}
+
+// ETag 返回密文的 ETag，可以用于判断文件是否变化
+func (i *fileInfo) ETag() string {
+	if etagger, ok := i.FileInfo.(fs.ETagger); ok {
+		return etagger.ETag()
+	}
+	return ""
+}
+
+// readCloser 解密读取并关闭驱动的读取流
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// readOnlyFile 只读打开的文件
+type readOnlyFile struct {
+	io.ReadCloser
+}
+
+func (f *readOnlyFile) Write([]byte) (int, error) {
+	return 0, os.ErrPermission
+}
+
+// writeOnlyFile 只写打开的文件
+type writeOnlyFile struct {
+	*encryptWriter
+}
+
+func (f *writeOnlyFile) Read([]byte) (int, error) {
+	return 0, os.ErrPermission
+}
@@ -0,0 +1,126 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+
+	"github.com/goairix/fs"
+)
+
+// rangeReader 基于密文的 fs.RangeReader 实现的解密 fs.RangeReader，只读取并解密需要的段
+type rangeReader struct {
+	ct   fs.RangeReader
+	aead cipher.AEAD
+	size int64 // 明文大小
+	last int64 // 最后一段的序号
+
+	offset       int64          // 当前读取位置
+	stream       *decryptReader // 从 streamOffset 开始的顺序解密流
+	streamOffset int64
+}
+
+func newRangeReader(ct fs.RangeReader, aead cipher.AEAD) *rangeReader {
+	size := ct.Size()
+	return &rangeReader{
+		ct:   ct,
+		aead: aead,
+		size: plainSize(size),
+		last: max((size+segmentCost-1)/segmentCost-1, 0),
+	}
+}
+
+func (r *rangeReader) Size() int64 {
+	return r.size
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Seek 后读取位置与当前解密流不一致时从所在的段重新读取
+	if r.stream == nil || r.streamOffset != r.offset {
+		segment := r.offset / SegmentSize
+		if _, err := r.ct.Seek(segment*segmentCost, io.SeekStart); err != nil {
+			return 0, err
+		}
+		r.stream = newDecryptReader(r.ct, r.aead, uint64(segment), r.last)
+		r.stream.skip = int(r.offset - segment*SegmentSize)
+		r.streamOffset = r.offset
+	}
+
+	n, err := r.stream.Read(p)
+	r.offset += int64(n)
+	r.streamOffset += int64(n)
+	if err == io.EOF && r.offset < r.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (r *rangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("encrypt: negative offset")
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	end := min(off+int64(len(p)), r.size)
+	first, last := off/SegmentSize, (end-1)/SegmentSize
+	ctOff := first * segmentCost
+	buf := make([]byte, min((last+1)*segmentCost, r.ct.Size())-ctOff)
+	if n, err := r.ct.ReadAt(buf, ctOff); n < len(buf) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+
+	n := 0
+	for segment := first; segment <= last; segment++ {
+		data := buf[(segment-first)*segmentCost : min((segment-first+1)*segmentCost, int64(len(buf)))]
+		plain, err := openSegment(r.aead, data, uint64(segment), segment == r.last)
+		if err != nil {
+			return n, err
+		}
+		if segment == first {
+			plain = plain[min(off-first*SegmentSize, int64(len(plain))):]
+		}
+		n += copy(p[n:end-off], plain)
+	}
+	if int64(len(p)) > end-off {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.offset + offset
+	case io.SeekEnd:
+		abs = r.size + offset
+	default:
+		return 0, errors.New("encrypt: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("encrypt: negative position")
+	}
+	r.offset = abs
+	return abs, nil
+}
+
+func (r *rangeReader) Close() error {
+	return r.ct.Close()
+}
@@ -0,0 +1,351 @@
+package encrypt
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// newSalt 返回加密流随机生成的 salt，每次加密（包括重新上传同一分片）使用新的 salt，
+// 相同的数据密钥不会以相同的 nonce 加密不同的明文。crypto/rand.Read 不会返回错误
+func newSalt() []byte {
+	salt := make([]byte, nonceSize)
+	_, _ = rand.Read(salt)
+	return salt
+}
+
+// additionalData 返回第 index 段的附加数据，最后一段带有结束标记，用于发现截断与重排
+func additionalData(index uint64, final bool) []byte {
+	ad := make([]byte, 9)
+	binary.BigEndian.PutUint64(ad, index)
+	if final {
+		ad[8] = 1
+	}
+	return ad
+}
+
+// sealSegment 加密第 index 段明文并追加到 dst，密文前附加 nonce。
+// nonce 为 salt 与段序号的异或，同一加密流 Seek 后重新加密得到相同的密文
+func sealSegment(dst []byte, aead cipher.AEAD, salt []byte, plain []byte, index uint64, final bool) []byte {
+	n := len(dst)
+	dst = append(dst, salt...)
+	nonce := dst[n : n+nonceSize]
+	for i := range 8 {
+		nonce[nonceSize-1-i] ^= byte(index >> (8 * i))
+	}
+	return aead.Seal(dst, nonce, plain, additionalData(index, final))
+}
+
+// openSegment 原地解密一段密文
+func openSegment(aead cipher.AEAD, segment []byte, index uint64, final bool) ([]byte, error) {
+	if len(segment) < overhead {
+		return nil, ErrDecrypt
+	}
+	nonce, sealed := segment[:nonceSize], segment[nonceSize:]
+	plain, err := aead.Open(sealed[:0], nonce, sealed, additionalData(index, final))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+// encryptWriter 分段加密写入的数据，关闭时写入最后一段
+type encryptWriter struct {
+	w      io.WriteCloser
+	aead   cipher.AEAD
+	salt   []byte
+	index  uint64
+	buf    []byte
+	sealed []byte
+	err    error
+}
+
+func newEncryptWriter(w io.WriteCloser, aead cipher.AEAD) *encryptWriter {
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		salt:   newSalt(),
+		buf:    make([]byte, 0, SegmentSize),
+		sealed: make([]byte, 0, segmentCost),
+	}
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	written := 0
+	for len(p) > 0 {
+		// 缓冲区满且还有数据时才写出，最后一段留到关闭时带结束标记写出
+		if len(w.buf) == SegmentSize {
+			if w.err = w.flush(false); w.err != nil {
+				return written, w.err
+			}
+		}
+		n := min(len(p), SegmentSize-len(w.buf))
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (w *encryptWriter) flush(final bool) error {
+	w.sealed = sealSegment(w.sealed[:0], w.aead, w.salt, w.buf, w.index, final)
+	w.index++
+	w.buf = w.buf[:0]
+	_, err := w.w.Write(w.sealed)
+	return err
+}
+
+func (w *encryptWriter) Close() error {
+	if w.err == nil {
+		w.err = w.flush(true)
+	}
+	err := w.w.Close()
+	if w.err != nil {
+		return w.err
+	}
+	w.err = errors.New("encrypt: write to closed file")
+	return err
+}
+
+// encryptReader 分段加密上传的数据。分片模式下只有不足一段的最后一段带结束标记，
+// 原 io.Reader 实现 io.Seeker 时支持 Seek，驱动与重试依赖于此确定长度或回到起始位置
+type encryptReader struct {
+	src    *bufio.Reader
+	aead   cipher.AEAD
+	salt   []byte
+	part   bool   // 分片模式
+	start  uint64 // 第一段的序号
+	index  uint64 // 下一段的序号
+	buf    []byte
+	sealed []byte
+	out    []byte // 尚未读取的密文
+	final  bool   // 已生成带结束标记的一段
+	eof    bool
+
+	raw      io.Reader // 原 io.Reader
+	seeker   io.Seeker
+	srcStart int64 // 原 io.Reader 的起始位置
+	size     int64 // 密文大小
+	skip     int64 // Seek 到段中间时需要跳过的密文字节数
+}
+
+func newEncryptReader(r io.Reader, aead cipher.AEAD, start uint64, part bool) (*encryptReader, error) {
+	er := &encryptReader{
+		src:    bufio.NewReader(r),
+		aead:   aead,
+		salt:   newSalt(),
+		part:   part,
+		start:  start,
+		index:  start,
+		buf:    make([]byte, SegmentSize),
+		sealed: make([]byte, 0, segmentCost),
+	}
+	if seeker, ok := r.(io.Seeker); ok {
+		cur, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = seeker.Seek(cur, io.SeekStart); err != nil {
+			return nil, err
+		}
+		er.raw, er.seeker, er.srcStart, er.size = r, seeker, cur, cipherSize(end-cur)
+	}
+	return er, nil
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		if err := r.fill(); err != nil {
+			return 0, err
+		}
+		skip := min(r.skip, int64(len(r.out)))
+		r.out, r.skip = r.out[skip:], r.skip-skip
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// fill 读取并加密下一段
+func (r *encryptReader) fill() error {
+	n, err := io.ReadFull(r.src, r.buf[:SegmentSize])
+	final := false
+	switch {
+	case err == nil:
+		if !r.part {
+			_, peekErr := r.src.Peek(1)
+			if peekErr != nil && peekErr != io.EOF {
+				return peekErr
+			}
+			final = peekErr == io.EOF
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		final = true
+	case err == io.EOF:
+		// 没有任何数据时生成一段空的最后一段
+		if r.index != r.start {
+			r.eof = true
+			return nil
+		}
+		final = true
+	default:
+		return err
+	}
+	r.sealed = sealSegment(r.sealed[:0], r.aead, r.salt, r.buf[:n], r.index, final)
+	r.out = r.sealed
+	r.index++
+	r.final, r.eof = final, final
+	return nil
+}
+
+// segments 返回已生成的段数
+func (r *encryptReader) segments() uint64 {
+	return r.index - r.start
+}
+
+// encryptSeeker 可以 Seek 的 encryptReader
+type encryptSeeker struct {
+	*encryptReader
+	pos int64
+}
+
+func (r *encryptSeeker) Read(p []byte) (int, error) {
+	n, err := r.encryptReader.Read(p)
+	r.pos += int64(n)
+	return n, err
+}
+
+func (r *encryptSeeker) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.pos + offset
+	case io.SeekEnd:
+		abs = r.size + offset
+	default:
+		return 0, errors.New("encrypt: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("encrypt: negative position")
+	}
+	if abs == r.pos {
+		return abs, nil
+	}
+
+	segment := abs / segmentCost
+	if _, err := r.seeker.Seek(r.srcStart+segment*SegmentSize, io.SeekStart); err != nil {
+		return 0, err
+	}
+	r.src.Reset(r.raw)
+	r.index = r.start + uint64(segment)
+	r.out, r.final, r.eof = nil, false, abs >= r.size
+	r.skip = abs - segment*segmentCost
+	r.pos = abs
+	return abs, nil
+}
+
+// uploadReader 返回加密上传数据的 io.Reader，原 io.Reader 实现 io.Seeker 时返回的 io.Reader 也实现
+func uploadReader(er *encryptReader) io.Reader {
+	if er.seeker != nil {
+		return &encryptSeeker{encryptReader: er}
+	}
+	return er
+}
+
+// decryptReader 分段解密读取的密文
+type decryptReader struct {
+	src       *bufio.Reader
+	aead      cipher.AEAD
+	index     uint64 // 下一段的序号
+	last      int64  // 最后一段的序号，为 -1 时读到末尾才能确定
+	skip      int    // 第一段需要跳过的明文字节数
+	remaining int64  // 剩余需要返回的明文字节数，为 -1 时不限制
+	buf       []byte
+	plain     []byte // 尚未读取的明文
+	done      bool   // 已解密最后一段
+	err       error
+}
+
+func newDecryptReader(r io.Reader, aead cipher.AEAD, index uint64, last int64) *decryptReader {
+	return &decryptReader{
+		src:       bufio.NewReader(r),
+		aead:      aead,
+		index:     index,
+		last:      last,
+		remaining: -1,
+		buf:       make([]byte, segmentCost),
+	}
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	for len(r.plain) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.next()
+	}
+	if r.remaining >= 0 && int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	if r.remaining >= 0 {
+		r.remaining -= int64(n)
+	}
+	return n, nil
+}
+
+// next 读取并解密下一段
+func (r *decryptReader) next() error {
+	n, err := io.ReadFull(r.src, r.buf)
+	final := false
+	switch {
+	case err == nil:
+		if r.last >= 0 {
+			final = int64(r.index) == r.last
+		} else {
+			_, peekErr := r.src.Peek(1)
+			if peekErr != nil && peekErr != io.EOF {
+				return peekErr
+			}
+			final = peekErr == io.EOF
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		final = true
+	case err == io.EOF:
+		// 最后一段之前没有更多数据，文件被截断
+		return ErrDecrypt
+	default:
+		return err
+	}
+
+	plain, err := openSegment(r.aead, r.buf[:n], r.index, final)
+	if err != nil {
+		return err
+	}
+	r.index++
+	skip := min(r.skip, len(plain))
+	r.plain, r.skip, r.done = plain[skip:], r.skip-skip, final
+	return nil
+}
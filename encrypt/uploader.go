@@ -0,0 +1,197 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+// uploadTTL 分片上传的加密状态超过该时间未使用时丢弃，未完成也未取消的上传不会一直占用内存
+const uploadTTL = 24 * time.Hour
+
+// upload 进行中的分片上传的加密状态
+type upload struct {
+	used      time.Time  // 最后使用的时间，由 uploads 的锁保护
+	mu        sync.Mutex // 分片按顺序上传，上传期间持有
+	aead      cipher.AEAD
+	lastPart  int    // 最后上传的分片号
+	lastStart uint64 // 最后上传的分片第一段的序号
+	next      uint64 // 下一个分片第一段的序号
+	final     bool   // 最后上传的分片以带结束标记的段结尾
+	// terminator 完成上传时追加的只包含结束标记的分片，完成失败后重试时复用
+	terminator *fs.MultipartPart
+}
+
+// uploads 按上传 ID 保存分片上传的加密状态
+type uploads struct {
+	mu sync.Mutex
+	m  map[string]*upload
+}
+
+func newUploads() *uploads {
+	return &uploads{m: make(map[string]*upload)}
+}
+
+func (u *uploads) get(uploadID string) (*upload, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := time.Now()
+	u.expire(now)
+	st, ok := u.m[uploadID]
+	if ok {
+		st.used = now
+	}
+	return st, ok
+}
+
+func (u *uploads) set(uploadID string, st *upload) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := time.Now()
+	u.expire(now)
+	st.used = now
+	u.m[uploadID] = st
+}
+
+// expire 丢弃超过 uploadTTL 未使用的加密状态，调用时需持有锁
+func (u *uploads) expire(now time.Time) {
+	for uploadID, st := range u.m {
+		if now.Sub(st.used) > uploadTTL {
+			delete(u.m, uploadID)
+		}
+	}
+}
+
+func (u *uploads) delete(uploadID string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.m, uploadID)
+}
+
+// uploader 加密上传数据的上传器
+type uploader struct {
+	fs.Uploader
+	e *encrypter
+}
+
+// NewUploader 返回加密上传数据的上传器，分片上传的限制与 New 相同
+func NewUploader(u fs.Uploader, keys KeyProvider) fs.Uploader {
+	return &uploader{Uploader: u, e: newEncrypter(keys)}
+}
+
+func (u *uploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	aead, encMeta, err := u.e.newKey(ctx)
+	if err != nil {
+		return newError("Upload", path, err)
+	}
+	er, err := newEncryptReader(reader, aead, 0, false)
+	if err != nil {
+		return newError("Upload", path, err)
+	}
+	return u.Uploader.Upload(ctx, path, uploadReader(er), writeOptions(path, opts, encMeta)...)
+}
+
+func (u *uploader) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	aead, encMeta, err := u.e.newKey(ctx)
+	if err != nil {
+		return "", newError("InitMultipartUpload", path, err)
+	}
+	uploadID, err := u.Uploader.InitMultipartUpload(ctx, path, writeOptions(path, opts, encMeta)...)
+	if err != nil {
+		return "", err
+	}
+	u.e.uploads.set(uploadID, &upload{aead: aead})
+	return uploadID, nil
+}
+
+// UploadPart 加密并上传分片。分片需要按分片号递增的顺序依次上传，可以重新上传最后一个分片，
+// 每次加密使用新的 nonce；除最后一个分片外，分片大小需要是 SegmentSize 的整数倍
+func (u *uploader) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
+	st, ok := u.e.uploads.get(uploadID)
+	if !ok {
+		return "", newError("UploadPart", path, fmt.Errorf("encrypt: upload %s was not initiated by this uploader: %w", uploadID, fs.ErrNotExist))
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var start uint64
+	switch {
+	case partNumber == st.lastPart:
+		start = st.lastStart
+	case partNumber < st.lastPart:
+		return "", newError("UploadPart", path, fmt.Errorf("encrypt: part %d uploaded after part %d, parts must be uploaded in order", partNumber, st.lastPart))
+	case st.final:
+		return "", newError("UploadPart", path, fmt.Errorf("encrypt: part %d follows part %d whose size is not a multiple of %d bytes", partNumber, st.lastPart, SegmentSize))
+	default:
+		start = st.next
+	}
+
+	er, err := newEncryptReader(data, st.aead, start, true)
+	if err != nil {
+		return "", newError("UploadPart", path, err)
+	}
+	etag, err := u.Uploader.UploadPart(ctx, path, uploadID, partNumber, uploadReader(er), opts...)
+	if err != nil {
+		return "", err
+	}
+	st.lastPart, st.lastStart = partNumber, start
+	st.next, st.final, st.terminator = start+er.segments(), er.final, nil
+	return etag, nil
+}
+
+// CompleteMultipartUpload 完成分片上传，最后一个分片的大小是 SegmentSize 的整数倍时追加一个只包含结束标记的分片
+func (u *uploader) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	st, ok := u.e.uploads.get(uploadID)
+	if !ok {
+		return newError("CompleteMultipartUpload", path, fmt.Errorf("encrypt: upload %s was not initiated by this uploader: %w", uploadID, fs.ErrNotExist))
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	lastPart := 0
+	for _, part := range parts {
+		lastPart = max(lastPart, part.PartNumber)
+	}
+	if lastPart != st.lastPart {
+		return newError("CompleteMultipartUpload", path, fmt.Errorf("encrypt: parts end with part %d, want the last uploaded part %d", lastPart, st.lastPart))
+	}
+	if !st.final && st.terminator == nil {
+		segment := sealSegment(nil, st.aead, newSalt(), nil, st.next, true)
+		etag, err := u.Uploader.UploadPart(ctx, path, uploadID, st.lastPart+1, bytes.NewReader(segment))
+		if err != nil {
+			return err
+		}
+		st.terminator = &fs.MultipartPart{PartNumber: st.lastPart + 1, ETag: etag, Size: int64(len(segment))}
+	}
+	if st.terminator != nil {
+		parts = append(parts[:len(parts):len(parts)], *st.terminator)
+	}
+	if err := u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...); err != nil {
+		return err
+	}
+	u.e.uploads.delete(uploadID)
+	return nil
+}
+
+func (u *uploader) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
+	err := u.Uploader.AbortMultipartUpload(ctx, path, uploadID, opts...)
+	if err == nil {
+		u.e.uploads.delete(uploadID)
+	}
+	return err
+}
+
+// ListUploadedParts 返回的分片大小为明文大小
+func (u *uploader) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
+	parts, err := u.Uploader.ListUploadedParts(ctx, path, uploadID, opts...)
+	for i := range parts {
+		parts[i].Size = plainSize(parts[i].Size)
+	}
+	return parts, err
+}
@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"errors"
+	iofs "io/fs"
+	"net/http"
+)
+
+// 通用错误，各驱动会将底层 SDK 的错误转换为以下错误，可通过 errors.Is 进行判断
+var (
+	ErrNotExist           = iofs.ErrNotExist                     // 文件或目录不存在
+	ErrExist              = iofs.ErrExist                        // 文件或目录已存在
+	ErrPermission         = iofs.ErrPermission                   // 没有权限
+	ErrIsDir              = errors.New("is a directory")         // 目标是目录
+	ErrNotDir             = errors.New("not a directory")        // 目标不是目录
+	ErrPreconditionFailed = errors.New("precondition failed")    // 前置条件不满足
+	ErrUnsupported        = errors.ErrUnsupported                // 驱动不支持该操作
+	ErrPathEscapes        = errors.New("path escapes from root") // 路径超出根目录
+	ErrTemporary          = errors.New("temporary failure")      // 临时错误，如限流、超时或服务端错误，可以重试
+)
+
+// PathError 记录驱动操作失败时的操作名、路径与原始错误
+type PathError struct {
+	Op     string // 操作名称
+	Path   string // 文件路径
+	Driver string // 驱动名称
+	Err    error  // 驱动返回的原始错误
+
+	kind error // 原始错误对应的通用错误
+}
+
+// NewPathError 创建 PathError，kind 为原始错误对应的通用错误，无法识别时传 nil
+func NewPathError(driver, op, path string, err, kind error) *PathError {
+	return &PathError{
+		Op:     op,
+		Path:   path,
+		Driver: driver,
+		Err:    err,
+		kind:   kind,
+	}
+}
+
+func (e *PathError) Error() string {
+	return e.Driver + " " + e.Op + " " + e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap 返回驱动原始错误
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// Is 判断是否为指定的通用错误
+func (e *PathError) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
+// ErrorKindFromStatus 根据 HTTP 状态码返回对应的通用错误，无法识别时返回 nil
+func ErrorKindFromStatus(statusCode int) error {
+	switch statusCode {
+	case http.StatusNotFound:
+		return ErrNotExist
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return ErrPermission
+	case http.StatusConflict:
+		return ErrExist
+	case http.StatusPreconditionFailed:
+		return ErrPreconditionFailed
+	case http.StatusNotImplemented, http.StatusMethodNotAllowed:
+		return ErrUnsupported
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return ErrTemporary
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package event 在文件变化时发布事件，同步钩子可以在操作执行前拒绝操作，订阅者通过带缓冲的队列异步接收事件
+package event
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+// ErrVetoed 操作被钩子拒绝，可通过 errors.Is 判断，钩子返回的错误可通过 errors.Unwrap 获取
+var ErrVetoed = errors.New("operation vetoed")
+
+// Type 事件类型
+type Type string
+
+const (
+	Created            Type = "created"             // 创建或覆盖文件：Create、OpenFile 写入与 Upload
+	Removed            Type = "removed"             // 删除文件
+	Moved              Type = "moved"               // 移动或重命名文件或目录
+	Copied             Type = "copied"              // 复制文件
+	MetadataChanged    Type = "metadata_changed"    // 设置元数据
+	MultipartCompleted Type = "multipart_completed" // 完成分片上传
+	DirRemoved         Type = "dir_removed"         // 删除目录
+)
+
+// Event 文件变化事件
+type Event struct {
+	Type        Type
+	Path        string      // 文件或目录路径，Moved 与 Copied 为目标路径
+	From        string      // Moved 与 Copied 的源路径
+	Size        int64       // 文件大小，未知或为目录时为 -1
+	ContentType string      // 文件类型，未设置时按扩展名推断
+	Metadata    fs.Metadata // MetadataChanged 设置的元数据
+	Time        time.Time   // 钩子收到的事件为操作开始的时间，订阅者收到的事件为操作完成的时间
+}
+
+// Hook 同步钩子，在操作执行前调用，返回错误时拒绝操作，操作返回包装了该错误的 ErrVetoed。
+// 钩子收到的事件中 Create、OpenFile 与流式 Upload 的 Size 为 -1
+type Hook func(ctx context.Context, e Event) error
+
+// Bus 事件总线，多个文件系统可以共用同一个 Bus
+type Bus struct {
+	mu    sync.RWMutex
+	hooks []hook
+	subs  []*Subscription
+}
+
+type hook struct {
+	fn    Hook
+	types []Type
+}
+
+// NewBus 返回事件总线
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Hook 注册同步钩子，types 为空时接收全部类型的事件，多个钩子按注册顺序调用
+func (b *Bus) Hook(fn Hook, types ...Type) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.hooks = append(b.hooks, hook{fn: fn, types: types})
+}
+
+// Subscribe 注册异步订阅者，types 为空时接收全部类型的事件。
+// 事件进入容量为 buffer 的队列，由单独的 goroutine 按顺序调用 fn；
+// 队列已满时发布事件的操作等待队列有空位，等待期间操作的 context 取消则丢弃该事件
+func (b *Bus) Subscribe(fn func(Event), buffer int, types ...Type) *Subscription {
+	s := &Subscription{
+		bus:      b,
+		types:    types,
+		ch:       make(chan Event, max(buffer, 0)),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	go func() {
+		defer close(s.finished)
+		for e := range s.ch {
+			fn(e)
+		}
+	}()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, s)
+	return s
+}
+
+// Publish 将事件发布给订阅者，操作完成后由文件系统调用，也可以用于发布自定义的事件
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	subs := slices.Clone(b.subs)
+	b.mu.RUnlock()
+	for _, s := range subs {
+		if match(s.types, e.Type) {
+			s.send(ctx, e)
+		}
+	}
+}
+
+// Close 关闭全部订阅，等待队列中的事件处理完成
+func (b *Bus) Close() {
+	b.mu.RLock()
+	subs := slices.Clone(b.subs)
+	b.mu.RUnlock()
+	for _, s := range subs {
+		s.Close()
+	}
+}
+
+// check 依次调用钩子，任一钩子返回错误时停止
+func (b *Bus) check(ctx context.Context, e Event) error {
+	b.mu.RLock()
+	hooks := slices.Clone(b.hooks)
+	b.mu.RUnlock()
+	for _, h := range hooks {
+		if !match(h.types, e.Type) {
+			continue
+		}
+		if err := h.fn(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wants 判断是否有钩子或订阅者接收该类型的事件，没有时不需要为事件获取文件大小
+func (b *Bus) wants(t Type) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, h := range b.hooks {
+		if match(h.types, t) {
+			return true
+		}
+	}
+	for _, s := range b.subs {
+		if match(s.types, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bus) unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = slices.DeleteFunc(b.subs, func(sub *Subscription) bool {
+		return sub == s
+	})
+}
+
+func match(types []Type, t Type) bool {
+	return len(types) == 0 || slices.Contains(types, t)
+}
+
+// Subscription 异步订阅
+type Subscription struct {
+	bus   *Bus
+	types []Type
+
+	mu       sync.RWMutex // 发送时持有读锁，关闭队列时持有写锁
+	ch       chan Event
+	done     chan struct{} // 关闭订阅时关闭，等待中的发送不再等待
+	finished chan struct{} // 队列中的事件处理完成时关闭
+	once     sync.Once
+	dropped  atomic.Int64
+}
+
+// send 将事件放入队列，队列已满时等待
+func (s *Subscription) send(ctx context.Context, e Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	// 队列有空位时直接放入，ctx 已取消（如请求结束后关闭写入流）也不会丢弃
+	select {
+	case s.ch <- e:
+		return
+	default:
+	}
+	select {
+	case s.ch <- e:
+	case <-s.done:
+	case <-ctx.Done():
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped 返回等待队列空位时因 context 取消而丢弃的事件数
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close 取消订阅，等待队列中的事件处理完成。不能在订阅者的 fn 中调用
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.unsubscribe(s)
+		close(s.done)
+		s.mu.Lock()
+		close(s.ch)
+		s.mu.Unlock()
+	})
+	<-s.finished
+}
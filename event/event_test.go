@@ -0,0 +1,247 @@
+package event_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/goairix/fs/event"
+	"github.com/goairix/fs/fstest"
+)
+
+func newMemory(t *testing.T) fs.FileSystem {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func write(t *testing.T, fsys fs.FileSystem, path, data string) {
+	t.Helper()
+	w, err := fsys.Create(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// collector 记录订阅者收到的事件
+type collector struct {
+	mu     sync.Mutex
+	events []event.Event
+}
+
+func (c *collector) add(e event.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+}
+
+func TestEventFileSystem(t *testing.T) {
+	fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+		bus := event.NewBus()
+		bus.Hook(func(context.Context, event.Event) error { return nil })
+		sub := bus.Subscribe(func(event.Event) {}, 4)
+		t.Cleanup(sub.Close)
+		return event.New(newMemory(t), bus)
+	})
+}
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+	bus := event.NewBus()
+	c := &collector{}
+	sub := bus.Subscribe(c.add, 16)
+	fsys := event.New(newMemory(t), bus)
+
+	write(t, fsys, "a.txt", "hello")
+	if err := fsys.Copy(ctx, "a.txt", "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Move(ctx, "b.txt", "c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.SetMetadata(ctx, "c.txt", map[string]interface{}{"k": "v"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Remove(ctx, "c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Uploader().Upload(ctx, "d/u.bin", iotest.HalfReader(bytes.NewReader(make([]byte, 7)))); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Uploader().Upload(ctx, "d/s.bin", bytes.NewReader(make([]byte, 9))); err != nil {
+		t.Fatal(err)
+	}
+	u := fsys.Uploader()
+	uploadID, err := u.InitMultipartUpload(ctx, "d/m.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := u.UploadPart(ctx, "d/m.bin", uploadID, 1, bytes.NewReader(make([]byte, 11)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.CompleteMultipartUpload(ctx, "d/m.bin", uploadID, []fs.MultipartPart{{PartNumber: 1, ETag: etag}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.RemoveDir(ctx, "d"); err != nil {
+		t.Fatal(err)
+	}
+	sub.Close()
+
+	want := []struct {
+		typ  event.Type
+		path string
+		from string
+		size int64
+	}{
+		{event.Created, "a.txt", "", 5},
+		{event.Copied, "b.txt", "a.txt", 5},
+		{event.Moved, "c.txt", "b.txt", 5},
+		{event.MetadataChanged, "c.txt", "", -1},
+		{event.Removed, "c.txt", "", 5},
+		{event.Created, "d/u.bin", "", 7},
+		{event.Created, "d/s.bin", "", 9},
+		{event.MultipartCompleted, "d/m.bin", "", 11},
+		{event.DirRemoved, "d", "", -1},
+	}
+	if len(c.events) != len(want) {
+		t.Fatalf("received %d events, want %d: %+v", len(c.events), len(want), c.events)
+	}
+	for i, w := range want {
+		e := c.events[i]
+		if e.Type != w.typ || e.Path != w.path || e.From != w.from || e.Size != w.size || e.Time.IsZero() {
+			t.Errorf("event %d = %+v, want %+v", i, e, w)
+		}
+	}
+	if c.events[0].ContentType != "text/plain" {
+		t.Errorf("Created event has content type %q", c.events[0].ContentType)
+	}
+	if c.events[3].Metadata["k"] != "v" {
+		t.Errorf("MetadataChanged event has metadata %v", c.events[3].Metadata)
+	}
+}
+
+func TestHook(t *testing.T) {
+	ctx := context.Background()
+	m := newMemory(t)
+	bus := event.NewBus()
+	veto := errors.New("executables are not allowed")
+	var sizes []int64
+	bus.Hook(func(_ context.Context, e event.Event) error {
+		sizes = append(sizes, e.Size)
+		if e.Path == "x.exe" {
+			return veto
+		}
+		return nil
+	}, event.Created, event.Moved)
+	c := &collector{}
+	sub := bus.Subscribe(c.add, 16)
+	fsys := event.New(m, bus)
+
+	if _, err := fsys.Create(ctx, "x.exe"); !errors.Is(err, event.ErrVetoed) || !errors.Is(err, veto) {
+		t.Fatalf("Create vetoed file: %v, want ErrVetoed wrapping the hook error", err)
+	}
+	if ok, _ := m.Exists(ctx, "x.exe"); ok {
+		t.Fatal("vetoed file was written")
+	}
+	write(t, fsys, "a.txt", "a")
+	if err := fsys.Move(ctx, "a.txt", "x.exe"); !errors.Is(err, event.ErrVetoed) {
+		t.Fatalf("Move to vetoed path: %v, want ErrVetoed", err)
+	}
+	if ok, _ := m.Exists(ctx, "a.txt"); !ok {
+		t.Fatal("vetoed Move changed the source")
+	}
+	if err := fsys.Uploader().Upload(ctx, "x.exe", bytes.NewReader(make([]byte, 3))); !errors.Is(err, event.ErrVetoed) {
+		t.Fatalf("Upload vetoed file: %v, want ErrVetoed", err)
+	}
+	// 钩子只收到注册的类型，失败的操作不发布事件
+	if err := fsys.Remove(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	sub.Close()
+
+	if want := []int64{-1, -1, 1, 3}; !slices.Equal(sizes, want) {
+		t.Fatalf("hook received sizes %v, want %v", sizes, want)
+	}
+	if len(c.events) != 2 || c.events[0].Type != event.Created || c.events[1].Type != event.Removed {
+		t.Fatalf("subscriber received %+v, want only the successful operations", c.events)
+	}
+}
+
+func TestBackPressure(t *testing.T) {
+	ctx := context.Background()
+	bus := event.NewBus()
+	release := make(chan struct{})
+	var handled int
+	sub := bus.Subscribe(func(event.Event) {
+		<-release
+		handled++
+	}, 1, event.Removed)
+	fsys := event.New(newMemory(t), bus)
+	for _, path := range []string{"1", "2", "3", "4"} {
+		write(t, fsys, path, path) // 未订阅的类型不阻塞
+	}
+
+	if err := fsys.Remove(ctx, "1"); err != nil { // 订阅者正在处理
+		t.Fatal(err)
+	}
+	if err := fsys.Remove(ctx, "2"); err != nil { // 缓冲
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		_ = fsys.Remove(ctx, "3")
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Publish did not block on a full buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// context 结束时丢弃事件，不影响操作结果
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := fsys.Remove(timeout, "4"); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	<-done
+	sub.Close()
+	if handled != 3 || sub.Dropped() != 1 {
+		t.Fatalf("handled %d events, dropped %d, want 3 and 1", handled, sub.Dropped())
+	}
+}
+
+func TestPublishCanceledContext(t *testing.T) {
+	bus := event.NewBus()
+	var handled int
+	sub := bus.Subscribe(func(event.Event) { handled++ }, 1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// 缓冲未满时不因 context 已结束丢弃事件
+	for range 500 {
+		bus.Publish(ctx, event.Event{Type: event.Created})
+	}
+	sub.Close()
+	if handled != 500 || sub.Dropped() != 0 {
+		t.Fatalf("handled %d events, dropped %d", handled, sub.Dropped())
+	}
+}
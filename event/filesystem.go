@@ -0,0 +1,207 @@
+package event
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+// fileSystem 发布变化事件的文件系统
+type fileSystem struct {
+	fs.Wrapper
+	bus *Bus
+}
+
+// New 返回发布变化事件的文件系统。修改文件的操作执行前调用 bus 的钩子，成功后向订阅者发布事件，
+// Create 与 OpenFile 在写入流成功关闭后发布。没有钩子或订阅者接收某类事件时不会为其读取文件信息
+func New(fsys fs.FileSystem, bus *Bus) fs.FileSystem {
+	return &fileSystem{Wrapper: fs.Wrapper{FileSystem: fsys}, bus: bus}
+}
+
+func (f *fileSystem) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	e := newEvent(Created, path, -1, opts)
+	if err := check(ctx, f.bus, "Create", e); err != nil {
+		return nil, err
+	}
+	w, err := f.FileSystem.Create(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &writer{WriteCloser: w, ctx: ctx, bus: f.bus, event: e, sized: true}, nil
+}
+
+// OpenFile 只读打开时不发布事件；写入时未设置 os.O_TRUNC 的事件 Size 为 -1
+func (f *fileSystem) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return f.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+	}
+	e := newEvent(Created, path, -1, opts)
+	if err := check(ctx, f.bus, "OpenFile", e); err != nil {
+		return nil, err
+	}
+	file, err := f.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+	if err != nil {
+		return nil, err
+	}
+	w := &writer{WriteCloser: file, ctx: ctx, bus: f.bus, event: e, sized: flag&os.O_TRUNC != 0}
+	return &readWriteFile{Reader: file, writer: w}, nil
+}
+
+func (f *fileSystem) Remove(ctx context.Context, path string, opts ...fs.Option) error {
+	e := newEvent(Removed, path, f.size(ctx, Removed, path), opts)
+	if err := check(ctx, f.bus, "Remove", e); err != nil {
+		return err
+	}
+	if err := f.FileSystem.Remove(ctx, path, opts...); err != nil {
+		return err
+	}
+	publish(ctx, f.bus, e)
+	return nil
+}
+
+func (f *fileSystem) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
+	e := Event{Type: DirRemoved, Path: path, Size: -1, Time: time.Now()}
+	if err := check(ctx, f.bus, "RemoveDir", e); err != nil {
+		return err
+	}
+	if err := f.FileSystem.RemoveDir(ctx, path, opts...); err != nil {
+		return err
+	}
+	publish(ctx, f.bus, e)
+	return nil
+}
+
+func (f *fileSystem) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	e := newEvent(Copied, dst, f.size(ctx, Copied, src), opts)
+	e.From = src
+	if err := check(ctx, f.bus, "Copy", e); err != nil {
+		return err
+	}
+	if err := f.FileSystem.Copy(ctx, src, dst, opts...); err != nil {
+		return err
+	}
+	publish(ctx, f.bus, e)
+	return nil
+}
+
+func (f *fileSystem) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	e := newEvent(Moved, dst, f.size(ctx, Moved, src), opts)
+	e.From = src
+	if err := check(ctx, f.bus, "Move", e); err != nil {
+		return err
+	}
+	if err := f.FileSystem.Move(ctx, src, dst, opts...); err != nil {
+		return err
+	}
+	publish(ctx, f.bus, e)
+	return nil
+}
+
+func (f *fileSystem) Rename(ctx context.Context, oldPath, newPath string, opts ...fs.Option) error {
+	e := newEvent(Moved, newPath, f.size(ctx, Moved, oldPath), opts)
+	e.From = oldPath
+	if err := check(ctx, f.bus, "Rename", e); err != nil {
+		return err
+	}
+	if err := f.FileSystem.Rename(ctx, oldPath, newPath, opts...); err != nil {
+		return err
+	}
+	publish(ctx, f.bus, e)
+	return nil
+}
+
+func (f *fileSystem) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	e := Event{Type: MetadataChanged, Path: path, Size: -1, Metadata: metadata, Time: time.Now()}
+	if err := check(ctx, f.bus, "SetMetadata", e); err != nil {
+		return err
+	}
+	if err := f.FileSystem.SetMetadata(ctx, path, metadata, opts...); err != nil {
+		return err
+	}
+	publish(ctx, f.bus, e)
+	return nil
+}
+
+func (f *fileSystem) Uploader() fs.Uploader {
+	return &uploader{Uploader: f.FileSystem.Uploader(), fsys: f}
+}
+
+// size 返回文件大小，没有钩子或订阅者接收该类事件、文件不存在或为目录时返回 -1
+func (f *fileSystem) size(ctx context.Context, t Type, path string) int64 {
+	if !f.bus.wants(t) {
+		return -1
+	}
+	info, err := f.FileSystem.Stat(ctx, path)
+	if err != nil || info.IsDir() {
+		return -1
+	}
+	return info.Size()
+}
+
+// newEvent 返回文件事件，文件类型取自 fs.WithContentType，未设置时按扩展名推断
+func newEvent(t Type, path string, size int64, opts []fs.Option) Event {
+	o := &fs.Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	contentType := o.ContentType
+	if contentType == "" {
+		contentType = fs.TypeByExtension(path)
+	}
+	return Event{Type: t, Path: path, Size: size, ContentType: contentType, Time: time.Now()}
+}
+
+// check 调用钩子，钩子拒绝时返回 ErrVetoed
+func check(ctx context.Context, bus *Bus, op string, e Event) error {
+	if err := bus.check(ctx, e); err != nil {
+		return fs.NewPathError("event", op, e.Path, err, ErrVetoed)
+	}
+	return nil
+}
+
+// publish 更新事件时间后发布
+func publish(ctx context.Context, bus *Bus, e Event) {
+	e.Time = time.Now()
+	bus.Publish(ctx, e)
+}
+
+// writer 关闭成功后发布 Created 事件的写入器
+type writer struct {
+	io.WriteCloser
+	ctx    context.Context
+	bus    *Bus
+	event  Event
+	sized  bool // 写入的字节数即为文件大小
+	n      int64
+	closed bool
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return w.WriteCloser.Close()
+	}
+	w.closed = true
+	if err := w.WriteCloser.Close(); err != nil {
+		return err
+	}
+	if w.sized {
+		w.event.Size = w.n
+	}
+	publish(w.ctx, w.bus, w.event)
+	return nil
+}
+
+// readWriteFile OpenFile 返回的文件
+type readWriteFile struct {
+	io.Reader
+	*writer
+}
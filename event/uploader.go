@@ -0,0 +1,55 @@
+package event
+
+import (
+	"context"
+	"io"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/internal/ioutil"
+)
+
+// uploader 发布变化事件的上传器
+type uploader struct {
+	fs.Uploader
+	fsys *fileSystem
+}
+
+// Upload reader 实现 io.Seeker 时钩子收到的事件包含文件大小，上传成功后发布 Created 事件
+func (u *uploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	e := newEvent(Created, path, -1, opts)
+	if seeker, ok := reader.(io.Seeker); ok {
+		if size, err := ioutil.Remaining(seeker); err == nil {
+			e.Size = size
+		}
+	}
+	if err := check(ctx, u.fsys.bus, "Upload", e); err != nil {
+		return err
+	}
+	// 不可 seek 的 reader 统计读取的字节数
+	var counter *ioutil.CountingReader
+	if e.Size < 0 {
+		reader, counter = ioutil.CountReader(reader)
+	}
+	if err := u.Uploader.Upload(ctx, path, reader, opts...); err != nil {
+		return err
+	}
+	if counter != nil {
+		e.Size = counter.N
+	}
+	publish(ctx, u.fsys.bus, e)
+	return nil
+}
+
+// CompleteMultipartUpload 钩子收到的事件 Size 为 -1，完成后发布 MultipartCompleted 事件
+func (u *uploader) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	e := newEvent(MultipartCompleted, path, -1, opts)
+	if err := check(ctx, u.fsys.bus, "CompleteMultipartUpload", e); err != nil {
+		return err
+	}
+	if err := u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...); err != nil {
+		return err
+	}
+	e.Size = u.fsys.size(ctx, MultipartCompleted, path)
+	publish(ctx, u.fsys.bus, e)
+	return nil
+}
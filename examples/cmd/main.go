@@ -25,5 +25,7 @@ func main() {
 		examples.TxCos()
 	case "s3":
 		examples.S3()
+	case "memory":
+		examples.Memory()
 	}
 }
@@ -0,0 +1,65 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	f "github.com/goairix/fs"
+	"github.com/goairix/fs/driver/memory"
+)
+
+func Memory() {
+	// 创建内存文件系统实例
+	fs, err := memory.New(memory.Config{
+		BaseURL:    "https://static.example.com",
+		AccessMode: f.PublicRead,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	// 写入文件
+	writer, err := fs.Create(ctx, "test/hello.txt", f.WithContentType("text/plain"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = writer.Write([]byte("Hello, Memory!"))
+	if err != nil {
+		_ = writer.Close()
+		log.Fatal(err)
+	}
+	_ = writer.Close()
+
+	// 读取文件
+	reader, err := fs.Open(ctx, "test/hello.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	_ = reader.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("文件内容: %s\n", string(data))
+
+	// 列出目录内容
+	files, err := fs.List(ctx, "test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("目录内容:")
+	for _, file := range files {
+		fmt.Printf("- %s\n", file.Name())
+	}
+
+	// 文件访问地址
+	fullUrl, err := fs.FullUrl(ctx, "test/hello.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("文件地址: %s\n", fullUrl)
+}
@@ -57,6 +57,25 @@ type FileSystem interface {
 	Uploader() Uploader
 }
 
+// Wrapper 包装 FileSystem 的装饰器嵌入 Wrapper 而不是直接嵌入 FileSystem。
+// 嵌入接口会隐藏被包装的文件系统实现的可选接口，Wrapper 将 ReaderOpener、Walker 与 ListPager 转发给被包装的文件系统，
+// 装饰器需要改变其行为时再覆盖对应的方法
+type Wrapper struct {
+	FileSystem
+}
+
+func (w Wrapper) OpenReader(ctx context.Context, path string, opts ...Option) (RangeReader, error) {
+	return OpenReader(ctx, w.FileSystem, path, opts...)
+}
+
+func (w Wrapper) Walk(ctx context.Context, root string, fn WalkFunc, opts ...Option) error {
+	return Walk(ctx, w.FileSystem, root, fn, opts...)
+}
+
+func (w Wrapper) ListPage(ctx context.Context, path string, opts ...Option) (*ListResult, error) {
+	return ListPage(ctx, w.FileSystem, path, opts...)
+}
+
 // Uploader 文件上传器
 type Uploader interface {
 	// Upload 文件上传
@@ -0,0 +1,1122 @@
+// Package fstest 提供文件系统驱动的一致性测试套件。
+//
+// 驱动实现者在自己的测试中调用 TestFileSystem，即可验证驱动的行为是否与其他驱动保持一致：
+//
+//	func TestDriver(t *testing.T) {
+//		fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+//			fsys, err := local.New(local.Config{RootPath: t.TempDir()})
+//			if err != nil {
+//				t.Fatal(err)
+//			}
+//			return fsys
+//		}, fstest.WithoutMetadata())
+//	}
+package fstest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	iofstest "testing/fstest"
+	"testing/iotest"
+
+	"github.com/goairix/fs"
+)
+
+// Factory 创建待测试的文件系统，每个测试用例调用一次，返回的文件系统应当为空
+type Factory func(t *testing.T) fs.FileSystem
+
+// Option 测试套件选项
+type Option func(*config)
+
+type config struct {
+	skipMetadata bool
+}
+
+// WithoutMetadata 跳过自定义元数据相关的测试，用于不支持自定义元数据的驱动（如本地文件系统）
+func WithoutMetadata() Option {
+	return func(c *config) {
+		c.skipMetadata = true
+	}
+}
+
+// partSize 分片上传测试中非最后分片的大小，与 S3 的最小分片大小一致
+const partSize = 5 << 20
+
+// TestFileSystem 对 factory 创建的文件系统执行一致性测试，覆盖 FileSystem 与 Uploader 的全部方法
+func TestFileSystem(t *testing.T, factory Factory, opts ...Option) {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, fsys fs.FileSystem, c *config)
+	}{
+		{"CreateAndOpen", testCreateAndOpen},
+		{"EmptyFile", testEmptyFile},
+		{"Overwrite", testOverwrite},
+		{"NotExist", testNotExist},
+		{"SpecialNames", testSpecialNames},
+		{"Directories", testDirectories},
+		{"MakeDir", testMakeDir},
+		{"Remove", testRemove},
+		{"RemoveDir", testRemoveDir},
+		{"Copy", testCopy},
+		{"Move", testMove},
+		{"Rename", testRename},
+		{"OpenFile", testOpenFile},
+		{"Walk", testWalk},
+		{"WalkSkip", testWalkSkip},
+		{"ListPage", testListPage},
+		{"ListSeq", testListSeq},
+		{"Range", testRange},
+		{"OpenReader", testOpenReader},
+		{"MimeType", testMimeType},
+		{"Metadata", testMetadata},
+		{"URL", testURL},
+		{"Upload", testUpload},
+		{"StreamingWrite", testStreamingWrite},
+		{"ManagedUpload", testManagedUpload},
+		{"ManagedUploadError", testManagedUploadError},
+		{"Multipart", testMultipart},
+		{"MultipartReplacePart", testMultipartReplacePart},
+		{"MultipartAbort", testMultipartAbort},
+		{"IOFS", testIOFS},
+		{"Concurrent", testConcurrent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, factory(t), c)
+		})
+	}
+}
+
+func testCreateAndOpen(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	data := []byte("hello, world")
+	writeFile(t, fsys, "hello.txt", data)
+
+	checkContent(t, fsys, "hello.txt", data)
+
+	info, err := fsys.Stat(ctx, "hello.txt")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	checkFileInfo(t, info, "hello.txt", int64(len(data)), false)
+
+	checkKind(t, fsys, "hello.txt", true, false)
+}
+
+func testEmptyFile(t *testing.T, fsys fs.FileSystem, _ *config) {
+	writeFile(t, fsys, "empty.txt", nil)
+	checkContent(t, fsys, "empty.txt", nil)
+
+	info, err := fsys.Stat(context.Background(), "empty.txt")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	checkFileInfo(t, info, "empty.txt", 0, false)
+}
+
+func testOverwrite(t *testing.T, fsys fs.FileSystem, _ *config) {
+	writeFile(t, fsys, "overwrite.txt", []byte("original content"))
+	writeFile(t, fsys, "overwrite.txt", []byte("new"))
+	checkContent(t, fsys, "overwrite.txt", []byte("new"))
+}
+
+func testNotExist(t *testing.T, fsys fs.FileSystem, c *config) {
+	ctx := context.Background()
+	const name = "missing/missing.txt"
+
+	_, err := fsys.Open(ctx, name)
+	checkNotExist(t, "Open", err)
+
+	_, err = fsys.OpenFile(ctx, name, os.O_RDONLY, 0)
+	checkNotExist(t, "OpenFile", err)
+
+	_, err = fsys.Stat(ctx, name)
+	checkNotExist(t, "Stat", err)
+
+	_, err = fsys.GetMimeType(ctx, name)
+	checkNotExist(t, "GetMimeType", err)
+
+	checkNotExist(t, "Copy", fsys.Copy(ctx, name, "copy.txt"))
+	checkNotExist(t, "Move", fsys.Move(ctx, name, "move.txt"))
+	checkNotExist(t, "Rename", fsys.Rename(ctx, name, "rename.txt"))
+
+	if !c.skipMetadata {
+		_, err = fsys.GetMetadata(ctx, name)
+		checkNotExist(t, "GetMetadata", err)
+		checkNotExist(t, "SetMetadata", fsys.SetMetadata(ctx, name, map[string]any{"k": "v"}))
+	}
+
+	// 删除不存在的文件时，对象存储不返回错误，本地文件系统返回 ErrNotExist，两者均可接受
+	if err = fsys.Remove(ctx, name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Remove: got %v, want nil or ErrNotExist", err)
+	}
+
+	checkKind(t, fsys, name, false, false)
+	if ok, err := fsys.Exists(ctx, "missing"); err != nil || ok {
+		t.Errorf("Exists(missing dir) = %v, %v; want false, nil", ok, err)
+	}
+}
+
+func testSpecialNames(t *testing.T, fsys fs.FileSystem, _ *config) {
+	names := []string{"hello world.txt", "中文文件.txt", "a+b=c&d.txt", "percent%20.txt"}
+	for _, name := range names {
+		writeFile(t, fsys, "special/"+name, []byte(name))
+	}
+	for _, name := range names {
+		checkContent(t, fsys, "special/"+name, []byte(name))
+	}
+	checkList(t, fsys, "special", names, nil)
+}
+
+func testDirectories(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	writeFile(t, fsys, "a/b/c.txt", []byte("c"))
+	writeFile(t, fsys, "a/d.txt", []byte("dd"))
+
+	checkKind(t, fsys, "a", false, true)
+	checkKind(t, fsys, "a/b", false, true)
+
+	info, err := fsys.Stat(ctx, "a/b")
+	if err != nil {
+		t.Fatalf("Stat(dir): %v", err)
+	}
+	if info.Name() != "b" || !info.IsDir() || !info.Mode().IsDir() {
+		t.Errorf("Stat(dir) = name %q, IsDir %v, mode %v; want b, true, ModeDir", info.Name(), info.IsDir(), info.Mode())
+	}
+
+	checkList(t, fsys, "a", []string{"d.txt"}, []string{"b"})
+	checkList(t, fsys, "a/", []string{"d.txt"}, []string{"b"})
+	checkList(t, fsys, "a/b", []string{"c.txt"}, nil)
+
+	files, err := fsys.List(ctx, "a")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for _, file := range files {
+		if file.Name() == "d.txt" {
+			checkFileInfo(t, file, "d.txt", 2, false)
+		}
+	}
+
+	// 不存在的目录可以返回空列表或 ErrNotExist
+	files, err = fsys.List(ctx, "nothing")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("List(missing): got %v, want nil or ErrNotExist", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("List(missing) returned %d entries, want 0", len(files))
+	}
+}
+
+func testMakeDir(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	if err := fsys.MakeDir(ctx, "made", 0755); err != nil {
+		t.Fatalf("MakeDir: %v", err)
+	}
+	if err := fsys.MakeDir(ctx, "made/x/y/z", 0755); err != nil {
+		t.Fatalf("MakeDir(nested): %v", err)
+	}
+	// 对象存储中空目录不存在，这里只要求目录存在时是目录
+	if ok, err := fsys.Exists(ctx, "made"); err != nil {
+		t.Fatalf("Exists: %v", err)
+	} else if ok {
+		checkKind(t, fsys, "made", false, true)
+	}
+
+	writeFile(t, fsys, "made/file.txt", []byte("file"))
+	checkKind(t, fsys, "made", false, true)
+	checkContent(t, fsys, "made/file.txt", []byte("file"))
+}
+
+func testRemove(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	writeFile(t, fsys, "remove/file.txt", []byte("data"))
+	writeFile(t, fsys, "remove/keep.txt", []byte("keep"))
+
+	if err := fsys.Remove(ctx, "remove/file.txt"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	checkKind(t, fsys, "remove/file.txt", false, false)
+	_, err := fsys.Open(ctx, "remove/file.txt")
+	checkNotExist(t, "Open(removed)", err)
+	checkContent(t, fsys, "remove/keep.txt", []byte("keep"))
+}
+
+func testRemoveDir(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	writeFile(t, fsys, "d/1.txt", []byte("1"))
+	writeFile(t, fsys, "d/sub/2.txt", []byte("2"))
+	// 同名前缀的文件和目录不应被删除
+	writeFile(t, fsys, "d2/3.txt", []byte("3"))
+	writeFile(t, fsys, "d.txt", []byte("4"))
+
+	if err := fsys.RemoveDir(ctx, "d"); err != nil {
+		t.Fatalf("RemoveDir: %v", err)
+	}
+	checkKind(t, fsys, "d", false, false)
+	checkKind(t, fsys, "d/1.txt", false, false)
+	checkKind(t, fsys, "d/sub/2.txt", false, false)
+	checkContent(t, fsys, "d2/3.txt", []byte("3"))
+	checkContent(t, fsys, "d.txt", []byte("4"))
+
+	if err := fsys.RemoveDir(ctx, "d"); err != nil {
+		t.Errorf("RemoveDir(removed): %v", err)
+	}
+}
+
+func testCopy(t *testing.T, fsys fs.FileSystem, _ *config) {
+	data := []byte("copy me")
+	writeFile(t, fsys, "copy/src.txt", data)
+
+	if err := fsys.Copy(context.Background(), "copy/src.txt", "copy/dst/dst.txt"); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	checkContent(t, fsys, "copy/src.txt", data)
+	checkContent(t, fsys, "copy/dst/dst.txt", data)
+}
+
+func testMove(t *testing.T, fsys fs.FileSystem, _ *config) {
+	data := []byte("move me")
+	writeFile(t, fsys, "move/src.txt", data)
+
+	if err := fsys.Move(context.Background(), "move/src.txt", "move/dst/dst.txt"); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	checkKind(t, fsys, "move/src.txt", false, false)
+	checkContent(t, fsys, "move/dst/dst.txt", data)
+}
+
+func testRename(t *testing.T, fsys fs.FileSystem, _ *config) {
+	data := []byte("rename me")
+	writeFile(t, fsys, "rename/old.txt", data)
+
+	if err := fsys.Rename(context.Background(), "rename/old.txt", "rename/new.txt"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	checkKind(t, fsys, "rename/old.txt", false, false)
+	checkContent(t, fsys, "rename/new.txt", data)
+}
+
+func testOpenFile(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	file, err := fsys.OpenFile(ctx, "openfile/file.txt", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile(write): %v", err)
+	}
+	if _, err = file.Write([]byte("written by OpenFile")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err = file.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	checkContent(t, fsys, "openfile/file.txt", []byte("written by OpenFile"))
+
+	file, err = fsys.OpenFile(ctx, "openfile/file.txt", os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile(read): %v", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(data) != "written by OpenFile" {
+		t.Errorf("OpenFile(read) = %q, want %q", data, "written by OpenFile")
+	}
+	if _, err = file.Write([]byte("x")); err == nil {
+		t.Errorf("Write to read-only file succeeded")
+	}
+}
+
+func testWalk(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	files := []string{"walk/a.txt", "walk/b/c.txt", "walk/b/d/e.txt", "walk/b.txt", "walk/f/g.txt"}
+	for _, name := range files {
+		writeFile(t, fsys, name, []byte(name))
+	}
+
+	visited := make(map[string]bool)
+	err := fs.Walk(ctx, fsys, "walk", func(name string, info fs.FileInfo, err error) error {
+		if err != nil {
+			t.Errorf("Walk(%q): %v", name, err)
+			return err
+		}
+		if _, ok := visited[name]; ok {
+			t.Errorf("Walk visited %q twice", name)
+		}
+		// 目录总是在其内容之前被访问
+		if parent := path.Dir(name); name != "walk" && !visited[parent] {
+			t.Errorf("Walk visited %q before its parent %q", name, parent)
+		}
+		if info.Name() != path.Base(name) {
+			t.Errorf("Walk(%q): Name() = %q", name, info.Name())
+		}
+		if !info.IsDir() && info.Size() != int64(len(name)) {
+			t.Errorf("Walk(%q): Size() = %d, want %d", name, info.Size(), len(name))
+		}
+		visited[name] = info.IsDir()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+
+	want := map[string]bool{"walk": true, "walk/b": true, "walk/b/d": true, "walk/f": true}
+	for _, name := range files {
+		want[name] = false
+	}
+	if !maps.Equal(visited, want) {
+		t.Errorf("Walk visited %v, want %v", visited, want)
+	}
+
+	// 遍历文件时只访问文件本身
+	var names []string
+	err = fs.Walk(ctx, fsys, "walk/a.txt", func(name string, info fs.FileInfo, err error) error {
+		names = append(names, name)
+		return err
+	})
+	if err != nil || !slices.Equal(names, []string{"walk/a.txt"}) {
+		t.Errorf("Walk(file) visited %v, %v; want [walk/a.txt], nil", names, err)
+	}
+
+	// 根目录不存在时以错误调用 fn
+	err = fs.Walk(ctx, fsys, "missing", func(name string, info fs.FileInfo, err error) error {
+		checkNotExist(t, "Walk(missing)", err)
+		return err
+	})
+	checkNotExist(t, "Walk(missing)", err)
+}
+
+func testWalkSkip(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	for _, name := range []string{"skip/a.txt", "skip/b/c.txt", "skip/b/d/e.txt", "skip/b.txt", "skip/f/g.txt"} {
+		writeFile(t, fsys, name, []byte(name))
+	}
+
+	var names []string
+	err := fs.Walk(ctx, fsys, "skip", func(name string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		if name == "skip/b" {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk(SkipDir): %v", err)
+	}
+	slices.Sort(names)
+	if want := []string{"skip", "skip/a.txt", "skip/b", "skip/b.txt", "skip/f", "skip/f/g.txt"}; !slices.Equal(names, want) {
+		t.Errorf("Walk(SkipDir) visited %v, want %v", names, want)
+	}
+
+	names = nil
+	err = fs.Walk(ctx, fsys, "skip", func(name string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		if !info.IsDir() {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk(SkipAll): %v", err)
+	}
+	files := slices.DeleteFunc(slices.Clone(names), func(name string) bool {
+		return !strings.HasSuffix(name, ".txt")
+	})
+	if len(files) != 1 || names[len(names)-1] != files[0] {
+		t.Errorf("Walk(SkipAll) visited %v, want to stop at the first file", names)
+	}
+
+	errStop := errors.New("stop")
+	err = fs.Walk(ctx, fsys, "skip", func(name string, info fs.FileInfo, err error) error {
+		return errStop
+	})
+	if err != errStop {
+		t.Errorf("Walk: got %v, want %v", err, errStop)
+	}
+}
+
+func testListPage(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	for _, name := range []string{"page/a-b.txt", "page/a/c.txt", "page/a/d.txt", "page/b.txt", "page/c.txt", "page/d/e.txt", "page/f.txt"} {
+		writeFile(t, fsys, name, []byte(name))
+	}
+	want := []string{"a-b.txt", "a/", "b.txt", "c.txt", "d/", "f.txt"}
+
+	// 逐页列出，每页不超过 limit 项，全部页的内容与 List 一致
+	var names []string
+	var marker string
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatalf("ListPage did not finish after %d pages", pages)
+		}
+		result, err := fs.ListPage(ctx, fsys, "page", fs.WithLimit(2), fs.WithMarker(marker))
+		if err != nil {
+			t.Fatalf("ListPage(marker %q): %v", marker, err)
+		}
+		if len(result.Files) > 2 {
+			t.Errorf("ListPage returned %d entries, want at most 2", len(result.Files))
+		}
+		if len(result.Files) == 0 && result.NextMarker != "" {
+			t.Errorf("ListPage returned an empty page with NextMarker %q", result.NextMarker)
+		}
+		for _, info := range result.Files {
+			name := info.Name()
+			if info.IsDir() {
+				name += "/"
+			}
+			names = append(names, name)
+		}
+		if result.NextMarker == "" {
+			break
+		}
+		marker = result.NextMarker
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, want) {
+		t.Errorf("ListPage pages = %q, want %q", names, want)
+	}
+
+	// 不设置 limit 时一页返回全部内容
+	result, err := fs.ListPage(ctx, fsys, "page")
+	if err != nil {
+		t.Fatalf("ListPage: %v", err)
+	}
+	if len(result.Files) != len(want) || result.NextMarker != "" {
+		t.Errorf("ListPage = %d entries, NextMarker %q; want %d entries and no marker", len(result.Files), result.NextMarker, len(want))
+	}
+}
+
+func testListSeq(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	var want []string
+	for i := range 5 {
+		name := fmt.Sprintf("seq/%d.txt", i)
+		writeFile(t, fsys, name, []byte(name))
+		want = append(want, path.Base(name))
+	}
+
+	var names []string
+	for info, err := range fs.ListSeq(ctx, fsys, "seq", fs.WithLimit(2)) {
+		if err != nil {
+			t.Fatalf("ListSeq: %v", err)
+		}
+		names = append(names, info.Name())
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, want) {
+		t.Errorf("ListSeq = %q, want %q", names, want)
+	}
+
+	// 提前结束迭代
+	names = nil
+	for info, err := range fs.ListSeq(ctx, fsys, "seq", fs.WithLimit(2)) {
+		if err != nil {
+			t.Fatalf("ListSeq: %v", err)
+		}
+		names = append(names, info.Name())
+		if len(names) == 3 {
+			break
+		}
+	}
+	if len(names) != 3 {
+		t.Errorf("ListSeq after break = %q, want 3 entries", names)
+	}
+}
+
+func testRange(t *testing.T, fsys fs.FileSystem, _ *config) {
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	writeFile(t, fsys, "range/file.bin", data)
+
+	tests := []struct {
+		offset, length int64
+		want           []byte
+	}{
+		{0, 0, data},
+		{10, 5, data[10:15]},
+		{99, 1, data[99:]},
+		{90, 0, data[90:]},
+		{95, 100, data[95:]},
+	}
+	for _, tt := range tests {
+		reader, err := fsys.Open(context.Background(), "range/file.bin", fs.WithRange(tt.offset, tt.length))
+		if err != nil {
+			t.Fatalf("Open(WithRange(%d, %d)): %v", tt.offset, tt.length, err)
+		}
+		got, err := io.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil {
+			t.Fatalf("Read(WithRange(%d, %d)): %v", tt.offset, tt.length, err)
+		}
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("Open(WithRange(%d, %d)) = %v, want %v", tt.offset, tt.length, got, tt.want)
+		}
+	}
+}
+
+func testOpenReader(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	writeFile(t, fsys, "reader/file.txt", data)
+	writeFile(t, fsys, "reader/empty.txt", nil)
+
+	for name, want := range map[string][]byte{"reader/file.txt": data, "reader/empty.txt": {}} {
+		reader, err := fs.OpenReader(ctx, fsys, name)
+		if err != nil {
+			t.Fatalf("OpenReader(%q): %v", name, err)
+		}
+		if reader.Size() != int64(len(want)) {
+			t.Errorf("OpenReader(%q).Size() = %d, want %d", name, reader.Size(), len(want))
+		}
+		// iotest.TestReader 会检查 Read、ReadAt 与 Seek 的行为
+		if err = iotest.TestReader(reader, want); err != nil {
+			t.Errorf("OpenReader(%q): %v", name, err)
+		}
+		if err = reader.Close(); err != nil {
+			t.Errorf("Close(%q): %v", name, err)
+		}
+	}
+
+	_, err := fs.OpenReader(ctx, fsys, "reader/missing.txt")
+	checkNotExist(t, "OpenReader", err)
+	if _, err = fs.OpenReader(ctx, fsys, "reader"); err == nil {
+		t.Errorf("OpenReader(dir) succeeded")
+	}
+}
+
+func testMimeType(t *testing.T, fsys fs.FileSystem, _ *config) {
+	writeFile(t, fsys, "mime/doc.txt", []byte("plain text content"), fs.WithContentType("text/plain"))
+
+	mimeType, err := fsys.GetMimeType(context.Background(), "mime/doc.txt")
+	if err != nil {
+		t.Fatalf("GetMimeType: %v", err)
+	}
+	if !strings.HasPrefix(mimeType, "text/plain") {
+		t.Errorf("GetMimeType = %q, want text/plain", mimeType)
+	}
+}
+
+func testMetadata(t *testing.T, fsys fs.FileSystem, c *config) {
+	if c.skipMetadata {
+		t.Skip("driver does not support custom metadata")
+	}
+	ctx := context.Background()
+	data := []byte("metadata content")
+	writeFile(t, fsys, "meta/file.txt", data,
+		fs.WithContentType("text/plain"),
+		fs.WithMetadata(fs.Metadata{"author": "fstest"}),
+	)
+	checkMetadata(t, fsys, "meta/file.txt", "author", "fstest")
+
+	if err := fsys.SetMetadata(ctx, "meta/file.txt", map[string]any{"version": 2}); err != nil {
+		t.Fatalf("SetMetadata: %v", err)
+	}
+	checkMetadata(t, fsys, "meta/file.txt", "version", "2")
+
+	// 更新元数据不应改变文件内容与类型，也不应留下临时文件
+	checkContent(t, fsys, "meta/file.txt", data)
+	mimeType, err := fsys.GetMimeType(ctx, "meta/file.txt")
+	if err != nil {
+		t.Fatalf("GetMimeType: %v", err)
+	}
+	if !strings.HasPrefix(mimeType, "text/plain") {
+		t.Errorf("GetMimeType after SetMetadata = %q, want text/plain", mimeType)
+	}
+	checkList(t, fsys, "meta", []string{"file.txt"}, nil)
+}
+
+func testURL(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	const name = "url/file.txt"
+	writeFile(t, fsys, name, []byte("url"))
+
+	checkURL := func(method, fullUrl string, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		if fullUrl == "" {
+			t.Fatalf("%s returned empty url", method)
+		}
+		relativePath, err := fsys.RelativePath(ctx, fullUrl)
+		if err != nil {
+			t.Fatalf("RelativePath(%s): %v", fullUrl, err)
+		}
+		if relativePath != name {
+			t.Errorf("RelativePath(%s(%q)) = %q, want %q", method, name, relativePath, name)
+		}
+	}
+
+	fullUrl, err := fsys.FullUrl(ctx, name)
+	checkURL("FullUrl", fullUrl, err)
+	fullUrl, err = fsys.SignFullUrl(ctx, name)
+	checkURL("SignFullUrl", fullUrl, err)
+
+	const cdnDomain = "https://cdn.example.com"
+	fullUrl, err = fsys.FullUrl(ctx, name, fs.WithCdnDomain(cdnDomain))
+	checkURL("FullUrl(cdn)", fullUrl, err)
+	if !strings.HasPrefix(fullUrl, cdnDomain+"/") {
+		t.Errorf("FullUrl(cdn) = %q, want prefix %q", fullUrl, cdnDomain)
+	}
+	fullUrl, err = fsys.SignFullUrl(ctx, name, fs.WithCdnDomain(cdnDomain))
+	checkURL("SignFullUrl(cdn)", fullUrl, err)
+	if !strings.HasPrefix(fullUrl, cdnDomain+"/") {
+		t.Errorf("SignFullUrl(cdn) = %q, want prefix %q", fullUrl, cdnDomain)
+	}
+}
+
+func testUpload(t *testing.T, fsys fs.FileSystem, _ *config) {
+	data := bytes.Repeat([]byte("upload "), 1024)
+	err := fsys.Uploader().Upload(context.Background(), "upload/nested/file.txt", bytes.NewReader(data), fs.WithContentType("text/plain"))
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	checkContent(t, fsys, "upload/nested/file.txt", data)
+}
+
+func testStreamingWrite(t *testing.T, fsys fs.FileSystem, c *config) {
+	ctx := context.Background()
+	const name = "streaming/file.bin"
+	data := make([]byte, 2*partSize+1000)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	opts := []fs.Option{fs.WithMultipartThreshold(partSize), fs.WithContentType("application/x-test")}
+	if !c.skipMetadata {
+		opts = append(opts, fs.WithMetadata(fs.Metadata{"author": "fstest"}))
+	}
+	writer, err := fsys.Create(ctx, name, opts...)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// 以较小的块写入，超过阈值后驱动应切换为分片上传
+	for chunk := range slices.Chunk(data, 64<<10) {
+		if _, err = writer.Write(chunk); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	checkContent(t, fsys, name, data)
+	if !c.skipMetadata {
+		checkMetadata(t, fsys, name, "author", "fstest")
+		if mimeType, err := fsys.GetMimeType(ctx, name); err != nil || mimeType != "application/x-test" {
+			t.Errorf("GetMimeType = %q, %v; want application/x-test", mimeType, err)
+		}
+	}
+
+	checkNoUploads(t, fsys.Uploader(), name)
+}
+
+func testManagedUpload(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	const name = "managed/file.bin"
+	data := make([]byte, 3*partSize+1000)
+	for i := range data {
+		data[i] = byte(i % 253)
+	}
+
+	// iotest.HalfReader 隐藏 bytes.Reader 的其他接口，模拟普通的 io.Reader
+	err := fsys.Uploader().Upload(ctx, name, iotest.HalfReader(bytes.NewReader(data)),
+		fs.WithMultipartThreshold(partSize), fs.WithPartSize(partSize), fs.WithConcurrency(2))
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	checkContent(t, fsys, name, data)
+	checkNoUploads(t, fsys.Uploader(), name)
+}
+
+func testManagedUploadError(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	uploader := fsys.Uploader()
+	errRead := errors.New("read failed")
+	data := bytes.Repeat([]byte("x"), 2*partSize)
+	opts := []fs.Option{fs.WithMultipartThreshold(partSize), fs.WithPartSize(partSize)}
+
+	// 读取失败时取消分片上传，不产生文件
+	reader := io.MultiReader(bytes.NewReader(data), iotest.ErrReader(errRead))
+	err := uploader.Upload(ctx, "managed/failed.bin", reader, opts...)
+	if !errors.Is(err, errRead) {
+		t.Fatalf("Upload: got %v, want %v", err, errRead)
+	}
+	checkKind(t, fsys, "managed/failed.bin", false, false)
+	checkNoUploads(t, uploader, "managed/failed.bin")
+
+	// 设置 WithLeavePartsOnError 时保留已上传的分片
+	reader = io.MultiReader(bytes.NewReader(data), iotest.ErrReader(errRead))
+	err = uploader.Upload(ctx, "managed/left.bin", reader, append(opts, fs.WithLeavePartsOnError())...)
+	var uploadErr *fs.MultipartUploadError
+	if !errors.As(err, &uploadErr) || !errors.Is(err, errRead) {
+		t.Fatalf("Upload(WithLeavePartsOnError): got %v, want *MultipartUploadError wrapping %v", err, errRead)
+	}
+	if !hasUpload(t, uploader, uploadErr.UploadID, "managed/left.bin") {
+		t.Errorf("upload %s not found after failure", uploadErr.UploadID)
+	}
+	parts, err := uploader.ListUploadedParts(ctx, "managed/left.bin", uploadErr.UploadID)
+	if err != nil {
+		t.Fatalf("ListUploadedParts: %v", err)
+	}
+	if len(parts) == 0 {
+		t.Errorf("ListUploadedParts returned no parts")
+	}
+	if err = uploader.AbortMultipartUpload(ctx, "managed/left.bin", uploadErr.UploadID); err != nil {
+		t.Fatalf("AbortMultipartUpload: %v", err)
+	}
+}
+
+func testMultipart(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	uploader := fsys.Uploader()
+	const name = "multipart/file.bin"
+	part1 := bytes.Repeat([]byte("0123456789abcdef"), partSize/16)
+	part2 := []byte("last part")
+
+	uploadID, err := uploader.InitMultipartUpload(ctx, name)
+	if err != nil {
+		t.Fatalf("InitMultipartUpload: %v", err)
+	}
+	if uploadID == "" {
+		t.Fatalf("InitMultipartUpload returned empty upload id")
+	}
+
+	// 分片可以乱序上传
+	etag2, err := uploader.UploadPart(ctx, name, uploadID, 2, bytes.NewReader(part2))
+	if err != nil {
+		t.Fatalf("UploadPart(2): %v", err)
+	}
+	etag1, err := uploader.UploadPart(ctx, name, uploadID, 1, bytes.NewReader(part1))
+	if err != nil {
+		t.Fatalf("UploadPart(1): %v", err)
+	}
+
+	parts, err := uploader.ListUploadedParts(ctx, name, uploadID)
+	if err != nil {
+		t.Fatalf("ListUploadedParts: %v", err)
+	}
+	want := []fs.MultipartPart{
+		{PartNumber: 1, ETag: etag1, Size: int64(len(part1))},
+		{PartNumber: 2, ETag: etag2, Size: int64(len(part2))},
+	}
+	checkParts(t, parts, want)
+
+	if !hasUpload(t, uploader, uploadID, name) {
+		t.Errorf("ListMultipartUploads does not contain upload %s", uploadID)
+	}
+	if ok, _ := fsys.Exists(ctx, name); ok {
+		t.Errorf("file exists before CompleteMultipartUpload")
+	}
+
+	if err = uploader.CompleteMultipartUpload(ctx, name, uploadID, want); err != nil {
+		t.Fatalf("CompleteMultipartUpload: %v", err)
+	}
+	checkContent(t, fsys, name, append(append([]byte(nil), part1...), part2...))
+	if hasUpload(t, uploader, uploadID, name) {
+		t.Errorf("ListMultipartUploads still contains completed upload %s", uploadID)
+	}
+}
+
+func testMultipartReplacePart(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	uploader := fsys.Uploader()
+	const name = "multipart/replace.txt"
+
+	uploadID, err := uploader.InitMultipartUpload(ctx, name)
+	if err != nil {
+		t.Fatalf("InitMultipartUpload: %v", err)
+	}
+	if _, err = uploader.UploadPart(ctx, name, uploadID, 1, bytes.NewReader([]byte("old"))); err != nil {
+		t.Fatalf("UploadPart: %v", err)
+	}
+	etag, err := uploader.UploadPart(ctx, name, uploadID, 1, bytes.NewReader([]byte("replaced")))
+	if err != nil {
+		t.Fatalf("UploadPart(replace): %v", err)
+	}
+
+	parts, err := uploader.ListUploadedParts(ctx, name, uploadID)
+	if err != nil {
+		t.Fatalf("ListUploadedParts: %v", err)
+	}
+	want := []fs.MultipartPart{{PartNumber: 1, ETag: etag, Size: int64(len("replaced"))}}
+	checkParts(t, parts, want)
+
+	if err = uploader.CompleteMultipartUpload(ctx, name, uploadID, want); err != nil {
+		t.Fatalf("CompleteMultipartUpload: %v", err)
+	}
+	checkContent(t, fsys, name, []byte("replaced"))
+}
+
+func testMultipartAbort(t *testing.T, fsys fs.FileSystem, _ *config) {
+	ctx := context.Background()
+	uploader := fsys.Uploader()
+	const name = "multipart/abort.txt"
+
+	uploadID, err := uploader.InitMultipartUpload(ctx, name)
+	if err != nil {
+		t.Fatalf("InitMultipartUpload: %v", err)
+	}
+	if _, err = uploader.UploadPart(ctx, name, uploadID, 1, bytes.NewReader([]byte("part"))); err != nil {
+		t.Fatalf("UploadPart: %v", err)
+	}
+	if err = uploader.AbortMultipartUpload(ctx, name, uploadID); err != nil {
+		t.Fatalf("AbortMultipartUpload: %v", err)
+	}
+
+	if hasUpload(t, uploader, uploadID, name) {
+		t.Errorf("ListMultipartUploads still contains aborted upload %s", uploadID)
+	}
+	if _, err = uploader.ListUploadedParts(ctx, name, uploadID); err == nil {
+		t.Errorf("ListUploadedParts on aborted upload succeeded")
+	}
+	checkKind(t, fsys, name, false, false)
+}
+
+func testIOFS(t *testing.T, fsys fs.FileSystem, _ *config) {
+	writeFile(t, fsys, "iofs/a.txt", []byte("a"))
+	writeFile(t, fsys, "iofs/dir/b.txt", []byte("bb"))
+	writeFile(t, fsys, "iofs/dir/sub/c.txt", []byte("ccc"))
+
+	sub, err := iofs.Sub(fs.AsIOFS(context.Background(), fsys), "iofs")
+	if err != nil {
+		t.Fatalf("Sub: %v", err)
+	}
+	if err = iofstest.TestFS(sub, "a.txt", "dir/b.txt", "dir/sub/c.txt"); err != nil {
+		t.Error(err)
+	}
+}
+
+func testConcurrent(t *testing.T, fsys fs.FileSystem, _ *config) {
+	const n = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("concurrent/%d.txt", i)
+			writer, err := fsys.Create(context.Background(), name)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if _, err = writer.Write([]byte(name)); err != nil {
+				_ = writer.Close()
+				errs <- err
+				return
+			}
+			errs <- writer.Close()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent Create: %v", err)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("concurrent/%d.txt", i)
+		checkContent(t, fsys, name, []byte(name))
+	}
+}
+
+// writeFile 通过 Create 写入文件
+func writeFile(t *testing.T, fsys fs.FileSystem, name string, data []byte, opts ...fs.Option) {
+	t.Helper()
+	writer, err := fsys.Create(context.Background(), name, opts...)
+	if err != nil {
+		t.Fatalf("Create(%q): %v", name, err)
+	}
+	if _, err = writer.Write(data); err != nil {
+		_ = writer.Close()
+		t.Fatalf("Write(%q): %v", name, err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Close(%q): %v", name, err)
+	}
+}
+
+// checkContent 检查文件内容
+func checkContent(t *testing.T, fsys fs.FileSystem, name string, want []byte) {
+	t.Helper()
+	reader, err := fsys.Open(context.Background(), name)
+	if err != nil {
+		t.Fatalf("Open(%q): %v", name, err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Read(%q): %v", name, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("content of %q = %q (%d bytes), want %q (%d bytes)", name, truncate(got), len(got), truncate(want), len(want))
+	}
+}
+
+// checkKind 检查 Exists、IsFile 与 IsDir 的结果
+func checkKind(t *testing.T, fsys fs.FileSystem, name string, isFile, isDir bool) {
+	t.Helper()
+	ctx := context.Background()
+	if ok, err := fsys.Exists(ctx, name); err != nil || ok != (isFile || isDir) {
+		t.Errorf("Exists(%q) = %v, %v; want %v, nil", name, ok, err, isFile || isDir)
+	}
+	if ok, err := fsys.IsFile(ctx, name); err != nil || ok != isFile {
+		t.Errorf("IsFile(%q) = %v, %v; want %v, nil", name, ok, err, isFile)
+	}
+	if ok, err := fsys.IsDir(ctx, name); err != nil || ok != isDir {
+		t.Errorf("IsDir(%q) = %v, %v; want %v, nil", name, ok, err, isDir)
+	}
+}
+
+// checkFileInfo 检查文件信息
+func checkFileInfo(t *testing.T, info fs.FileInfo, name string, size int64, isDir bool) {
+	t.Helper()
+	if info.Name() != name {
+		t.Errorf("Name() = %q, want %q", info.Name(), name)
+	}
+	if !isDir && info.Size() != size {
+		t.Errorf("Size() of %q = %d, want %d", name, info.Size(), size)
+	}
+	if info.IsDir() != isDir || info.Mode().IsDir() != isDir {
+		t.Errorf("IsDir() of %q = %v, Mode() = %v; want dir %v", name, info.IsDir(), info.Mode(), isDir)
+	}
+}
+
+// checkList 检查目录内容，只比较名称与类型，不要求顺序
+func checkList(t *testing.T, fsys fs.FileSystem, dir string, files, dirs []string) {
+	t.Helper()
+	infos, err := fsys.List(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("List(%q): %v", dir, err)
+	}
+
+	var gotFiles, gotDirs []string
+	for _, info := range infos {
+		if info.IsDir() {
+			if !info.Mode().IsDir() {
+				t.Errorf("List(%q): directory %q has mode %v, want ModeDir", dir, info.Name(), info.Mode())
+			}
+			gotDirs = append(gotDirs, info.Name())
+		} else {
+			gotFiles = append(gotFiles, info.Name())
+		}
+	}
+	slices.Sort(gotFiles)
+	slices.Sort(gotDirs)
+	files, dirs = slices.Sorted(slices.Values(files)), slices.Sorted(slices.Values(dirs))
+	if !slices.Equal(gotFiles, files) || !slices.Equal(gotDirs, dirs) {
+		t.Errorf("List(%q) = files %q, dirs %q; want files %q, dirs %q", dir, gotFiles, gotDirs, files, dirs)
+	}
+}
+
+// checkMetadata 检查元数据，键名不区分大小写
+func checkMetadata(t *testing.T, fsys fs.FileSystem, name, key, want string) {
+	t.Helper()
+	metadata, err := fsys.GetMetadata(context.Background(), name)
+	if err != nil {
+		t.Fatalf("GetMetadata(%q): %v", name, err)
+	}
+	for k, v := range metadata {
+		if strings.EqualFold(k, key) {
+			if got := fmt.Sprintf("%v", v); got != want {
+				t.Errorf("GetMetadata(%q)[%q] = %q, want %q", name, key, got, want)
+			}
+			return
+		}
+	}
+	t.Errorf("GetMetadata(%q) = %v, missing key %q", name, metadata, key)
+}
+
+// checkNotExist 检查错误是否为 ErrNotExist
+func checkNotExist(t *testing.T, op string, err error) {
+	t.Helper()
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("%s: got %v, want ErrNotExist", op, err)
+	}
+}
+
+// checkParts 检查已上传的分片，ETag 忽略引号
+func checkParts(t *testing.T, got, want []fs.MultipartPart) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("ListUploadedParts returned %d parts, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].PartNumber != want[i].PartNumber ||
+			got[i].Size != want[i].Size ||
+			strings.Trim(got[i].ETag, `"`) != strings.Trim(want[i].ETag, `"`) {
+			t.Errorf("part %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// hasUpload 判断未完成的分片上传中是否包含指定的上传
+func hasUpload(t *testing.T, uploader fs.Uploader, uploadID, name string) bool {
+	t.Helper()
+	uploads, err := uploader.ListMultipartUploads(context.Background())
+	if err != nil {
+		t.Fatalf("ListMultipartUploads: %v", err)
+	}
+	for _, upload := range uploads {
+		if upload.UploadID == uploadID {
+			if !strings.HasSuffix(upload.Path, name) {
+				t.Errorf("upload %s has path %q, want %q", uploadID, upload.Path, name)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func checkNoUploads(t *testing.T, uploader fs.Uploader, name string) {
+	t.Helper()
+	uploads, err := uploader.ListMultipartUploads(context.Background())
+	if err != nil {
+		t.Fatalf("ListMultipartUploads: %v", err)
+	}
+	for _, upload := range uploads {
+		if strings.HasSuffix(upload.Path, name) {
+			t.Errorf("multipart upload %s of %s left", upload.UploadID, name)
+		}
+	}
+}
+
+func truncate(data []byte) []byte {
+	if len(data) > 64 {
+		return data[:64]
+	}
+	return data
+}
@@ -0,0 +1,685 @@
+// Package s3fake 提供进程内的 S3 协议模拟服务，
+// 用于在没有真实对象存储的情况下测试 S3、MinIO 等兼容 S3 协议的驱动。
+//
+// 仅支持路径样式访问，不校验请求签名，数据全部保存在内存中。
+package s3fake
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MinPartSize 除最后一个分片外，每个分片的最小大小，与 S3 保持一致
+const MinPartSize = 5 << 20
+
+// Server 基于 httptest.Server 的 S3 模拟服务
+type Server struct {
+	*Handler
+
+	URL      string // 服务地址，如 http://127.0.0.1:12345
+	Endpoint string // 不含协议头的服务地址，如 127.0.0.1:12345
+
+	server *httptest.Server
+}
+
+// New 启动 S3 模拟服务，使用完毕后需调用 Close
+func New() *Server {
+	handler := NewHandler()
+	server := httptest.NewServer(handler)
+	return &Server{
+		Handler:  handler,
+		URL:      server.URL,
+		Endpoint: strings.TrimPrefix(server.URL, "http://"),
+		server:   server,
+	}
+}
+
+// Close 关闭服务
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Handler 实现 S3 协议的 http.Handler
+type Handler struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	nextID  int
+}
+
+type bucket struct {
+	objects map[string]*object
+	uploads map[string]*upload
+}
+
+type object struct {
+	data         []byte
+	contentType  string
+	metadata     map[string]string
+	lastModified time.Time
+	etag         string
+}
+
+type upload struct {
+	key         string
+	contentType string
+	metadata    map[string]string
+	initiated   time.Time
+	parts       map[int]*object
+}
+
+// NewHandler 创建 S3 协议处理器
+func NewHandler() *Handler {
+	return &Handler{buckets: make(map[string]*bucket)}
+}
+
+// CreateBucket 创建存储桶，已存在时不做处理
+func (h *Handler) CreateBucket(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.createBucket(name)
+}
+
+func (h *Handler) createBucket(name string) {
+	if _, ok := h.buckets[name]; !ok {
+		h.buckets[name] = &bucket{
+			objects: make(map[string]*object),
+			uploads: make(map[string]*upload),
+		}
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucketName == "" {
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", "service level operations are not supported")
+		return
+	}
+
+	// 在加锁前读取请求体，避免慢速上传阻塞其他请求
+	var body []byte
+	if r.Method == http.MethodPut || r.Method == http.MethodPost {
+		var err error
+		if body, err = readBody(r); err != nil {
+			writeError(w, r, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if key == "" {
+		h.serveBucket(w, r, bucketName)
+		return
+	}
+
+	b, ok := h.buckets[bucketName]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket", "the specified bucket does not exist")
+		return
+	}
+	h.serveObject(w, r, b, bucketName, key, body)
+}
+
+func (h *Handler) serveBucket(w http.ResponseWriter, r *http.Request, name string) {
+	query := r.URL.Query()
+	if r.Method == http.MethodPut {
+		h.createBucket(name)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	b, ok := h.buckets[name]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket", "the specified bucket does not exist")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete:
+		delete(h.buckets, name)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && query.Has("location"):
+		writeXML(w, http.StatusOK, locationConstraint{Xmlns: xmlns})
+	case r.Method == http.MethodGet && query.Has("uploads"):
+		listMultipartUploads(w, b, name, query)
+	case r.Method == http.MethodGet:
+		listObjects(w, b, name, query)
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", "unsupported bucket operation")
+	}
+}
+
+func (h *Handler) serveObject(w http.ResponseWriter, r *http.Request, b *bucket, bucketName, key string, body []byte) {
+	query := r.URL.Query()
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if query.Has("uploadId") {
+			listParts(w, r, b, bucketName, key, query)
+			return
+		}
+		getObject(w, r, b, key)
+	case http.MethodPut:
+		if query.Has("uploadId") {
+			uploadPart(w, r, b, query, body)
+			return
+		}
+		if r.Header.Get("X-Amz-Copy-Source") != "" {
+			h.copyObject(w, r, b, key)
+			return
+		}
+		putObject(w, r, b, key, body)
+	case http.MethodDelete:
+		if query.Has("uploadId") {
+			if _, ok := b.uploads[query.Get("uploadId")]; !ok {
+				writeError(w, r, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+				return
+			}
+			delete(b.uploads, query.Get("uploadId"))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		delete(b.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPost:
+		if query.Has("uploads") {
+			h.nextID++
+			uploadID := fmt.Sprintf("upload-%d", h.nextID)
+			b.uploads[uploadID] = &upload{
+				key:         key,
+				contentType: r.Header.Get("Content-Type"),
+				metadata:    metadataFromHeader(r.Header),
+				initiated:   now(),
+				parts:       make(map[int]*object),
+			}
+			writeXML(w, http.StatusOK, initiateMultipartUploadResult{Bucket: bucketName, Key: key, UploadID: uploadID})
+			return
+		}
+		if query.Has("uploadId") {
+			completeMultipartUpload(w, r, b, bucketName, key, query.Get("uploadId"), body)
+			return
+		}
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", "unsupported object operation")
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "the specified method is not allowed")
+	}
+}
+
+func getObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	obj, ok := b.objects[key]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey", "the specified key does not exist")
+		return
+	}
+	if match := r.Header.Get("If-Match"); match != "" && !etagMatch(match, obj.etag) {
+		writeError(w, r, http.StatusPreconditionFailed, "PreconditionFailed", "at least one of the preconditions you specified did not hold")
+		return
+	}
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatch(match, obj.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", obj.contentType)
+	header.Set("ETag", obj.etag)
+	header.Set("Last-Modified", obj.lastModified.Format(http.TimeFormat))
+	header.Set("Accept-Ranges", "bytes")
+	if r.Header.Get("Range") == "" && strings.EqualFold(r.Header.Get("X-Amz-Checksum-Mode"), "ENABLED") {
+		header.Set("X-Amz-Checksum-Crc32", obj.checksum())
+		header.Set("X-Amz-Checksum-Type", "FULL_OBJECT")
+	}
+	for k, v := range obj.metadata {
+		header.Set("X-Amz-Meta-"+k, v)
+	}
+
+	data, status := obj.data, http.StatusOK
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		start, end, ok := parseRange(rangeHeader, int64(len(obj.data)))
+		if !ok {
+			header.Set("Content-Range", fmt.Sprintf("bytes */%d", len(obj.data)))
+			writeError(w, r, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "the requested range is not satisfiable")
+			return
+		}
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(obj.data)))
+		data, status = obj.data[start:end+1], http.StatusPartialContent
+	}
+
+	header.Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+func putObject(w http.ResponseWriter, r *http.Request, b *bucket, key string, data []byte) {
+	if existing, ok := b.objects[key]; ok {
+		if r.Header.Get("If-None-Match") == "*" {
+			writeError(w, r, http.StatusPreconditionFailed, "PreconditionFailed", "at least one of the preconditions you specified did not hold")
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && !etagMatch(match, existing.etag) {
+			writeError(w, r, http.StatusPreconditionFailed, "PreconditionFailed", "at least one of the preconditions you specified did not hold")
+			return
+		}
+	} else if r.Header.Get("If-Match") != "" {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey", "the specified key does not exist")
+		return
+	}
+
+	obj := newObject(data, r.Header.Get("Content-Type"), metadataFromHeader(r.Header))
+	b.objects[key] = obj
+	w.Header().Set("ETag", obj.etag)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) copyObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	source, err := url.PathUnescape(strings.TrimPrefix(r.Header.Get("X-Amz-Copy-Source"), "/"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", "invalid copy source")
+		return
+	}
+	source, _, _ = strings.Cut(source, "?")
+	srcBucketName, srcKey, _ := strings.Cut(source, "/")
+
+	srcBucket, ok := h.buckets[srcBucketName]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket", "the specified bucket does not exist")
+		return
+	}
+	src, ok := srcBucket.objects[srcKey]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey", "the specified key does not exist")
+		return
+	}
+
+	contentType, metadata := src.contentType, src.metadata
+	if strings.EqualFold(r.Header.Get("X-Amz-Metadata-Directive"), "REPLACE") {
+		contentType, metadata = r.Header.Get("Content-Type"), metadataFromHeader(r.Header)
+	} else if srcBucket == b && srcKey == key {
+		writeError(w, r, http.StatusBadRequest, "InvalidRequest", "copying an object to itself without changing the metadata is not allowed")
+		return
+	}
+
+	obj := newObject(src.data, contentType, metadata)
+	b.objects[key] = obj
+	writeXML(w, http.StatusOK, copyObjectResult{ETag: obj.etag, LastModified: formatTime(obj.lastModified)})
+}
+
+func uploadPart(w http.ResponseWriter, r *http.Request, b *bucket, query url.Values, data []byte) {
+	u, ok := b.uploads[query.Get("uploadId")]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+	partNumber, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > 10000 {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", "part number must be an integer between 1 and 10000")
+		return
+	}
+
+	part := newObject(data, "", nil)
+	u.parts[partNumber] = part
+	w.Header().Set("ETag", part.etag)
+	w.WriteHeader(http.StatusOK)
+}
+
+func completeMultipartUpload(w http.ResponseWriter, r *http.Request, b *bucket, bucketName, key, uploadID string, body []byte) {
+	u, ok := b.uploads[uploadID]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+
+	var request completeMultipartUploadRequest
+	if err := xml.Unmarshal(body, &request); err != nil || len(request.Parts) == 0 {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML", "the XML you provided was not well-formed")
+		return
+	}
+
+	var data bytes.Buffer
+	var sums []byte
+	for i, p := range request.Parts {
+		if i > 0 && p.PartNumber <= request.Parts[i-1].PartNumber {
+			writeError(w, r, http.StatusBadRequest, "InvalidPartOrder", "the list of parts was not in ascending order")
+			return
+		}
+		part, ok := u.parts[p.PartNumber]
+		if !ok || !etagMatch(p.ETag, part.etag) {
+			writeError(w, r, http.StatusBadRequest, "InvalidPart", "one or more of the specified parts could not be found")
+			return
+		}
+		if i < len(request.Parts)-1 && len(part.data) < MinPartSize {
+			writeError(w, r, http.StatusBadRequest, "EntityTooSmall", "your proposed upload is smaller than the minimum allowed object size")
+			return
+		}
+		data.Write(part.data)
+		sum, _ := hex.DecodeString(strings.Trim(part.etag, `"`))
+		sums = append(sums, sum...)
+	}
+
+	obj := newObject(data.Bytes(), u.contentType, u.metadata)
+	sum := md5.Sum(sums)
+	obj.etag = fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sum[:]), len(request.Parts))
+	b.objects[key] = obj
+	delete(b.uploads, uploadID)
+
+	writeXML(w, http.StatusOK, completeMultipartUploadResult{
+		Location: "/" + bucketName + "/" + key,
+		Bucket:   bucketName,
+		Key:      key,
+		ETag:     obj.etag,
+	})
+}
+
+func listObjects(w http.ResponseWriter, b *bucket, bucketName string, query url.Values) {
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	maxKeys := 1000
+	if v, err := strconv.Atoi(query.Get("max-keys")); err == nil && v >= 0 {
+		maxKeys = min(v, 1000)
+	}
+
+	v2 := query.Get("list-type") == "2"
+	marker := query.Get("marker")
+	if v2 {
+		marker = query.Get("start-after")
+		if token := query.Get("continuation-token"); token != "" {
+			decoded, _ := base64.StdEncoding.DecodeString(token)
+			marker = string(decoded)
+		}
+	}
+
+	keys := make([]string, 0, len(b.objects))
+	for k := range b.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var contents []listEntry
+	var prefixes []commonPrefix
+	var last string
+	truncated := false
+	for _, k := range keys {
+		if k <= marker {
+			continue
+		}
+		entry := k
+		if delimiter != "" {
+			if i := strings.Index(k[len(prefix):], delimiter); i >= 0 {
+				entry = k[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if entry == last || (entry != k && strings.HasPrefix(marker, entry)) {
+			// 同一公共前缀下的对象只返回一次
+			continue
+		}
+		if len(contents)+len(prefixes) >= maxKeys {
+			truncated = true
+			break
+		}
+		last = entry
+		if entry != k {
+			prefixes = append(prefixes, commonPrefix{Prefix: entry})
+			continue
+		}
+		obj := b.objects[k]
+		contents = append(contents, listEntry{
+			Key:          k,
+			LastModified: formatTime(obj.lastModified),
+			ETag:         obj.etag,
+			Size:         int64(len(obj.data)),
+			StorageClass: "STANDARD",
+		})
+	}
+
+	result := listBucketResult{
+		Name:           bucketName,
+		Prefix:         prefix,
+		Delimiter:      delimiter,
+		MaxKeys:        maxKeys,
+		IsTruncated:    truncated,
+		Contents:       contents,
+		CommonPrefixes: prefixes,
+	}
+	if v2 {
+		result.KeyCount = len(contents) + len(prefixes)
+		result.StartAfter = query.Get("start-after")
+		result.ContinuationToken = query.Get("continuation-token")
+		if truncated {
+			result.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(last))
+		}
+	} else {
+		result.Marker = marker
+		if truncated {
+			result.NextMarker = last
+		}
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+func listMultipartUploads(w http.ResponseWriter, b *bucket, bucketName string, query url.Values) {
+	prefix := query.Get("prefix")
+	result := listMultipartUploadsResult{Bucket: bucketName, Prefix: prefix, MaxUploads: 1000}
+	for uploadID, u := range b.uploads {
+		if !strings.HasPrefix(u.key, prefix) {
+			continue
+		}
+		result.Uploads = append(result.Uploads, uploadEntry{
+			Key:          u.key,
+			UploadID:     uploadID,
+			Initiated:    formatTime(u.initiated),
+			StorageClass: "STANDARD",
+		})
+	}
+	sort.Slice(result.Uploads, func(i, j int) bool {
+		if result.Uploads[i].Key != result.Uploads[j].Key {
+			return result.Uploads[i].Key < result.Uploads[j].Key
+		}
+		return result.Uploads[i].UploadID < result.Uploads[j].UploadID
+	})
+	writeXML(w, http.StatusOK, result)
+}
+
+func listParts(w http.ResponseWriter, r *http.Request, b *bucket, bucketName, key string, query url.Values) {
+	uploadID := query.Get("uploadId")
+	u, ok := b.uploads[uploadID]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+
+	marker, _ := strconv.Atoi(query.Get("part-number-marker"))
+	maxParts := 1000
+	if v, err := strconv.Atoi(query.Get("max-parts")); err == nil && v > 0 {
+		maxParts = min(v, 1000)
+	}
+
+	numbers := make([]int, 0, len(u.parts))
+	for n := range u.parts {
+		if n > marker {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+
+	result := listPartsResult{
+		Bucket:           bucketName,
+		Key:              key,
+		UploadID:         uploadID,
+		PartNumberMarker: marker,
+		MaxParts:         maxParts,
+	}
+	if len(numbers) > maxParts {
+		numbers = numbers[:maxParts]
+		result.IsTruncated = true
+	}
+	for _, n := range numbers {
+		part := u.parts[n]
+		result.Parts = append(result.Parts, partEntry{
+			PartNumber:   n,
+			LastModified: formatTime(part.lastModified),
+			ETag:         part.etag,
+			Size:         int64(len(part.data)),
+		})
+		result.NextPartNumberMarker = n
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+func newObject(data []byte, contentType string, metadata map[string]string) *object {
+	if contentType == "" {
+		contentType = "binary/octet-stream"
+	}
+	sum := md5.Sum(data)
+	return &object{
+		data:         data,
+		contentType:  contentType,
+		metadata:     metadata,
+		lastModified: now(),
+		etag:         `"` + hex.EncodeToString(sum[:]) + `"`,
+	}
+}
+
+// checksum 返回 base64 编码的 CRC32 校验和
+func (obj *object) checksum() string {
+	sum := crc32.ChecksumIEEE(obj.data)
+	return base64.StdEncoding.EncodeToString([]byte{byte(sum >> 24), byte(sum >> 16), byte(sum >> 8), byte(sum)})
+}
+
+// now 返回当前时间，与 S3 一致只保留到秒
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func metadataFromHeader(header http.Header) map[string]string {
+	metadata := make(map[string]string)
+	for k, v := range header {
+		if name, ok := strings.CutPrefix(k, "X-Amz-Meta-"); ok && len(v) > 0 {
+			metadata[strings.ToLower(name)] = v[0]
+		}
+	}
+	return metadata
+}
+
+func etagMatch(condition, etag string) bool {
+	if condition == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(condition, ",") {
+		if strings.Trim(strings.TrimSpace(candidate), `"`) == strings.Trim(etag, `"`) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRange 解析单个字节范围，返回闭区间
+func parseRange(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	startStr, endStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	if startStr == "" {
+		suffix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || suffix <= 0 || size == 0 {
+			return 0, 0, false
+		}
+		return max(size-suffix, 0), size - 1, true
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if endStr != "" {
+		if end, err = strconv.ParseInt(endStr, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+		end = min(end, size-1)
+	}
+	return start, end, true
+}
+
+// readBody 读取请求体，兼容 aws-chunked 分块编码
+func readBody(r *http.Request) ([]byte, error) {
+	chunked := strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") ||
+		strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked")
+	if !chunked {
+		return io.ReadAll(r.Body)
+	}
+
+	var data bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("read chunk header: %w", err)
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chunk size %q", sizeHex)
+		}
+		if size == 0 {
+			// 剩余部分为校验和等尾部字段，直接忽略
+			_, _ = io.Copy(io.Discard, reader)
+			return data.Bytes(), nil
+		}
+		if _, err = io.CopyN(&data, reader, size); err != nil {
+			return nil, fmt.Errorf("read chunk: %w", err)
+		}
+		if _, err = reader.Discard(2); err != nil {
+			return nil, fmt.Errorf("read chunk: %w", err)
+		}
+	}
+}
+
+func writeXML(w http.ResponseWriter, status int, v any) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(xml.Header)+len(body)))
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, xml.Header)
+	_, _ = w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+	writeXML(w, status, errorResponse{Code: code, Message: message, Resource: r.URL.Path, RequestID: "s3fake"})
+}
@@ -0,0 +1,60 @@
+// Package ioutil 提供各装饰器包装上传数据时共用的 io 辅助函数
+package ioutil
+
+import "io"
+
+// CountingReader 统计读取字节数的 io.Reader
+type CountingReader struct {
+	io.Reader
+	N      int64
+	OnRead func(n int) // 不为空时每次读取后调用
+}
+
+func (r *CountingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.N += int64(n)
+	if r.OnRead != nil {
+		r.OnRead(n)
+	}
+	return n, err
+}
+
+// CountReader 包装上传的 io.Reader，返回包装后的 reader 与读取字节数的计数器，包装后的 reader 保留 io.Seeker 接口
+func CountReader(r io.Reader) (io.Reader, *CountingReader) {
+	counter := &CountingReader{Reader: r}
+	return KeepSeeker(counter, r), counter
+}
+
+// KeepSeeker 返回 wrapped，r 实现 io.Seeker 时返回的 reader 同时实现 io.Seeker 并转发给 r。
+// 驱动与重试依赖 io.Seeker 确定长度或回到起始位置，包装上传的 io.Reader 时不能隐藏该接口
+func KeepSeeker(wrapped, r io.Reader) io.Reader {
+	if seeker, ok := r.(io.Seeker); ok {
+		return &seekingReader{Reader: wrapped, seeker: seeker}
+	}
+	return wrapped
+}
+
+type seekingReader struct {
+	io.Reader
+	seeker io.Seeker
+}
+
+func (r *seekingReader) Seek(offset int64, whence int) (int64, error) {
+	return r.seeker.Seek(offset, whence)
+}
+
+// Remaining 返回 seeker 从当前位置到末尾的字节数
+func Remaining(seeker io.Seeker) (int64, error) {
+	cur, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = seeker.Seek(cur, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return end - cur, nil
+}
@@ -0,0 +1,251 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	iofs "io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AsIOFS 将 FileSystem 适配为标准库 io/fs.FS，
+// 返回值同时实现了 fs.ReadDirFS、fs.StatFS、fs.ReadFileFS 与 fs.SubFS，
+// 可直接用于 http.FileServerFS、template.ParseFS、fs.WalkDir 等标准库函数
+func AsIOFS(ctx context.Context, fsys FileSystem) iofs.FS {
+	return &ioFS{ctx: ctx, fsys: fsys}
+}
+
+type ioFS struct {
+	ctx  context.Context
+	fsys FileSystem
+	root string // Sub 后的根目录
+}
+
+var (
+	_ iofs.ReadDirFS  = (*ioFS)(nil)
+	_ iofs.StatFS     = (*ioFS)(nil)
+	_ iofs.ReadFileFS = (*ioFS)(nil)
+	_ iofs.SubFS      = (*ioFS)(nil)
+)
+
+func (f *ioFS) Open(name string) (iofs.File, error) {
+	if !iofs.ValidPath(name) {
+		return nil, &iofs.PathError{Op: "open", Path: name, Err: iofs.ErrInvalid}
+	}
+
+	info, err := f.stat(name)
+	if err != nil {
+		return nil, &iofs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if info.IsDir() {
+		return &ioDir{fsys: f, name: name, info: info}, nil
+	}
+
+	// 返回的文件支持 Seek 与 ReadAt，http.FileServerFS 等可以直接处理范围请求
+	reader, err := openRangeReader(f.ctx, f.fsys, f.path(name), info.Size())
+	if err != nil {
+		return nil, &iofs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &ioFile{RangeReader: reader, info: info}, nil
+}
+
+func (f *ioFS) Stat(name string) (iofs.FileInfo, error) {
+	if !iofs.ValidPath(name) {
+		return nil, &iofs.PathError{Op: "stat", Path: name, Err: iofs.ErrInvalid}
+	}
+
+	info, err := f.stat(name)
+	if err != nil {
+		return nil, &iofs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return info, nil
+}
+
+func (f *ioFS) ReadDir(name string) ([]iofs.DirEntry, error) {
+	if !iofs.ValidPath(name) {
+		return nil, &iofs.PathError{Op: "readdir", Path: name, Err: iofs.ErrInvalid}
+	}
+
+	info, err := f.stat(name)
+	if err != nil {
+		return nil, &iofs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if !info.IsDir() {
+		return nil, &iofs.PathError{Op: "readdir", Path: name, Err: ErrNotDir}
+	}
+
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &iofs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	return entries, nil
+}
+
+func (f *ioFS) ReadFile(name string) ([]byte, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, &iofs.PathError{Op: "read", Path: name, Err: errors.Unwrap(err)}
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if _, ok := file.(*ioDir); ok {
+		return nil, &iofs.PathError{Op: "read", Path: name, Err: ErrIsDir}
+	}
+	return io.ReadAll(file)
+}
+
+func (f *ioFS) Sub(dir string) (iofs.FS, error) {
+	if !iofs.ValidPath(dir) {
+		return nil, &iofs.PathError{Op: "sub", Path: dir, Err: iofs.ErrInvalid}
+	}
+	if dir == "." {
+		return f, nil
+	}
+	return &ioFS{ctx: f.ctx, fsys: f.fsys, root: f.path(dir)}, nil
+}
+
+// path 将 io/fs 路径转换为 FileSystem 路径
+func (f *ioFS) path(name string) string {
+	if name == "." {
+		return f.root
+	}
+	if f.root == "" {
+		return name
+	}
+	return f.root + "/" + name
+}
+
+// stat 获取文件信息，对象存储中不存在的目录对象通过前缀判断
+func (f *ioFS) stat(name string) (iofs.FileInfo, error) {
+	info, err := f.fsys.Stat(f.ctx, f.path(name))
+	if err == nil {
+		return &ioFileInfo{FileInfo: info, name: path.Base(name)}, nil
+	}
+	if name == "." {
+		return &ioDirInfo{name: "."}, nil
+	}
+	if !errors.Is(err, ErrNotExist) {
+		return nil, err
+	}
+
+	isDir, dirErr := f.fsys.IsDir(f.ctx, f.path(name))
+	if dirErr != nil || !isDir {
+		return nil, err
+	}
+	return &ioDirInfo{name: path.Base(name)}, nil
+}
+
+// readDir 列出目录内容并按名称排序
+func (f *ioFS) readDir(name string) ([]iofs.DirEntry, error) {
+	files, err := f.fsys.List(f.ctx, f.path(name))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]iofs.DirEntry, 0, len(files))
+	for _, file := range files {
+		// 兼容返回完整路径作为名称的 FileSystem 实现，这里统一取最后一级名称
+		entryName := path.Base(strings.TrimSuffix(file.Name(), "/"))
+		if entryName == "" || entryName == "." || entryName == "/" {
+			continue
+		}
+		entries = append(entries, iofs.FileInfoToDirEntry(&ioFileInfo{FileInfo: file, name: entryName}))
+	}
+	slices.SortFunc(entries, func(a, b iofs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// ioFile 实现 io/fs.File 接口，同时支持 io.Seeker 与 io.ReaderAt
+type ioFile struct {
+	RangeReader
+	info iofs.FileInfo
+}
+
+func (file *ioFile) Stat() (iofs.FileInfo, error) {
+	return file.info, nil
+}
+
+// ioDir 实现 io/fs.ReadDirFile 接口
+type ioDir struct {
+	fsys    *ioFS
+	name    string
+	info    iofs.FileInfo
+	entries []iofs.DirEntry
+	offset  int
+	loaded  bool
+}
+
+func (dir *ioDir) Stat() (iofs.FileInfo, error) {
+	return dir.info, nil
+}
+
+func (dir *ioDir) Read(_ []byte) (int, error) {
+	return 0, &iofs.PathError{Op: "read", Path: dir.name, Err: ErrIsDir}
+}
+
+func (dir *ioDir) Close() error {
+	return nil
+}
+
+func (dir *ioDir) ReadDir(n int) ([]iofs.DirEntry, error) {
+	if !dir.loaded {
+		entries, err := dir.fsys.readDir(dir.name)
+		if err != nil {
+			return nil, &iofs.PathError{Op: "readdir", Path: dir.name, Err: err}
+		}
+		dir.entries = entries
+		dir.loaded = true
+	}
+
+	remain := len(dir.entries) - dir.offset
+	if n <= 0 {
+		entries := dir.entries[dir.offset:]
+		dir.offset = len(dir.entries)
+		return entries, nil
+	}
+	if remain == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, remain)
+	entries := dir.entries[dir.offset : dir.offset+n]
+	dir.offset += n
+	return entries, nil
+}
+
+// ioFileInfo 统一文件名为最后一级名称，并为目录补充 ModeDir
+type ioFileInfo struct {
+	FileInfo
+	name string
+}
+
+func (info *ioFileInfo) Name() string {
+	return info.name
+}
+
+func (info *ioFileInfo) Mode() iofs.FileMode {
+	mode := info.FileInfo.Mode()
+	if info.FileInfo.IsDir() && mode&iofs.ModeDir == 0 {
+		// 对象存储的目录为公共前缀，没有实际权限，与 ioDirInfo 保持一致
+		return iofs.ModeDir | 0755
+	}
+	return mode
+}
+
+// ioDirInfo 对象存储中没有实体对象的目录信息
+type ioDirInfo struct {
+	name string
+}
+
+func (info *ioDirInfo) Name() string        { return info.name }
+func (info *ioDirInfo) Size() int64         { return 0 }
+func (info *ioDirInfo) Mode() iofs.FileMode { return iofs.ModeDir | 0755 }
+func (info *ioDirInfo) ModTime() time.Time  { return time.Time{} }
+func (info *ioDirInfo) IsDir() bool         { return true }
+func (info *ioDirInfo) Sys() interface{}    { return nil }
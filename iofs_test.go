@@ -0,0 +1,115 @@
+package fs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	iofs "io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/memory"
+)
+
+// newMemory 返回写入了 files 的内存文件系统
+func newMemory(t *testing.T, files map[string]string) fs.FileSystem {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, data := range files {
+		writeFile(t, m, path, data)
+	}
+	return m
+}
+
+func writeFile(t *testing.T, fsys fs.FileSystem, path, data string) {
+	t.Helper()
+	if err := fsys.Uploader().Upload(context.Background(), path, strings.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAsIOFS(t *testing.T) {
+	m := newMemory(t, map[string]string{
+		"a.txt":     "a",
+		"d/b.txt":   "bb",
+		"d/e/c.txt": "ccc",
+	})
+	fsys := fs.AsIOFS(context.Background(), m)
+	if err := fstest.TestFS(fsys, "a.txt", "d/b.txt", "d/e/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := iofs.Sub(fsys, "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(sub, "b.txt", "e/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := iofs.ReadFile(sub, "e/c.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "ccc" {
+		t.Fatalf("ReadFile = %q, want %q", data, "ccc")
+	}
+}
+
+func TestAsIOFSErrors(t *testing.T) {
+	fsys := fs.AsIOFS(context.Background(), newMemory(t, map[string]string{"d/a.txt": "a"}))
+
+	if _, err := fsys.Open("missing.txt"); !errors.Is(err, iofs.ErrNotExist) {
+		t.Errorf("Open missing file: %v, want ErrNotExist", err)
+	}
+	if _, err := iofs.Stat(fsys, "d/missing.txt"); !errors.Is(err, iofs.ErrNotExist) {
+		t.Errorf("Stat missing file: %v, want ErrNotExist", err)
+	}
+	for _, name := range []string{"/d/a.txt", "d/../a.txt", "d/", ""} {
+		if _, err := fsys.Open(name); !errors.Is(err, iofs.ErrInvalid) {
+			t.Errorf("Open(%q): %v, want ErrInvalid", name, err)
+		}
+	}
+	if _, err := iofs.ReadFile(fsys, "d"); !errors.Is(err, fs.ErrIsDir) {
+		t.Errorf("ReadFile directory: %v, want ErrIsDir", err)
+	}
+	if _, err := iofs.ReadDir(fsys, "d/a.txt"); !errors.Is(err, fs.ErrNotDir) {
+		t.Errorf("ReadDir file: %v, want ErrNotDir", err)
+	}
+}
+
+func TestAsIOFSSeek(t *testing.T) {
+	fsys := fs.AsIOFS(context.Background(), newMemory(t, map[string]string{"a.txt": "0123456789"}))
+	file, err := fsys.Open("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	seeker, ok := file.(io.ReadSeeker)
+	if !ok {
+		t.Fatal("file does not implement io.Seeker")
+	}
+	if _, err := seeker.Seek(-3, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(seeker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "789" {
+		t.Fatalf("read after Seek = %q, want %q", data, "789")
+	}
+
+	buf := make([]byte, 4)
+	if _, err := file.(io.ReaderAt).ReadAt(buf, 2); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "2345" {
+		t.Fatalf("ReadAt = %q, want %q", buf, "2345")
+	}
+}
@@ -0,0 +1,98 @@
+package fs
+
+import (
+	"context"
+	"iter"
+	"sort"
+)
+
+// DefaultListLimit 分页列出时每页的默认数量
+const DefaultListLimit = 1000
+
+// ListResult 分页列出的结果
+type ListResult struct {
+	Files      []FileInfo // 当前页的文件和目录
+	NextMarker string     // 下一页的标记，为空时表示没有更多内容
+}
+
+// ListPager 由支持分页列出的驱动实现，ListPage 与 ListSeq 会优先使用该接口
+type ListPager interface {
+	// ListPage 分页列出目录内容，每页数量由 WithLimit 设置，通过 WithMarker 传入上一页返回的 NextMarker 获取下一页
+	ListPage(ctx context.Context, path string, opts ...Option) (*ListResult, error)
+}
+
+// ListPage 分页列出目录内容，每页数量由 WithLimit 设置，通过 WithMarker 传入上一页返回的 NextMarker 获取下一页。
+// 标记的格式由驱动决定，调用方不应解析；驱动未实现 ListPager 时列出全部内容后按名称分页
+func ListPage(ctx context.Context, fsys FileSystem, path string, opts ...Option) (*ListResult, error) {
+	if pager, ok := fsys.(ListPager); ok {
+		return pager.ListPage(ctx, path, opts...)
+	}
+	return listPage(ctx, fsys, path, opts...)
+}
+
+// listPage 列出全部内容后按名称分页
+func listPage(ctx context.Context, fsys FileSystem, path string, opts ...Option) (*ListResult, error) {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	files, err := fsys.List(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+
+	start := 0
+	if o.Marker != "" {
+		start = sort.Search(len(files), func(i int) bool {
+			return files[i].Name() > o.Marker
+		})
+	}
+	end := min(start+o.ListLimit(), len(files))
+
+	result := &ListResult{Files: files[start:end]}
+	if end < len(files) {
+		result.NextMarker = files[end-1].Name()
+	}
+	return result, nil
+}
+
+// ListSeq 逐页列出目录内容并依次返回，只在需要时才请求下一页，适用于内容很多的目录：
+//
+//	for info, err := range fs.ListSeq(ctx, fsys, "logs") {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(info.Name())
+//	}
+func ListSeq(ctx context.Context, fsys FileSystem, path string, opts ...Option) iter.Seq2[FileInfo, error] {
+	return func(yield func(FileInfo, error) bool) {
+		opts := append([]Option(nil), opts...)
+		for {
+			result, err := ListPage(ctx, fsys, path, opts...)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, info := range result.Files {
+				if !yield(info, nil) {
+					return
+				}
+			}
+			if result.NextMarker == "" {
+				return
+			}
+			opts = append(opts, WithMarker(result.NextMarker))
+		}
+	}
+}
+
+// ListLimit 返回分页列出时每页的数量，未设置时为 DefaultListLimit
+func (o *Options) ListLimit() int {
+	if o.Limit > 0 {
+		return o.Limit
+	}
+	return DefaultListLimit
+}
@@ -0,0 +1,126 @@
+package fs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/goairix/fs"
+)
+
+// pageCounter 记录 ListPage 的调用次数
+type pageCounter struct {
+	fs.FileSystem
+	pages int
+}
+
+func (c *pageCounter) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	c.pages++
+	return fs.ListPage(ctx, c.FileSystem, path, opts...)
+}
+
+// failingList 的 List 总是返回 err
+type failingList struct {
+	fs.FileSystem
+	err error
+}
+
+func (f failingList) List(context.Context, string, ...fs.Option) ([]fs.FileInfo, error) {
+	return nil, f.err
+}
+
+func newListFS(t *testing.T, n int) (fs.FileSystem, []string) {
+	t.Helper()
+	files := map[string]string{}
+	var names []string
+	for i := range n {
+		name := fmt.Sprintf("f%02d.txt", i)
+		files["l/"+name] = name
+		names = append(names, name)
+	}
+	files["l/sub/x.txt"] = "x"
+	names = append(names, "sub")
+	return newMemory(t, files), names
+}
+
+func TestListPage(t *testing.T) {
+	ctx := context.Background()
+	m, want := newListFS(t, 10)
+	for name, fsys := range map[string]fs.FileSystem{"driver": m, "fallback": listOnly{m}} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				got    []string
+				marker string
+				pages  int
+			)
+			for {
+				result, err := fs.ListPage(ctx, fsys, "l", fs.WithLimit(4), fs.WithMarker(marker))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(result.Files) > 4 {
+					t.Fatalf("page has %d entries, want at most 4", len(result.Files))
+				}
+				for _, info := range result.Files {
+					got = append(got, info.Name())
+				}
+				pages++
+				if result.NextMarker == "" {
+					break
+				}
+				marker = result.NextMarker
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("listed %q, want %q", got, want)
+			}
+			if pages != 3 {
+				t.Fatalf("listed %d pages, want 3", pages)
+			}
+		})
+	}
+}
+
+func TestListSeq(t *testing.T) {
+	ctx := context.Background()
+	m, want := newListFS(t, 10)
+	fsys := &pageCounter{FileSystem: listOnly{m}}
+
+	var got []string
+	for info, err := range fs.ListSeq(ctx, fsys, "l", fs.WithLimit(3)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, info.Name())
+	}
+	if !slices.Equal(got, want) || fsys.pages != 4 {
+		t.Fatalf("listed %q in %d pages, want %q in 4 pages", got, fsys.pages, want)
+	}
+
+	// 提前结束时不再请求后续的页
+	fsys.pages = 0
+	for info, err := range fs.ListSeq(ctx, fsys, "l", fs.WithLimit(3)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Name() == "f01.txt" {
+			break
+		}
+	}
+	if fsys.pages != 1 {
+		t.Fatalf("requested %d pages after break, want 1", fsys.pages)
+	}
+
+	errList := errors.New("list failed")
+	var errs []error
+	for info, err := range fs.ListSeq(ctx, failingList{m, errList}, "l") {
+		if info != nil {
+			t.Fatalf("ListSeq returned %q with a failing List", info.Name())
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], errList) {
+		t.Fatalf("ListSeq returned errors %v, want %v", errs, errList)
+	}
+}
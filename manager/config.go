@@ -0,0 +1,288 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goairix/fs"
+	"gopkg.in/yaml.v3"
+)
+
+// Config 多存储配置
+type Config struct {
+	Default string                `json:"default" yaml:"default"` // 默认存储名称，只有一个存储时可以为空
+	Disks   map[string]DiskConfig `json:"disks" yaml:"disks"`     // 存储名称 -> 存储配置
+}
+
+// DiskConfig 单个存储的配置，Driver 决定需要填写的字段，设置了 URL 时通过 fs.OpenURL 创建
+type DiskConfig struct {
+	Driver    string `json:"driver" yaml:"driver"`         // 驱动名称：local、memory、minio、alioss、hwobs、txcos、s3
+	URL       string `json:"url" yaml:"url"`               // 存储 URL，格式见 fs.OpenURL，与驱动配置二选一
+	CdnDomain string `json:"cdn_domain" yaml:"cdn_domain"` // 默认 CDN 域名，调用时未通过 fs.WithCdnDomain 指定时使用
+
+	RootPath        string `json:"root_path" yaml:"root_path"`                 // 根目录路径（local）
+	SubPath         string `json:"sub_path" yaml:"sub_path"`                   // 子目录路径
+	Endpoint        string `json:"endpoint" yaml:"endpoint"`                   // 服务地址
+	Region          string `json:"region" yaml:"region"`                       // 区域（s3）
+	AccessKeyID     string `json:"access_key_id" yaml:"access_key_id"`         // AccessKey
+	SecretAccessKey string `json:"secret_access_key" yaml:"secret_access_key"` // SecretKey
+	BucketName      string `json:"bucket_name" yaml:"bucket_name"`             // 存储桶名称
+	UsePathStyle    bool   `json:"use_path_style" yaml:"use_path_style"`       // 是否使用路径样式访问（s3）
+	UseSSL          bool   `json:"use_ssl" yaml:"use_ssl"`                     // 是否使用SSL（minio）
+	Location        string `json:"location" yaml:"location"`                   // 区域（minio）
+	BucketURL       string `json:"bucket_url" yaml:"bucket_url"`               // 存储桶URL（txcos）
+	SecretID        string `json:"secret_id" yaml:"secret_id"`                 // 密钥ID（txcos）
+	SecretKey       string `json:"secret_key" yaml:"secret_key"`               // 密钥Key（txcos）
+	BaseURL         string `json:"base_url" yaml:"base_url"`                   // 文件访问基础地址（memory）
+	SignKey         string `json:"sign_key" yaml:"sign_key"`                   // URL 签名密钥（memory）
+	AccessMode      string `json:"access_mode" yaml:"access_mode"`             // 访问模式：private、public-read、public-read-write
+}
+
+// ConfigError 配置错误，Field 为出错字段的路径，如 disks.public.bucket_name
+type ConfigError struct {
+	Field string // 出错的字段
+	Err   error  // 错误原因
+}
+
+func (e *ConfigError) Error() string {
+	return "manager: " + e.Field + ": " + e.Err.Error()
+}
+
+// Unwrap 返回错误原因
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	errRequired    = errors.New("required")
+	errUnsupported = errors.New("not supported by the driver")
+)
+
+// driverFields 各驱动支持的字段与必填字段，driver、url 与 cdn_domain 对所有驱动有效
+var driverFields = map[string]struct {
+	fields   []string
+	required []string
+}{
+	"local":  {[]string{"root_path", "sub_path"}, []string{"root_path"}},
+	"memory": {[]string{"base_url", "sign_key", "access_mode"}, nil},
+	"minio": {
+		[]string{"endpoint", "access_key_id", "secret_access_key", "use_ssl", "bucket_name", "sub_path", "location", "access_mode"},
+		[]string{"endpoint", "bucket_name"},
+	},
+	"alioss": {
+		[]string{"endpoint", "access_key_id", "secret_access_key", "bucket_name", "sub_path", "access_mode"},
+		[]string{"endpoint", "bucket_name"},
+	},
+	"hwobs": {
+		[]string{"endpoint", "access_key_id", "secret_access_key", "bucket_name", "sub_path", "access_mode"},
+		[]string{"endpoint", "bucket_name"},
+	},
+	"txcos": {
+		[]string{"bucket_url", "secret_id", "secret_key", "sub_path", "access_mode"},
+		[]string{"bucket_url"},
+	},
+	"s3": {
+		[]string{"region", "endpoint", "access_key_id", "secret_access_key", "bucket_name", "sub_path", "use_path_style", "access_mode"},
+		[]string{"region", "bucket_name"},
+	},
+}
+
+// Validate 检查配置，返回的错误由 *ConfigError 组成，通过 errors.As 可以获取出错的字段
+func (c *Config) Validate() error {
+	var errs []error
+	if len(c.Disks) == 0 {
+		errs = append(errs, &ConfigError{Field: "disks", Err: errRequired})
+	}
+	if c.Default != "" {
+		if _, ok := c.Disks[c.Default]; !ok {
+			errs = append(errs, &ConfigError{Field: "default", Err: fmt.Errorf("disk %q is not configured", c.Default)})
+		}
+	} else if len(c.Disks) > 1 {
+		errs = append(errs, &ConfigError{Field: "default", Err: errors.New("required when more than one disk is configured")})
+	}
+
+	names := make([]string, 0, len(c.Disks))
+	for name := range c.Disks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		disk := c.Disks[name]
+		errs = append(errs, disk.validate("disks."+name)...)
+	}
+	return errors.Join(errs...)
+}
+
+// validate 检查单个存储的配置，prefix 为字段路径的前缀
+func (c *DiskConfig) validate(prefix string) []error {
+	fieldErr := func(field string, err error) error {
+		return &ConfigError{Field: prefix + "." + field, Err: err}
+	}
+
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return []error{fieldErr("url", errors.New("invalid URL"))}
+		}
+		var errs []error
+		if c.Driver != "" {
+			errs = append(errs, fieldErr("driver", errors.New("must be empty when url is set")))
+		}
+		if !slices.Contains(fs.Drivers(), u.Scheme) {
+			errs = append(errs, fieldErr("url", fmt.Errorf("unknown scheme %q", u.Scheme)))
+		}
+		for _, field := range c.setFields() {
+			if field != "url" && field != "cdn_domain" && field != "driver" {
+				errs = append(errs, fieldErr(field, errors.New("must be empty when url is set")))
+			}
+		}
+		return errs
+	}
+
+	spec, ok := driverFields[c.Driver]
+	if !ok {
+		if c.Driver == "" {
+			return []error{fieldErr("driver", errRequired)}
+		}
+		return []error{fieldErr("driver", fmt.Errorf("unknown driver %q", c.Driver))}
+	}
+
+	var errs []error
+	set := c.setFields()
+	for _, field := range set {
+		if field != "driver" && field != "cdn_domain" && !slices.Contains(spec.fields, field) {
+			errs = append(errs, fieldErr(field, errUnsupported))
+		}
+	}
+	for _, field := range spec.required {
+		if !slices.Contains(set, field) {
+			errs = append(errs, fieldErr(field, errRequired))
+		}
+	}
+	if _, err := fs.ParseAccessMode(c.AccessMode); err != nil {
+		errs = append(errs, fieldErr("access_mode", err))
+	}
+	return errs
+}
+
+// setFields 返回已设置的字段名
+func (c *DiskConfig) setFields() []string {
+	var fields []string
+	v := reflect.ValueOf(c).Elem()
+	for i := range v.NumField() {
+		if !v.Field(i).IsZero() {
+			fields = append(fields, fieldName(v.Type().Field(i)))
+		}
+	}
+	return fields
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	return name
+}
+
+// envPattern 配置文件中引用的环境变量，只匹配 ${NAME} 形式，$NAME 原样保留
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv 将 ${NAME} 替换为环境变量，未设置时替换为空字符串
+func expandEnv(data []byte) []byte {
+	return envPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		name := envPattern.FindSubmatch(match)[1]
+		return []byte(os.Getenv(string(name)))
+	})
+}
+
+// LoadFile 读取 YAML 或 JSON 配置文件，格式由扩展名决定，文件中的 ${VAR} 会被替换为环境变量，$VAR 不会被替换
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = expandEnv(data)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	case ".json":
+		return ParseJSON(data)
+	}
+	return nil, fmt.Errorf("manager: unsupported config file %q, want .yaml, .yml or .json", path)
+}
+
+// ParseYAML 解析 YAML 配置，不允许出现未知字段
+func ParseYAML(data []byte) (*Config, error) {
+	conf := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(conf); err != nil {
+		return nil, fmt.Errorf("manager: parse yaml: %w", err)
+	}
+	return conf, nil
+}
+
+// ParseJSON 解析 JSON 配置，不允许出现未知字段
+func ParseJSON(data []byte) (*Config, error) {
+	conf := &Config{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(conf); err != nil {
+		return nil, fmt.Errorf("manager: parse json: %w", err)
+	}
+	return conf, nil
+}
+
+// FromEnv 从环境变量读取配置，prefix 为变量名前缀，如 FS：
+//
+//	FS_DISKS=public,docs         存储名称列表
+//	FS_DEFAULT=public            默认存储
+//	FS_PUBLIC_DRIVER=alioss      存储的字段，变量名为 <前缀>_<存储名称>_<字段名>，均为大写
+//	FS_PUBLIC_BUCKET_NAME=assets
+//
+// 存储名称中的 "-" 在变量名中替换为 "_"
+func FromEnv(prefix string) (*Config, error) {
+	prefix = strings.ToUpper(prefix) + "_"
+	conf := &Config{
+		Default: os.Getenv(prefix + "DEFAULT"),
+		Disks:   make(map[string]DiskConfig),
+	}
+
+	for _, name := range strings.Split(os.Getenv(prefix+"DISKS"), ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		diskPrefix := prefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+		var disk DiskConfig
+		v := reflect.ValueOf(&disk).Elem()
+		for i := range v.NumField() {
+			key := diskPrefix + strings.ToUpper(fieldName(v.Type().Field(i)))
+			value, ok := os.LookupEnv(key)
+			if !ok {
+				continue
+			}
+			switch v.Field(i).Kind() {
+			case reflect.Bool:
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, &ConfigError{Field: key, Err: fmt.Errorf("invalid bool %q", value)}
+				}
+				v.Field(i).SetBool(b)
+			default:
+				v.Field(i).SetString(value)
+			}
+		}
+		conf.Disks[name] = disk
+	}
+	return conf, nil
+}
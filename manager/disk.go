@@ -0,0 +1,30 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/goairix/fs"
+)
+
+// cdnDisk 为访问 URL 设置默认 CDN 域名，调用时通过 fs.WithCdnDomain 指定的域名优先
+type cdnDisk struct {
+	fs.Wrapper
+	cdnDomain string
+}
+
+func (d *cdnDisk) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	return d.FileSystem.SignFullUrl(ctx, path, d.options(opts)...)
+}
+
+func (d *cdnDisk) FullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	return d.FileSystem.FullUrl(ctx, path, d.options(opts)...)
+}
+
+func (d *cdnDisk) RelativePath(ctx context.Context, fullUrl string, opts ...fs.Option) (string, error) {
+	return d.FileSystem.RelativePath(ctx, fullUrl, d.options(opts)...)
+}
+
+// options 在调用方的选项之前加入默认 CDN 域名
+func (d *cdnDisk) options(opts []fs.Option) []fs.Option {
+	return append([]fs.Option{fs.WithCdnDomain(d.cdnDomain)}, opts...)
+}
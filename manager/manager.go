@@ -0,0 +1,181 @@
+// Package manager 管理多个命名存储，支持通过 YAML、JSON 或环境变量配置
+package manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/alioss"
+	"github.com/goairix/fs/driver/hwobs"
+	"github.com/goairix/fs/driver/local"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/goairix/fs/driver/minio"
+	"github.com/goairix/fs/driver/s3"
+	"github.com/goairix/fs/driver/txcos"
+)
+
+// Manager 命名存储管理器，可以同时使用多个存储，如公共资源使用 OSS、私有文档使用 S3、临时文件使用本地存储
+type Manager struct {
+	mu          sync.RWMutex
+	disks       map[string]fs.FileSystem
+	defaultDisk string
+}
+
+// New 根据配置创建全部存储，配置无效时返回 *ConfigError
+func New(conf Config) (*Manager, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		disks:       make(map[string]fs.FileSystem, len(conf.Disks)),
+		defaultDisk: conf.Default,
+	}
+	for name, diskConf := range conf.Disks {
+		disk, err := newDisk(diskConf)
+		if err != nil {
+			return nil, &ConfigError{Field: "disks." + name, Err: err}
+		}
+		m.disks[name] = disk
+		if m.defaultDisk == "" {
+			m.defaultDisk = name
+		}
+	}
+	return m, nil
+}
+
+// Disk 返回指定名称的存储，name 为空时返回默认存储，存储不存在时返回 nil
+func (m *Manager) Disk(name string) fs.FileSystem {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if name == "" {
+		name = m.defaultDisk
+	}
+	return m.disks[name]
+}
+
+// Default 返回默认存储
+func (m *Manager) Default() fs.FileSystem {
+	return m.Disk("")
+}
+
+// Names 返回全部存储名称，按字典序排列
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.disks))
+	for name := range m.disks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Set 添加或替换存储，用于注册无法通过配置创建的存储
+func (m *Manager) Set(name string, disk fs.FileSystem) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.disks == nil {
+		m.disks = make(map[string]fs.FileSystem)
+	}
+	m.disks[name] = disk
+	if m.defaultDisk == "" {
+		m.defaultDisk = name
+	}
+}
+
+// SetDefault 设置默认存储
+func (m *Manager) SetDefault(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.disks[name]; !ok {
+		return fmt.Errorf("manager: disk %q is not configured", name)
+	}
+	m.defaultDisk = name
+	return nil
+}
+
+// newDisk 根据配置创建存储，调用前配置已通过检查
+func newDisk(c DiskConfig) (fs.FileSystem, error) {
+	disk, err := openDisk(c)
+	if err != nil {
+		return nil, err
+	}
+	if c.CdnDomain != "" {
+		disk = &cdnDisk{Wrapper: fs.Wrapper{FileSystem: disk}, cdnDomain: c.CdnDomain}
+	}
+	return disk, nil
+}
+
+func openDisk(c DiskConfig) (fs.FileSystem, error) {
+	if c.URL != "" {
+		return fs.OpenURL(context.Background(), c.URL)
+	}
+
+	accessMode, _ := fs.ParseAccessMode(c.AccessMode)
+	switch c.Driver {
+	case "local":
+		return local.New(local.Config{
+			RootPath: c.RootPath,
+			SubPath:  c.SubPath,
+		})
+	case "memory":
+		return memory.New(memory.Config{
+			BaseURL:    c.BaseURL,
+			SignKey:    c.SignKey,
+			AccessMode: accessMode,
+		})
+	case "minio":
+		return minio.New(minio.Config{
+			Endpoint:        c.Endpoint,
+			AccessKeyID:     c.AccessKeyID,
+			SecretAccessKey: c.SecretAccessKey,
+			UseSSL:          c.UseSSL,
+			BucketName:      c.BucketName,
+			SubPath:         c.SubPath,
+			Location:        c.Location,
+			AccessMode:      accessMode,
+		})
+	case "alioss":
+		return alioss.New(alioss.Config{
+			Endpoint:        c.Endpoint,
+			AccessKeyID:     c.AccessKeyID,
+			SecretAccessKey: c.SecretAccessKey,
+			BucketName:      c.BucketName,
+			SubPath:         c.SubPath,
+			AccessMode:      accessMode,
+		})
+	case "hwobs":
+		return hwobs.New(hwobs.Config{
+			Endpoint:        c.Endpoint,
+			AccessKeyID:     c.AccessKeyID,
+			SecretAccessKey: c.SecretAccessKey,
+			BucketName:      c.BucketName,
+			SubPath:         c.SubPath,
+			AccessMode:      accessMode,
+		})
+	case "txcos":
+		return txcos.New(txcos.Config{
+			BucketURL:  c.BucketURL,
+			SecretID:   c.SecretID,
+			SecretKey:  c.SecretKey,
+			SubPath:    c.SubPath,
+			AccessMode: accessMode,
+		})
+	case "s3":
+		return s3.New(s3.Config{
+			Region:          c.Region,
+			Endpoint:        c.Endpoint,
+			AccessKeyID:     c.AccessKeyID,
+			SecretAccessKey: c.SecretAccessKey,
+			BucketName:      c.BucketName,
+			SubPath:         c.SubPath,
+			UsePathStyle:    c.UsePathStyle,
+			AccessMode:      accessMode,
+		})
+	}
+	return nil, fmt.Errorf("unknown driver %q", c.Driver)
+}
@@ -0,0 +1,566 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MountFS 将多个文件系统挂载到同一个命名空间，每次调用路由到挂载路径与文件路径最长匹配的文件系统：
+//
+//	m := fs.NewMountFS()
+//	_ = m.Mount("/", localFs)
+//	_ = m.Mount("/avatars", ossFs)   // avatars/a.png 对应 ossFs 中的 a.png
+//	_ = m.Mount("/exports", s3Fs)
+//
+// 列出包含挂载点的目录时会合并挂载点，跨挂载点的 Copy、Move 与 Rename 通过流式读写在驱动之间复制数据
+type MountFS struct {
+	mu     sync.RWMutex
+	mounts []mountPoint // 按挂载路径长度从长到短排列
+}
+
+// mountPoint 挂载点
+type mountPoint struct {
+	prefix string // 规范化后的挂载路径，根目录为空字符串
+	fsys   FileSystem
+}
+
+var (
+	_ FileSystem   = (*MountFS)(nil)
+	_ ReaderOpener = (*MountFS)(nil)
+	_ Walker       = (*MountFS)(nil)
+	_ ListPager    = (*MountFS)(nil)
+)
+
+// NewMountFS 创建没有挂载点的 MountFS
+func NewMountFS() *MountFS {
+	return &MountFS{}
+}
+
+// Mount 将文件系统挂载到 prefix，prefix 为 "/" 或空字符串时挂载到根目录；prefix 已被挂载时返回 ErrExist
+func (m *MountFS) Mount(prefix string, fsys FileSystem) error {
+	prefix = CleanPath(prefix)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, mp := range m.mounts {
+		if mp.prefix == prefix {
+			return NewPathError("mount", "Mount", prefix, ErrExist, ErrExist)
+		}
+	}
+	m.mounts = append(m.mounts, mountPoint{prefix: prefix, fsys: fsys})
+	slices.SortStableFunc(m.mounts, func(a, b mountPoint) int {
+		return len(b.prefix) - len(a.prefix)
+	})
+	return nil
+}
+
+// Unmount 取消挂载，prefix 未被挂载时返回 ErrNotExist
+func (m *MountFS) Unmount(prefix string) error {
+	prefix = CleanPath(prefix)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, mp := range m.mounts {
+		if mp.prefix == prefix {
+			m.mounts = slices.Delete(m.mounts, i, i+1)
+			return nil
+		}
+	}
+	return NewPathError("mount", "Unmount", prefix, ErrNotExist, ErrNotExist)
+}
+
+// Mounts 返回全部挂载路径，按字典序排列
+func (m *MountFS) Mounts() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	prefixes := make([]string, len(m.mounts))
+	for i, mp := range m.mounts {
+		prefixes[i] = "/" + mp.prefix
+	}
+	slices.Sort(prefixes)
+	return prefixes
+}
+
+// resolve 返回路径所在的挂载点与路径在挂载点中的相对路径
+func (m *MountFS) resolve(op, p string) (mountPoint, string, error) {
+	name := CleanPath(p)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, mp := range m.mounts {
+		if mp.prefix == "" {
+			return mp, name, nil
+		}
+		if name == mp.prefix {
+			return mp, "", nil
+		}
+		if strings.HasPrefix(name, mp.prefix+"/") {
+			return mp, name[len(mp.prefix)+1:], nil
+		}
+	}
+	return mountPoint{}, "", NewPathError("mount", op, p, ErrNotExist, ErrNotExist)
+}
+
+// children 返回 p 下一级的挂载点名称，p 为挂载点或挂载点的上级目录时 virtual 为 true
+func (m *MountFS) children(p string) (names []string, virtual bool) {
+	name := CleanPath(p)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, mp := range m.mounts {
+		if mp.prefix == name {
+			virtual = true
+			continue
+		}
+		rest, ok := strings.CutPrefix(mp.prefix, name+"/")
+		if name == "" {
+			rest, ok = mp.prefix, mp.prefix != ""
+		}
+		if !ok {
+			continue
+		}
+		virtual = true
+		child, _, _ := strings.Cut(rest, "/")
+		if !slices.Contains(names, child) {
+			names = append(names, child)
+		}
+	}
+	return names, virtual
+}
+
+func (m *MountFS) List(ctx context.Context, p string, opts ...Option) ([]FileInfo, error) {
+	children, virtual := m.children(p)
+	mp, rel, err := m.resolve("List", p)
+
+	var files []FileInfo
+	if err == nil {
+		files, err = mp.fsys.List(ctx, rel, opts...)
+		err = mp.wrapError(err)
+	}
+	if err != nil {
+		// 挂载点的上级目录只包含挂载点
+		if !virtual || !errors.Is(err, ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	// 挂载点覆盖同名的文件或目录
+	files = slices.DeleteFunc(files, func(info FileInfo) bool {
+		return slices.Contains(children, info.Name())
+	})
+	for _, child := range children {
+		files = append(files, newDirInfo(child))
+	}
+	return files, nil
+}
+
+func (m *MountFS) MakeDir(ctx context.Context, p string, perm os.FileMode, opts ...Option) error {
+	mp, rel, err := m.resolve("MakeDir", p)
+	if err != nil {
+		return err
+	}
+	return mp.wrapError(mp.fsys.MakeDir(ctx, rel, perm, opts...))
+}
+
+// RemoveDir 删除目录，不会删除其中挂载的其他文件系统的内容
+func (m *MountFS) RemoveDir(ctx context.Context, p string, opts ...Option) error {
+	mp, rel, err := m.resolve("RemoveDir", p)
+	if err != nil {
+		return err
+	}
+	return mp.wrapError(mp.fsys.RemoveDir(ctx, rel, opts...))
+}
+
+func (m *MountFS) Create(ctx context.Context, p string, opts ...Option) (io.WriteCloser, error) {
+	mp, rel, err := m.resolve("Create", p)
+	if err != nil {
+		return nil, err
+	}
+	w, err := mp.fsys.Create(ctx, rel, opts...)
+	return w, mp.wrapError(err)
+}
+
+func (m *MountFS) Open(ctx context.Context, p string, opts ...Option) (io.ReadCloser, error) {
+	mp, rel, err := m.resolve("Open", p)
+	if err != nil {
+		return nil, err
+	}
+	r, err := mp.fsys.Open(ctx, rel, opts...)
+	return r, mp.wrapError(err)
+}
+
+func (m *MountFS) OpenReader(ctx context.Context, p string, opts ...Option) (RangeReader, error) {
+	mp, rel, err := m.resolve("OpenReader", p)
+	if err != nil {
+		return nil, err
+	}
+	r, err := OpenReader(ctx, mp.fsys, rel, opts...)
+	return r, mp.wrapError(err)
+}
+
+func (m *MountFS) OpenFile(ctx context.Context, p string, flag int, perm os.FileMode, opts ...Option) (io.ReadWriteCloser, error) {
+	mp, rel, err := m.resolve("OpenFile", p)
+	if err != nil {
+		return nil, err
+	}
+	f, err := mp.fsys.OpenFile(ctx, rel, flag, perm, opts...)
+	return f, mp.wrapError(err)
+}
+
+func (m *MountFS) Remove(ctx context.Context, p string, opts ...Option) error {
+	mp, rel, err := m.resolve("Remove", p)
+	if err != nil {
+		return err
+	}
+	return mp.wrapError(mp.fsys.Remove(ctx, rel, opts...))
+}
+
+// Copy 复制文件，源文件与目标文件位于不同的挂载点时通过流式读写复制，并保留源文件的 MIME 类型
+func (m *MountFS) Copy(ctx context.Context, src, dst string, opts ...Option) error {
+	srcMount, srcRel, err := m.resolve("Copy", src)
+	if err != nil {
+		return err
+	}
+	dstMount, dstRel, err := m.resolve("Copy", dst)
+	if err != nil {
+		return err
+	}
+	if srcMount.prefix == dstMount.prefix {
+		return srcMount.wrapError(srcMount.fsys.Copy(ctx, srcRel, dstRel, opts...))
+	}
+	return m.copyAcross(ctx, "Copy", srcMount, srcRel, dstMount, dstRel, opts)
+}
+
+// Move 移动文件，源文件与目标文件位于不同的挂载点时复制后删除源文件
+func (m *MountFS) Move(ctx context.Context, src, dst string, opts ...Option) error {
+	return m.move(ctx, "Move", src, dst, opts, FileSystem.Move)
+}
+
+// Rename 重命名文件，源文件与目标文件位于不同的挂载点时复制后删除源文件
+func (m *MountFS) Rename(ctx context.Context, oldPath, newPath string, opts ...Option) error {
+	return m.move(ctx, "Rename", oldPath, newPath, opts, FileSystem.Rename)
+}
+
+func (m *MountFS) move(ctx context.Context, op, src, dst string, opts []Option,
+	fn func(FileSystem, context.Context, string, string, ...Option) error) error {
+	srcMount, srcRel, err := m.resolve(op, src)
+	if err != nil {
+		return err
+	}
+	dstMount, dstRel, err := m.resolve(op, dst)
+	if err != nil {
+		return err
+	}
+	if srcMount.prefix == dstMount.prefix {
+		return srcMount.wrapError(fn(srcMount.fsys, ctx, srcRel, dstRel, opts...))
+	}
+	if err = m.copyAcross(ctx, op, srcMount, srcRel, dstMount, dstRel, opts); err != nil {
+		return err
+	}
+	return srcMount.wrapError(srcMount.fsys.Remove(ctx, srcRel, opts...))
+}
+
+// copyAcross 在不同挂载点之间流式复制文件
+func (m *MountFS) copyAcross(ctx context.Context, op string, src mountPoint, srcRel string, dst mountPoint, dstRel string, opts []Option) error {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.ContentType == "" {
+		if contentType, err := src.fsys.GetMimeType(ctx, srcRel); err == nil {
+			opts = append([]Option{WithContentType(contentType)}, opts...)
+		}
+	}
+
+	reader, err := src.fsys.Open(ctx, srcRel)
+	if err != nil {
+		return src.wrapError(err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	if err = dst.fsys.Uploader().Upload(ctx, dstRel, reader, opts...); err != nil {
+		err = dst.wrapError(err)
+		var pathErr *PathError
+		if errors.As(err, &pathErr) {
+			return err
+		}
+		return NewPathError("mount", op, path.Join(dst.prefix, dstRel), err, nil)
+	}
+	return nil
+}
+
+func (m *MountFS) Stat(ctx context.Context, p string, opts ...Option) (FileInfo, error) {
+	if _, virtual := m.children(p); virtual {
+		return newDirInfo(path.Base(CleanPath(p))), nil
+	}
+	mp, rel, err := m.resolve("Stat", p)
+	if err != nil {
+		return nil, err
+	}
+	info, err := mp.fsys.Stat(ctx, rel, opts...)
+	return info, mp.wrapError(err)
+}
+
+func (m *MountFS) GetMimeType(ctx context.Context, p string, opts ...Option) (string, error) {
+	mp, rel, err := m.resolve("GetMimeType", p)
+	if err != nil {
+		return "", err
+	}
+	mimeType, err := mp.fsys.GetMimeType(ctx, rel, opts...)
+	return mimeType, mp.wrapError(err)
+}
+
+func (m *MountFS) SetMetadata(ctx context.Context, p string, metadata map[string]interface{}, opts ...Option) error {
+	mp, rel, err := m.resolve("SetMetadata", p)
+	if err != nil {
+		return err
+	}
+	return mp.wrapError(mp.fsys.SetMetadata(ctx, rel, metadata, opts...))
+}
+
+func (m *MountFS) GetMetadata(ctx context.Context, p string, opts ...Option) (map[string]interface{}, error) {
+	mp, rel, err := m.resolve("GetMetadata", p)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := mp.fsys.GetMetadata(ctx, rel, opts...)
+	return metadata, mp.wrapError(err)
+}
+
+func (m *MountFS) Exists(ctx context.Context, p string, opts ...Option) (bool, error) {
+	if _, virtual := m.children(p); virtual {
+		return true, nil
+	}
+	mp, rel, err := m.resolve("Exists", p)
+	if err != nil {
+		return false, nil
+	}
+	ok, err := mp.fsys.Exists(ctx, rel, opts...)
+	return ok, mp.wrapError(err)
+}
+
+func (m *MountFS) IsDir(ctx context.Context, p string, opts ...Option) (bool, error) {
+	if _, virtual := m.children(p); virtual {
+		return true, nil
+	}
+	mp, rel, err := m.resolve("IsDir", p)
+	if err != nil {
+		return false, nil
+	}
+	ok, err := mp.fsys.IsDir(ctx, rel, opts...)
+	return ok, mp.wrapError(err)
+}
+
+func (m *MountFS) IsFile(ctx context.Context, p string, opts ...Option) (bool, error) {
+	if _, virtual := m.children(p); virtual {
+		return false, nil
+	}
+	mp, rel, err := m.resolve("IsFile", p)
+	if err != nil {
+		return false, nil
+	}
+	ok, err := mp.fsys.IsFile(ctx, rel, opts...)
+	return ok, mp.wrapError(err)
+}
+
+func (m *MountFS) SignFullUrl(ctx context.Context, p string, opts ...Option) (string, error) {
+	mp, rel, err := m.resolve("SignFullUrl", p)
+	if err != nil {
+		return "", err
+	}
+	u, err := mp.fsys.SignFullUrl(ctx, rel, opts...)
+	return u, mp.wrapError(err)
+}
+
+func (m *MountFS) FullUrl(ctx context.Context, p string, opts ...Option) (string, error) {
+	mp, rel, err := m.resolve("FullUrl", p)
+	if err != nil {
+		return "", err
+	}
+	u, err := mp.fsys.FullUrl(ctx, rel, opts...)
+	return u, mp.wrapError(err)
+}
+
+// RelativePath 还原文件路径，返回挂载后的路径。
+// 依次由各挂载点还原路径，再由 FullUrl 生成的地址与 fullUrl 一致的挂载点决定结果（忽略查询参数），
+// 因此不同挂载点的访问地址需要能够区分，例如使用不同的存储桶或 CDN 域名；没有挂载点匹配时由根目录的挂载点还原
+func (m *MountFS) RelativePath(ctx context.Context, fullUrl string, opts ...Option) (string, error) {
+	target, err := url.Parse(fullUrl)
+	if err != nil {
+		return "", NewPathError("mount", "RelativePath", fullUrl, err, nil)
+	}
+
+	m.mu.RLock()
+	mounts := slices.Clone(m.mounts)
+	m.mu.RUnlock()
+
+	for _, mp := range mounts {
+		rel, err := mp.fsys.RelativePath(ctx, fullUrl, opts...)
+		if err != nil {
+			continue
+		}
+		u, err := mp.fsys.FullUrl(ctx, rel, opts...)
+		if err != nil {
+			continue
+		}
+		if got, err := url.Parse(u); err == nil && sameLocation(got, target) {
+			return path.Join(mp.prefix, rel), nil
+		}
+	}
+
+	// 无法确定时由根目录的挂载点还原，例如生成地址时使用了调用方指定的 CDN 域名
+	if len(mounts) > 0 && mounts[len(mounts)-1].prefix == "" {
+		return mounts[len(mounts)-1].fsys.RelativePath(ctx, fullUrl, opts...)
+	}
+	return "", NewPathError("mount", "RelativePath", fullUrl, fmt.Errorf("no mount matches the URL: %w", ErrNotExist), ErrNotExist)
+}
+
+// sameLocation 判断两个 URL 是否指向同一位置，忽略查询参数与路径两端的 "/"
+func sameLocation(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host) &&
+		strings.Trim(a.Path, "/") == strings.Trim(b.Path, "/")
+}
+
+// Walk 递归遍历，root 下没有其他挂载点时使用所在文件系统的遍历实现
+func (m *MountFS) Walk(ctx context.Context, root string, fn WalkFunc, opts ...Option) error {
+	if _, virtual := m.children(root); virtual {
+		info, err := m.Stat(ctx, root, opts...)
+		if err != nil {
+			return err
+		}
+		err = walkList(ctx, m, root, info, fn, opts)
+		if err == SkipDir || err == SkipAll {
+			return nil
+		}
+		return err
+	}
+
+	mp, rel, err := m.resolve("Walk", root)
+	if err != nil {
+		err = fn(root, nil, err)
+		if err == SkipDir || err == SkipAll {
+			return nil
+		}
+		return err
+	}
+	return Walk(ctx, mp.fsys, rel, func(name string, info FileInfo, err error) error {
+		return fn(path.Join(root, strings.TrimPrefix(name, rel)), info, mp.wrapError(err))
+	}, opts...)
+}
+
+// ListPage 分页列出，目录下有其他挂载点时列出全部内容后分页
+func (m *MountFS) ListPage(ctx context.Context, p string, opts ...Option) (*ListResult, error) {
+	if children, _ := m.children(p); len(children) > 0 {
+		return listPage(ctx, m, p, opts...)
+	}
+	mp, rel, err := m.resolve("ListPage", p)
+	if err != nil {
+		return nil, err
+	}
+	result, err := ListPage(ctx, mp.fsys, rel, opts...)
+	return result, mp.wrapError(err)
+}
+
+func (m *MountFS) Uploader() Uploader {
+	return &mountUploader{m: m}
+}
+
+// wrapError 将驱动返回的 PathError 中的路径转换为挂载后的路径
+func (mp mountPoint) wrapError(err error) error {
+	var pathErr *PathError
+	if mp.prefix == "" || !errors.As(err, &pathErr) || err != error(pathErr) {
+		return err
+	}
+	e := *pathErr
+	e.Path = path.Join(mp.prefix, strings.TrimLeft(e.Path, "/"))
+	return &e
+}
+
+// mountUploader 将上传路由到路径所在的挂载点
+type mountUploader struct {
+	m *MountFS
+}
+
+func (u *mountUploader) Upload(ctx context.Context, p string, reader io.Reader, opts ...Option) error {
+	mp, rel, err := u.m.resolve("Upload", p)
+	if err != nil {
+		return err
+	}
+	return mp.wrapError(mp.fsys.Uploader().Upload(ctx, rel, reader, opts...))
+}
+
+func (u *mountUploader) InitMultipartUpload(ctx context.Context, p string, opts ...Option) (string, error) {
+	mp, rel, err := u.m.resolve("InitMultipartUpload", p)
+	if err != nil {
+		return "", err
+	}
+	uploadID, err := mp.fsys.Uploader().InitMultipartUpload(ctx, rel, opts...)
+	return uploadID, mp.wrapError(err)
+}
+
+func (u *mountUploader) UploadPart(ctx context.Context, p string, uploadID string, partNumber int, data io.Reader, opts ...Option) (string, error) {
+	mp, rel, err := u.m.resolve("UploadPart", p)
+	if err != nil {
+		return "", err
+	}
+	etag, err := mp.fsys.Uploader().UploadPart(ctx, rel, uploadID, partNumber, data, opts...)
+	return etag, mp.wrapError(err)
+}
+
+func (u *mountUploader) CompleteMultipartUpload(ctx context.Context, p string, uploadID string, parts []MultipartPart, opts ...Option) error {
+	mp, rel, err := u.m.resolve("CompleteMultipartUpload", p)
+	if err != nil {
+		return err
+	}
+	return mp.wrapError(mp.fsys.Uploader().CompleteMultipartUpload(ctx, rel, uploadID, parts, opts...))
+}
+
+func (u *mountUploader) AbortMultipartUpload(ctx context.Context, p string, uploadID string, opts ...Option) error {
+	mp, rel, err := u.m.resolve("AbortMultipartUpload", p)
+	if err != nil {
+		return err
+	}
+	return mp.wrapError(mp.fsys.Uploader().AbortMultipartUpload(ctx, rel, uploadID, opts...))
+}
+
+// ListMultipartUploads 列出全部挂载点中未完成的分片上传，路径为挂载后的路径
+func (u *mountUploader) ListMultipartUploads(ctx context.Context, opts ...Option) ([]MultipartUploadInfo, error) {
+	u.m.mu.RLock()
+	mounts := slices.Clone(u.m.mounts)
+	u.m.mu.RUnlock()
+
+	var result []MultipartUploadInfo
+	for _, mp := range mounts {
+		uploads, err := mp.fsys.Uploader().ListMultipartUploads(ctx, opts...)
+		if err != nil {
+			return nil, mp.wrapError(err)
+		}
+		for _, upload := range uploads {
+			upload.Path = path.Join(mp.prefix, upload.Path)
+			// 被其他挂载点覆盖的路径无法通过 MountFS 访问，不返回其中的上传
+			if owner, _, err := u.m.resolve("ListMultipartUploads", upload.Path); err == nil && owner.prefix == mp.prefix {
+				result = append(result, upload)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (u *mountUploader) ListUploadedParts(ctx context.Context, p string, uploadID string, opts ...Option) ([]MultipartPart, error) {
+	mp, rel, err := u.m.resolve("ListUploadedParts", p)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := mp.fsys.Uploader().ListUploadedParts(ctx, rel, uploadID, opts...)
+	return parts, mp.wrapError(err)
+}
@@ -0,0 +1,154 @@
+package fs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/goairix/fs/fstest"
+)
+
+func newMountMemory(t *testing.T, baseURL string) fs.FileSystem {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: baseURL, AccessMode: fs.PublicRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMountFS(t *testing.T) {
+	fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+		m := fs.NewMountFS()
+		if err := m.Mount("/", newMountMemory(t, "https://root.example.com")); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Mount("/mnt/other", newMountMemory(t, "https://other.example.com")); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	})
+}
+
+func names(infos []fs.FileInfo) []string {
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestMountRouting(t *testing.T) {
+	ctx := context.Background()
+	avatars := newMountMemory(t, "https://avatars.example.com")
+	exports := newMountMemory(t, "https://exports.example.com")
+	m := fs.NewMountFS()
+	for prefix, fsys := range map[string]fs.FileSystem{"/avatars": avatars, "data/exports/": exports} {
+		if err := m.Mount(prefix, fsys); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := m.Mounts(); !slices.Equal(got, []string{"/avatars", "/data/exports"}) {
+		t.Fatalf("Mounts() = %q", got)
+	}
+	if err := m.Mount("/avatars", exports); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("Mount existing prefix: %v, want ErrExist", err)
+	}
+
+	writeFile(t, m, "/avatars/a.png", "png")
+	if ok, _ := avatars.Exists(ctx, "a.png"); !ok {
+		t.Fatal("file was not written to the mounted file system")
+	}
+
+	for dir, want := range map[string][]string{
+		"/":             {"avatars", "data"},
+		"/data":         {"exports"},
+		"/avatars":      {"a.png"},
+		"/data/exports": nil,
+	} {
+		infos, err := m.List(ctx, dir)
+		if err != nil {
+			t.Fatalf("List(%q): %v", dir, err)
+		}
+		if got := names(infos); !slices.Equal(got, want) {
+			t.Errorf("List(%q) = %q, want %q", dir, got, want)
+		}
+	}
+	if _, err := m.List(ctx, "/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("List unmounted path: %v, want ErrNotExist", err)
+	}
+
+	// 驱动返回的错误使用挂载后的路径
+	_, err := m.Stat(ctx, "/avatars/missing.png")
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "avatars/missing.png" {
+		t.Errorf("Stat missing file: %v, want the mounted path", err)
+	}
+
+	if err := m.Unmount("/avatars"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Unmount("/avatars"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Unmount twice: %v, want ErrNotExist", err)
+	}
+	if _, err := m.Open(ctx, "/avatars/a.png"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Open after Unmount: %v, want ErrNotExist", err)
+	}
+}
+
+func TestMountAcross(t *testing.T) {
+	ctx := context.Background()
+	src := newMountMemory(t, "https://src.example.com")
+	dst := newMountMemory(t, "https://dst.example.com")
+	m := fs.NewMountFS()
+	if err := m.Mount("/src", src); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Mount("/dst", dst); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Uploader().Upload(ctx, "/src/a.bin", io.LimitReader(zeros{}, 10), fs.WithContentType("image/png")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Copy(ctx, "/src/a.bin", "/dst/b.bin"); err != nil {
+		t.Fatal(err)
+	}
+	if mimeType, err := dst.GetMimeType(ctx, "b.bin"); err != nil || mimeType != "image/png" {
+		t.Fatalf("copied file has MIME type %q, %v, want image/png", mimeType, err)
+	}
+	if err := m.Move(ctx, "/src/a.bin", "/dst/c.bin"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := src.Exists(ctx, "a.bin"); ok {
+		t.Fatal("moved file still exists in the source")
+	}
+	info, err := m.Stat(ctx, "/dst/c.bin")
+	if err != nil || info.Size() != 10 {
+		t.Fatalf("moved file: %v, %v", info, err)
+	}
+
+	fullUrl, err := m.FullUrl(ctx, "/dst/c.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fullUrl != "https://dst.example.com/c.bin" {
+		t.Fatalf("FullUrl = %q", fullUrl)
+	}
+	if rel, err := m.RelativePath(ctx, fullUrl); err != nil || rel != "dst/c.bin" {
+		t.Fatalf("RelativePath(%q) = %q, %v, want dst/c.bin", fullUrl, rel, err)
+	}
+}
+
+// zeros 读取时返回 0
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
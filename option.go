@@ -14,10 +14,19 @@ const (
 type Option func(*Options)
 
 type Options struct {
-	Metadata       Metadata
-	ContentType    string
-	CdnDomain      string
-	SignUrlExpires time.Duration
+	Metadata           Metadata
+	ContentType        string
+	ContentEncoding    string
+	CdnDomain          string
+	SignUrlExpires     time.Duration
+	Range              *Range
+	MultipartThreshold int64
+	PartSize           int64
+	Concurrency        int
+	PartRetries        *int
+	LeavePartsOnError  bool
+	Limit              int
+	Marker             string
 }
 
 // WithMetadata 设置元数据
@@ -34,6 +43,13 @@ func WithContentType(contentType string) Option {
 	}
 }
 
+// WithContentEncoding 设置文件的 Content-Encoding，通过 URL 下载时浏览器会按该编码解码，本地文件系统与内存文件系统忽略该选项
+func WithContentEncoding(contentEncoding string) Option {
+	return func(o *Options) {
+		o.ContentEncoding = contentEncoding
+	}
+}
+
 // WithCdnDomain 设置cdn域名
 func WithCdnDomain(cdnDomain string) Option {
 	return func(o *Options) {
@@ -46,3 +62,61 @@ func WithSignUrlExpires(expires time.Duration) Option {
 		o.SignUrlExpires = expires
 	}
 }
+
+// WithRange 设置读取范围，从 offset 开始读取 length 字节，length 小于等于 0 时读取到文件末尾。
+// 驱动的 Open 需要支持该选项，OpenReader 与 AsIOFS 的随机读取依赖于此
+func WithRange(offset, length int64) Option {
+	return func(o *Options) {
+		o.Range = &Range{Offset: offset, Length: length}
+	}
+}
+
+// WithMultipartThreshold 设置流式写入与上传切换为分片上传的阈值，未设置 WithPartSize 时也作为分片大小
+func WithMultipartThreshold(size int64) Option {
+	return func(o *Options) {
+		o.MultipartThreshold = size
+	}
+}
+
+// WithPartSize 设置分片上传的分片大小，最小为 MinPartSize
+func WithPartSize(size int64) Option {
+	return func(o *Options) {
+		o.PartSize = size
+	}
+}
+
+// WithConcurrency 设置同时上传的分片数
+func WithConcurrency(concurrency int) Option {
+	return func(o *Options) {
+		o.Concurrency = concurrency
+	}
+}
+
+// WithPartRetries 设置单个分片上传失败后的重试次数，为 0 时不重试
+func WithPartRetries(retries int) Option {
+	return func(o *Options) {
+		o.PartRetries = &retries
+	}
+}
+
+// WithLeavePartsOnError 分片上传失败时保留已上传的分片，不取消分片上传，
+// 返回的 *MultipartUploadError 中包含 UploadID，可用于断点续传
+func WithLeavePartsOnError() Option {
+	return func(o *Options) {
+		o.LeavePartsOnError = true
+	}
+}
+
+// WithLimit 设置分页列出时每页的数量
+func WithLimit(limit int) Option {
+	return func(o *Options) {
+		o.Limit = limit
+	}
+}
+
+// WithMarker 设置分页列出的起始标记，取值为上一页返回的 NextMarker
+func WithMarker(marker string) Option {
+	return func(o *Options) {
+		o.Marker = marker
+	}
+}
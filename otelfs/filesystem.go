@@ -0,0 +1,202 @@
+package otelfs
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/goairix/fs"
+)
+
+// fileSystem 记录链路追踪与指标的文件系统
+type fileSystem struct {
+	fs.Wrapper
+	in *instruments
+}
+
+// New 返回记录链路追踪与指标的文件系统，每次调用生成一个 span 并记录操作次数与耗时。
+// Open、OpenReader、Create 与 OpenFile 的 span 在返回的读写流关闭时结束，包括数据传输的时间与字节数
+func New(fsys fs.FileSystem, conf Config) (fs.FileSystem, error) {
+	in, err := newInstruments(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSystem{Wrapper: fs.Wrapper{FileSystem: fsys}, in: in}, nil
+}
+
+func (f *fileSystem) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
+	ctx, s := f.in.start(ctx, "List", AttrPath.String(path))
+	infos, err := f.FileSystem.List(ctx, path, opts...)
+	s.end(err)
+	return infos, err
+}
+
+func (f *fileSystem) MakeDir(ctx context.Context, path string, perm os.FileMode, opts ...fs.Option) error {
+	ctx, s := f.in.start(ctx, "MakeDir", AttrPath.String(path))
+	err := f.FileSystem.MakeDir(ctx, path, perm, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
+	ctx, s := f.in.start(ctx, "RemoveDir", AttrPath.String(path))
+	err := f.FileSystem.RemoveDir(ctx, path, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	ctx, s := f.in.start(ctx, "Create", AttrPath.String(path))
+	writer, err := f.FileSystem.Create(ctx, path, opts...)
+	if err != nil {
+		s.end(err)
+		return nil, err
+	}
+	return &writeCloser{WriteCloser: writer, stream: newStream(s)}, nil
+}
+
+func (f *fileSystem) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	ctx, s := f.in.start(ctx, "Open", AttrPath.String(path))
+	reader, err := f.FileSystem.Open(ctx, path, opts...)
+	if err != nil {
+		s.end(err)
+		return nil, err
+	}
+	return &readCloser{ReadCloser: reader, stream: newStream(s)}, nil
+}
+
+func (f *fileSystem) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	ctx, s := f.in.start(ctx, "OpenFile", AttrPath.String(path))
+	file, err := f.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+	if err != nil {
+		s.end(err)
+		return nil, err
+	}
+	return &readWriteCloser{ReadWriteCloser: file, stream: newStream(s)}, nil
+}
+
+func (f *fileSystem) Remove(ctx context.Context, path string, opts ...fs.Option) error {
+	ctx, s := f.in.start(ctx, "Remove", AttrPath.String(path))
+	err := f.FileSystem.Remove(ctx, path, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	ctx, s := f.in.start(ctx, "Copy", AttrPath.String(src), AttrDestPath.String(dst))
+	err := f.FileSystem.Copy(ctx, src, dst, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	ctx, s := f.in.start(ctx, "Move", AttrPath.String(src), AttrDestPath.String(dst))
+	err := f.FileSystem.Move(ctx, src, dst, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) Rename(ctx context.Context, oldPath, newPath string, opts ...fs.Option) error {
+	ctx, s := f.in.start(ctx, "Rename", AttrPath.String(oldPath), AttrDestPath.String(newPath))
+	err := f.FileSystem.Rename(ctx, oldPath, newPath, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	ctx, s := f.in.start(ctx, "Stat", AttrPath.String(path))
+	info, err := f.FileSystem.Stat(ctx, path, opts...)
+	s.end(err)
+	return info, err
+}
+
+func (f *fileSystem) GetMimeType(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	ctx, s := f.in.start(ctx, "GetMimeType", AttrPath.String(path))
+	mimeType, err := f.FileSystem.GetMimeType(ctx, path, opts...)
+	s.end(err)
+	return mimeType, err
+}
+
+func (f *fileSystem) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	ctx, s := f.in.start(ctx, "SetMetadata", AttrPath.String(path))
+	err := f.FileSystem.SetMetadata(ctx, path, metadata, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]interface{}, error) {
+	ctx, s := f.in.start(ctx, "GetMetadata", AttrPath.String(path))
+	metadata, err := f.FileSystem.GetMetadata(ctx, path, opts...)
+	s.end(err)
+	return metadata, err
+}
+
+func (f *fileSystem) Exists(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	ctx, s := f.in.start(ctx, "Exists", AttrPath.String(path))
+	exists, err := f.FileSystem.Exists(ctx, path, opts...)
+	s.end(err)
+	return exists, err
+}
+
+func (f *fileSystem) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	ctx, s := f.in.start(ctx, "IsDir", AttrPath.String(path))
+	isDir, err := f.FileSystem.IsDir(ctx, path, opts...)
+	s.end(err)
+	return isDir, err
+}
+
+func (f *fileSystem) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	ctx, s := f.in.start(ctx, "IsFile", AttrPath.String(path))
+	isFile, err := f.FileSystem.IsFile(ctx, path, opts...)
+	s.end(err)
+	return isFile, err
+}
+
+func (f *fileSystem) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	ctx, s := f.in.start(ctx, "SignFullUrl", AttrPath.String(path))
+	fullUrl, err := f.FileSystem.SignFullUrl(ctx, path, opts...)
+	s.end(err)
+	return fullUrl, err
+}
+
+func (f *fileSystem) FullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	ctx, s := f.in.start(ctx, "FullUrl", AttrPath.String(path))
+	fullUrl, err := f.FileSystem.FullUrl(ctx, path, opts...)
+	s.end(err)
+	return fullUrl, err
+}
+
+func (f *fileSystem) RelativePath(ctx context.Context, fullUrl string, opts ...fs.Option) (string, error) {
+	ctx, s := f.in.start(ctx, "RelativePath")
+	path, err := f.FileSystem.RelativePath(ctx, fullUrl, opts...)
+	s.end(err, AttrPath.String(path))
+	return path, err
+}
+
+func (f *fileSystem) Uploader() fs.Uploader {
+	return &uploader{Uploader: f.FileSystem.Uploader(), in: f.in}
+}
+
+func (f *fileSystem) OpenReader(ctx context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	ctx, s := f.in.start(ctx, "OpenReader", AttrPath.String(path))
+	reader, err := fs.OpenReader(ctx, f.FileSystem, path, opts...)
+	if err != nil {
+		s.end(err)
+		return nil, err
+	}
+	return &rangeReader{RangeReader: reader, stream: newStream(s)}, nil
+}
+
+func (f *fileSystem) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	ctx, s := f.in.start(ctx, "Walk", AttrPath.String(root))
+	err := fs.Walk(ctx, f.FileSystem, root, fn, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	ctx, s := f.in.start(ctx, "ListPage", AttrPath.String(path))
+	result, err := fs.ListPage(ctx, f.FileSystem, path, opts...)
+	s.end(err)
+	return result, err
+}
@@ -0,0 +1,174 @@
+// Package otelfs 为文件系统增加 OpenTelemetry 链路追踪与指标
+package otelfs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/goairix/fs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 链路追踪与指标的 instrumentation scope 名称
+const instrumentationName = "github.com/goairix/fs/otelfs"
+
+// 属性名称
+const (
+	AttrOperation  = attribute.Key("fs.operation")   // 操作名称，如 Open、Upload
+	AttrDriver     = attribute.Key("fs.driver")      // 驱动名称
+	AttrBucket     = attribute.Key("fs.bucket")      // 存储桶名称
+	AttrPath       = attribute.Key("fs.path")        // 文件路径，只记录在 span 上
+	AttrDestPath   = attribute.Key("fs.dest_path")   // 复制、移动与重命名的目标路径，只记录在 span 上
+	AttrBytes      = attribute.Key("fs.bytes")       // 读取或写入的字节数
+	AttrPartNumber = attribute.Key("fs.part_number") // 分片号
+	AttrUploadID   = attribute.Key("fs.upload_id")   // 分片上传ID
+	AttrErrorType  = attribute.Key("error.type")     // 错误分类，见 ErrorType
+)
+
+// Config 链路追踪与指标配置
+type Config struct {
+	TracerProvider trace.TracerProvider // 为 nil 时使用 otel.GetTracerProvider()
+	MeterProvider  metric.MeterProvider // 为 nil 时使用 otel.GetMeterProvider()
+	Driver         string               // 驱动名称，记录为 fs.driver 属性
+	Bucket         string               // 存储桶名称，记录为 fs.bucket 属性
+}
+
+// ErrorType 返回错误分类，用作 error.type 属性，如 not_exist、permission、temporary，无法识别时返回 other
+func ErrorType(err error) string {
+	for _, kind := range errorTypes {
+		if errors.Is(err, kind.err) {
+			return kind.name
+		}
+	}
+	return "other"
+}
+
+var errorTypes = []struct {
+	err  error
+	name string
+}{
+	{context.Canceled, "canceled"},
+	{context.DeadlineExceeded, "deadline_exceeded"},
+	{fs.ErrNotExist, "not_exist"},
+	{fs.ErrExist, "exist"},
+	{fs.ErrPermission, "permission"},
+	{fs.ErrIsDir, "is_dir"},
+	{fs.ErrNotDir, "not_dir"},
+	{fs.ErrPreconditionFailed, "precondition_failed"},
+	{fs.ErrUnsupported, "unsupported"},
+	{fs.ErrPathEscapes, "path_escapes"},
+	{fs.ErrTemporary, "temporary"},
+}
+
+// instruments 链路追踪与指标
+type instruments struct {
+	tracer        trace.Tracer
+	attrs         []attribute.KeyValue // 驱动与存储桶
+	operations    metric.Int64Counter
+	duration      metric.Float64Histogram
+	bytesRead     metric.Int64Counter
+	bytesWritten  metric.Int64Counter
+	activeUploads metric.Int64UpDownCounter
+}
+
+func newInstruments(conf Config) (*instruments, error) {
+	if conf.TracerProvider == nil {
+		conf.TracerProvider = otel.GetTracerProvider()
+	}
+	if conf.MeterProvider == nil {
+		conf.MeterProvider = otel.GetMeterProvider()
+	}
+
+	in := &instruments{tracer: conf.TracerProvider.Tracer(instrumentationName)}
+	if conf.Driver != "" {
+		in.attrs = append(in.attrs, AttrDriver.String(conf.Driver))
+	}
+	if conf.Bucket != "" {
+		in.attrs = append(in.attrs, AttrBucket.String(conf.Bucket))
+	}
+
+	meter := conf.MeterProvider.Meter(instrumentationName)
+	var err, e error
+	in.operations, e = meter.Int64Counter("fs.operations",
+		metric.WithDescription("Number of file system operations"), metric.WithUnit("{operation}"))
+	err = errors.Join(err, e)
+	in.duration, e = meter.Float64Histogram("fs.operation.duration",
+		metric.WithDescription("Duration of file system operations, including data transfer for reads and writes"), metric.WithUnit("s"))
+	err = errors.Join(err, e)
+	in.bytesRead, e = meter.Int64Counter("fs.bytes.read",
+		metric.WithDescription("Number of bytes read"), metric.WithUnit("By"))
+	err = errors.Join(err, e)
+	in.bytesWritten, e = meter.Int64Counter("fs.bytes.written",
+		metric.WithDescription("Number of bytes written"), metric.WithUnit("By"))
+	err = errors.Join(err, e)
+	in.activeUploads, e = meter.Int64UpDownCounter("fs.multipart.uploads.active",
+		metric.WithDescription("Number of multipart uploads in progress"), metric.WithUnit("{upload}"))
+	err = errors.Join(err, e)
+	if err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// attributes 返回驱动与存储桶属性
+func (in *instruments) attributes() metric.MeasurementOption {
+	return metric.WithAttributes(in.attrs...)
+}
+
+// span 一次操作的 span 与开始时间
+type span struct {
+	in    *instruments
+	ctx   context.Context
+	span  trace.Span
+	op    string
+	start time.Time
+}
+
+// start 开始记录操作，attrs 只记录在 span 上
+func (in *instruments) start(ctx context.Context, op string, attrs ...attribute.KeyValue) (context.Context, *span) {
+	ctx, s := in.tracer.Start(ctx, "fs."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(in.attrs...),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, &span{in: in, ctx: ctx, span: s, op: op, start: time.Now()}
+}
+
+// end 结束记录操作，err 不为 nil 时记录错误与错误分类
+func (s *span) end(err error, attrs ...attribute.KeyValue) {
+	metricAttrs := append([]attribute.KeyValue{AttrOperation.String(s.op)}, s.in.attrs...)
+	s.span.SetAttributes(attrs...)
+	if err != nil {
+		errorType := AttrErrorType.String(ErrorType(err))
+		metricAttrs = append(metricAttrs, errorType)
+		s.span.SetAttributes(errorType)
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+
+	set := metric.WithAttributes(metricAttrs...)
+	// span 结束后 ctx 可能已经取消，指标使用不会取消的 context
+	ctx := context.WithoutCancel(s.ctx)
+	s.in.operations.Add(ctx, 1, set)
+	s.in.duration.Record(ctx, time.Since(s.start).Seconds(), set)
+}
+
+// read 记录读取的字节数
+func (s *span) read(n int) {
+	if n > 0 {
+		s.in.bytesRead.Add(context.WithoutCancel(s.ctx), int64(n), s.in.attributes())
+	}
+}
+
+// written 记录写入的字节数
+func (s *span) written(n int) {
+	if n > 0 {
+		s.in.bytesWritten.Add(context.WithoutCancel(s.ctx), int64(n), s.in.attributes())
+	}
+}
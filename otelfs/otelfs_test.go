@@ -0,0 +1,203 @@
+package otelfs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/goairix/fs/fstest"
+	"github.com/goairix/fs/otelfs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recorder 记录 span 与指标
+type recorder struct {
+	spans  *tracetest.SpanRecorder
+	reader *sdkmetric.ManualReader
+}
+
+func newFS(t *testing.T) (fs.FileSystem, *recorder) {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &recorder{spans: tracetest.NewSpanRecorder(), reader: sdkmetric.NewManualReader()}
+	fsys, err := otelfs.New(m, otelfs.Config{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec.spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(rec.reader)),
+		Driver:         "memory",
+		Bucket:         "bucket",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fsys, rec
+}
+
+// span 返回最后结束的名为 name 的 span
+func (r *recorder) span(t *testing.T, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	ended := r.spans.Ended()
+	for i := len(ended) - 1; i >= 0; i-- {
+		if ended[i].Name() == name {
+			return ended[i]
+		}
+	}
+	t.Fatalf("span %s was not ended", name)
+	return nil
+}
+
+// sum 返回名为 name 的计数器中属性包含 attrs 的数据点之和
+func (r *recorder) sum(t *testing.T, name string, attrs ...attribute.KeyValue) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := r.reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			data, ok := m.Data.(metricdata.Sum[int64])
+			if m.Name != name || !ok {
+				continue
+			}
+			for _, point := range data.DataPoints {
+				if hasAttributes(point.Attributes.ToSlice(), attrs) {
+					total += point.Value
+				}
+			}
+		}
+	}
+	return total
+}
+
+func hasAttributes(got, want []attribute.KeyValue) bool {
+	for _, kv := range want {
+		found := false
+		for _, g := range got {
+			if g.Key == kv.Key && g.Value == kv.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func TestOtelFileSystem(t *testing.T) {
+	fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+		fsys, _ := newFS(t)
+		return fsys
+	})
+}
+
+func TestSpans(t *testing.T) {
+	ctx := context.Background()
+	fsys, rec := newFS(t)
+
+	if err := fsys.Uploader().Upload(ctx, "a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	span := rec.span(t, "fs.Upload")
+	if !hasAttributes(span.Attributes(), []attribute.KeyValue{
+		otelfs.AttrDriver.String("memory"), otelfs.AttrBucket.String("bucket"),
+		otelfs.AttrPath.String("a.txt"), otelfs.AttrBytes.Int64(5),
+	}) {
+		t.Errorf("Upload span attributes = %v", span.Attributes())
+	}
+
+	// 读取流的 span 在关闭时结束，记录读取的字节数
+	reader, err := fsys.Open(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range rec.spans.Ended() {
+		if s.Name() == "fs.Open" {
+			t.Fatal("Open span ended before the reader was closed")
+		}
+	}
+	if err := reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	span = rec.span(t, "fs.Open")
+	if span.Status().Code == codes.Error || !hasAttributes(span.Attributes(), []attribute.KeyValue{otelfs.AttrBytes.Int64(5)}) {
+		t.Errorf("Open span: status %v, attributes %v", span.Status(), span.Attributes())
+	}
+
+	if _, err := fsys.Stat(ctx, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Stat missing file: %v", err)
+	}
+	span = rec.span(t, "fs.Stat")
+	if span.Status().Code != codes.Error || !hasAttributes(span.Attributes(), []attribute.KeyValue{otelfs.AttrErrorType.String("not_exist")}) {
+		t.Errorf("failed Stat span: status %v, attributes %v", span.Status(), span.Attributes())
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	fsys, rec := newFS(t)
+
+	w, err := fsys.Create(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "0123456789"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if _, err := fsys.Stat(ctx, "a.txt"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _ = fsys.Stat(ctx, "missing.txt")
+
+	op := func(name string) attribute.KeyValue { return otelfs.AttrOperation.String(name) }
+	for _, tt := range []struct {
+		metric string
+		attrs  []attribute.KeyValue
+		want   int64
+	}{
+		{"fs.operations", []attribute.KeyValue{op("Stat")}, 4},
+		{"fs.operations", []attribute.KeyValue{op("Stat"), otelfs.AttrErrorType.String("not_exist")}, 1},
+		{"fs.operations", []attribute.KeyValue{op("Create"), otelfs.AttrDriver.String("memory")}, 1},
+		{"fs.bytes.written", []attribute.KeyValue{otelfs.AttrBucket.String("bucket")}, 10},
+	} {
+		if got := rec.sum(t, tt.metric, tt.attrs...); got != tt.want {
+			t.Errorf("%s%v = %d, want %d", tt.metric, tt.attrs, got, tt.want)
+		}
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	for err, want := range map[error]string{
+		fs.NewPathError("s3", "Stat", "a", errors.New("404"), fs.ErrNotExist):   "not_exist",
+		fs.NewPathError("s3", "Stat", "a", errors.New("503"), fs.ErrTemporary):  "temporary",
+		fmt.Errorf("upload: %w", context.Canceled):                              "canceled",
+		fs.NewPathError("s3", "Stat", "a", errors.New("403"), fs.ErrPermission): "permission",
+		errors.New("unknown"): "other",
+	} {
+		if got := otelfs.ErrorType(err); got != want {
+			t.Errorf("ErrorType(%v) = %q, want %q", err, got, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package otelfs
+
+import (
+	"context"
+	"io"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/internal/ioutil"
+)
+
+// uploader 记录链路追踪与指标的上传器
+type uploader struct {
+	fs.Uploader
+	in *instruments
+}
+
+// NewUploader 返回记录链路追踪与指标的上传器
+func NewUploader(u fs.Uploader, conf Config) (fs.Uploader, error) {
+	in, err := newInstruments(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &uploader{Uploader: u, in: in}, nil
+}
+
+func (u *uploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	ctx, s := u.in.start(ctx, "Upload", AttrPath.String(path))
+	reader, counter := ioutil.CountReader(reader)
+	counter.OnRead = s.written
+	err := u.Uploader.Upload(ctx, path, reader, opts...)
+	s.end(err, AttrBytes.Int64(counter.N))
+	return err
+}
+
+func (u *uploader) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	ctx, s := u.in.start(ctx, "InitMultipartUpload", AttrPath.String(path))
+	uploadID, err := u.Uploader.InitMultipartUpload(ctx, path, opts...)
+	if err != nil {
+		s.end(err)
+		return "", err
+	}
+	s.end(nil, AttrUploadID.String(uploadID))
+	u.in.activeUploads.Add(context.WithoutCancel(ctx), 1, u.in.attributes())
+	return uploadID, nil
+}
+
+func (u *uploader) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
+	ctx, s := u.in.start(ctx, "UploadPart", AttrPath.String(path), AttrUploadID.String(uploadID), AttrPartNumber.Int(partNumber))
+	data, counter := ioutil.CountReader(data)
+	counter.OnRead = s.written
+	etag, err := u.Uploader.UploadPart(ctx, path, uploadID, partNumber, data, opts...)
+	s.end(err, AttrBytes.Int64(counter.N))
+	return etag, err
+}
+
+func (u *uploader) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	ctx, s := u.in.start(ctx, "CompleteMultipartUpload", AttrPath.String(path), AttrUploadID.String(uploadID))
+	err := u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...)
+	s.end(err)
+	if err == nil {
+		u.in.activeUploads.Add(context.WithoutCancel(ctx), -1, u.in.attributes())
+	}
+	return err
+}
+
+func (u *uploader) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
+	ctx, s := u.in.start(ctx, "AbortMultipartUpload", AttrPath.String(path), AttrUploadID.String(uploadID))
+	err := u.Uploader.AbortMultipartUpload(ctx, path, uploadID, opts...)
+	s.end(err)
+	if err == nil {
+		u.in.activeUploads.Add(context.WithoutCancel(ctx), -1, u.in.attributes())
+	}
+	return err
+}
+
+func (u *uploader) ListMultipartUploads(ctx context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
+	ctx, s := u.in.start(ctx, "ListMultipartUploads")
+	uploads, err := u.Uploader.ListMultipartUploads(ctx, opts...)
+	s.end(err)
+	return uploads, err
+}
+
+func (u *uploader) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
+	ctx, s := u.in.start(ctx, "ListUploadedParts", AttrPath.String(path), AttrUploadID.String(uploadID))
+	parts, err := u.Uploader.ListUploadedParts(ctx, path, uploadID, opts...)
+	s.end(err)
+	return parts, err
+}
@@ -0,0 +1,17 @@
+package fs
+
+import (
+	"path"
+	"strings"
+)
+
+// CleanPath 返回规范化的路径：去掉 "."、".." 与重复的 "/"，不以 "/" 开头或结尾，根目录为空字符串。
+// ".." 不会超出根目录，如 "/a//b/../../../c" 规范化为 "c"
+func CleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+// JoinPath 将子目录与规范化后的路径拼接为对象键，路径中的 ".." 不会超出子目录，供对象存储驱动使用
+func JoinPath(subPath, p string) string {
+	return CleanPath(path.Join(CleanPath(subPath), CleanPath(p)))
+}
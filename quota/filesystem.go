@@ -0,0 +1,299 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/goairix/fs"
+)
+
+// Create 逐步预留写入的数据，超出限额时拒绝写入并取消上传，关闭时返回 ErrQuotaExceeded
+func (q *Quota) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	c, err := q.begin(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.prefixes) == 0 {
+		return q.FileSystem.Create(ctx, path, opts...)
+	}
+	// 覆盖写入，原文件的大小不再计入用量
+	_ = c.grow(ctx, -c.old)
+
+	writeCtx, cancel := context.WithCancel(ctx)
+	w, err := q.FileSystem.Create(writeCtx, path, opts...)
+	if err != nil {
+		cancel()
+		c.release()
+		return nil, err
+	}
+	return &writer{WriteCloser: w, ctx: ctx, cancel: cancel, change: c, op: "Create", exact: true}, nil
+}
+
+// OpenFile 写入时与 Create 相同，未设置 os.O_TRUNC 时关闭后按文件大小更新用量
+func (q *Quota) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return q.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+	}
+	c, err := q.begin(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.prefixes) == 0 {
+		return q.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+	}
+	truncate := flag&os.O_TRUNC != 0
+	if truncate {
+		_ = c.grow(ctx, -c.old)
+	}
+
+	writeCtx, cancel := context.WithCancel(ctx)
+	file, err := q.FileSystem.OpenFile(writeCtx, path, flag, perm, opts...)
+	if err != nil {
+		cancel()
+		c.release()
+		return nil, err
+	}
+	w := &writer{WriteCloser: file, ctx: ctx, cancel: cancel, change: c, op: "OpenFile", exact: truncate}
+	return &readWriteFile{Reader: file, writer: w}, nil
+}
+
+// Remove 删除文件后减少所属前缀的用量
+func (q *Quota) Remove(ctx context.Context, path string, opts ...fs.Option) error {
+	prefixes, err := q.prefixes(ctx, path)
+	if err != nil {
+		return err
+	}
+	if len(prefixes) == 0 {
+		return q.FileSystem.Remove(ctx, path, opts...)
+	}
+	size, err := q.size(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err = q.FileSystem.Remove(ctx, path, opts...); err != nil {
+		return err
+	}
+	return q.add(ctx, prefixes, -size)
+}
+
+// RemoveDir 统计目录下各前缀的文件大小，删除后减少相应前缀的用量
+func (q *Quota) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
+	prefixes, err := q.overlapping(ctx, path)
+	if err != nil {
+		return err
+	}
+	if len(prefixes) == 0 {
+		return q.FileSystem.RemoveDir(ctx, path, opts...)
+	}
+
+	sizes := make(map[string]int64, len(prefixes))
+	err = fs.Walk(ctx, q.FileSystem, path, func(name string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		name = fs.CleanPath(name)
+		for _, prefix := range prefixes {
+			if contains(prefix, name) {
+				sizes[prefix] += info.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err = q.FileSystem.RemoveDir(ctx, path, opts...); err != nil {
+		return err
+	}
+	for prefix, size := range sizes {
+		if err = q.add(ctx, []string{prefix}, -size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Copy 目标所属前缀的剩余用量不足时拒绝复制
+func (q *Quota) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	c, err := q.begin(ctx, dst)
+	if err != nil {
+		return err
+	}
+	if len(c.prefixes) == 0 {
+		return q.FileSystem.Copy(ctx, src, dst, opts...)
+	}
+	info, err := q.FileSystem.Stat(ctx, src)
+	if err != nil {
+		return err
+	}
+	if err = c.grow(ctx, info.Size()-c.old); err != nil {
+		return fs.NewPathError("quota", "Copy", dst, err, nil)
+	}
+	if err = q.FileSystem.Copy(ctx, src, dst, opts...); err != nil {
+		c.release()
+		return err
+	}
+	return c.commit(ctx)
+}
+
+func (q *Quota) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	return q.move(ctx, "Move", src, dst, func() error {
+		return q.FileSystem.Move(ctx, src, dst, opts...)
+	})
+}
+
+func (q *Quota) Rename(ctx context.Context, oldPath, newPath string, opts ...fs.Option) error {
+	return q.move(ctx, "Rename", oldPath, newPath, func() error {
+		return q.FileSystem.Rename(ctx, oldPath, newPath, opts...)
+	})
+}
+
+// move 用量从源文件所属的前缀转移到目标所属的前缀，目标所属前缀的剩余用量不足时拒绝移动
+func (q *Quota) move(ctx context.Context, op, src, dst string, move func() error) error {
+	srcPrefixes, err := q.prefixes(ctx, src)
+	if err != nil {
+		return err
+	}
+	c, err := q.begin(ctx, dst)
+	if err != nil {
+		return err
+	}
+	if len(srcPrefixes) == 0 && len(c.prefixes) == 0 {
+		return move()
+	}
+	info, err := q.FileSystem.Stat(ctx, src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return q.moveDir(ctx, op, src, dst, c, move)
+	}
+
+	extra := make(map[string]int64, len(srcPrefixes)+len(c.prefixes))
+	for _, prefix := range c.prefixes {
+		extra[prefix] += info.Size() - c.old
+	}
+	for _, prefix := range srcPrefixes {
+		extra[prefix] -= info.Size()
+	}
+	if err = c.apply(ctx, extra); err != nil {
+		return fs.NewPathError("quota", op, dst, err, nil)
+	}
+	if err = move(); err != nil {
+		c.release()
+		return err
+	}
+	return c.commit(ctx)
+}
+
+// moveDir 统计源目录下的文件，按移动后各前缀的用量变化检查限额，移动后记录变化
+func (q *Quota) moveDir(ctx context.Context, op, src, dst string, c *change, move func() error) error {
+	srcPrefixes, err := q.overlapping(ctx, src)
+	if err != nil {
+		return err
+	}
+	dstPrefixes, err := q.overlapping(ctx, dst)
+	if err != nil {
+		return err
+	}
+	src, dst = fs.CleanPath(src), fs.CleanPath(dst)
+
+	extra := make(map[string]int64, len(srcPrefixes)+len(dstPrefixes))
+	err = fs.Walk(ctx, q.FileSystem, src, func(name string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		name = fs.CleanPath(name)
+		target := path.Join(dst, strings.TrimPrefix(name, src+"/"))
+		for _, prefix := range dstPrefixes {
+			if contains(prefix, target) {
+				extra[prefix] += info.Size()
+			}
+		}
+		for _, prefix := range srcPrefixes {
+			if contains(prefix, name) {
+				extra[prefix] -= info.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err = c.apply(ctx, extra); err != nil {
+		return fs.NewPathError("quota", op, dst, err, nil)
+	}
+	if err = move(); err != nil {
+		c.release()
+		return err
+	}
+	return c.commit(ctx)
+}
+
+func (q *Quota) Uploader() fs.Uploader {
+	return &uploader{Uploader: q.FileSystem.Uploader(), q: q}
+}
+
+// writer 写入前预留用量的写入器
+type writer struct {
+	io.WriteCloser
+	ctx    context.Context
+	cancel context.CancelFunc // 超出限额时取消写入，驱动不会保存已写入的数据
+	change *change
+	op     string
+	exact  bool  // 写入成功后用量变化为已预留的变化，否则按文件大小更新
+	err    error // 超出限额的错误
+	closed bool
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if err := w.change.grow(w.ctx, int64(len(p))); err != nil {
+		w.err = fs.NewPathError("quota", w.op, w.change.path, err, nil)
+		w.cancel()
+		return 0, w.err
+	}
+	n, err := w.WriteCloser.Write(p)
+	if n < len(p) {
+		w.exact = false
+	}
+	return n, err
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return w.WriteCloser.Close()
+	}
+	w.closed = true
+	defer w.cancel()
+	err := w.WriteCloser.Close()
+	if w.err != nil || err != nil || !w.exact {
+		if reconcileErr := w.change.reconcile(w.ctx); err == nil {
+			err = reconcileErr
+		}
+		if w.err != nil {
+			return w.err
+		}
+		return err
+	}
+	return w.change.commit(w.ctx)
+}
+
+// readWriteFile OpenFile 返回的文件
+type readWriteFile struct {
+	io.Reader
+	*writer
+}
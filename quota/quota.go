@@ -0,0 +1,393 @@
+// Package quota 按路径前缀限制文件系统的存储用量
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/goairix/fs"
+)
+
+// ErrQuotaExceeded 写入会超出限额，可通过 errors.Is 判断，通过 errors.As 获取 *ExceededError
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// ExceededError 写入会超出前缀的限额时返回的错误
+type ExceededError struct {
+	Prefix string // 超出限额的前缀
+	Limit  int64  // 限额
+	Usage  int64  // 当前用量，包括正在进行的写入预留的用量
+	Size   int64  // 本次写入需要增加的用量
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded for prefix %q: usage %d + %d > limit %d", e.Prefix, e.Usage, e.Size, e.Limit)
+}
+
+// Is 判断是否为 ErrQuotaExceeded
+func (e *ExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Config 限额配置
+type Config struct {
+	Limits map[string]int64 // 各前缀的限额（字节），前缀按目录匹配，如 "tenants/a" 匹配 "tenants/a/x.txt"
+	Store  Store            // 用量存储，为 nil 时使用 NewMemoryStore()
+}
+
+// Quota 按前缀限制用量的文件系统。
+// 前缀第一次使用时扫描其下的全部文件得到用量，之后随写入、删除、移动与分片上传的完成增量更新。
+// 文件同时属于多个前缀时计入每个前缀。写入前检查限额：大小已知时超出限额直接拒绝，
+// 大小未知时写入的数据超出限额前拒绝写入并取消上传。
+// 正在进行的写入会预留用量，同一个 Quota 的并发写入不会共同超出限额
+type Quota struct {
+	fs.Wrapper
+	store Store
+
+	mu       sync.Mutex
+	limits   map[string]int64
+	reserved map[string]int64  // 正在进行的写入预留的用量
+	versions map[string]uint64 // 各前缀的用量记录修改的次数，用于判断读取的用量是否已过期
+
+	scanMu sync.Mutex // 同一时间只扫描一个前缀
+}
+
+// New 返回按前缀限制用量的文件系统
+func New(fsys fs.FileSystem, conf Config) *Quota {
+	q := &Quota{
+		Wrapper:  fs.Wrapper{FileSystem: fsys},
+		store:    conf.Store,
+		limits:   make(map[string]int64, len(conf.Limits)),
+		reserved: make(map[string]int64),
+		versions: make(map[string]uint64),
+	}
+	if q.store == nil {
+		q.store = NewMemoryStore()
+	}
+	for prefix, limit := range conf.Limits {
+		q.limits[fs.CleanPath(prefix)] = limit
+	}
+	return q
+}
+
+// SetLimit 设置前缀的限额
+func (q *Quota) SetLimit(prefix string, limit int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.limits[fs.CleanPath(prefix)] = limit
+}
+
+// RemoveLimit 移除前缀的限额
+func (q *Quota) RemoveLimit(prefix string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.limits, fs.CleanPath(prefix))
+}
+
+// Usage 返回前缀的用量，没有记录时扫描前缀下的全部文件
+func (q *Quota) Usage(ctx context.Context, prefix string) (int64, error) {
+	prefix = fs.CleanPath(prefix)
+	if err := q.seed(ctx, prefix); err != nil {
+		return 0, err
+	}
+	usage, _, err := q.store.Get(ctx, prefix)
+	return usage, err
+}
+
+// Rescan 重新扫描前缀下的全部文件，修正用量。扫描期间的写入可能导致结果不准确
+func (q *Quota) Rescan(ctx context.Context, prefix string) error {
+	prefix = fs.CleanPath(prefix)
+	q.scanMu.Lock()
+	defer q.scanMu.Unlock()
+	usage, err := q.scan(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	if err = q.store.Set(ctx, prefix, usage); err != nil {
+		return err
+	}
+	q.bump(prefix)
+	return nil
+}
+
+// seed 前缀没有用量记录时扫描并记录
+func (q *Quota) seed(ctx context.Context, prefix string) error {
+	if _, ok, err := q.store.Get(ctx, prefix); err != nil || ok {
+		return err
+	}
+	q.scanMu.Lock()
+	defer q.scanMu.Unlock()
+	if _, ok, err := q.store.Get(ctx, prefix); err != nil || ok {
+		return err
+	}
+	usage, err := q.scan(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	if err = q.store.Set(ctx, prefix, usage); err != nil {
+		return err
+	}
+	q.bump(prefix)
+	return nil
+}
+
+// scan 返回前缀下全部文件的大小之和
+func (q *Quota) scan(ctx context.Context, prefix string) (int64, error) {
+	var usage int64
+	err := fs.Walk(ctx, q.FileSystem, prefix, func(_ string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			usage += info.Size()
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+	return usage, nil
+}
+
+// prefixes 返回 path 所属的有限额的前缀，并确保这些前缀已有用量记录
+func (q *Quota) prefixes(ctx context.Context, path string) ([]string, error) {
+	path = fs.CleanPath(path)
+	q.mu.Lock()
+	var prefixes []string
+	for prefix := range q.limits {
+		if contains(prefix, path) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	q.mu.Unlock()
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		if err := q.seed(ctx, prefix); err != nil {
+			return nil, err
+		}
+	}
+	return prefixes, nil
+}
+
+// overlapping 返回与目录 dir 有重叠的有限额的前缀，即 dir 所属的前缀与 dir 下的前缀，并确保这些前缀已有用量记录
+func (q *Quota) overlapping(ctx context.Context, dir string) ([]string, error) {
+	dir = fs.CleanPath(dir)
+	q.mu.Lock()
+	var prefixes []string
+	for prefix := range q.limits {
+		if contains(prefix, dir) || contains(dir, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	q.mu.Unlock()
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		if err := q.seed(ctx, prefix); err != nil {
+			return nil, err
+		}
+	}
+	return prefixes, nil
+}
+
+// contains 判断规范化后的路径 path 是否在前缀 prefix 下
+func contains(prefix, path string) bool {
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// add 将各前缀的用量增加 delta
+func (q *Quota) add(ctx context.Context, prefixes []string, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	for _, prefix := range prefixes {
+		if err := q.store.Add(context.WithoutCancel(ctx), prefix, delta); err != nil {
+			return err
+		}
+		q.bump(prefix)
+	}
+	return nil
+}
+
+// bump 记录前缀的用量已修改
+func (q *Quota) bump(prefix string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.versions[prefix]++
+}
+
+// size 返回文件的大小，文件不存在或为目录时返回 0
+func (q *Quota) size(ctx context.Context, path string) (int64, error) {
+	info, err := q.FileSystem.Stat(ctx, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if info.IsDir() {
+		return 0, nil
+	}
+	return info.Size(), nil
+}
+
+// change 一次写入对各前缀用量的变化，增加的用量在完成前预留
+type change struct {
+	q        *Quota
+	path     string
+	prefixes []string         // path 所属的前缀
+	old      int64            // 写入前 path 的大小
+	deltas   map[string]int64 // 各前缀的用量变化
+	reserved map[string]int64 // 各前缀预留的用量
+}
+
+// begin 开始对 path 的写入，此时用量没有变化，通过 grow 与 apply 记录变化
+func (q *Quota) begin(ctx context.Context, path string) (*change, error) {
+	prefixes, err := q.prefixes(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	c := &change{
+		q:        q,
+		path:     path,
+		prefixes: prefixes,
+		deltas:   make(map[string]int64),
+		reserved: make(map[string]int64),
+	}
+	if len(prefixes) > 0 {
+		if c.old, err = q.size(ctx, path); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// grow 将 path 所属各前缀的用量变化增加 n
+func (c *change) grow(ctx context.Context, n int64) error {
+	extra := make(map[string]int64, len(c.prefixes))
+	for _, prefix := range c.prefixes {
+		extra[prefix] = n
+	}
+	return c.apply(ctx, extra)
+}
+
+// apply 将各前缀的用量变化增加 extra，预留增加的用量；任一前缀超出限额时返回 *ExceededError 且不做修改。
+// 用量在加锁前读取，读取期间用量被修改时重新读取，锁内只计算预留的用量
+func (c *change) apply(ctx context.Context, extra map[string]int64) error {
+	q := c.q
+	for {
+		// 读取有限额的前缀的用量与版本
+		q.mu.Lock()
+		versions := make(map[string]uint64, len(extra))
+		for prefix := range extra {
+			if _, ok := q.limits[prefix]; ok {
+				versions[prefix] = q.versions[prefix]
+			}
+		}
+		q.mu.Unlock()
+		usages := make(map[string]int64, len(versions))
+		for prefix := range versions {
+			usage, _, err := q.store.Get(ctx, prefix)
+			if err != nil {
+				return err
+			}
+			usages[prefix] = usage
+		}
+
+		q.mu.Lock()
+		if !c.current(versions) {
+			q.mu.Unlock()
+			continue
+		}
+		err := c.reserve(extra, usages)
+		q.mu.Unlock()
+		return err
+	}
+}
+
+// current 判断读取的用量是否仍是最新的，调用时需持有 q.mu
+func (c *change) current(versions map[string]uint64) bool {
+	for prefix, version := range versions {
+		if c.q.versions[prefix] != version {
+			return false
+		}
+	}
+	return true
+}
+
+// reserve 按读取的用量检查限额并预留，调用时需持有 q.mu
+func (c *change) reserve(extra, usages map[string]int64) error {
+	q := c.q
+	need := make(map[string]int64, len(extra))
+	for prefix, n := range extra {
+		delta := c.deltas[prefix] + n
+		need[prefix] = max(delta, 0) - c.reserved[prefix]
+		if need[prefix] <= 0 {
+			continue
+		}
+		limit, ok := q.limits[prefix]
+		if !ok {
+			continue
+		}
+		usage, ok := usages[prefix]
+		if !ok {
+			// 读取用量后设置的限额，下次写入时检查
+			continue
+		}
+		if usage+q.reserved[prefix]+need[prefix] > limit {
+			return &ExceededError{Prefix: prefix, Limit: limit, Usage: usage + q.reserved[prefix], Size: need[prefix]}
+		}
+	}
+	for prefix, n := range extra {
+		c.deltas[prefix] += n
+		c.reserved[prefix] += need[prefix]
+		q.reserved[prefix] += need[prefix]
+	}
+	return nil
+}
+
+// commit 写入成功，记录用量变化并释放预留的用量
+func (c *change) commit(ctx context.Context) error {
+	var firstErr error
+	for prefix, delta := range c.deltas {
+		if err := c.q.add(ctx, []string{prefix}, delta); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.release()
+	return firstErr
+}
+
+// reconcile 写入失败或大小未知，按 path 当前的大小记录用量变化并释放预留的用量
+func (c *change) reconcile(ctx context.Context) error {
+	if len(c.prefixes) == 0 {
+		return nil
+	}
+	size, err := c.q.size(context.WithoutCancel(ctx), c.path)
+	if err != nil {
+		c.release()
+		return err
+	}
+	for _, prefix := range c.prefixes {
+		c.deltas[prefix] = size - c.old
+	}
+	return c.commit(ctx)
+}
+
+// release 释放预留的用量，不记录用量变化
+func (c *change) release() {
+	q := c.q
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for prefix, n := range c.reserved {
+		if q.reserved[prefix] -= n; q.reserved[prefix] == 0 {
+			delete(q.reserved, prefix)
+		}
+	}
+	clear(c.reserved)
+	clear(c.deltas)
+}
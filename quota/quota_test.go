@@ -0,0 +1,276 @@
+package quota_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/local"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/goairix/fs/fstest"
+	"github.com/goairix/fs/quota"
+)
+
+func newMemory(t *testing.T) fs.FileSystem {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func write(t *testing.T, fsys fs.FileSystem, path, data string) {
+	t.Helper()
+	w, err := fsys.Create(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func usage(t *testing.T, q *quota.Quota, prefix string) int64 {
+	t.Helper()
+	n, err := q.Usage(context.Background(), prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestQuotaFileSystem(t *testing.T) {
+	fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+		q := quota.New(newMemory(t), quota.Config{Limits: map[string]int64{"": 1 << 40, "a": 1 << 30}})
+		// 增量统计的用量与重新扫描的结果一致
+		t.Cleanup(func() {
+			before := usage(t, q, "")
+			if err := q.Rescan(context.Background(), ""); err != nil {
+				t.Fatal(err)
+			}
+			if after := usage(t, q, ""); before != after {
+				t.Errorf("tracked usage %d, rescanned usage %d", before, after)
+			}
+		})
+		return q
+	})
+}
+
+func TestReservation(t *testing.T) {
+	ctx := context.Background()
+	m := newMemory(t)
+	write(t, m, "t/old.txt", "0123456789")
+	q := quota.New(m, quota.Config{Limits: map[string]int64{"t": 100}})
+
+	// 首次查询时扫描已有的文件
+	if n := usage(t, q, "t"); n != 10 {
+		t.Fatalf("initial usage = %d, want 10", n)
+	}
+	write(t, q, "t/a.txt", strings.Repeat("a", 40))
+	if n := usage(t, q, "t"); n != 50 {
+		t.Fatalf("usage after Create = %d, want 50", n)
+	}
+
+	// 已知大小的上传在上传前拒绝
+	err := q.Uploader().Upload(ctx, "t/big.bin", bytes.NewReader(make([]byte, 60)))
+	var exceeded *quota.ExceededError
+	if !errors.Is(err, quota.ErrQuotaExceeded) || !errors.As(err, &exceeded) || exceeded.Prefix != "t" {
+		t.Fatalf("Upload over the limit: %v, want ExceededError for t", err)
+	}
+	if ok, _ := m.Exists(ctx, "t/big.bin"); ok {
+		t.Fatal("rejected upload was written")
+	}
+	err = q.Uploader().Upload(ctx, "t/stream.bin", iotest.HalfReader(bytes.NewReader(make([]byte, 60))))
+	if !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Fatalf("streaming Upload over the limit: %v", err)
+	}
+	if ok, _ := m.Exists(ctx, "t/stream.bin"); ok {
+		t.Fatal("rejected streaming upload was written")
+	}
+
+	// 写入流超过限额时 Write 与 Close 返回错误，已预留的用量被释放
+	w, err := q.Create(ctx, "t/c.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(make([]byte, 30)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(make([]byte, 30)); !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Fatalf("Write over the limit: %v", err)
+	}
+	if err := w.Close(); !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Fatalf("Close after exceeding the limit: %v", err)
+	}
+	if n := usage(t, q, "t"); n != 50 {
+		t.Fatalf("usage after rejected writes = %d, want 50", n)
+	}
+
+	// 其他前缀不受限制
+	write(t, q, "u/free.txt", strings.Repeat("f", 200))
+	if n := usage(t, q, "t"); n != 50 {
+		t.Fatalf("write outside the prefix changed usage to %d", n)
+	}
+}
+
+func TestConcurrentReservation(t *testing.T) {
+	ctx := context.Background()
+	q := quota.New(newMemory(t), quota.Config{Limits: map[string]int64{"t": 1000}})
+	var succeeded atomic.Int64
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := q.Uploader().Upload(ctx, fmt.Sprintf("t/%d.bin", i), bytes.NewReader(make([]byte, 100)))
+			if err == nil {
+				succeeded.Add(1)
+			} else if !errors.Is(err, quota.ErrQuotaExceeded) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := succeeded.Load(); n != 10 || usage(t, q, "t") != 1000 {
+		t.Fatalf("%d uploads succeeded, usage %d", n, usage(t, q, "t"))
+	}
+}
+
+func TestOverwrite(t *testing.T) {
+	ctx := context.Background()
+	q := quota.New(newMemory(t), quota.Config{Limits: map[string]int64{"t": 100}})
+	write(t, q, "t/a.txt", strings.Repeat("a", 80))
+
+	// 覆盖只计算大小的差值
+	write(t, q, "t/a.txt", strings.Repeat("a", 90))
+	if n := usage(t, q, "t"); n != 90 {
+		t.Fatalf("usage after growing overwrite = %d, want 90", n)
+	}
+	if err := q.Uploader().Upload(ctx, "t/a.txt", strings.NewReader("small")); err != nil {
+		t.Fatal(err)
+	}
+	if n := usage(t, q, "t"); n != 5 {
+		t.Fatalf("usage after shrinking overwrite = %d, want 5", n)
+	}
+	if err := q.Remove(ctx, "t/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if n := usage(t, q, "t"); n != 0 {
+		t.Fatalf("usage after Remove = %d, want 0", n)
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	ctx := context.Background()
+	q := quota.New(newMemory(t), quota.Config{Limits: map[string]int64{"t": 100}})
+	u := q.Uploader()
+	uploadID, err := u.InitMultipartUpload(ctx, "t/m.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag1, err := u.UploadPart(ctx, "t/m.bin", uploadID, 1, bytes.NewReader(make([]byte, 60)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag2, err := u.UploadPart(ctx, "t/m.bin", uploadID, 2, bytes.NewReader(make([]byte, 60)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 单个分片超过限额时拒绝
+	if _, err := u.UploadPart(ctx, "t/m.bin", uploadID, 3, bytes.NewReader(make([]byte, 101))); !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Fatalf("UploadPart over the limit: %v", err)
+	}
+
+	// 完成时按组装后的大小计算
+	parts := []fs.MultipartPart{{PartNumber: 1, ETag: etag1}, {PartNumber: 2, ETag: etag2}}
+	if err := u.CompleteMultipartUpload(ctx, "t/m.bin", uploadID, parts); !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Fatalf("CompleteMultipartUpload over the limit: %v", err)
+	}
+	if n := usage(t, q, "t"); n != 0 {
+		t.Fatalf("usage after a rejected upload = %d, want 0", n)
+	}
+	if err := u.CompleteMultipartUpload(ctx, "t/m.bin", uploadID, parts[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if n := usage(t, q, "t"); n != 60 {
+		t.Fatalf("usage after CompleteMultipartUpload = %d, want 60", n)
+	}
+}
+
+func TestMoveAndCopy(t *testing.T) {
+	ctx := context.Background()
+	m := newMemory(t)
+	q := quota.New(m, quota.Config{
+		Limits: map[string]int64{"t1": 100, "t2": 50},
+		Store:  quota.NewFileStore(m, "quota.json"),
+	})
+	write(t, q, "t1/a.txt", strings.Repeat("a", 80))
+	write(t, q, "t1/b.txt", strings.Repeat("b", 10))
+
+	if err := q.Move(ctx, "t1/a.txt", "t2/a.txt"); !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Fatalf("Move over the destination limit: %v", err)
+	}
+	if err := q.Move(ctx, "t1/b.txt", "t2/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if usage(t, q, "t1") != 80 || usage(t, q, "t2") != 10 {
+		t.Fatalf("usage after Move: t1 %d, t2 %d", usage(t, q, "t1"), usage(t, q, "t2"))
+	}
+	if err := q.Copy(ctx, "t1/a.txt", "t1/c.txt"); !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Fatalf("Copy over the limit: %v", err)
+	}
+	if err := q.Copy(ctx, "t2/b.txt", "t2/c.txt"); err != nil || usage(t, q, "t2") != 20 {
+		t.Fatalf("Copy: %v, usage %d", err, usage(t, q, "t2"))
+	}
+	if err := q.RemoveDir(ctx, "t2"); err != nil || usage(t, q, "t2") != 0 {
+		t.Fatalf("RemoveDir: %v, usage %d", err, usage(t, q, "t2"))
+	}
+
+	// 用量保存在 Store 中，重新创建时继续使用
+	reopened := quota.New(m, quota.Config{Limits: map[string]int64{"t1": 100}, Store: quota.NewFileStore(m, "quota.json")})
+	if n := usage(t, reopened, "t1"); n != 80 {
+		t.Fatalf("persisted usage = %d, want 80", n)
+	}
+}
+
+func TestMoveDir(t *testing.T) {
+	ctx := context.Background()
+	l, err := local.New(local.Config{RootPath: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := quota.New(l, quota.Config{Limits: map[string]int64{"t1": 100, "t2": 50, "t2/sub": 20}})
+	write(t, q, "t1/d/a.txt", strings.Repeat("a", 30))
+	write(t, q, "t1/d/b.txt", strings.Repeat("b", 30))
+
+	if err := q.Move(ctx, "t1/d", "t2/d"); !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Fatalf("Move directory over the limit: %v", err)
+	}
+	if err := q.Move(ctx, "t1/d", "t2/sub/d"); !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Fatalf("Move directory over a nested limit: %v", err)
+	}
+	if err := q.Remove(ctx, "t1/d/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Move(ctx, "t1/d", "t2/d"); err != nil {
+		t.Fatal(err)
+	}
+	if usage(t, q, "t1") != 0 || usage(t, q, "t2") != 30 || usage(t, q, "t2/sub") != 0 {
+		t.Fatalf("usage after moving a directory: t1 %d, t2 %d", usage(t, q, "t1"), usage(t, q, "t2"))
+	}
+	if err := q.Rename(ctx, "t2/d", "t1/e"); err != nil || usage(t, q, "t1") != 30 || usage(t, q, "t2") != 0 {
+		t.Fatalf("Rename directory: %v, t1 %d, t2 %d", err, usage(t, q, "t1"), usage(t, q, "t2"))
+	}
+}
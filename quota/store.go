@@ -0,0 +1,134 @@
+package quota
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/goairix/fs"
+)
+
+// Store 保存各前缀的用量，多个进程共用限额时需要使用共享的存储，如基于 Redis 实现
+type Store interface {
+	// Get 返回前缀的用量，没有记录时 ok 为 false，此时会扫描前缀下的全部文件后通过 Set 记录
+	Get(ctx context.Context, prefix string) (usage int64, ok bool, err error)
+	// Set 设置前缀的用量
+	Set(ctx context.Context, prefix string, usage int64) error
+	// Add 将前缀的用量增加 delta，delta 可以为负数
+	Add(ctx context.Context, prefix string, delta int64) error
+}
+
+// MemoryStore 保存在内存中的用量，进程重启后重新扫描
+type MemoryStore struct {
+	mu    sync.Mutex
+	usage map[string]int64
+}
+
+// NewMemoryStore 创建内存用量存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{usage: make(map[string]int64)}
+}
+
+func (s *MemoryStore) Get(_ context.Context, prefix string) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usage, ok := s.usage[prefix]
+	return usage, ok, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, prefix string, usage int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage[prefix] = usage
+	return nil
+}
+
+func (s *MemoryStore) Add(_ context.Context, prefix string, delta int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage[prefix] += delta
+	return nil
+}
+
+// FileStore 将用量以 JSON 保存在文件系统的一个文件中，每次变化后写回，适用于单进程
+type FileStore struct {
+	fsys  fs.FileSystem
+	path  string
+	mu    sync.Mutex
+	usage map[string]int64 // 为 nil 时尚未读取
+}
+
+// NewFileStore 创建保存在 fsys 中 path 处的用量存储，文件不存在时视为没有记录
+func NewFileStore(fsys fs.FileSystem, path string) *FileStore {
+	return &FileStore{fsys: fsys, path: path}
+}
+
+func (s *FileStore) Get(ctx context.Context, prefix string) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(ctx); err != nil {
+		return 0, false, err
+	}
+	usage, ok := s.usage[prefix]
+	return usage, ok, nil
+}
+
+func (s *FileStore) Set(ctx context.Context, prefix string, usage int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	s.usage[prefix] = usage
+	return s.save(ctx)
+}
+
+func (s *FileStore) Add(ctx context.Context, prefix string, delta int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	s.usage[prefix] += delta
+	return s.save(ctx)
+}
+
+// load 第一次使用时读取文件，调用方需持有锁
+func (s *FileStore) load(ctx context.Context) error {
+	if s.usage != nil {
+		return nil
+	}
+	usage := make(map[string]int64)
+	reader, err := s.fsys.Open(ctx, s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.usage = usage
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, &usage); err != nil {
+		return err
+	}
+	s.usage = usage
+	return nil
+}
+
+// save 写回文件，调用方需持有锁
+func (s *FileStore) save(ctx context.Context) error {
+	data, err := json.Marshal(s.usage)
+	if err != nil {
+		return err
+	}
+	return s.fsys.Uploader().Upload(ctx, s.path, bytes.NewReader(data), fs.WithContentType("application/json"))
+}
@@ -0,0 +1,124 @@
+package quota
+
+import (
+	"context"
+	"io"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/internal/ioutil"
+)
+
+// uploader 按前缀限制用量的上传器
+type uploader struct {
+	fs.Uploader
+	q *Quota
+}
+
+// Upload reader 实现 io.Seeker 时按大小预留用量，超出限额时不上传；
+// 否则逐步预留读取的数据，超出限额时读取失败，驱动取消上传
+func (u *uploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	c, err := u.q.begin(ctx, path)
+	if err != nil {
+		return err
+	}
+	if len(c.prefixes) == 0 {
+		return u.Uploader.Upload(ctx, path, reader, opts...)
+	}
+
+	if seeker, ok := reader.(io.Seeker); ok {
+		size, err := ioutil.Remaining(seeker)
+		if err != nil {
+			return fs.NewPathError("quota", "Upload", path, err, nil)
+		}
+		if err = c.grow(ctx, size-c.old); err != nil {
+			return fs.NewPathError("quota", "Upload", path, err, nil)
+		}
+	} else {
+		_ = c.grow(ctx, -c.old)
+		reader = &limitReader{Reader: reader, ctx: ctx, change: c}
+	}
+
+	if err = u.Uploader.Upload(ctx, path, reader, opts...); err != nil {
+		_ = c.reconcile(ctx)
+		if lr, ok := reader.(*limitReader); ok && lr.err != nil {
+			return lr.err
+		}
+		return err
+	}
+	return c.commit(ctx)
+}
+
+// UploadPart data 实现 io.Seeker 时检查分片本身是否超出剩余用量，完成分片上传时检查全部分片
+func (u *uploader) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
+	if seeker, ok := data.(io.Seeker); ok {
+		c, err := u.q.begin(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		size, err := ioutil.Remaining(seeker)
+		if err != nil {
+			return "", fs.NewPathError("quota", "UploadPart", path, err, nil)
+		}
+		err = c.grow(ctx, size-c.old)
+		c.release()
+		if err != nil {
+			return "", fs.NewPathError("quota", "UploadPart", path, err, nil)
+		}
+	}
+	return u.Uploader.UploadPart(ctx, path, uploadID, partNumber, data, opts...)
+}
+
+// CompleteMultipartUpload 按已上传分片的大小预留用量，超出限额时不完成上传，分片保留，可以取消上传
+func (u *uploader) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	c, err := u.q.begin(ctx, path)
+	if err != nil {
+		return err
+	}
+	if len(c.prefixes) == 0 {
+		return u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...)
+	}
+
+	uploaded, err := u.Uploader.ListUploadedParts(ctx, path, uploadID)
+	if err != nil {
+		return err
+	}
+	sizes := make(map[int]int64, len(uploaded))
+	for _, part := range uploaded {
+		sizes[part.PartNumber] = part.Size
+	}
+	var size int64
+	for _, part := range parts {
+		size += sizes[part.PartNumber]
+	}
+	if err = c.grow(ctx, size-c.old); err != nil {
+		return fs.NewPathError("quota", "CompleteMultipartUpload", path, err, nil)
+	}
+
+	if err = u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...); err != nil {
+		_ = c.reconcile(ctx)
+		return err
+	}
+	return c.commit(ctx)
+}
+
+// limitReader 读取前预留用量，超出限额时返回错误，超出的数据不会交给驱动
+type limitReader struct {
+	io.Reader
+	ctx    context.Context
+	change *change
+	err    error // 超出限额的错误
+}
+
+func (r *limitReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		if growErr := r.change.grow(r.ctx, int64(n)); growErr != nil {
+			r.err = fs.NewPathError("quota", "Upload", r.change.path, growErr, nil)
+			return 0, r.err
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,158 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	iofs "io/fs"
+)
+
+// RangeReader 支持随机访问的文件读取器
+type RangeReader interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+	// Size 返回文件大小
+	Size() int64
+}
+
+// ReaderOpener 由支持原生随机读取的驱动实现，OpenReader 会优先使用该接口
+type ReaderOpener interface {
+	// OpenReader 打开文件并返回支持随机访问的读取器
+	OpenReader(ctx context.Context, path string, opts ...Option) (RangeReader, error)
+}
+
+// OpenReader 打开文件并返回支持随机访问的读取器，
+// 驱动未实现 ReaderOpener 时通过 WithRange 范围读取实现，只在实际读取时才发起请求
+func OpenReader(ctx context.Context, fsys FileSystem, path string, opts ...Option) (RangeReader, error) {
+	if opener, ok := fsys.(ReaderOpener); ok {
+		return opener.OpenReader(ctx, path, opts...)
+	}
+
+	info, err := fsys.Stat(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, &iofs.PathError{Op: "open", Path: path, Err: ErrIsDir}
+	}
+	return newRangeReader(ctx, fsys, path, info.Size(), opts...), nil
+}
+
+// openRangeReader 在已知文件大小时打开 RangeReader，省去一次 Stat
+func openRangeReader(ctx context.Context, fsys FileSystem, path string, size int64) (RangeReader, error) {
+	if opener, ok := fsys.(ReaderOpener); ok {
+		return opener.OpenReader(ctx, path)
+	}
+	return newRangeReader(ctx, fsys, path, size), nil
+}
+
+// rangeReader 基于范围读取实现的 RangeReader
+type rangeReader struct {
+	ctx  context.Context
+	fsys FileSystem
+	path string
+	size int64
+	opts []Option
+
+	offset     int64         // 当前读取位置
+	body       io.ReadCloser // 从 bodyOffset 开始的顺序读取流
+	bodyOffset int64
+}
+
+func newRangeReader(ctx context.Context, fsys FileSystem, path string, size int64, opts ...Option) *rangeReader {
+	return &rangeReader{ctx: ctx, fsys: fsys, path: path, size: size, opts: opts}
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Seek 后读取位置与当前读取流不一致时重新发起范围读取
+	if r.body != nil && r.bodyOffset != r.offset {
+		_ = r.body.Close()
+		r.body = nil
+	}
+	if r.body == nil {
+		body, err := r.open(r.offset, 0)
+		if err != nil {
+			return 0, err
+		}
+		r.body, r.bodyOffset = body, r.offset
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	r.bodyOffset += int64(n)
+	if err == io.EOF && r.offset < r.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (r *rangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("fs.rangeReader.ReadAt: negative offset")
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	length := min(int64(len(p)), r.size-off)
+	body, err := r.open(off, length)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	n, err := io.ReadFull(body, p[:length])
+	if err == nil && int64(len(p)) > length {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.offset + offset
+	case io.SeekEnd:
+		abs = r.size + offset
+	default:
+		return 0, errors.New("fs.rangeReader.Seek: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("fs.rangeReader.Seek: negative position")
+	}
+	r.offset = abs
+	return abs, nil
+}
+
+func (r *rangeReader) Size() int64 {
+	return r.size
+}
+
+func (r *rangeReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+func (r *rangeReader) open(offset, length int64) (io.ReadCloser, error) {
+	opts := append(append([]Option(nil), r.opts...), WithRange(offset, length))
+	return r.fsys.Open(r.ctx, r.path, opts...)
+}
@@ -0,0 +1,94 @@
+package fs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goairix/fs"
+)
+
+// rangeOnly 隐藏驱动的 ReaderOpener，并记录 Open 的调用次数
+type rangeOnly struct {
+	fs.FileSystem
+	opens int
+}
+
+func (r *rangeOnly) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	r.opens++
+	return r.FileSystem.Open(ctx, path, opts...)
+}
+
+func TestOpenReaderFallback(t *testing.T) {
+	ctx := context.Background()
+	content := "0123456789abcdefghijklmnopqrstuvwxyz"
+	fsys := &rangeOnly{FileSystem: newMemory(t, map[string]string{"a.txt": content})}
+
+	reader, err := fs.OpenReader(ctx, fsys, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if fsys.opens != 0 {
+		t.Fatalf("OpenReader opened the file %d times before reading", fsys.opens)
+	}
+	if reader.Size() != int64(len(content)) {
+		t.Fatalf("Size() = %d, want %d", reader.Size(), len(content))
+	}
+	if err := iotest.TestReader(reader, []byte(content)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenReaderSequential(t *testing.T) {
+	ctx := context.Background()
+	fsys := &rangeOnly{FileSystem: newMemory(t, map[string]string{"a.txt": "0123456789"})}
+	reader, err := fs.OpenReader(ctx, fsys, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	buf := make([]byte, 2)
+	for i := 0; i < 3; i++ {
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fsys.opens != 1 {
+		t.Fatalf("sequential reads opened the file %d times, want 1", fsys.opens)
+	}
+
+	if _, err := reader.Seek(1, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "12" {
+		t.Fatalf("read after Seek = %q, want %q", buf, "12")
+	}
+
+	buf = make([]byte, 4)
+	n, err := reader.ReadAt(buf, 8)
+	if n != 2 || err != io.EOF || string(buf[:n]) != "89" {
+		t.Fatalf("ReadAt past the end = %d, %v, %q", n, err, buf[:n])
+	}
+	if _, err := reader.Seek(-1, io.SeekStart); err == nil {
+		t.Fatal("Seek to a negative position succeeded")
+	}
+}
+
+func TestOpenReaderErrors(t *testing.T) {
+	ctx := context.Background()
+	fsys := &rangeOnly{FileSystem: newMemory(t, map[string]string{"d/a.txt": "a"})}
+
+	if _, err := fs.OpenReader(ctx, fsys, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("OpenReader missing file: %v, want ErrNotExist", err)
+	}
+	if _, err := fs.OpenReader(ctx, fsys, "d"); !errors.Is(err, fs.ErrIsDir) {
+		t.Errorf("OpenReader directory: %v, want ErrIsDir", err)
+	}
+}
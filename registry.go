@@ -0,0 +1,110 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DriverFactory 根据 URL 创建文件系统，由驱动通过 Register 注册
+type DriverFactory func(ctx context.Context, u *url.URL) (FileSystem, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]DriverFactory)
+)
+
+// Register 注册 URL scheme 对应的驱动，通常在驱动包的 init 中调用。
+// scheme 不区分大小写，重复注册或 factory 为 nil 时 panic
+func Register(scheme string, factory DriverFactory) {
+	scheme = strings.ToLower(scheme)
+	if factory == nil {
+		panic("fs: Register factory is nil for scheme " + scheme)
+	}
+
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if _, ok := drivers[scheme]; ok {
+		panic("fs: Register called twice for scheme " + scheme)
+	}
+	drivers[scheme] = factory
+}
+
+// Drivers 返回已注册的全部 scheme，按字典序排列
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	schemes := make([]string, 0, len(drivers))
+	for scheme := range drivers {
+		schemes = append(schemes, scheme)
+	}
+	slices.Sort(schemes)
+	return schemes
+}
+
+// OpenURL 根据 URL 创建文件系统，驱动由 URL 的 scheme 决定，需要先导入对应的驱动包完成注册：
+//
+//	import _ "github.com/goairix/fs/driver/s3"
+//
+//	fsys, err := fs.OpenURL(ctx, "s3://AK:SK@bucket/prefix?region=us-east-1")
+//
+// 各驱动支持的参数见驱动包的说明
+func OpenURL(ctx context.Context, rawURL string) (FileSystem, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// url.Error 中包含完整的 URL，不能将其中的密钥写入错误信息
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("fs.OpenURL: invalid URL: %w", err)
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("fs.OpenURL: missing scheme in %q", RedactURL(u))
+	}
+
+	driversMu.RLock()
+	factory, ok := drivers[u.Scheme]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("fs.OpenURL: unknown scheme %q (forgotten import of the driver package?)", u.Scheme)
+	}
+
+	fsys, err := factory(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("fs.OpenURL %s: %w", RedactURL(u), err)
+	}
+	return fsys, nil
+}
+
+// RedactURL 返回隐藏了密码与查询参数的值的 URL，用于错误信息与日志，
+// 驱动的密钥可以放在 userinfo 或查询参数中，签名 URL 的签名与凭证都在查询参数中
+func RedactURL(u *url.URL) string {
+	if u.RawQuery != "" {
+		redacted := *u
+		query := u.Query()
+		for key := range query {
+			query[key] = []string{"REDACTED"}
+		}
+		redacted.RawQuery = query.Encode()
+		u = &redacted
+	}
+	return u.Redacted()
+}
+
+// ParseAccessMode 解析访问模式，支持 private、public-read 与 public-read-write，为空时返回 Private
+func ParseAccessMode(s string) (AccessMode, error) {
+	switch strings.ToLower(s) {
+	case "", "private":
+		return Private, nil
+	case "public-read":
+		return PublicRead, nil
+	case "public-read-write":
+		return PublicReadWrite, nil
+	}
+	return Private, fmt.Errorf("invalid access mode %q", s)
+}
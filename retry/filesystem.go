@@ -0,0 +1,142 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/goairix/fs"
+)
+
+// fileSystem 失败时重试的文件系统
+type fileSystem struct {
+	fs.Wrapper
+	r *retryer
+}
+
+// New 返回失败时自动重试的文件系统，各操作的重试规则：
+//
+//   - 查询、List、Open、MakeDir、Copy、SetMetadata 等幂等操作失败时直接重试
+//   - Remove 与 RemoveDir 重试时返回文件不存在视为成功，CompleteMultipartUpload 重试时返回分片上传不存在视为成功
+//   - Create 只重试创建写入器，写入过程中的错误不重试
+//   - OpenFile 使用 os.O_EXCL 时不重试
+//   - Move 与 Rename 不是幂等操作，不重试
+//   - 上传的 io.Reader 实现 io.Seeker 时回到起始位置后重试，否则不重试
+func New(fsys fs.FileSystem, opts Options) fs.FileSystem {
+	return &fileSystem{Wrapper: fs.Wrapper{FileSystem: fsys}, r: newRetryer(opts)}
+}
+
+func (f *fileSystem) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
+	return do(ctx, f.r, nil, func(int) ([]fs.FileInfo, error) {
+		return f.FileSystem.List(ctx, path, opts...)
+	})
+}
+
+func (f *fileSystem) MakeDir(ctx context.Context, path string, perm os.FileMode, opts ...fs.Option) error {
+	return do0(ctx, f.r, nil, func(int) error {
+		return f.FileSystem.MakeDir(ctx, path, perm, opts...)
+	})
+}
+
+func (f *fileSystem) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
+	return do0(ctx, f.r, nil, func(attempt int) error {
+		return ignoreNotExist(attempt, f.FileSystem.RemoveDir(ctx, path, opts...))
+	})
+}
+
+func (f *fileSystem) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	return do(ctx, f.r, nil, func(int) (io.WriteCloser, error) {
+		return f.FileSystem.Create(ctx, path, opts...)
+	})
+}
+
+func (f *fileSystem) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	return do(ctx, f.r, nil, func(int) (io.ReadCloser, error) {
+		return f.FileSystem.Open(ctx, path, opts...)
+	})
+}
+
+func (f *fileSystem) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	if flag&os.O_EXCL != 0 {
+		return f.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+	}
+	return do(ctx, f.r, nil, func(int) (io.ReadWriteCloser, error) {
+		return f.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+	})
+}
+
+func (f *fileSystem) Remove(ctx context.Context, path string, opts ...fs.Option) error {
+	return do0(ctx, f.r, nil, func(attempt int) error {
+		return ignoreNotExist(attempt, f.FileSystem.Remove(ctx, path, opts...))
+	})
+}
+
+func (f *fileSystem) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	return do0(ctx, f.r, nil, func(int) error {
+		return f.FileSystem.Copy(ctx, src, dst, opts...)
+	})
+}
+
+func (f *fileSystem) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	return do(ctx, f.r, nil, func(int) (fs.FileInfo, error) {
+		return f.FileSystem.Stat(ctx, path, opts...)
+	})
+}
+
+func (f *fileSystem) GetMimeType(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	return do(ctx, f.r, nil, func(int) (string, error) {
+		return f.FileSystem.GetMimeType(ctx, path, opts...)
+	})
+}
+
+func (f *fileSystem) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	return do0(ctx, f.r, nil, func(int) error {
+		return f.FileSystem.SetMetadata(ctx, path, metadata, opts...)
+	})
+}
+
+func (f *fileSystem) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]interface{}, error) {
+	return do(ctx, f.r, nil, func(int) (map[string]interface{}, error) {
+		return f.FileSystem.GetMetadata(ctx, path, opts...)
+	})
+}
+
+func (f *fileSystem) Exists(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	return do(ctx, f.r, nil, func(int) (bool, error) {
+		return f.FileSystem.Exists(ctx, path, opts...)
+	})
+}
+
+func (f *fileSystem) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	return do(ctx, f.r, nil, func(int) (bool, error) {
+		return f.FileSystem.IsDir(ctx, path, opts...)
+	})
+}
+
+func (f *fileSystem) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	return do(ctx, f.r, nil, func(int) (bool, error) {
+		return f.FileSystem.IsFile(ctx, path, opts...)
+	})
+}
+
+func (f *fileSystem) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	return do(ctx, f.r, nil, func(int) (string, error) {
+		return f.FileSystem.SignFullUrl(ctx, path, opts...)
+	})
+}
+
+func (f *fileSystem) Uploader() fs.Uploader {
+	return &uploader{Uploader: f.FileSystem.Uploader(), r: f.r}
+}
+
+func (f *fileSystem) OpenReader(ctx context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	return do(ctx, f.r, nil, func(int) (fs.RangeReader, error) {
+		return fs.OpenReader(ctx, f.FileSystem, path, opts...)
+	})
+}
+
+func (f *fileSystem) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	return do(ctx, f.r, nil, func(int) (*fs.ListResult, error) {
+		return fs.ListPage(ctx, f.FileSystem, path, opts...)
+	})
+}
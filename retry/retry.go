@@ -0,0 +1,153 @@
+// Package retry 为文件系统操作提供失败重试，使用带随机抖动的指数退避
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+const (
+	DefaultMaxAttempts = 3                      // 默认最多尝试次数
+	DefaultBaseDelay   = 100 * time.Millisecond // 默认首次重试前的等待时间
+	DefaultMaxDelay    = 5 * time.Second        // 默认单次等待时间的上限
+)
+
+// Options 重试选项
+type Options struct {
+	MaxAttempts int                  // 最多尝试次数，包括首次调用，为 0 时使用 DefaultMaxAttempts
+	BaseDelay   time.Duration        // 首次重试前等待时间的上限，之后每次翻倍，为 0 时使用 DefaultBaseDelay
+	MaxDelay    time.Duration        // 单次等待时间的上限，为 0 时使用 DefaultMaxDelay
+	Retryable   func(err error) bool // 判断错误是否可以重试，为 nil 时使用 IsRetryable
+}
+
+// IsRetryable 默认的重试判断：驱动返回的 fs.ErrTemporary（限流、超时与 5xx 等服务端错误）、
+// 网络连接错误与超时可以重试，context 取消或超时不重试
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, fs.ErrTemporary), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EPIPE):
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryer 按选项执行重试
+type retryer struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	retryable   func(err error) bool
+}
+
+func newRetryer(opts Options) *retryer {
+	r := &retryer{
+		maxAttempts: opts.MaxAttempts,
+		baseDelay:   opts.BaseDelay,
+		maxDelay:    opts.MaxDelay,
+		retryable:   opts.Retryable,
+	}
+	if r.maxAttempts <= 0 {
+		r.maxAttempts = DefaultMaxAttempts
+	}
+	if r.baseDelay <= 0 {
+		r.baseDelay = DefaultBaseDelay
+	}
+	if r.maxDelay <= 0 {
+		r.maxDelay = DefaultMaxDelay
+	}
+	if r.retryable == nil {
+		r.retryable = IsRetryable
+	}
+	return r
+}
+
+// delay 返回第 attempt 次重试前的等待时间，在 0 到退避上限之间随机选取
+func (r *retryer) delay(attempt int) time.Duration {
+	backoff := r.maxDelay
+	if shift := attempt - 1; shift < 62 && r.baseDelay<<shift > 0 {
+		backoff = min(r.baseDelay<<shift, r.maxDelay)
+	}
+	return rand.N(backoff + 1)
+}
+
+// wait 等待重试，context 在等待结束前取消或超时返回 false
+func (r *retryer) wait(ctx context.Context, attempt int) bool {
+	d := r.delay(attempt)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// do 执行 fn，失败且可以重试时等待后重新执行，before 在每次重试前调用，返回错误时停止重试。
+// 重试因 context 结束而停止时返回最后一次的错误
+func do[T any](ctx context.Context, r *retryer, before func() error, fn func(attempt int) (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		value, err := fn(attempt)
+		if err == nil || attempt >= r.maxAttempts || !r.retryable(err) || ctx.Err() != nil {
+			return value, err
+		}
+		if !r.wait(ctx, attempt) {
+			return value, err
+		}
+		if before != nil {
+			if beforeErr := before(); beforeErr != nil {
+				return value, err
+			}
+		}
+	}
+}
+
+// do0 执行只返回错误的 fn
+func do0(ctx context.Context, r *retryer, before func() error, fn func(attempt int) error) error {
+	_, err := do(ctx, r, before, func(attempt int) (struct{}, error) {
+		return struct{}{}, fn(attempt)
+	})
+	return err
+}
+
+// rewind 返回将 reader 恢复到当前位置的函数，reader 无法 Seek 时返回 nil，此时不能重试
+func rewind(reader io.Reader) func() error {
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return nil
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	return func() error {
+		_, err := seeker.Seek(offset, io.SeekStart)
+		return err
+	}
+}
+
+// ignoreNotExist 重试的删除操作或完成分片上传返回不存在时，说明之前失败的请求实际已经成功
+func ignoreNotExist(attempt int, err error) error {
+	if attempt > 1 && errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,245 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/goairix/fs/fstest"
+	"github.com/goairix/fs/retry"
+)
+
+var fastRetry = retry.Options{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func newMemory(t *testing.T) fs.FileSystem {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// flakyFS 在 fails 减到 0 之前返回临时错误
+type flakyFS struct {
+	fs.FileSystem
+	fails atomic.Int64
+	calls atomic.Int64
+}
+
+func (f *flakyFS) failures(n int64) {
+	f.fails.Store(n)
+	f.calls.Store(0)
+}
+
+func (f *flakyFS) fail() error {
+	f.calls.Add(1)
+	if f.fails.Add(-1) >= 0 {
+		return fs.NewPathError("flaky", "op", "path", errors.New("503 Service Unavailable"), fs.ErrTemporary)
+	}
+	return nil
+}
+
+func (f *flakyFS) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return f.FileSystem.Stat(ctx, path, opts...)
+}
+
+// Remove 删除成功后返回错误，模拟响应丢失
+func (f *flakyFS) Remove(ctx context.Context, path string, opts ...fs.Option) error {
+	err := f.FileSystem.Remove(ctx, path, opts...)
+	if failErr := f.fail(); failErr != nil {
+		return failErr
+	}
+	return err
+}
+
+func (f *flakyFS) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	if err := f.fail(); err != nil {
+		return err
+	}
+	return f.FileSystem.Move(ctx, src, dst, opts...)
+}
+
+func (f *flakyFS) Uploader() fs.Uploader {
+	return &flakyUploader{Uploader: f.FileSystem.Uploader(), f: f}
+}
+
+type flakyUploader struct {
+	fs.Uploader
+	f *flakyFS
+}
+
+// Upload 失败前读取部分数据
+func (u *flakyUploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	if err := u.f.fail(); err != nil {
+		_, _ = io.CopyN(io.Discard, reader, 2)
+		return err
+	}
+	return u.Uploader.Upload(ctx, path, reader, opts...)
+}
+
+func TestRetryFileSystem(t *testing.T) {
+	fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+		return retry.New(newMemory(t), retry.Options{})
+	})
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	m := newMemory(t)
+	flaky := &flakyFS{FileSystem: m}
+	r := retry.New(flaky, fastRetry)
+	if err := m.Uploader().Upload(ctx, "a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	flaky.failures(3)
+	if _, err := r.Stat(ctx, "a.txt"); err != nil || flaky.calls.Load() != 4 {
+		t.Fatalf("Stat after 3 failures: %v, %d calls", err, flaky.calls.Load())
+	}
+	flaky.failures(10)
+	if _, err := r.Stat(ctx, "a.txt"); !errors.Is(err, fs.ErrTemporary) || flaky.calls.Load() != 4 {
+		t.Fatalf("Stat after MaxAttempts: %v, %d calls", err, flaky.calls.Load())
+	}
+	flaky.failures(0)
+	if _, err := r.Stat(ctx, "missing.txt"); !errors.Is(err, fs.ErrNotExist) || flaky.calls.Load() != 1 {
+		t.Fatalf("Stat missing file: %v, %d calls", err, flaky.calls.Load())
+	}
+
+	// 重试的删除返回文件不存在时视为成功
+	flaky.failures(1)
+	if err := r.Remove(ctx, "a.txt"); err != nil {
+		t.Fatalf("Remove with a lost response: %v", err)
+	}
+
+	if err := m.Uploader().Upload(ctx, "b.txt", strings.NewReader("b")); err != nil {
+		t.Fatal(err)
+	}
+	flaky.failures(1)
+	if err := r.Move(ctx, "b.txt", "c.txt"); err == nil || flaky.calls.Load() != 1 {
+		t.Fatalf("Move was retried: %v, %d calls", err, flaky.calls.Load())
+	}
+}
+
+func TestRetryUpload(t *testing.T) {
+	ctx := context.Background()
+	m := newMemory(t)
+	flaky := &flakyFS{FileSystem: m}
+	r := retry.New(flaky, fastRetry)
+
+	// 实现 io.Seeker 的 reader 回到起始位置后重试
+	flaky.failures(2)
+	reader := strings.NewReader("xxpayload")
+	if _, err := reader.Seek(2, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Uploader().Upload(ctx, "a.txt", reader); err != nil {
+		t.Fatal(err)
+	}
+	body, err := m.Open(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	if err := iotest.TestReader(body, []byte("payload")); err != nil || flaky.calls.Load() != 3 {
+		t.Fatalf("uploaded content: %v, %d calls", err, flaky.calls.Load())
+	}
+
+	flaky.failures(1)
+	if err := r.Uploader().Upload(ctx, "b.txt", io.MultiReader(strings.NewReader("x"))); err == nil || flaky.calls.Load() != 1 {
+		t.Fatalf("non-seekable upload was retried: %v, %d calls", err, flaky.calls.Load())
+	}
+}
+
+func TestRetryStops(t *testing.T) {
+	ctx := context.Background()
+	flaky := &flakyFS{FileSystem: newMemory(t)}
+
+	// context 在等待期间结束时返回最后一次的错误
+	slow := retry.New(flaky, retry.Options{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: time.Second})
+	flaky.failures(100)
+	deadline, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := slow.Stat(deadline, "a.txt"); !errors.Is(err, fs.ErrTemporary) {
+		t.Fatalf("Stat with deadline: %v, want the last error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Fatalf("retry did not stop at the deadline, took %v", elapsed)
+	}
+
+	flaky.failures(5)
+	never := retry.New(flaky, retry.Options{Retryable: func(error) bool { return false }})
+	if _, err := never.Stat(ctx, "a.txt"); err == nil || flaky.calls.Load() != 1 {
+		t.Fatalf("custom Retryable was ignored: %v, %d calls", err, flaky.calls.Load())
+	}
+}
+
+// lostComplete 第一次完成分片上传后返回错误，模拟响应丢失
+type lostComplete struct {
+	fs.Uploader
+	calls int
+}
+
+func (u *lostComplete) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	u.calls++
+	err := u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...)
+	if u.calls == 1 && err == nil {
+		return fs.NewPathError("lost", "CompleteMultipartUpload", path, errors.New("timeout"), fs.ErrTemporary)
+	}
+	return err
+}
+
+func TestRetryCompleteLostResponse(t *testing.T) {
+	ctx := context.Background()
+	lost := &lostComplete{Uploader: newMemory(t).Uploader()}
+	u := retry.NewUploader(lost, fastRetry)
+	uploadID, err := u.InitMultipartUpload(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := u.UploadPart(ctx, "a.txt", uploadID, 1, strings.NewReader("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.CompleteMultipartUpload(ctx, "a.txt", uploadID, []fs.MultipartPart{{PartNumber: 1, ETag: etag}}); err != nil {
+		t.Fatal(err)
+	}
+	if lost.calls != 2 {
+		t.Fatalf("CompleteMultipartUpload called %d times, want 2", lost.calls)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{fs.NewPathError("s3", "Stat", "a", errors.New("503"), fs.ErrTemporary), true},
+		{fs.NewPathError("s3", "Stat", "a", errors.New("404"), fs.ErrNotExist), false},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{fmt.Errorf("write: %w", syscall.ECONNRESET), true},
+		{context.Canceled, false},
+		{fmt.Errorf("request: %w", context.DeadlineExceeded), false},
+		{errors.New("permission denied"), false},
+	} {
+		if got := retry.IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package retry
+
+import (
+	"context"
+	"io"
+
+	"github.com/goairix/fs"
+)
+
+// uploader 失败时重试的上传器
+type uploader struct {
+	fs.Uploader
+	r *retryer
+}
+
+// NewUploader 返回失败时自动重试的上传器，重试规则见 New
+func NewUploader(u fs.Uploader, opts Options) fs.Uploader {
+	return &uploader{Uploader: u, r: newRetryer(opts)}
+}
+
+func (u *uploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	before := rewind(reader)
+	if before == nil {
+		return u.Uploader.Upload(ctx, path, reader, opts...)
+	}
+	return do0(ctx, u.r, before, func(int) error {
+		return u.Uploader.Upload(ctx, path, reader, opts...)
+	})
+}
+
+func (u *uploader) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	return do(ctx, u.r, nil, func(int) (string, error) {
+		return u.Uploader.InitMultipartUpload(ctx, path, opts...)
+	})
+}
+
+func (u *uploader) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
+	before := rewind(data)
+	if before == nil {
+		return u.Uploader.UploadPart(ctx, path, uploadID, partNumber, data, opts...)
+	}
+	return do(ctx, u.r, before, func(int) (string, error) {
+		return u.Uploader.UploadPart(ctx, path, uploadID, partNumber, data, opts...)
+	})
+}
+
+func (u *uploader) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	// 完成请求成功但响应丢失时，重试会因分片上传已不存在而失败，此时文件已经写入
+	return do0(ctx, u.r, nil, func(attempt int) error {
+		return ignoreNotExist(attempt, u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...))
+	})
+}
+
+func (u *uploader) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
+	return do0(ctx, u.r, nil, func(attempt int) error {
+		return ignoreNotExist(attempt, u.Uploader.AbortMultipartUpload(ctx, path, uploadID, opts...))
+	})
+}
+
+func (u *uploader) ListMultipartUploads(ctx context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
+	return do(ctx, u.r, nil, func(int) ([]fs.MultipartUploadInfo, error) {
+		return u.Uploader.ListMultipartUploads(ctx, opts...)
+	})
+}
+
+func (u *uploader) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
+	return do(ctx, u.r, nil, func(int) ([]fs.MultipartPart, error) {
+		return u.Uploader.ListUploadedParts(ctx, path, uploadID, opts...)
+	})
+}
@@ -0,0 +1,199 @@
+package slogfs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/goairix/fs"
+)
+
+// fileSystem 记录操作日志的文件系统
+type fileSystem struct {
+	fs.Wrapper
+	l *logger
+}
+
+// New 返回记录操作日志的文件系统，每次调用记录一条日志，包括路径、耗时、大小与错误。
+// Open、OpenReader、Create 与 OpenFile 在返回的读写流关闭时记录，包括数据传输的时间与字节数
+func New(fsys fs.FileSystem, conf Config) fs.FileSystem {
+	return &fileSystem{Wrapper: fs.Wrapper{FileSystem: fsys}, l: newLogger(conf)}
+}
+
+func (f *fileSystem) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
+	s := f.l.start(ctx, "List", slog.String("path", path))
+	infos, err := f.FileSystem.List(ctx, path, opts...)
+	s.end(err)
+	return infos, err
+}
+
+func (f *fileSystem) MakeDir(ctx context.Context, path string, perm os.FileMode, opts ...fs.Option) error {
+	s := f.l.start(ctx, "MakeDir", slog.String("path", path))
+	err := f.FileSystem.MakeDir(ctx, path, perm, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
+	s := f.l.start(ctx, "RemoveDir", slog.String("path", path))
+	err := f.FileSystem.RemoveDir(ctx, path, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	s := f.l.start(ctx, "Create", slog.String("path", path))
+	writer, err := f.FileSystem.Create(ctx, path, opts...)
+	if err != nil {
+		s.end(err)
+		return nil, err
+	}
+	return &writeCloser{WriteCloser: writer, stream: newStream(s)}, nil
+}
+
+func (f *fileSystem) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	s := f.l.start(ctx, "Open", slog.String("path", path))
+	reader, err := f.FileSystem.Open(ctx, path, opts...)
+	if err != nil {
+		s.end(err)
+		return nil, err
+	}
+	return &readCloser{ReadCloser: reader, stream: newStream(s)}, nil
+}
+
+func (f *fileSystem) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	s := f.l.start(ctx, "OpenFile", slog.String("path", path))
+	file, err := f.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+	if err != nil {
+		s.end(err)
+		return nil, err
+	}
+	return &readWriteCloser{ReadWriteCloser: file, stream: newStream(s)}, nil
+}
+
+func (f *fileSystem) Remove(ctx context.Context, path string, opts ...fs.Option) error {
+	s := f.l.start(ctx, "Remove", slog.String("path", path))
+	err := f.FileSystem.Remove(ctx, path, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	s := f.l.start(ctx, "Copy", slog.String("path", src), slog.String("dst", dst))
+	err := f.FileSystem.Copy(ctx, src, dst, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	s := f.l.start(ctx, "Move", slog.String("path", src), slog.String("dst", dst))
+	err := f.FileSystem.Move(ctx, src, dst, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) Rename(ctx context.Context, oldPath, newPath string, opts ...fs.Option) error {
+	s := f.l.start(ctx, "Rename", slog.String("path", oldPath), slog.String("dst", newPath))
+	err := f.FileSystem.Rename(ctx, oldPath, newPath, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	s := f.l.start(ctx, "Stat", slog.String("path", path))
+	info, err := f.FileSystem.Stat(ctx, path, opts...)
+	s.end(err)
+	return info, err
+}
+
+func (f *fileSystem) GetMimeType(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	s := f.l.start(ctx, "GetMimeType", slog.String("path", path))
+	mimeType, err := f.FileSystem.GetMimeType(ctx, path, opts...)
+	s.end(err)
+	return mimeType, err
+}
+
+func (f *fileSystem) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	s := f.l.start(ctx, "SetMetadata", slog.String("path", path))
+	err := f.FileSystem.SetMetadata(ctx, path, metadata, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]interface{}, error) {
+	s := f.l.start(ctx, "GetMetadata", slog.String("path", path))
+	metadata, err := f.FileSystem.GetMetadata(ctx, path, opts...)
+	s.end(err)
+	return metadata, err
+}
+
+func (f *fileSystem) Exists(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	s := f.l.start(ctx, "Exists", slog.String("path", path))
+	exists, err := f.FileSystem.Exists(ctx, path, opts...)
+	s.end(err)
+	return exists, err
+}
+
+func (f *fileSystem) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	s := f.l.start(ctx, "IsDir", slog.String("path", path))
+	isDir, err := f.FileSystem.IsDir(ctx, path, opts...)
+	s.end(err)
+	return isDir, err
+}
+
+func (f *fileSystem) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	s := f.l.start(ctx, "IsFile", slog.String("path", path))
+	isFile, err := f.FileSystem.IsFile(ctx, path, opts...)
+	s.end(err)
+	return isFile, err
+}
+
+func (f *fileSystem) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	s := f.l.start(ctx, "SignFullUrl", slog.String("path", path))
+	fullUrl, err := f.FileSystem.SignFullUrl(ctx, path, opts...)
+	s.end(err, slog.String("url", RedactURL(fullUrl)))
+	return fullUrl, err
+}
+
+func (f *fileSystem) FullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	s := f.l.start(ctx, "FullUrl", slog.String("path", path))
+	fullUrl, err := f.FileSystem.FullUrl(ctx, path, opts...)
+	s.end(err, slog.String("url", RedactURL(fullUrl)))
+	return fullUrl, err
+}
+
+func (f *fileSystem) RelativePath(ctx context.Context, fullUrl string, opts ...fs.Option) (string, error) {
+	s := f.l.start(ctx, "RelativePath", slog.String("url", RedactURL(fullUrl)))
+	path, err := f.FileSystem.RelativePath(ctx, fullUrl, opts...)
+	s.end(err, slog.String("path", path))
+	return path, err
+}
+
+func (f *fileSystem) Uploader() fs.Uploader {
+	return &uploader{Uploader: f.FileSystem.Uploader(), l: f.l}
+}
+
+func (f *fileSystem) OpenReader(ctx context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	s := f.l.start(ctx, "OpenReader", slog.String("path", path))
+	reader, err := fs.OpenReader(ctx, f.FileSystem, path, opts...)
+	if err != nil {
+		s.end(err)
+		return nil, err
+	}
+	return &rangeReader{RangeReader: reader, stream: newStream(s)}, nil
+}
+
+func (f *fileSystem) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	s := f.l.start(ctx, "Walk", slog.String("path", root))
+	err := fs.Walk(ctx, f.FileSystem, root, fn, opts...)
+	s.end(err)
+	return err
+}
+
+func (f *fileSystem) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	s := f.l.start(ctx, "ListPage", slog.String("path", path))
+	result, err := fs.ListPage(ctx, f.FileSystem, path, opts...)
+	s.end(err)
+	return result, err
+}
@@ -0,0 +1,127 @@
+package slogfs
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/goairix/fs"
+)
+
+// stream 记录读写流传输的字节数，关闭时记录日志
+type stream struct {
+	call *call
+	n    atomic.Int64
+	once sync.Once
+
+	mu  sync.Mutex
+	err error // 读写过程中的第一个错误
+}
+
+func newStream(c *call) *stream {
+	return &stream{call: c}
+}
+
+func (s *stream) add(n int, err error) {
+	s.n.Add(int64(n))
+	if err == nil || errors.Is(err, io.EOF) {
+		return
+	}
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+}
+
+// close 记录日志，读写过程中出错时记录第一个错误
+func (s *stream) close(err error) error {
+	s.once.Do(func() {
+		s.mu.Lock()
+		logErr := s.err
+		s.mu.Unlock()
+		if logErr == nil {
+			logErr = err
+		}
+		s.call.end(logErr, slog.Int64("size", s.n.Load()))
+	})
+	return err
+}
+
+// readCloser 记录读取字节数的 io.ReadCloser
+type readCloser struct {
+	io.ReadCloser
+	stream *stream
+}
+
+func (r *readCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.stream.add(n, err)
+	return n, err
+}
+
+func (r *readCloser) Close() error {
+	return r.stream.close(r.ReadCloser.Close())
+}
+
+// rangeReader 记录读取字节数的 fs.RangeReader
+type rangeReader struct {
+	fs.RangeReader
+	stream *stream
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	n, err := r.RangeReader.Read(p)
+	r.stream.add(n, err)
+	return n, err
+}
+
+func (r *rangeReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.RangeReader.ReadAt(p, off)
+	r.stream.add(n, err)
+	return n, err
+}
+
+func (r *rangeReader) Close() error {
+	return r.stream.close(r.RangeReader.Close())
+}
+
+// writeCloser 记录写入字节数的 io.WriteCloser
+type writeCloser struct {
+	io.WriteCloser
+	stream *stream
+}
+
+func (w *writeCloser) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.stream.add(n, err)
+	return n, err
+}
+
+func (w *writeCloser) Close() error {
+	return w.stream.close(w.WriteCloser.Close())
+}
+
+// readWriteCloser 记录读写字节数的 io.ReadWriteCloser
+type readWriteCloser struct {
+	io.ReadWriteCloser
+	stream *stream
+}
+
+func (f *readWriteCloser) Read(p []byte) (int, error) {
+	n, err := f.ReadWriteCloser.Read(p)
+	f.stream.add(n, err)
+	return n, err
+}
+
+func (f *readWriteCloser) Write(p []byte) (int, error) {
+	n, err := f.ReadWriteCloser.Write(p)
+	f.stream.add(n, err)
+	return n, err
+}
+
+func (f *readWriteCloser) Close() error {
+	return f.stream.close(f.ReadWriteCloser.Close())
+}
@@ -0,0 +1,134 @@
+// Package slogfs 通过 log/slog 记录文件系统操作日志
+package slogfs
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+// Config 日志配置
+type Config struct {
+	Logger     *slog.Logger            // 为 nil 时使用 slog.Default()
+	Driver     string                  // 驱动名称，记录为 driver 属性
+	Level      slog.Leveler            // 操作成功时的日志级别，为 nil 时使用 slog.LevelInfo
+	ErrorLevel slog.Leveler            // 操作失败时的日志级别，为 nil 时使用 slog.LevelError
+	Levels     map[string]slog.Leveler // 按方法名设置操作成功时的日志级别，如 {"Stat": slog.LevelDebug}
+	// ReadSampleRate 读取类操作的采样率，为 N 时成功的读取类操作每 N 次记录一次，失败的操作总是记录。
+	// 读取类操作包括 Open、OpenReader、Stat、Exists、IsDir、IsFile、GetMimeType、GetMetadata、List 与 ListPage
+	ReadSampleRate int
+}
+
+// readOps 参与采样的读取类操作
+var readOps = map[string]bool{
+	"Open":        true,
+	"OpenReader":  true,
+	"Stat":        true,
+	"Exists":      true,
+	"IsDir":       true,
+	"IsFile":      true,
+	"GetMimeType": true,
+	"GetMetadata": true,
+	"List":        true,
+	"ListPage":    true,
+}
+
+// logger 按配置记录操作日志
+type logger struct {
+	log        *slog.Logger
+	driver     string
+	level      slog.Leveler
+	errorLevel slog.Leveler
+	levels     map[string]slog.Leveler
+	sampleRate uint64
+	reads      atomic.Uint64
+}
+
+func newLogger(conf Config) *logger {
+	l := &logger{
+		log:        conf.Logger,
+		driver:     conf.Driver,
+		level:      conf.Level,
+		errorLevel: conf.ErrorLevel,
+		levels:     conf.Levels,
+	}
+	if l.log == nil {
+		l.log = slog.Default()
+	}
+	if l.level == nil {
+		l.level = slog.LevelInfo
+	}
+	if l.errorLevel == nil {
+		l.errorLevel = slog.LevelError
+	}
+	if conf.ReadSampleRate > 1 {
+		l.sampleRate = uint64(conf.ReadSampleRate)
+	}
+	return l
+}
+
+// call 一次操作的开始时间与属性
+type call struct {
+	l     *logger
+	ctx   context.Context
+	op    string
+	start time.Time
+	attrs []slog.Attr
+}
+
+// start 开始记录操作
+func (l *logger) start(ctx context.Context, op string, attrs ...slog.Attr) *call {
+	return &call{l: l, ctx: ctx, op: op, start: time.Now(), attrs: attrs}
+}
+
+// end 记录操作日志，成功的读取类操作按采样率记录
+func (c *call) end(err error, attrs ...slog.Attr) {
+	l := c.l
+	level := l.level.Level()
+	if err != nil {
+		level = l.errorLevel.Level()
+	} else if leveler, ok := l.levels[c.op]; ok {
+		level = leveler.Level()
+	}
+	if !l.log.Enabled(c.ctx, level) {
+		return
+	}
+	if err == nil && l.sampleRate > 0 && readOps[c.op] && l.reads.Add(1)%l.sampleRate != 1 {
+		return
+	}
+
+	all := make([]slog.Attr, 0, len(c.attrs)+len(attrs)+4)
+	all = append(all, slog.String("op", c.op))
+	if l.driver != "" {
+		all = append(all, slog.String("driver", l.driver))
+	}
+	all = append(all, c.attrs...)
+	all = append(all, attrs...)
+	all = append(all, slog.Duration("duration", time.Since(c.start)))
+	if err != nil {
+		all = append(all, slog.String("error", redactText(err.Error())))
+	}
+	l.log.LogAttrs(context.WithoutCancel(c.ctx), level, "fs."+c.op, all...)
+}
+
+// RedactURL 隐藏 URL 中的密码与查询参数的值，签名 URL 的签名与凭证都在查询参数中，
+// 无法解析的 URL 原样返回
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		return rawURL
+	}
+	return fs.RedactURL(u)
+}
+
+var urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+
+// redactText 隐藏文本中出现的 URL 的敏感内容，驱动的错误信息中可能包含请求的 URL
+func redactText(s string) string {
+	return urlPattern.ReplaceAllStringFunc(s, RedactURL)
+}
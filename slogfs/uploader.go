@@ -0,0 +1,76 @@
+package slogfs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/internal/ioutil"
+)
+
+// uploader 记录操作日志的上传器
+type uploader struct {
+	fs.Uploader
+	l *logger
+}
+
+// NewUploader 返回记录操作日志的上传器
+func NewUploader(u fs.Uploader, conf Config) fs.Uploader {
+	return &uploader{Uploader: u, l: newLogger(conf)}
+}
+
+func (u *uploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	s := u.l.start(ctx, "Upload", slog.String("path", path))
+	reader, counter := ioutil.CountReader(reader)
+	err := u.Uploader.Upload(ctx, path, reader, opts...)
+	s.end(err, slog.Int64("size", counter.N))
+	return err
+}
+
+func (u *uploader) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	s := u.l.start(ctx, "InitMultipartUpload", slog.String("path", path))
+	uploadID, err := u.Uploader.InitMultipartUpload(ctx, path, opts...)
+	if err != nil {
+		s.end(err)
+		return "", err
+	}
+	s.end(nil, slog.String("upload_id", uploadID))
+	return uploadID, nil
+}
+
+func (u *uploader) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
+	s := u.l.start(ctx, "UploadPart", slog.String("path", path), slog.String("upload_id", uploadID), slog.Int("part_number", partNumber))
+	data, counter := ioutil.CountReader(data)
+	etag, err := u.Uploader.UploadPart(ctx, path, uploadID, partNumber, data, opts...)
+	s.end(err, slog.Int64("size", counter.N))
+	return etag, err
+}
+
+func (u *uploader) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	s := u.l.start(ctx, "CompleteMultipartUpload", slog.String("path", path), slog.String("upload_id", uploadID))
+	err := u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...)
+	s.end(err)
+	return err
+}
+
+func (u *uploader) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
+	s := u.l.start(ctx, "AbortMultipartUpload", slog.String("path", path), slog.String("upload_id", uploadID))
+	err := u.Uploader.AbortMultipartUpload(ctx, path, uploadID, opts...)
+	s.end(err)
+	return err
+}
+
+func (u *uploader) ListMultipartUploads(ctx context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
+	s := u.l.start(ctx, "ListMultipartUploads")
+	uploads, err := u.Uploader.ListMultipartUploads(ctx, opts...)
+	s.end(err)
+	return uploads, err
+}
+
+func (u *uploader) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
+	s := u.l.start(ctx, "ListUploadedParts", slog.String("path", path), slog.String("upload_id", uploadID))
+	parts, err := u.Uploader.ListUploadedParts(ctx, path, uploadID, opts...)
+	s.end(err)
+	return parts, err
+}
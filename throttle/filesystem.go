@@ -0,0 +1,190 @@
+package throttle
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/goairix/fs"
+)
+
+// fileSystem 限制请求速率与传输带宽的文件系统
+type fileSystem struct {
+	fs.Wrapper
+	l *Limiter
+}
+
+// New 返回按 l 限速的文件系统，每次调用前等待对应类别的请求令牌，
+// 读写流与上传按字节速率限制。多个文件系统共用同一个 Limiter 时共享限额。
+// FullUrl、SignFullUrl 与 RelativePath 不发起请求，不限速
+func New(fsys fs.FileSystem, l *Limiter) fs.FileSystem {
+	return &fileSystem{Wrapper: fs.Wrapper{FileSystem: fsys}, l: l}
+}
+
+func (f *fileSystem) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
+	if err := f.l.wait(ctx, ClassList); err != nil {
+		return nil, err
+	}
+	return f.FileSystem.List(ctx, path, opts...)
+}
+
+func (f *fileSystem) MakeDir(ctx context.Context, path string, perm os.FileMode, opts ...fs.Option) error {
+	if err := f.l.wait(ctx, ClassWrite); err != nil {
+		return err
+	}
+	return f.FileSystem.MakeDir(ctx, path, perm, opts...)
+}
+
+func (f *fileSystem) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
+	if err := f.l.wait(ctx, ClassDelete); err != nil {
+		return err
+	}
+	return f.FileSystem.RemoveDir(ctx, path, opts...)
+}
+
+func (f *fileSystem) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	if err := f.l.wait(ctx, ClassWrite); err != nil {
+		return nil, err
+	}
+	writer, err := f.FileSystem.Create(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &writeCloser{WriteCloser: writer, ctx: ctx, lim: f.l.writeBytes}, nil
+}
+
+func (f *fileSystem) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	if err := f.l.wait(ctx, ClassRead); err != nil {
+		return nil, err
+	}
+	reader, err := f.FileSystem.Open(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &readCloser{ReadCloser: reader, ctx: ctx, lim: f.l.readBytes}, nil
+}
+
+func (f *fileSystem) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	class := ClassRead
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		class = ClassWrite
+	}
+	if err := f.l.wait(ctx, class); err != nil {
+		return nil, err
+	}
+	file, err := f.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &readWriteCloser{ReadWriteCloser: file, ctx: ctx, readLim: f.l.readBytes, writeLim: f.l.writeBytes}, nil
+}
+
+func (f *fileSystem) Remove(ctx context.Context, path string, opts ...fs.Option) error {
+	if err := f.l.wait(ctx, ClassDelete); err != nil {
+		return err
+	}
+	return f.FileSystem.Remove(ctx, path, opts...)
+}
+
+func (f *fileSystem) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	if err := f.l.wait(ctx, ClassWrite); err != nil {
+		return err
+	}
+	return f.FileSystem.Copy(ctx, src, dst, opts...)
+}
+
+func (f *fileSystem) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	if err := f.l.wait(ctx, ClassWrite); err != nil {
+		return err
+	}
+	return f.FileSystem.Move(ctx, src, dst, opts...)
+}
+
+func (f *fileSystem) Rename(ctx context.Context, oldPath, newPath string, opts ...fs.Option) error {
+	if err := f.l.wait(ctx, ClassWrite); err != nil {
+		return err
+	}
+	return f.FileSystem.Rename(ctx, oldPath, newPath, opts...)
+}
+
+func (f *fileSystem) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	if err := f.l.wait(ctx, ClassRead); err != nil {
+		return nil, err
+	}
+	return f.FileSystem.Stat(ctx, path, opts...)
+}
+
+func (f *fileSystem) GetMimeType(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	if err := f.l.wait(ctx, ClassRead); err != nil {
+		return "", err
+	}
+	return f.FileSystem.GetMimeType(ctx, path, opts...)
+}
+
+func (f *fileSystem) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	if err := f.l.wait(ctx, ClassWrite); err != nil {
+		return err
+	}
+	return f.FileSystem.SetMetadata(ctx, path, metadata, opts...)
+}
+
+func (f *fileSystem) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]interface{}, error) {
+	if err := f.l.wait(ctx, ClassRead); err != nil {
+		return nil, err
+	}
+	return f.FileSystem.GetMetadata(ctx, path, opts...)
+}
+
+func (f *fileSystem) Exists(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	if err := f.l.wait(ctx, ClassRead); err != nil {
+		return false, err
+	}
+	return f.FileSystem.Exists(ctx, path, opts...)
+}
+
+func (f *fileSystem) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	if err := f.l.wait(ctx, ClassRead); err != nil {
+		return false, err
+	}
+	return f.FileSystem.IsDir(ctx, path, opts...)
+}
+
+func (f *fileSystem) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	if err := f.l.wait(ctx, ClassRead); err != nil {
+		return false, err
+	}
+	return f.FileSystem.IsFile(ctx, path, opts...)
+}
+
+func (f *fileSystem) Uploader() fs.Uploader {
+	return &uploader{Uploader: f.FileSystem.Uploader(), l: f.l}
+}
+
+func (f *fileSystem) OpenReader(ctx context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	if err := f.l.wait(ctx, ClassRead); err != nil {
+		return nil, err
+	}
+	reader, err := fs.OpenReader(ctx, f.FileSystem, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &rangeReader{RangeReader: reader, ctx: ctx, lim: f.l.readBytes}, nil
+}
+
+// Walk 驱动实现 fs.Walker 时整个遍历计为一次列出请求，否则逐级调用限速后的 Stat 与 List
+func (f *fileSystem) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	if _, ok := f.FileSystem.(fs.Walker); !ok {
+		return fs.Walk(ctx, struct{ fs.FileSystem }{f}, root, fn, opts...)
+	}
+	if err := f.l.wait(ctx, ClassList); err != nil {
+		return err
+	}
+	return fs.Walk(ctx, f.FileSystem, root, fn, opts...)
+}
+
+func (f *fileSystem) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	if err := f.l.wait(ctx, ClassList); err != nil {
+		return nil, err
+	}
+	return fs.ListPage(ctx, f.FileSystem, path, opts...)
+}
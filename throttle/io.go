@@ -0,0 +1,114 @@
+package throttle
+
+import (
+	"context"
+	"io"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/internal/ioutil"
+	"golang.org/x/time/rate"
+)
+
+// readLimit 按字节速率限制读取，读取后等待实际读取字节数的令牌
+func readLimit(ctx context.Context, lim *rate.Limiter, p []byte, read func([]byte) (int, error)) (int, error) {
+	n, err := read(p[:chunkSize(lim, len(p))])
+	if n > 0 {
+		if werr := waitBytes(ctx, lim, n); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
+
+// writeLimit 按字节速率限制写入，每次写入前等待该块字节数的令牌
+func writeLimit(ctx context.Context, lim *rate.Limiter, p []byte, write func([]byte) (int, error)) (int, error) {
+	written := 0
+	for written < len(p) {
+		chunk := p[written : written+chunkSize(lim, len(p)-written)]
+		if err := waitBytes(ctx, lim, len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// readCloser 限制读取速率的 io.ReadCloser
+type readCloser struct {
+	io.ReadCloser
+	ctx context.Context
+	lim *rate.Limiter
+}
+
+func (r *readCloser) Read(p []byte) (int, error) {
+	return readLimit(r.ctx, r.lim, p, r.ReadCloser.Read)
+}
+
+// rangeReader 限制读取速率的 fs.RangeReader
+type rangeReader struct {
+	fs.RangeReader
+	ctx context.Context
+	lim *rate.Limiter
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	return readLimit(r.ctx, r.lim, p, r.RangeReader.Read)
+}
+
+func (r *rangeReader) ReadAt(p []byte, off int64) (int, error) {
+	// ReadAt 需要读满 p，不能缩短读取的长度，读取后等待令牌
+	n, err := r.RangeReader.ReadAt(p, off)
+	if n > 0 {
+		if werr := waitBytes(r.ctx, r.lim, n); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
+
+// writeCloser 限制写入速率的 io.WriteCloser
+type writeCloser struct {
+	io.WriteCloser
+	ctx context.Context
+	lim *rate.Limiter
+}
+
+func (w *writeCloser) Write(p []byte) (int, error) {
+	return writeLimit(w.ctx, w.lim, p, w.WriteCloser.Write)
+}
+
+// readWriteCloser 限制读写速率的 io.ReadWriteCloser
+type readWriteCloser struct {
+	io.ReadWriteCloser
+	ctx      context.Context
+	readLim  *rate.Limiter
+	writeLim *rate.Limiter
+}
+
+func (f *readWriteCloser) Read(p []byte) (int, error) {
+	return readLimit(f.ctx, f.readLim, p, f.ReadWriteCloser.Read)
+}
+
+func (f *readWriteCloser) Write(p []byte) (int, error) {
+	return writeLimit(f.ctx, f.writeLim, p, f.ReadWriteCloser.Write)
+}
+
+// reader 限制上传时驱动读取速率的 io.Reader
+type reader struct {
+	io.Reader
+	ctx context.Context
+	lim *rate.Limiter
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	return readLimit(r.ctx, r.lim, p, r.Reader.Read)
+}
+
+// limitReader 包装上传的 io.Reader，按写入字节速率限制驱动读取
+func limitReader(ctx context.Context, lim *rate.Limiter, r io.Reader) io.Reader {
+	return ioutil.KeepSeeker(&reader{Reader: r, ctx: ctx, lim: lim}, r)
+}
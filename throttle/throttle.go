@@ -0,0 +1,115 @@
+// Package throttle 为文件系统操作提供请求速率与传输带宽限制，使用令牌桶算法
+package throttle
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Class 操作类别，每个类别使用独立的请求速率限制
+type Class int
+
+const (
+	ClassList   Class = iota // List、ListPage、Walk 与分片上传的列出操作
+	ClassRead                // Open、OpenReader 与查询操作
+	ClassWrite               // 创建、上传、复制、移动与修改元数据
+	ClassDelete              // Remove、RemoveDir 与 AbortMultipartUpload
+	numClasses
+)
+
+// Limits 速率限制，为 0 时不限制
+type Limits struct {
+	List   float64 // 每秒列出请求数
+	Read   float64 // 每秒读取与查询请求数
+	Write  float64 // 每秒写入请求数
+	Delete float64 // 每秒删除请求数
+
+	ReadBytes  int64 // Open、OpenReader 与 OpenFile 返回的读取流每秒读取的字节数
+	WriteBytes int64 // Create、OpenFile 返回的写入流与上传每秒写入的字节数
+}
+
+// Limiter 令牌桶限速器，可以被多个 goroutine 与多个文件系统共用，通过 SetLimits 在运行时调整
+type Limiter struct {
+	mu         sync.Mutex
+	limits     Limits
+	requests   [numClasses]*rate.Limiter
+	readBytes  *rate.Limiter
+	writeBytes *rate.Limiter
+}
+
+// NewLimiter 创建限速器
+func NewLimiter(limits Limits) *Limiter {
+	l := &Limiter{
+		readBytes:  rate.NewLimiter(rate.Inf, 0),
+		writeBytes: rate.NewLimiter(rate.Inf, 0),
+	}
+	for i := range l.requests {
+		l.requests[i] = rate.NewLimiter(rate.Inf, 0)
+	}
+	l.SetLimits(limits)
+	return l
+}
+
+// Limits 返回当前的速率限制
+func (l *Limiter) Limits() Limits {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limits
+}
+
+// SetLimits 调整速率限制，立即对正在进行的操作与读写流生效
+func (l *Limiter) SetLimits(limits Limits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+	setRate(l.requests[ClassList], limits.List)
+	setRate(l.requests[ClassRead], limits.Read)
+	setRate(l.requests[ClassWrite], limits.Write)
+	setRate(l.requests[ClassDelete], limits.Delete)
+	setRate(l.readBytes, float64(limits.ReadBytes))
+	setRate(l.writeBytes, float64(limits.WriteBytes))
+}
+
+// setRate 设置每秒速率，令牌桶容量为一秒的令牌数
+func setRate(lim *rate.Limiter, perSecond float64) {
+	if perSecond <= 0 {
+		lim.SetLimit(rate.Inf)
+		return
+	}
+	lim.SetBurst(max(int(math.Ceil(perSecond)), 1))
+	lim.SetLimit(rate.Limit(perSecond))
+}
+
+// wait 等待一个 class 类别的请求令牌
+func (l *Limiter) wait(ctx context.Context, class Class) error {
+	return l.requests[class].Wait(ctx)
+}
+
+// waitBytes 等待 n 个字节的令牌，超过令牌桶容量时分多次等待
+func waitBytes(ctx context.Context, lim *rate.Limiter, n int) error {
+	for n > 0 {
+		if lim.Limit() == rate.Inf {
+			return nil
+		}
+		chunk := min(n, max(lim.Burst(), 1))
+		if err := lim.WaitN(ctx, chunk); err != nil {
+			if chunk > lim.Burst() && ctx.Err() == nil {
+				continue // 令牌桶容量在等待前被调小，按新的容量重新等待
+			}
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// chunkSize 返回单次读写的最大字节数，限速时不超过令牌桶容量，使等待时间均匀分布
+func chunkSize(lim *rate.Limiter, n int) int {
+	if lim.Limit() == rate.Inf {
+		return n
+	}
+	return min(n, max(lim.Burst(), 1))
+}
@@ -0,0 +1,151 @@
+package throttle_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/goairix/fs/fstest"
+	"github.com/goairix/fs/throttle"
+)
+
+func newMemory(t *testing.T) fs.FileSystem {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// elapsed 返回 fn 的执行时间
+func elapsed(t *testing.T, fn func() error) time.Duration {
+	t.Helper()
+	start := time.Now()
+	if err := fn(); err != nil {
+		t.Fatal(err)
+	}
+	return time.Since(start)
+}
+
+func TestThrottleFileSystem(t *testing.T) {
+	fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+		return throttle.New(newMemory(t), throttle.NewLimiter(throttle.Limits{
+			List: 1e6, Read: 1e6, Write: 1e6, Delete: 1e6, ReadBytes: 1 << 30, WriteBytes: 1 << 30,
+		}))
+	})
+}
+
+func TestRequestRate(t *testing.T) {
+	ctx := context.Background()
+	l := throttle.NewLimiter(throttle.Limits{Read: 50})
+	// 共用 Limiter 的文件系统共享限额
+	a, b := throttle.New(newMemory(t), l), throttle.New(newMemory(t), l)
+
+	// 令牌桶容量为一秒的请求数，之后每秒 50 次
+	d := elapsed(t, func() error {
+		for i := range 75 {
+			fsys := a
+			if i%2 == 1 {
+				fsys = b
+			}
+			if _, err := fsys.Exists(ctx, "a.txt"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if d < 400*time.Millisecond || d > 2*time.Second {
+		t.Fatalf("75 reads at 50/s took %v, want about 500ms", d)
+	}
+
+	// 其他类别不受读取限速影响
+	if d := elapsed(t, func() error { _, err := a.List(ctx, ""); return err }); d > 100*time.Millisecond {
+		t.Fatalf("List waited %v for the read limit", d)
+	}
+
+	l.SetLimits(throttle.Limits{List: 0.1})
+	if got := l.Limits(); got.List != 0.1 || got.Read != 0 {
+		t.Fatalf("Limits() = %+v", got)
+	}
+	if _, err := a.List(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := a.List(timeout, ""); err == nil {
+		t.Fatal("List did not fail when the context expires before the next token")
+	}
+
+	// 取消限速后不再等待
+	l.SetLimits(throttle.Limits{})
+	if d := elapsed(t, func() error {
+		for range 1000 {
+			if _, err := a.List(ctx, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); d > time.Second {
+		t.Fatalf("unlimited List took %v", d)
+	}
+}
+
+func TestByteRate(t *testing.T) {
+	ctx := context.Background()
+	l := throttle.NewLimiter(throttle.Limits{ReadBytes: 1000, WriteBytes: 1000})
+	fsys := throttle.New(newMemory(t), l)
+	data := bytes.Repeat([]byte("x"), 1500)
+
+	if d := elapsed(t, func() error {
+		return fsys.Uploader().Upload(ctx, "a.txt", bytes.NewReader(data))
+	}); d < 400*time.Millisecond {
+		t.Fatalf("uploading 1500 bytes at 1000 B/s took %v", d)
+	}
+
+	if d := elapsed(t, func() error {
+		w, err := fsys.Create(ctx, "b.txt")
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		return w.Close()
+	}); d < 1400*time.Millisecond {
+		t.Fatalf("writing 1500 bytes after the upload took %v", d)
+	}
+
+	var got []byte
+	if d := elapsed(t, func() error {
+		r, err := fsys.Open(ctx, "a.txt")
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		got, err = io.ReadAll(r)
+		return err
+	}); d < 400*time.Millisecond {
+		t.Fatalf("reading 1500 bytes at 1000 B/s took %v", d)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("read %d bytes, want %d", len(got), len(data))
+	}
+
+	// 读取流使用打开时的 context
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	r, err := fsys.Open(timeout, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := io.ReadAll(r); err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("read with an expired context: %v", err)
+	}
+}
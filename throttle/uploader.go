@@ -0,0 +1,68 @@
+package throttle
+
+import (
+	"context"
+	"io"
+
+	"github.com/goairix/fs"
+)
+
+// uploader 限制请求速率与上传带宽的上传器
+type uploader struct {
+	fs.Uploader
+	l *Limiter
+}
+
+// NewUploader 返回按 l 限速的上传器
+func NewUploader(u fs.Uploader, l *Limiter) fs.Uploader {
+	return &uploader{Uploader: u, l: l}
+}
+
+func (u *uploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	if err := u.l.wait(ctx, ClassWrite); err != nil {
+		return err
+	}
+	return u.Uploader.Upload(ctx, path, limitReader(ctx, u.l.writeBytes, reader), opts...)
+}
+
+func (u *uploader) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	if err := u.l.wait(ctx, ClassWrite); err != nil {
+		return "", err
+	}
+	return u.Uploader.InitMultipartUpload(ctx, path, opts...)
+}
+
+func (u *uploader) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
+	if err := u.l.wait(ctx, ClassWrite); err != nil {
+		return "", err
+	}
+	return u.Uploader.UploadPart(ctx, path, uploadID, partNumber, limitReader(ctx, u.l.writeBytes, data), opts...)
+}
+
+func (u *uploader) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	if err := u.l.wait(ctx, ClassWrite); err != nil {
+		return err
+	}
+	return u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...)
+}
+
+func (u *uploader) AbortMultipartUpload(ctx context.Context, path string, uploadID string, opts ...fs.Option) error {
+	if err := u.l.wait(ctx, ClassDelete); err != nil {
+		return err
+	}
+	return u.Uploader.AbortMultipartUpload(ctx, path, uploadID, opts...)
+}
+
+func (u *uploader) ListMultipartUploads(ctx context.Context, opts ...fs.Option) ([]fs.MultipartUploadInfo, error) {
+	if err := u.l.wait(ctx, ClassList); err != nil {
+		return nil, err
+	}
+	return u.Uploader.ListMultipartUploads(ctx, opts...)
+}
+
+func (u *uploader) ListUploadedParts(ctx context.Context, path string, uploadID string, opts ...fs.Option) ([]fs.MultipartPart, error) {
+	if err := u.l.wait(ctx, ClassList); err != nil {
+		return nil, err
+	}
+	return u.Uploader.ListUploadedParts(ctx, path, uploadID, opts...)
+}
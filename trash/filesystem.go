@@ -0,0 +1,275 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	pathpkg "path"
+	"strings"
+
+	"github.com/goairix/fs"
+)
+
+// Remove 将文件移动到回收站
+func (t *Trash) Remove(ctx context.Context, path string, opts ...fs.Option) error {
+	path = fs.CleanPath(path)
+	if t.hidden(path) {
+		return fs.NewPathError("trash", "Remove", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	return t.FileSystem.Move(ctx, path, pathpkg.Join(t.dir, t.version(), path), opts...)
+}
+
+// RemoveDir 将目录下的全部文件以同一版本移动到回收站，再删除目录。删除根目录时保留回收站
+func (t *Trash) RemoveDir(ctx context.Context, path string, opts ...fs.Option) error {
+	path = fs.CleanPath(path)
+	if t.hidden(path) {
+		return fs.NewPathError("trash", "RemoveDir", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	files, err := t.files(ctx, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	version := t.version()
+	for _, file := range files {
+		if err = t.FileSystem.Move(ctx, file, pathpkg.Join(t.dir, version, file), opts...); err != nil {
+			return err
+		}
+	}
+	return t.removeDirs(ctx, path, opts...)
+}
+
+// removeDirs 删除目录 path，path 包含回收站时只删除回收站以外的目录
+func (t *Trash) removeDirs(ctx context.Context, path string, opts ...fs.Option) error {
+	if path != "" && !strings.HasPrefix(t.dir, path+"/") {
+		return t.FileSystem.RemoveDir(ctx, path, opts...)
+	}
+	infos, err := t.FileSystem.List(ctx, path, opts...)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		name := pathpkg.Join(path, info.Name())
+		if !info.IsDir() || name == t.dir {
+			continue
+		}
+		if err = t.removeDirs(ctx, name, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// files 返回 path 下的全部文件，path 为文件时返回其本身。path 不在回收站中时跳过回收站
+func (t *Trash) files(ctx context.Context, path string) ([]string, error) {
+	skip := !t.hidden(path)
+	var files []string
+	err := fs.Walk(ctx, t.FileSystem, path, func(name string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name = fs.CleanPath(name)
+		if skip && t.hidden(name) {
+			if info.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			files = append(files, name)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func (t *Trash) List(ctx context.Context, path string, opts ...fs.Option) ([]fs.FileInfo, error) {
+	path = fs.CleanPath(path)
+	if t.hidden(path) {
+		return nil, fs.NewPathError("trash", "List", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	infos, err := t.FileSystem.List(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return t.filter(path, infos), nil
+}
+
+func (t *Trash) ListPage(ctx context.Context, path string, opts ...fs.Option) (*fs.ListResult, error) {
+	path = fs.CleanPath(path)
+	if t.hidden(path) {
+		return nil, fs.NewPathError("trash", "ListPage", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	result, err := fs.ListPage(ctx, t.FileSystem, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	result.Files = t.filter(path, result.Files)
+	return result, nil
+}
+
+// filter 去掉目录 dir 的列出结果中的回收站
+func (t *Trash) filter(dir string, infos []fs.FileInfo) []fs.FileInfo {
+	filtered := infos[:0]
+	for _, info := range infos {
+		if !t.hidden(pathpkg.Join(dir, info.Name())) {
+			filtered = append(filtered, info)
+		}
+	}
+	return filtered
+}
+
+// Walk 跳过回收站
+func (t *Trash) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	if t.hidden(fs.CleanPath(root)) {
+		return fn(root, nil, fs.NewPathError("trash", "Walk", root, fs.ErrNotExist, fs.ErrNotExist))
+	}
+	return fs.Walk(ctx, t.FileSystem, root, func(name string, info fs.FileInfo, err error) error {
+		if t.hidden(fs.CleanPath(name)) {
+			if info != nil && info.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		return fn(name, info, err)
+	}, opts...)
+}
+
+func (t *Trash) Stat(ctx context.Context, path string, opts ...fs.Option) (fs.FileInfo, error) {
+	if t.hidden(fs.CleanPath(path)) {
+		return nil, fs.NewPathError("trash", "Stat", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	return t.FileSystem.Stat(ctx, path, opts...)
+}
+
+func (t *Trash) Exists(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	if t.hidden(fs.CleanPath(path)) {
+		return false, nil
+	}
+	return t.FileSystem.Exists(ctx, path, opts...)
+}
+
+func (t *Trash) IsDir(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	if t.hidden(fs.CleanPath(path)) {
+		return false, nil
+	}
+	return t.FileSystem.IsDir(ctx, path, opts...)
+}
+
+func (t *Trash) IsFile(ctx context.Context, path string, opts ...fs.Option) (bool, error) {
+	if t.hidden(fs.CleanPath(path)) {
+		return false, nil
+	}
+	return t.FileSystem.IsFile(ctx, path, opts...)
+}
+
+func (t *Trash) Open(ctx context.Context, path string, opts ...fs.Option) (io.ReadCloser, error) {
+	if t.hidden(fs.CleanPath(path)) {
+		return nil, fs.NewPathError("trash", "Open", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	return t.FileSystem.Open(ctx, path, opts...)
+}
+
+func (t *Trash) GetMimeType(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	if t.hidden(fs.CleanPath(path)) {
+		return "", fs.NewPathError("trash", "GetMimeType", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	return t.FileSystem.GetMimeType(ctx, path, opts...)
+}
+
+func (t *Trash) GetMetadata(ctx context.Context, path string, opts ...fs.Option) (map[string]interface{}, error) {
+	if t.hidden(fs.CleanPath(path)) {
+		return nil, fs.NewPathError("trash", "GetMetadata", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	return t.FileSystem.GetMetadata(ctx, path, opts...)
+}
+
+func (t *Trash) SignFullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	if t.hidden(fs.CleanPath(path)) {
+		return "", fs.NewPathError("trash", "SignFullUrl", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	return t.FileSystem.SignFullUrl(ctx, path, opts...)
+}
+
+func (t *Trash) FullUrl(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	if t.hidden(fs.CleanPath(path)) {
+		return "", fs.NewPathError("trash", "FullUrl", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	return t.FileSystem.FullUrl(ctx, path, opts...)
+}
+
+// 回收站只能通过 Remove、RemoveDir、Restore 与 Purge 修改，写入回收站中的路径返回 fs.ErrPermission
+
+func (t *Trash) Create(ctx context.Context, path string, opts ...fs.Option) (io.WriteCloser, error) {
+	if err := t.writable("Create", path); err != nil {
+		return nil, err
+	}
+	return t.FileSystem.Create(ctx, path, opts...)
+}
+
+func (t *Trash) OpenFile(ctx context.Context, path string, flag int, perm os.FileMode, opts ...fs.Option) (io.ReadWriteCloser, error) {
+	if err := t.writable("OpenFile", path); err != nil {
+		return nil, err
+	}
+	return t.FileSystem.OpenFile(ctx, path, flag, perm, opts...)
+}
+
+func (t *Trash) MakeDir(ctx context.Context, path string, perm os.FileMode, opts ...fs.Option) error {
+	if err := t.writable("MakeDir", path); err != nil {
+		return err
+	}
+	return t.FileSystem.MakeDir(ctx, path, perm, opts...)
+}
+
+func (t *Trash) SetMetadata(ctx context.Context, path string, metadata map[string]interface{}, opts ...fs.Option) error {
+	if err := t.writable("SetMetadata", path); err != nil {
+		return err
+	}
+	return t.FileSystem.SetMetadata(ctx, path, metadata, opts...)
+}
+
+func (t *Trash) Copy(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	if err := t.writable("Copy", src, dst); err != nil {
+		return err
+	}
+	return t.FileSystem.Copy(ctx, src, dst, opts...)
+}
+
+func (t *Trash) Move(ctx context.Context, src, dst string, opts ...fs.Option) error {
+	if err := t.writable("Move", src, dst); err != nil {
+		return err
+	}
+	return t.FileSystem.Move(ctx, src, dst, opts...)
+}
+
+func (t *Trash) Rename(ctx context.Context, oldPath, newPath string, opts ...fs.Option) error {
+	if err := t.writable("Rename", oldPath, newPath); err != nil {
+		return err
+	}
+	return t.FileSystem.Rename(ctx, oldPath, newPath, opts...)
+}
+
+func (t *Trash) Uploader() fs.Uploader {
+	return &uploader{Uploader: t.FileSystem.Uploader(), t: t}
+}
+
+// writable 路径在回收站中时返回 fs.ErrPermission
+func (t *Trash) writable(op string, paths ...string) error {
+	for _, path := range paths {
+		if t.hidden(fs.CleanPath(path)) {
+			return fs.NewPathError("trash", op, path, errReserved, fs.ErrPermission)
+		}
+	}
+	return nil
+}
+
+func (t *Trash) OpenReader(ctx context.Context, path string, opts ...fs.Option) (fs.RangeReader, error) {
+	if t.hidden(fs.CleanPath(path)) {
+		return nil, fs.NewPathError("trash", "OpenReader", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	return fs.OpenReader(ctx, t.FileSystem, path, opts...)
+}
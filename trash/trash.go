@@ -0,0 +1,260 @@
+// Package trash 为文件系统提供回收站，删除的文件移动到隐藏的回收站目录，可以恢复
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	pathpkg "path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goairix/fs"
+)
+
+// DefaultDir 默认的回收站目录
+const DefaultDir = ".trash"
+
+// ErrInvalidVersion Restore 的版本不是 ListTrash 返回的格式
+var ErrInvalidVersion = errors.New("invalid trash version")
+
+// errReserved 写入回收站中的路径时返回的错误
+var errReserved = errors.New("path is inside the trash")
+
+// versionLayout 版本的格式，即删除时间，按字符串排序与按时间排序一致
+const versionLayout = "20060102T150405.000000000Z"
+
+// Config 回收站配置
+type Config struct {
+	Dir string // 回收站目录，为空时使用 DefaultDir
+}
+
+// Trash 带回收站的文件系统。
+// Remove 与 RemoveDir 将文件移动到 <Dir>/<版本>/<原路径>，版本为删除时间，一次 RemoveDir 删除的文件属于同一版本。
+// 移动通过驱动的 Move 完成，对象存储的服务端复制会保留元数据与 ContentType。
+// 读取回收站中的路径时返回 fs.ErrNotExist，列出与遍历时跳过回收站；写入、复制、移动到回收站或从回收站移出时返回 fs.ErrPermission
+type Trash struct {
+	fs.Wrapper
+	dir string
+
+	mu   sync.Mutex
+	last time.Time // 上一个版本的时间，保证版本递增
+}
+
+// New 返回带回收站的文件系统
+func New(fsys fs.FileSystem, conf Config) *Trash {
+	dir := fs.CleanPath(conf.Dir)
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return &Trash{Wrapper: fs.Wrapper{FileSystem: fsys}, dir: dir}
+}
+
+// Entry 回收站中的文件
+type Entry struct {
+	Path      string    // 删除前的路径
+	Version   string    // 版本，恢复时使用
+	DeletedAt time.Time // 删除时间
+	Size      int64     // 文件大小
+}
+
+// ListTrash 返回回收站中的全部文件，按删除时间从新到旧排列，同一版本按路径排列
+func (t *Trash) ListTrash(ctx context.Context) ([]Entry, error) {
+	var entries []Entry
+	err := fs.Walk(ctx, t.FileSystem, t.dir, func(name string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		version, path, ok := t.split(name)
+		if !ok {
+			return nil
+		}
+		deletedAt, err := time.Parse(versionLayout, version)
+		if err != nil {
+			return nil
+		}
+		entries = append(entries, Entry{Path: path, Version: version, DeletedAt: deletedAt, Size: info.Size()})
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Version != entries[j].Version {
+			return entries[i].Version > entries[j].Version
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, nil
+}
+
+// Restore 将回收站中 version 版本的 path 恢复到原路径，path 可以是通过 RemoveDir 删除的目录，
+// version 为空时恢复最新的版本。原路径已存在时恢复为 "name (1).ext"、"name (2).ext" 等不存在的路径，
+// 返回恢复后的路径
+func (t *Trash) Restore(ctx context.Context, path, version string) (string, error) {
+	path = fs.CleanPath(path)
+	if path == "" || t.hidden(path) {
+		return "", fs.NewPathError("trash", "Restore", path, fs.ErrNotExist, fs.ErrNotExist)
+	}
+	if version == "" {
+		latest, err := t.latest(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		version = latest
+	}
+	if !validVersion(version) {
+		return "", fs.NewPathError("trash", "Restore", path, ErrInvalidVersion, nil)
+	}
+
+	src := pathpkg.Join(t.dir, version, path)
+	info, err := t.FileSystem.Stat(ctx, src)
+	if err != nil {
+		return "", fs.NewPathError("trash", "Restore", path, err, nil)
+	}
+	dst, err := t.available(ctx, path, info.IsDir())
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		if err = t.FileSystem.Move(ctx, src, dst); err != nil {
+			return "", err
+		}
+		return dst, nil
+	}
+
+	files, err := t.files(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if err = t.FileSystem.Move(ctx, file, pathpkg.Join(dst, strings.TrimPrefix(file, src+"/"))); err != nil {
+			return "", err
+		}
+	}
+	// 本地文件系统需要删除留下的空目录
+	_ = t.FileSystem.RemoveDir(ctx, src)
+	return dst, nil
+}
+
+// latest 返回回收站中包含 path 的最新版本
+func (t *Trash) latest(ctx context.Context, path string) (string, error) {
+	versions, err := t.versions(ctx)
+	if err != nil {
+		return "", err
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		exists, err := t.FileSystem.Exists(ctx, pathpkg.Join(t.dir, versions[i], path))
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return versions[i], nil
+		}
+	}
+	return "", fs.NewPathError("trash", "Restore", path, fs.ErrNotExist, fs.ErrNotExist)
+}
+
+// available 返回恢复的目标路径，path 已存在时在名称后追加序号
+func (t *Trash) available(ctx context.Context, path string, isDir bool) (string, error) {
+	base, ext := path, ""
+	if !isDir {
+		ext = pathpkg.Ext(path)
+		base = strings.TrimSuffix(path, ext)
+	}
+	candidate := path
+	for i := 1; ; i++ {
+		exists, err := t.FileSystem.Exists(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+}
+
+// Purge 永久删除早于 olderThan 之前删除的文件，返回删除的版本数。可以定期调用实现保留期限
+func (t *Trash) Purge(ctx context.Context, olderThan time.Duration) (int, error) {
+	versions, err := t.versions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	before := time.Now().Add(-olderThan)
+	purged := 0
+	for _, version := range versions {
+		deletedAt, _ := time.Parse(versionLayout, version)
+		if !deletedAt.Before(before) {
+			break
+		}
+		if err = t.FileSystem.RemoveDir(ctx, pathpkg.Join(t.dir, version)); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// versions 返回回收站中的全部版本，从旧到新排列
+func (t *Trash) versions(ctx context.Context) ([]string, error) {
+	infos, err := t.FileSystem.List(ctx, t.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		if _, err = time.Parse(versionLayout, info.Name()); err == nil {
+			versions = append(versions, info.Name())
+		}
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// version 返回新的版本，同一个 Trash 生成的版本严格递增
+func (t *Trash) version() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now().UTC()
+	if !now.After(t.last) {
+		now = t.last.Add(time.Nanosecond)
+	}
+	t.last = now
+	return now.Format(versionLayout)
+}
+
+// validVersion 判断版本是否为删除时间，版本会拼接到路径中，不能包含 "/" 或 ".."
+func validVersion(version string) bool {
+	if strings.ContainsAny(version, "/\\") {
+		return false
+	}
+	_, err := time.Parse(versionLayout, version)
+	return err == nil
+}
+
+// hidden 判断规范化后的路径是否在回收站中
+func (t *Trash) hidden(path string) bool {
+	return path == t.dir || strings.HasPrefix(path, t.dir+"/")
+}
+
+// split 将回收站中的路径拆分为版本与原路径
+func (t *Trash) split(name string) (version, path string, ok bool) {
+	rest, ok := strings.CutPrefix(fs.CleanPath(name), t.dir+"/")
+	if !ok {
+		return "", "", false
+	}
+	version, path, ok = strings.Cut(rest, "/")
+	return version, path, ok
+}
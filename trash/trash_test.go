@@ -0,0 +1,267 @@
+package trash_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goairix/fs"
+	"github.com/goairix/fs/driver/memory"
+	"github.com/goairix/fs/fstest"
+	"github.com/goairix/fs/trash"
+)
+
+func newTrash(t *testing.T) (*trash.Trash, fs.FileSystem) {
+	t.Helper()
+	m, err := memory.New(memory.Config{BaseURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trash.New(m, trash.Config{}), m
+}
+
+func write(t *testing.T, fsys fs.FileSystem, path, data string, opts ...fs.Option) {
+	t.Helper()
+	w, err := fsys.Create(context.Background(), path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func read(t *testing.T, fsys fs.FileSystem, path string) string {
+	t.Helper()
+	r, err := fsys.Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func exists(t *testing.T, fsys fs.FileSystem, path string) bool {
+	t.Helper()
+	ok, err := fsys.Exists(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ok
+}
+
+func listTrash(t *testing.T, tr *trash.Trash) []trash.Entry {
+	t.Helper()
+	entries, err := tr.ListTrash(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestTrashFileSystem(t *testing.T) {
+	fstest.TestFileSystem(t, func(t *testing.T) fs.FileSystem {
+		tr, _ := newTrash(t)
+		return tr
+	})
+}
+
+func TestRemove(t *testing.T) {
+	ctx := context.Background()
+	tr, m := newTrash(t)
+	write(t, tr, "docs/a.txt", "v1", fs.WithContentType("text/plain"), fs.WithMetadata(fs.Metadata{"owner": "alice"}))
+
+	if err := tr.Remove(ctx, "docs/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if exists(t, tr, "docs/a.txt") {
+		t.Fatal("removed file still exists")
+	}
+	if err := tr.Remove(ctx, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Remove missing file: %v, want ErrNotExist", err)
+	}
+	entries := listTrash(t, tr)
+	if len(entries) != 1 || entries[0].Path != "docs/a.txt" || entries[0].Size != 2 {
+		t.Fatalf("ListTrash = %+v", entries)
+	}
+	if !exists(t, m, trash.DefaultDir+"/"+entries[0].Version+"/docs/a.txt") {
+		t.Fatal("removed file was not moved to the trash directory")
+	}
+
+	// 恢复保留元数据与文件类型
+	restored, err := tr.Restore(ctx, "docs/a.txt", entries[0].Version)
+	if err != nil || restored != "docs/a.txt" || read(t, tr, restored) != "v1" {
+		t.Fatalf("Restore = %q, %v", restored, err)
+	}
+	if mimeType, _ := tr.GetMimeType(ctx, restored); mimeType != "text/plain" {
+		t.Fatalf("restored file has MIME type %q", mimeType)
+	}
+	if metadata, _ := tr.GetMetadata(ctx, restored); metadata["owner"] != "alice" {
+		t.Fatalf("restored file has metadata %v", metadata)
+	}
+	if len(listTrash(t, tr)) != 0 {
+		t.Fatal("restored file is still in the trash")
+	}
+}
+
+func TestRestoreVersions(t *testing.T) {
+	ctx := context.Background()
+	tr, _ := newTrash(t)
+	for _, data := range []string{"v1", "v2"} {
+		write(t, tr, "a.txt", data)
+		if err := tr.Remove(ctx, "a.txt"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(t, tr, "a.txt", "v3")
+
+	entries := listTrash(t, tr)
+	if len(entries) != 2 || entries[0].Version <= entries[1].Version {
+		t.Fatalf("ListTrash = %+v, want the newest version first", entries)
+	}
+	// 原路径已存在时恢复到新的名称
+	restored, err := tr.Restore(ctx, "a.txt", entries[1].Version)
+	if err != nil || restored != "a (1).txt" || read(t, tr, restored) != "v1" {
+		t.Fatalf("Restore oldest = %q, %v", restored, err)
+	}
+	restored, err = tr.Restore(ctx, "a.txt", "")
+	if err != nil || restored != "a (2).txt" || read(t, tr, restored) != "v2" {
+		t.Fatalf("Restore latest = %q, %v", restored, err)
+	}
+	if read(t, tr, "a.txt") != "v3" {
+		t.Fatal("Restore overwrote the existing file")
+	}
+	if _, err := tr.Restore(ctx, "a.txt", ""); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Restore with an empty trash: %v, want ErrNotExist", err)
+	}
+	for _, version := range []string{"..", "../x", "20260101T000000.000000000Z/..", "x"} {
+		if _, err := tr.Restore(ctx, "a.txt", version); !errors.Is(err, trash.ErrInvalidVersion) {
+			t.Errorf("Restore version %q: %v, want ErrInvalidVersion", version, err)
+		}
+	}
+}
+
+func TestRemoveDir(t *testing.T) {
+	ctx := context.Background()
+	tr, _ := newTrash(t)
+	write(t, tr, "docs/a.txt", "a")
+	write(t, tr, "docs/sub/b.txt", "b")
+	write(t, tr, "top.txt", "t")
+
+	if err := tr.RemoveDir(ctx, "docs"); err != nil {
+		t.Fatal(err)
+	}
+	if exists(t, tr, "docs") {
+		t.Fatal("removed directory still exists")
+	}
+	// 一次删除的文件属于同一版本
+	entries := listTrash(t, tr)
+	if len(entries) != 2 || entries[0].Version != entries[1].Version {
+		t.Fatalf("ListTrash = %+v, want one version", entries)
+	}
+	restored, err := tr.Restore(ctx, "docs", "")
+	if err != nil || restored != "docs" || read(t, tr, "docs/a.txt") != "a" || read(t, tr, "docs/sub/b.txt") != "b" {
+		t.Fatalf("Restore directory = %q, %v", restored, err)
+	}
+
+	write(t, tr, "d2/x.txt", "x")
+	if err := tr.RemoveDir(ctx, "d2"); err != nil {
+		t.Fatal(err)
+	}
+	write(t, tr, "d2/y.txt", "y")
+	restored, err = tr.Restore(ctx, "d2", "")
+	if err != nil || restored != "d2 (1)" || read(t, tr, "d2 (1)/x.txt") != "x" {
+		t.Fatalf("Restore directory to an existing path = %q, %v", restored, err)
+	}
+
+	// 删除根目录保留回收站
+	if err := tr.RemoveDir(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if infos, err := tr.List(ctx, ""); err != nil || len(infos) != 0 {
+		t.Fatalf("List root after RemoveDir = %v, %v", infos, err)
+	}
+	if len(listTrash(t, tr)) == 0 {
+		t.Fatal("RemoveDir of the root emptied the trash")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	tr, m := newTrash(t)
+	for _, path := range []string{"a.txt", "b.txt"} {
+		write(t, tr, path, path)
+		if err := tr.Remove(ctx, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := tr.Purge(ctx, time.Hour); err != nil || n != 0 {
+		t.Fatalf("Purge recent versions = %d, %v, want 0", n, err)
+	}
+	if n, err := tr.Purge(ctx, 0); err != nil || n != 2 {
+		t.Fatalf("Purge = %d, %v, want 2", n, err)
+	}
+	if len(listTrash(t, tr)) != 0 || exists(t, m, trash.DefaultDir+"/") {
+		t.Fatal("purged files are still in the trash")
+	}
+}
+
+func TestHidden(t *testing.T) {
+	ctx := context.Background()
+	tr, _ := newTrash(t)
+	write(t, tr, "a.txt", "a")
+	write(t, tr, "b.txt", "b")
+	if err := tr.Remove(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	trashed := trash.DefaultDir + "/" + listTrash(t, tr)[0].Version + "/a.txt"
+
+	// 列出、遍历与读取时不可见
+	infos, err := tr.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if info.Name() == trash.DefaultDir {
+			t.Fatal("List returned the trash directory")
+		}
+	}
+	err = fs.Walk(ctx, tr, "", func(path string, _ fs.FileInfo, err error) error {
+		if strings.HasPrefix(fs.CleanPath(path), trash.DefaultDir) {
+			t.Errorf("Walk visited %s", path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists(t, tr, trash.DefaultDir) || exists(t, tr, trashed) {
+		t.Fatal("trash directory is visible")
+	}
+	if _, err := tr.GetMetadata(ctx, trashed); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("GetMetadata in the trash: %v, want ErrNotExist", err)
+	}
+
+	// 写入回收站或从回收站移出时拒绝
+	for name, err := range map[string]error{
+		"Create":      func() error { _, err := tr.Create(ctx, trash.DefaultDir+"/x/a.txt"); return err }(),
+		"Copy":        tr.Copy(ctx, trashed, "c.txt"),
+		"Move":        tr.Move(ctx, "b.txt", "./"+trash.DefaultDir+"/b.txt"),
+		"Upload":      tr.Uploader().Upload(ctx, "/"+trash.DefaultDir+"/u", strings.NewReader("x")),
+		"SetMetadata": tr.SetMetadata(ctx, trashed, map[string]interface{}{"k": "v"}),
+	} {
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("%s inside the trash: %v, want ErrPermission", name, err)
+		}
+	}
+}
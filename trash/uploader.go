@@ -0,0 +1,42 @@
+package trash
+
+import (
+	"context"
+	"io"
+
+	"github.com/goairix/fs"
+)
+
+// uploader 拒绝上传到回收站的上传器
+type uploader struct {
+	fs.Uploader
+	t *Trash
+}
+
+func (u *uploader) Upload(ctx context.Context, path string, reader io.Reader, opts ...fs.Option) error {
+	if err := u.t.writable("Upload", path); err != nil {
+		return err
+	}
+	return u.Uploader.Upload(ctx, path, reader, opts...)
+}
+
+func (u *uploader) InitMultipartUpload(ctx context.Context, path string, opts ...fs.Option) (string, error) {
+	if err := u.t.writable("InitMultipartUpload", path); err != nil {
+		return "", err
+	}
+	return u.Uploader.InitMultipartUpload(ctx, path, opts...)
+}
+
+func (u *uploader) UploadPart(ctx context.Context, path string, uploadID string, partNumber int, data io.Reader, opts ...fs.Option) (string, error) {
+	if err := u.t.writable("UploadPart", path); err != nil {
+		return "", err
+	}
+	return u.Uploader.UploadPart(ctx, path, uploadID, partNumber, data, opts...)
+}
+
+func (u *uploader) CompleteMultipartUpload(ctx context.Context, path string, uploadID string, parts []fs.MultipartPart, opts ...fs.Option) error {
+	if err := u.t.writable("CompleteMultipartUpload", path); err != nil {
+		return err
+	}
+	return u.Uploader.CompleteMultipartUpload(ctx, path, uploadID, parts, opts...)
+}
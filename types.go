@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"fmt"
 	"os"
 	"time"
 )
@@ -15,6 +16,12 @@ type FileInfo interface {
 	Sys() interface{}
 }
 
+// ETagger 由能够提供 ETag 的 FileInfo 实现，对象存储驱动与内存驱动的 Stat 与 List 返回的文件信息均实现了该接口
+type ETagger interface {
+	// ETag 返回不含引号的 ETag，未知时返回空字符串
+	ETag() string
+}
+
 // Metadata 文件元数据
 type Metadata map[string]interface{}
 
@@ -32,3 +39,17 @@ type MultipartUploadInfo struct {
 	Parts      []MultipartPart `json:"parts"`       // 已上传的分片
 	CreateTime time.Time       `json:"create_time"` // 创建时间
 }
+
+// Range 文件读取范围
+type Range struct {
+	Offset int64 // 起始位置
+	Length int64 // 读取长度，小于等于 0 时读取到文件末尾
+}
+
+// HeaderValue 返回 HTTP Range 请求头的值
+func (r *Range) HeaderValue() string {
+	if r.Length <= 0 {
+		return fmt.Sprintf("bytes=%d-", r.Offset)
+	}
+	return fmt.Sprintf("bytes=%d-%d", r.Offset, r.Offset+r.Length-1)
+}
@@ -0,0 +1,33 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// MultipartUploadError 设置 WithLeavePartsOnError 时分片上传失败返回的错误，
+// 已上传的分片会被保留，可通过 UploadID 续传或取消
+type MultipartUploadError struct {
+	Path     string
+	UploadID string
+	Err      error
+}
+
+func (e *MultipartUploadError) Error() string {
+	return fmt.Sprintf("multipart upload %s of %s failed: %v", e.UploadID, e.Path, e.Err)
+}
+
+func (e *MultipartUploadError) Unwrap() error {
+	return e.Err
+}
+
+// ManagedUpload 将 reader 拆分为分片，通过 uploader 的分片上传接口并发上传，供驱动实现 Uploader.Upload。
+// 数据不超过阈值时通过 put 单次上传；单个分片上传失败时会重试，最终失败时取消分片上传
+func ManagedUpload(ctx context.Context, uploader Uploader, path string, reader io.Reader, put PutFunc, opts ...Option) error {
+	writer := newStreamWriter(ctx, uploader, path, put, opts...)
+	if _, err := io.Copy(writer, reader); err != nil {
+		return writer.closeWithError(err)
+	}
+	return writer.Close()
+}
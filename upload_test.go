@@ -0,0 +1,117 @@
+package fs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/goairix/fs"
+)
+
+func TestManagedUpload(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		size      int
+		opts      []fs.Option
+		wantParts int // 为 0 时单次上传
+	}{
+		{"single", 1 << 20, nil, 0},
+		{"threshold", fs.MinPartSize, []fs.Option{fs.WithMultipartThreshold(fs.MinPartSize)}, 0},
+		{"multipart", 3*fs.MinPartSize + 1, []fs.Option{fs.WithMultipartThreshold(fs.MinPartSize)}, 4},
+		{"part size", 3*fs.MinPartSize + 1, []fs.Option{fs.WithMultipartThreshold(fs.MinPartSize), fs.WithPartSize(2 * fs.MinPartSize)}, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			uploader, put := newFakeUploader(), &fakePut{}
+			data := testData(tt.size)
+			if err := fs.ManagedUpload(context.Background(), uploader, "a.bin", bytes.NewReader(data), put.put, tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantParts == 0 {
+				if put.calls != 1 || !bytes.Equal(put.data, data) || len(uploader.parts) != 0 {
+					t.Fatalf("put called %d times with %d bytes, %d parts uploaded", put.calls, len(put.data), len(uploader.parts))
+				}
+				return
+			}
+			if put.calls != 0 || len(uploader.parts) != tt.wantParts {
+				t.Fatalf("put called %d times, %d parts uploaded, want %d parts", put.calls, len(uploader.parts), tt.wantParts)
+			}
+			if !bytes.Equal(uploader.completed, data) {
+				t.Fatal("completed upload differs from the reader")
+			}
+		})
+	}
+}
+
+func TestManagedUploadRetry(t *testing.T) {
+	uploader, put := newFakeUploader(), &fakePut{}
+	uploader.failPart = func(_, attempt int) error {
+		if attempt <= 2 {
+			return errors.New("transient")
+		}
+		return nil
+	}
+	data := testData(2 * fs.MinPartSize)
+	err := fs.ManagedUpload(context.Background(), uploader, "a.bin", bytes.NewReader(data), put.put,
+		fs.WithMultipartThreshold(fs.MinPartSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(uploader.completed, data) {
+		t.Fatal("completed upload differs from the reader")
+	}
+	for partNumber, attempts := range uploader.attempts {
+		if attempts != 3 {
+			t.Errorf("part %d uploaded %d times, want 3", partNumber, attempts)
+		}
+	}
+}
+
+func TestManagedUploadLeaveParts(t *testing.T) {
+	uploader, put := newFakeUploader(), &fakePut{}
+	errPart := errors.New("part failed")
+	uploader.failPart = func(partNumber, _ int) error {
+		if partNumber == 2 {
+			return errPart
+		}
+		return nil
+	}
+	err := fs.ManagedUpload(context.Background(), uploader, "a.bin", bytes.NewReader(testData(3*fs.MinPartSize)), put.put,
+		fs.WithMultipartThreshold(fs.MinPartSize), fs.WithPartRetries(0), fs.WithLeavePartsOnError())
+
+	var uploadErr *fs.MultipartUploadError
+	if !errors.As(err, &uploadErr) {
+		t.Fatalf("error = %v, want *MultipartUploadError", err)
+	}
+	if uploadErr.UploadID != "upload-1" || uploadErr.Path != "a.bin" || !errors.Is(err, errPart) {
+		t.Fatalf("error = %+v", uploadErr)
+	}
+	if uploader.aborted {
+		t.Fatal("upload was aborted with WithLeavePartsOnError")
+	}
+	if _, ok := uploader.parts[1]; !ok {
+		t.Fatal("uploaded parts were not kept")
+	}
+}
+
+func TestManagedUploadReaderError(t *testing.T) {
+	errRead := errors.New("read failed")
+	for _, size := range []int{0, 2 * fs.MinPartSize} {
+		uploader, put := newFakeUploader(), &fakePut{}
+		reader := io.MultiReader(bytes.NewReader(testData(size)), iotest.ErrReader(errRead))
+		err := fs.ManagedUpload(context.Background(), uploader, "a.bin", reader, put.put,
+			fs.WithMultipartThreshold(fs.MinPartSize))
+		if !errors.Is(err, errRead) {
+			t.Fatalf("error = %v, want %v", err, errRead)
+		}
+		if put.calls != 0 || uploader.completed != nil {
+			t.Fatalf("upload of %d bytes was written after a read error", size)
+		}
+		// 切换为分片上传后读取失败时取消分片上传
+		if size > fs.MinPartSize && !uploader.aborted {
+			t.Fatalf("upload of %d bytes was not aborted after a read error", size)
+		}
+	}
+}
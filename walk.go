@@ -0,0 +1,223 @@
+package fs
+
+import (
+	"context"
+	iofs "io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// SkipDir WalkFunc 返回该错误时跳过当前目录，对文件返回时跳过所在目录中的其余内容
+	SkipDir = iofs.SkipDir
+	// SkipAll WalkFunc 返回该错误时停止遍历
+	SkipAll = iofs.SkipAll
+)
+
+// WalkFunc Walk 遍历时对每个文件和目录调用的函数，语义与 io/fs.WalkDirFunc 一致：
+// 根目录不存在或列出目录失败时 err 不为 nil，返回 SkipDir 或 SkipAll 可跳过部分或全部内容
+type WalkFunc func(path string, info FileInfo, err error) error
+
+// Walker 由支持高效递归遍历的驱动实现，Walk 会优先使用该接口
+type Walker interface {
+	// Walk 递归遍历 root 下的全部文件和目录
+	Walk(ctx context.Context, root string, fn WalkFunc, opts ...Option) error
+}
+
+// Walk 递归遍历 root 下的全部文件和目录，目录总是在其内容之前被访问。
+// 驱动实现 Walker 时使用驱动的实现（对象存储驱动按对象键的顺序遍历），否则逐级调用 List，按名称顺序遍历
+func Walk(ctx context.Context, fsys FileSystem, root string, fn WalkFunc, opts ...Option) error {
+	if walker, ok := fsys.(Walker); ok {
+		return walker.Walk(ctx, root, fn, opts...)
+	}
+
+	info, err := fsys.Stat(ctx, root, opts...)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkList(ctx, fsys, root, info, fn, opts)
+	}
+	if err == SkipDir || err == SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkList 通过 List 递归遍历目录
+func walkList(ctx context.Context, fsys FileSystem, name string, info FileInfo, fn WalkFunc, opts []Option) error {
+	if err := fn(name, info, nil); err != nil || !info.IsDir() {
+		if err == SkipDir && info.IsDir() {
+			err = nil
+		}
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entries, err := fsys.List(ctx, name, opts...)
+	if err != nil {
+		if err = fn(name, info, err); err != nil {
+			if err == SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	for _, entry := range entries {
+		if err := walkList(ctx, fsys, path.Join(name, entry.Name()), entry, fn, opts); err != nil {
+			if err == SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// ObjectIterator 依次回调 root 下全部对象相对 root 的路径与信息，路径必须按字典序排列，
+// 目录占位对象的路径以 "/" 结尾；yield 返回 false 时应停止列举并返回 nil
+type ObjectIterator func(yield func(name string, info FileInfo) bool) error
+
+// WalkObjects 基于不带分隔符的扁平列举实现 Walk，供对象存储驱动实现 Walker。
+// 目录由对象键推导得出，遍历的开销取决于列举的页数而不是目录数；
+// root 下没有对象时通过 Stat 判断 root 是文件还是不存在
+func WalkObjects(ctx context.Context, fsys FileSystem, root string, fn WalkFunc, list ObjectIterator, opts ...Option) error {
+	w := &objectWalk{ctx: ctx, root: root, fn: fn}
+	if err := list(w.visit); err != nil && !w.done {
+		w.started = true
+		w.err = fn(root, nil, err)
+	}
+	if !w.started {
+		info, err := fsys.Stat(ctx, root, opts...)
+		w.err = fn(root, info, err)
+	}
+	if w.err == SkipDir || w.err == SkipAll {
+		return nil
+	}
+	return w.err
+}
+
+// objectWalk 扁平列举的遍历状态
+type objectWalk struct {
+	ctx     context.Context
+	root    string
+	fn      WalkFunc
+	started bool
+	done    bool
+	err     error
+	dirs    []string // 当前对象所在目录链上已访问的目录
+	skip    string   // 需要跳过的目录前缀
+}
+
+func (w *objectWalk) visit(name string, info FileInfo) bool {
+	if err := w.ctx.Err(); err != nil {
+		return w.stop(err)
+	}
+	if !w.started {
+		w.started = true
+		if err := w.fn(w.root, newDirInfo(path.Base(w.root)), nil); err != nil {
+			return w.stop(err)
+		}
+	}
+	if w.skip != "" && strings.HasPrefix(name, w.skip) {
+		return true
+	}
+
+	isDir := strings.HasSuffix(name, "/")
+	name = strings.TrimSuffix(name, "/")
+	if name == "" {
+		return true
+	}
+	dir := path.Dir(name)
+	if dir == "." {
+		dir = ""
+	}
+
+	// 对象按字典序排列，同一目录下的对象是连续的，离开的目录不会再出现
+	for len(w.dirs) > 0 {
+		last := w.dirs[len(w.dirs)-1]
+		if dir == last || strings.HasPrefix(dir, last+"/") {
+			break
+		}
+		w.dirs = w.dirs[:len(w.dirs)-1]
+	}
+
+	// 访问对象所在的尚未访问的上级目录
+	if dir != "" {
+		start := 0
+		if len(w.dirs) > 0 {
+			start = len(w.dirs[len(w.dirs)-1]) + 1
+		}
+		for start <= len(dir) {
+			end := strings.IndexByte(dir[start:], '/')
+			if end < 0 {
+				end = len(dir)
+			} else {
+				end += start
+			}
+			if !w.visitDir(dir[:end], newDirInfo(path.Base(dir[:end]))) {
+				return !w.done
+			}
+			start = end + 1
+		}
+	}
+
+	if isDir {
+		w.visitDir(name, info)
+		return !w.done
+	}
+	if err := w.fn(path.Join(w.root, name), info, nil); err != nil {
+		if err == SkipDir && dir != "" {
+			// 跳过所在目录中的其余内容
+			w.skip = dir + "/"
+			return true
+		}
+		return w.stop(err)
+	}
+	return true
+}
+
+// visitDir 访问目录，返回 false 表示该目录被跳过或遍历已结束
+func (w *objectWalk) visitDir(name string, info FileInfo) bool {
+	if err := w.fn(path.Join(w.root, name), info, nil); err != nil {
+		if err == SkipDir {
+			w.skip = name + "/"
+			return false
+		}
+		w.stop(err)
+		return false
+	}
+	w.dirs = append(w.dirs, name)
+	return true
+}
+
+// stop 结束遍历并记录 WalkFunc 返回的错误
+func (w *objectWalk) stop(err error) bool {
+	w.done = true
+	w.err = err
+	return false
+}
+
+// dirInfo 由对象键推导出的目录信息
+type dirInfo struct {
+	name string
+}
+
+func newDirInfo(name string) *dirInfo {
+	return &dirInfo{name: name}
+}
+
+func (d *dirInfo) Name() string       { return d.name }
+func (d *dirInfo) Size() int64        { return 0 }
+func (d *dirInfo) Mode() os.FileMode  { return os.ModeDir | 0755 }
+func (d *dirInfo) ModTime() time.Time { return time.Time{} }
+func (d *dirInfo) IsDir() bool        { return true }
+func (d *dirInfo) Sys() interface{}   { return nil }
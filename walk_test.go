@@ -0,0 +1,138 @@
+package fs_test
+
+import (
+	"context"
+	"errors"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/goairix/fs"
+)
+
+var walkFiles = []string{"a.txt", "d/b.txt", "d/e/c.txt", "d/f.txt", "g/h.txt"}
+
+// listOnly 隐藏驱动的 Walker，Walk 逐级调用 List 遍历
+type listOnly struct {
+	fs.FileSystem
+}
+
+// objectWalker 基于 w 下 walkFiles 的扁平列举通过 fs.WalkObjects 遍历
+type objectWalker struct {
+	fs.FileSystem
+}
+
+func (w objectWalker) Walk(ctx context.Context, root string, fn fs.WalkFunc, opts ...fs.Option) error {
+	return fs.WalkObjects(ctx, w.FileSystem, root, fn, func(yield func(string, fs.FileInfo) bool) error {
+		for _, name := range walkFiles {
+			name, ok := strings.CutPrefix("w/"+name, root+"/")
+			if !ok {
+				continue
+			}
+			info, err := w.FileSystem.Stat(ctx, path.Join(root, name))
+			if err != nil {
+				return err
+			}
+			if !yield(name, info) {
+				return nil
+			}
+		}
+		return nil
+	}, opts...)
+}
+
+func newWalkFS(t *testing.T) fs.FileSystem {
+	t.Helper()
+	files := map[string]string{}
+	for _, name := range walkFiles {
+		files["w/"+name] = name
+	}
+	return newMemory(t, files)
+}
+
+// walkPaths 返回遍历访问的路径，skip 指定访问某个路径时 WalkFunc 返回的错误
+func walkPaths(t *testing.T, fsys fs.FileSystem, root string, skip map[string]error) ([]string, error) {
+	t.Helper()
+	var paths []string
+	err := fs.Walk(context.Background(), fsys, root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, ".txt") == info.IsDir() {
+			t.Errorf("%s: IsDir() = %v", path, info.IsDir())
+		}
+		paths = append(paths, path)
+		return skip[path]
+	})
+	return paths, err
+}
+
+func TestWalk(t *testing.T) {
+	for name, wrap := range map[string]func(fs.FileSystem) fs.FileSystem{
+		"List":        func(f fs.FileSystem) fs.FileSystem { return listOnly{f} },
+		"WalkObjects": func(f fs.FileSystem) fs.FileSystem { return objectWalker{f} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			fsys := wrap(newWalkFS(t))
+			for _, tt := range []struct {
+				name string
+				skip map[string]error
+				want []string
+			}{
+				{"all", nil, []string{"w", "w/a.txt", "w/d", "w/d/b.txt", "w/d/e", "w/d/e/c.txt", "w/d/f.txt", "w/g", "w/g/h.txt"}},
+				{"skip dir", map[string]error{"w/d": fs.SkipDir}, []string{"w", "w/a.txt", "w/d", "w/g", "w/g/h.txt"}},
+				{"skip from file", map[string]error{"w/d/b.txt": fs.SkipDir}, []string{"w", "w/a.txt", "w/d", "w/d/b.txt", "w/g", "w/g/h.txt"}},
+				{"skip all", map[string]error{"w/d/e": fs.SkipAll}, []string{"w", "w/a.txt", "w/d", "w/d/b.txt", "w/d/e"}},
+			} {
+				got, err := walkPaths(t, fsys, "w", tt.skip)
+				if err != nil {
+					t.Fatalf("%s: %v", tt.name, err)
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("%s: visited %q, want %q", tt.name, got, tt.want)
+				}
+			}
+
+			got, err := walkPaths(t, fsys, "w/d/e", nil)
+			if err != nil || !slices.Equal(got, []string{"w/d/e", "w/d/e/c.txt"}) {
+				t.Errorf("Walk subdirectory visited %q: %v", got, err)
+			}
+			got, err = walkPaths(t, fsys, "w/a.txt", nil)
+			if err != nil || !slices.Equal(got, []string{"w/a.txt"}) {
+				t.Errorf("Walk file visited %q: %v", got, err)
+			}
+		})
+	}
+}
+
+func TestWalkErrors(t *testing.T) {
+	fsys := listOnly{newWalkFS(t)}
+	var rootErr error
+	err := fs.Walk(context.Background(), fsys, "missing", func(path string, info fs.FileInfo, err error) error {
+		rootErr = err
+		return err
+	})
+	if !errors.Is(rootErr, fs.ErrNotExist) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Walk missing root: fn got %v, returned %v", rootErr, err)
+	}
+
+	errStop := errors.New("stop")
+	var visited int
+	err = fs.Walk(context.Background(), fsys, "w", func(path string, info fs.FileInfo, err error) error {
+		visited++
+		if path == "w/d/b.txt" {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || visited != 4 {
+		t.Fatalf("Walk returned %v after %d paths, want %v after 4", err, visited, errStop)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := fs.Walk(ctx, fsys, "w", func(string, fs.FileInfo, error) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Walk with canceled context: %v", err)
+	}
+}
@@ -0,0 +1,285 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	iofs "io/fs"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// MinPartSize 分片上传中除最后一个分片外的最小分片大小
+	MinPartSize = 5 << 20
+	// DefaultMultipartThreshold 流式写入切换为分片上传的默认阈值
+	DefaultMultipartThreshold = 8 << 20
+	// DefaultConcurrency 默认同时上传的分片数
+	DefaultConcurrency = 4
+	// DefaultPartRetries 单个分片上传失败后的默认重试次数
+	DefaultPartRetries = 2
+)
+
+// PutFunc 单次上传完整文件，由驱动实现
+type PutFunc func(ctx context.Context, body io.Reader, size int64) error
+
+// streamWriter 流式写入器，写入数据不超过阈值时通过 put 单次上传，
+// 超过阈值后自动切换为分片上传，同时上传的分片数有上限，因此占用的内存是有界的
+type streamWriter struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	uploader   Uploader
+	path       string
+	put        PutFunc
+	opts       []Option
+	threshold  int
+	partSize   int
+	retries    int
+	leaveParts bool
+
+	buf      []byte
+	uploadID string
+	nextPart int
+	sem      chan struct{}
+	wg       sync.WaitGroup
+	closed   bool
+
+	mu    sync.Mutex
+	parts []MultipartPart
+	err   error
+}
+
+// NewStreamWriter 创建流式写入器，供对象存储驱动实现 Create。
+// 写入数据不超过 WithMultipartThreshold 设置的阈值时在 Close 时通过 put 单次上传，
+// 超过阈值后通过 uploader 分片上传，分片大小与并发数分别由 WithPartSize 与 WithConcurrency 设置；
+// 写入失败或 ctx 被取消时会取消分片上传
+func NewStreamWriter(ctx context.Context, uploader Uploader, path string, put PutFunc, opts ...Option) io.WriteCloser {
+	return newStreamWriter(ctx, uploader, path, put, opts...)
+}
+
+func newStreamWriter(ctx context.Context, uploader Uploader, path string, put PutFunc, opts ...Option) *streamWriter {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	threshold := int64(DefaultMultipartThreshold)
+	if o.MultipartThreshold > 0 {
+		threshold = o.MultipartThreshold
+	}
+	// 未设置分片大小时与阈值相同
+	partSize := max(threshold, MinPartSize)
+	if o.PartSize > 0 {
+		partSize = max(o.PartSize, MinPartSize)
+	}
+	concurrency := DefaultConcurrency
+	if o.Concurrency > 0 {
+		concurrency = o.Concurrency
+	}
+	retries := DefaultPartRetries
+	if o.PartRetries != nil {
+		retries = max(*o.PartRetries, 0)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &streamWriter{
+		ctx:        ctx,
+		cancel:     cancel,
+		uploader:   uploader,
+		path:       path,
+		put:        put,
+		opts:       opts,
+		threshold:  int(threshold),
+		partSize:   int(partSize),
+		retries:    retries,
+		leaveParts: o.LeavePartsOnError,
+		sem:        make(chan struct{}, concurrency),
+	}
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, iofs.ErrClosed
+	}
+	if err := w.error(); err != nil {
+		return 0, err
+	}
+
+	var n int
+	for len(p) > 0 {
+		limit := w.partSize
+		if w.uploadID == "" {
+			limit = w.threshold
+		}
+		// 缓冲区已满且还有数据写入时，上传缓冲区中的完整分片
+		if len(w.buf) >= limit {
+			if err := w.flush(false); err != nil {
+				// 返回第一个错误，分片上传失败时 flush 可能只看到 ctx 已取消
+				w.fail(err)
+				return n, w.error()
+			}
+			continue
+		}
+		m := min(len(p), limit-len(w.buf))
+		w.buf = append(w.buf, p[:m]...)
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+func (w *streamWriter) Close() error {
+	return w.closeWithError(nil)
+}
+
+// closeWithError 结束写入，err 不为 nil 时取消上传并返回 err
+func (w *streamWriter) closeWithError(err error) error {
+	if w.closed {
+		return iofs.ErrClosed
+	}
+	w.closed = true
+	defer w.cancel()
+
+	if err != nil {
+		// 保留分片时先上传已缓冲的完整分片并等待上传结束，便于之后续传
+		if w.leaveParts && w.uploadID != "" && w.error() == nil {
+			if flushErr := w.flush(false); flushErr == nil {
+				w.wg.Wait()
+			}
+		}
+		w.fail(err)
+	}
+	if err = w.error(); err != nil {
+		return w.abort(err)
+	}
+
+	// 未超过阈值，单次上传
+	if w.uploadID == "" {
+		return w.put(w.ctx, bytes.NewReader(w.buf), int64(len(w.buf)))
+	}
+
+	if err = w.flush(true); err != nil {
+		w.fail(err)
+	}
+	w.wg.Wait()
+	if err = w.error(); err != nil {
+		return w.abort(err)
+	}
+
+	sort.Slice(w.parts, func(i, j int) bool {
+		return w.parts[i].PartNumber < w.parts[j].PartNumber
+	})
+	if err = w.uploader.CompleteMultipartUpload(w.ctx, w.path, w.uploadID, w.parts, w.opts...); err != nil {
+		return w.abort(err)
+	}
+	return nil
+}
+
+// flush 异步上传缓冲区中的完整分片，final 为 true 时剩余数据作为最后一个分片上传；
+// 同时上传的分片数达到上限时阻塞等待
+func (w *streamWriter) flush(final bool) error {
+	if w.ctx.Err() != nil {
+		return w.error()
+	}
+	if w.uploadID == "" {
+		uploadID, err := w.uploader.InitMultipartUpload(w.ctx, w.path, w.opts...)
+		if err != nil {
+			return err
+		}
+		w.uploadID = uploadID
+	}
+
+	for len(w.buf) >= w.partSize || (final && len(w.buf) > 0) {
+		select {
+		case w.sem <- struct{}{}:
+		case <-w.ctx.Done():
+			return w.error()
+		}
+
+		size := min(len(w.buf), w.partSize)
+		data := w.buf[:size:size]
+		if len(w.buf) > size {
+			w.buf = append([]byte(nil), w.buf[size:]...)
+		} else {
+			w.buf = nil
+		}
+		w.nextPart++
+		partNumber := w.nextPart
+
+		w.wg.Add(1)
+		go func() {
+			defer func() {
+				<-w.sem
+				w.wg.Done()
+			}()
+			etag, err := w.uploadPart(partNumber, data)
+			if err != nil {
+				w.fail(err)
+				return
+			}
+			w.mu.Lock()
+			w.parts = append(w.parts, MultipartPart{PartNumber: partNumber, ETag: etag, Size: int64(len(data))})
+			w.mu.Unlock()
+		}()
+	}
+	return nil
+}
+
+// uploadPart 上传单个分片，失败后按重试次数重新上传
+func (w *streamWriter) uploadPart(partNumber int, data []byte) (string, error) {
+	var err error
+	for attempt := 0; attempt <= w.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * 100 * time.Millisecond):
+			case <-w.ctx.Done():
+				return "", w.ctx.Err()
+			}
+		}
+		var etag string
+		etag, err = w.uploader.UploadPart(w.ctx, w.path, w.uploadID, partNumber, bytes.NewReader(data), w.opts...)
+		if err == nil {
+			return etag, nil
+		}
+		if w.ctx.Err() != nil {
+			return "", err
+		}
+	}
+	return "", err
+}
+
+// error 返回第一个上传错误，ctx 被取消时返回 ctx 的错误
+func (w *streamWriter) error() error {
+	w.mu.Lock()
+	err := w.err
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return w.ctx.Err()
+}
+
+// fail 记录第一个上传错误并取消其余正在上传的分片
+func (w *streamWriter) fail(err error) {
+	w.mu.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.mu.Unlock()
+	w.cancel()
+}
+
+// abort 等待正在上传的分片结束后取消分片上传，ctx 已被取消时仍需要执行；
+// 设置了 WithLeavePartsOnError 时保留已上传的分片，返回的错误中包含 UploadID
+func (w *streamWriter) abort(err error) error {
+	w.cancel()
+	w.wg.Wait()
+	if w.uploadID == "" {
+		return err
+	}
+	if w.leaveParts {
+		return &MultipartUploadError{Path: w.path, UploadID: w.uploadID, Err: err}
+	}
+	_ = w.uploader.AbortMultipartUpload(context.WithoutCancel(w.ctx), w.path, w.uploadID, w.opts...)
+	return err
+}
@@ -0,0 +1,218 @@
+package fs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/goairix/fs"
+)
+
+// fakeUploader 在内存中记录分片上传，failPart 返回上传分片的错误
+type fakeUploader struct {
+	fs.Uploader
+
+	mu        sync.Mutex
+	parts     map[int][]byte
+	attempts  map[int]int
+	completed []byte
+	aborted   bool
+	active    int
+	maxActive int
+	failPart  func(partNumber, attempt int) error
+}
+
+func newFakeUploader() *fakeUploader {
+	return &fakeUploader{parts: map[int][]byte{}, attempts: map[int]int{}}
+}
+
+func (u *fakeUploader) InitMultipartUpload(context.Context, string, ...fs.Option) (string, error) {
+	return "upload-1", nil
+}
+
+func (u *fakeUploader) UploadPart(_ context.Context, _ string, _ string, partNumber int, data io.Reader, _ ...fs.Option) (string, error) {
+	u.mu.Lock()
+	u.attempts[partNumber]++
+	attempt := u.attempts[partNumber]
+	u.active++
+	u.maxActive = max(u.maxActive, u.active)
+	u.mu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		u.active--
+		u.mu.Unlock()
+	}()
+
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return "", err
+	}
+	if u.failPart != nil {
+		if err := u.failPart(partNumber, attempt); err != nil {
+			return "", err
+		}
+	}
+	u.mu.Lock()
+	u.parts[partNumber] = b
+	u.mu.Unlock()
+	return fmt.Sprintf("etag-%d", partNumber), nil
+}
+
+func (u *fakeUploader) CompleteMultipartUpload(_ context.Context, _ string, _ string, parts []fs.MultipartPart, _ ...fs.Option) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !sort.SliceIsSorted(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber }) {
+		return errors.New("parts are not sorted")
+	}
+	var data []byte
+	for _, part := range parts {
+		if part.ETag != fmt.Sprintf("etag-%d", part.PartNumber) {
+			return fmt.Errorf("part %d has etag %q", part.PartNumber, part.ETag)
+		}
+		data = append(data, u.parts[part.PartNumber]...)
+	}
+	u.completed = data
+	return nil
+}
+
+func (u *fakeUploader) AbortMultipartUpload(context.Context, string, string, ...fs.Option) error {
+	u.mu.Lock()
+	u.aborted = true
+	u.mu.Unlock()
+	return nil
+}
+
+// fakePut 记录单次上传的数据
+type fakePut struct {
+	calls int
+	data  []byte
+}
+
+func (p *fakePut) put(_ context.Context, body io.Reader, size int64) error {
+	p.calls++
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if int64(len(b)) != size {
+		return fmt.Errorf("put %d bytes, size %d", len(b), size)
+	}
+	p.data = b
+	return nil
+}
+
+// testData 返回 n 字节的测试数据
+func testData(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return data
+}
+
+func TestStreamWriterSmall(t *testing.T) {
+	uploader, put := newFakeUploader(), &fakePut{}
+	data := testData(1 << 10)
+	w := fs.NewStreamWriter(context.Background(), uploader, "a.bin", put.put)
+	for i := 0; i < len(data); i += 100 {
+		if _, err := w.Write(data[i:min(i+100, len(data))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if put.calls != 1 || !bytes.Equal(put.data, data) {
+		t.Fatalf("put called %d times with %d bytes", put.calls, len(put.data))
+	}
+	if len(uploader.parts) != 0 {
+		t.Fatalf("uploaded %d parts for a small file", len(uploader.parts))
+	}
+	if _, err := w.Write(data); !errors.Is(err, iofs.ErrClosed) {
+		t.Fatalf("Write after Close: %v, want ErrClosed", err)
+	}
+}
+
+func TestStreamWriterMultipart(t *testing.T) {
+	uploader, put := newFakeUploader(), &fakePut{}
+	data := testData(2*fs.MinPartSize + 123)
+	w := fs.NewStreamWriter(context.Background(), uploader, "a.bin", put.put,
+		fs.WithMultipartThreshold(fs.MinPartSize), fs.WithConcurrency(2))
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if put.calls != 0 {
+		t.Fatalf("put called %d times for a multipart upload", put.calls)
+	}
+	if len(uploader.parts) != 3 {
+		t.Fatalf("uploaded %d parts, want 3", len(uploader.parts))
+	}
+	for partNumber, size := range map[int]int{1: fs.MinPartSize, 2: fs.MinPartSize, 3: 123} {
+		if len(uploader.parts[partNumber]) != size {
+			t.Errorf("part %d has %d bytes, want %d", partNumber, len(uploader.parts[partNumber]), size)
+		}
+	}
+	if !bytes.Equal(uploader.completed, data) {
+		t.Fatal("completed upload differs from the written data")
+	}
+	if uploader.maxActive > 2 {
+		t.Fatalf("%d parts uploaded concurrently, want at most 2", uploader.maxActive)
+	}
+}
+
+func TestStreamWriterPartFailure(t *testing.T) {
+	uploader, put := newFakeUploader(), &fakePut{}
+	errPart := errors.New("part failed")
+	uploader.failPart = func(partNumber, _ int) error {
+		if partNumber == 2 {
+			return errPart
+		}
+		return nil
+	}
+	w := fs.NewStreamWriter(context.Background(), uploader, "a.bin", put.put,
+		fs.WithMultipartThreshold(fs.MinPartSize), fs.WithPartRetries(0))
+	_, err := w.Write(testData(3 * fs.MinPartSize))
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if !errors.Is(err, errPart) {
+		t.Fatalf("error = %v, want %v", err, errPart)
+	}
+	if !uploader.aborted {
+		t.Fatal("failed upload was not aborted")
+	}
+	if uploader.completed != nil {
+		t.Fatal("failed upload was completed")
+	}
+}
+
+func TestStreamWriterCancel(t *testing.T) {
+	uploader, put := newFakeUploader(), &fakePut{}
+	ctx, cancel := context.WithCancel(context.Background())
+	w := fs.NewStreamWriter(ctx, uploader, "a.bin", put.put, fs.WithMultipartThreshold(fs.MinPartSize))
+	if _, err := w.Write(testData(fs.MinPartSize + 1)); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, err := w.Write(testData(1)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Write after cancel: %v, want context.Canceled", err)
+	}
+	if err := w.Close(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Close after cancel: %v, want context.Canceled", err)
+	}
+	if !uploader.aborted {
+		t.Fatal("canceled upload was not aborted")
+	}
+	if put.calls != 0 || uploader.completed != nil {
+		t.Fatal("canceled upload was written")
+	}
+}